@@ -2,6 +2,7 @@ package cmdbuild
 
 import (
 	"compress/gzip"
+	"crypto/ed25519"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -24,6 +25,13 @@ func doBuild(opts Opts, platforms Platforms) {
 
 	fmt.Println("Building for platforms", platforms)
 
+	var signingKey ed25519.PrivateKey
+	if opts.SigningKeyFile != "" {
+		signingKey = readSigningKey(opts.SigningKeyFile)
+	} else {
+		fmt.Println("signing-key-file not passed, release will not be signed and can't be used for updates")
+	}
+
 	{
 		// create a agent binary
 		commitSHA := getCommitSHA()
@@ -32,6 +40,9 @@ func doBuild(opts Opts, platforms Platforms) {
 		ldflags := "-X " + pkg + "/cmd.Commit=" + commitSHA
 		ldflags += " -X " + pkg + "/cmd.Version=" + opts.Version
 		ldflags += " -X " + pkg + "/cmd.IntegrationBinariesAll=" + strings.Join(integrationBinaries, ",")
+		if signingKey != nil {
+			ldflags += " -X " + pkg + "/cmd.UpdatePublicKey=" + publicKeyString(signingKey)
+		}
 
 		platforms.Each(func(pl Platform) {
 			buildAgent(opts, pl, ldflags)
@@ -84,6 +95,9 @@ func doBuild(opts Opts, platforms Platforms) {
 	}
 
 	gzipAgentAndIntegrations(opts, platforms)
+	if signingKey != nil {
+		writeManifests(opts, platforms, signingKey)
+	}
 	prepareGithubReleaseFiles(opts, platforms)
 }
 
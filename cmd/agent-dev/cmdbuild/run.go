@@ -14,16 +14,21 @@ type Opts struct {
 	OnlyPlatform string // only build for this platform
 	OnlyAgent    bool   // build only agent and skip the rest
 	SkipArchives bool   // do not create zips and gzips
+	// SigningKeyFile is the ed25519 private key used to sign release manifest, see GenerateSigningKey. Public key is embedded in the agent for update verification.
+	SigningKeyFile string
 }
 
 var integrationBinaries = []string{
 	"azure",
 	"bitbucket",
+	"coverage",
 	"github",
 	"gitlab",
 	"jira-cloud",
 	"jira-hosted",
 	"mock",
+	"push",
+	"sarif",
 	"sonarqube",
 }
 
@@ -0,0 +1,158 @@
+package cmdbuild
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pinpt/agent/pkg/build"
+	"github.com/pinpt/agent/pkg/fs"
+	"github.com/pinpt/agent/pkg/plugins"
+)
+
+// GenerateSigningKey creates a new ed25519 key for signing release manifests. Private key is written to loc, public key to loc + ".pub". Public key is also set in agent ldflags from the private key at build time.
+func GenerateSigningKey(loc string) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	err = ioutil.WriteFile(loc, []byte(base64.StdEncoding.EncodeToString(priv)), 0600)
+	if err != nil {
+		panic(err)
+	}
+	err = ioutil.WriteFile(loc+".pub", []byte(base64.StdEncoding.EncodeToString(pub)), 0644)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("Created signing key", loc)
+}
+
+func readSigningKey(loc string) ed25519.PrivateKey {
+	b, err := ioutil.ReadFile(loc)
+	if err != nil {
+		panic(err)
+	}
+	key, err := build.ParsePrivateKey(string(b))
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func publicKeyString(key ed25519.PrivateKey) string {
+	return base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+}
+
+// writeManifests creates signed manifest with checksums of all binaries for each platform. Stored in bin-gz next to gzipped binaries that are downloaded by the updater.
+func writeManifests(opts Opts, platforms Platforms, key ed25519.PrivateKey) {
+	fmt.Println("creating signed manifests", platforms)
+
+	platforms.Each(func(pl Platform) {
+		binDir := fjoin(opts.BuildDir, "bin", pl.OSArch())
+		m := build.Manifest{}
+		m.Version = opts.Version
+		m.Platform = pl.OSArch()
+		m.Files = map[string]string{}
+		err := filepath.Walk(binDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(binDir, path)
+			if err != nil {
+				return err
+			}
+			sum, err := build.FileSHA256(path)
+			if err != nil {
+				return err
+			}
+			m.Files[filepath.ToSlash(rel)] = sum
+			return nil
+		})
+		if err != nil {
+			panic(err)
+		}
+		data, sig, err := build.SignManifest(m, key)
+		if err != nil {
+			panic(err)
+		}
+		dir := fjoin(opts.BuildDir, "bin-gz", pl.OSArch())
+		err = os.MkdirAll(dir, 0777)
+		if err != nil {
+			panic(err)
+		}
+		err = fs.WriteToTempAndRename(bytes.NewReader(data), fjoin(dir, build.ManifestFile))
+		if err != nil {
+			panic(err)
+		}
+		err = fs.WriteToTempAndRename(bytes.NewReader(sig), fjoin(dir, build.ManifestSigFile))
+		if err != nil {
+			panic(err)
+		}
+	})
+}
+
+// checkManifests exits if release is not signed, since updater refuses to install unsigned binaries.
+func checkManifests(opts Opts, platforms Platforms) {
+	var missing []string
+	for _, pl := range platforms {
+		for _, n := range []string{build.ManifestFile, build.ManifestSigFile} {
+			ok, err := fs.Exists(fjoin(opts.BuildDir, "bin-gz", pl.OSArch(), n))
+			if err != nil {
+				panic(err)
+			}
+			if !ok {
+				missing = append(missing, pl.OSArch()+"/"+n)
+			}
+		}
+	}
+	if len(missing) != 0 {
+		fmt.Println("release is not signed, pass --signing-key-file to build, missing:", strings.Join(missing, ", "))
+		os.Exit(1)
+	}
+}
+
+// SignPlugin sets sha256 of the binary in third-party plugin manifest and signs the manifest. Public key of keyFile needs to be added to plugins.trusted_keys in agent config.
+func SignPlugin(dir string, keyFile string) {
+	key := readSigningKey(keyFile)
+	manifestLoc := fjoin(dir, plugins.ManifestFile)
+	b, err := ioutil.ReadFile(manifestLoc)
+	if err != nil {
+		panic(err)
+	}
+	var m plugins.Manifest
+	err = json.Unmarshal(b, &m)
+	if err != nil {
+		panic(err)
+	}
+	bin := m.Binary
+	if bin == "" {
+		bin = m.Name
+	}
+	m.SHA256, err = build.FileSHA256(fjoin(dir, bin))
+	if err != nil {
+		panic(err)
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	err = fs.WriteToTempAndRename(bytes.NewReader(data), manifestLoc)
+	if err != nil {
+		panic(err)
+	}
+	err = fs.WriteToTempAndRename(bytes.NewReader(plugins.Sign(data, key)), fjoin(dir, plugins.SigFile))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("Signed plugin", m.Name, m.Version, "public key", publicKeyString(key))
+}
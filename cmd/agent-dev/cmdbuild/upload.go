@@ -41,6 +41,7 @@ func upload(opts Opts, platforms Platforms) {
 	if opts.OnlyAgent {
 		fmt.Println("only-agent passed skipping bin-gz folder upload, including gz agent")
 	} else {
+		checkManifests(opts, platforms)
 		err = fs.CopyDir(fjoin(opts.BuildDir, "bin-gz"), fjoin(releaseDir, "bin-gz"))
 		if err != nil {
 			panic(err)
@@ -0,0 +1,97 @@
+// Package cmdreplay runs integrations against recorded http fixtures and compares exported objects with golden files.
+//
+// Record fixtures by running a normal export with PP_AGENT_HTTP_RECORD_DIR env variable set. Auth headers, cookies and tokens in query params are not saved.
+package cmdreplay
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/cmd/cmdintegration"
+	"github.com/pinpt/agent/cmd/cmdrunnorestarts/inconfig"
+	"github.com/pinpt/agent/pkg/replay"
+	"github.com/pinpt/agent/pkg/reqstats"
+)
+
+type Opts struct {
+	Logger hclog.Logger
+	// CustomerID is used when creating object ids, needs to be the same as when golden files were created
+	CustomerID string
+	// Integrations to run, same format as in export command. Auth does not matter for replay, but urls do.
+	Integrations    []inconfig.Integration
+	IntegrationsDir string
+	// FixtureDir contains recorded http responses
+	FixtureDir string
+	// GoldenDir contains expected objects, one file per model
+	GoldenDir string
+	// Update overwrites golden files with the current output instead of comparing
+	Update bool
+	// IgnoreFields are removed from all objects before comparing, for fields based on current time
+	IgnoreFields []string
+}
+
+func Run(opts Opts) error {
+	if opts.Logger == nil || opts.FixtureDir == "" || opts.GoldenDir == "" || len(opts.Integrations) == 0 {
+		return fmt.Errorf("provide all required params")
+	}
+	if opts.CustomerID == "" {
+		opts.CustomerID = "c1"
+	}
+	logger := opts.Logger
+
+	// integrations inherit env, this makes reqstats replay responses instead of making real requests
+	os.Unsetenv(reqstats.EnvRecordDir)
+	os.Setenv(reqstats.EnvReplayDir, opts.FixtureDir)
+
+	tempRoot, err := ioutil.TempDir("", "agent-replay-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempRoot)
+
+	cmdOpts := cmdintegration.Opts{}
+	cmdOpts.Logger = logger
+	cmdOpts.AgentConfig.CustomerID = opts.CustomerID
+	cmdOpts.AgentConfig.PinpointRoot = tempRoot
+	cmdOpts.AgentConfig.IntegrationsDir = opts.IntegrationsDir
+	cmdOpts.AgentConfig.SkipGit = true
+	cmdOpts.Integrations = opts.Integrations
+
+	command, err := cmdintegration.NewCommand(cmdOpts)
+	if err != nil {
+		return err
+	}
+
+	output := replay.NewOutput(logger)
+	err = command.SetupIntegrations(output.Agent)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var exportErrs []string
+	for exp, in := range command.Integrations {
+		logger.Info("replaying export", "integration", exp.String())
+		_, err := in.ILoader.RPCClient().Export(ctx, in.ExportConfig)
+		if err != nil {
+			exportErrs = append(exportErrs, exp.String()+": "+err.Error())
+		}
+		err = command.CloseOnlyIntegrationAndHandlePanic(in.ILoader)
+		if err != nil {
+			return err
+		}
+	}
+	if len(exportErrs) != 0 {
+		return fmt.Errorf("export failed on replay: %v", strings.Join(exportErrs, ", "))
+	}
+
+	got := output.Data(opts.IgnoreFields)
+	if opts.Update {
+		return replay.WriteGolden(logger, opts.GoldenDir, got)
+	}
+	return replay.CompareGolden(logger, opts.GoldenDir, got)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,6 +16,7 @@ import (
 	"github.com/pinpt/agent/cmd/agent-dev/cmddownloadlogs"
 
 	"github.com/pinpt/agent/cmd/agent-dev/cmdbuild"
+	"github.com/pinpt/agent/cmd/agent-dev/cmdreplay"
 	"github.com/pinpt/agent/cmd/cmdexport/process"
 	"github.com/pinpt/agent/cmd/cmdupload"
 	"github.com/pinpt/agent/integrations/pkg/commiturl"
@@ -24,6 +26,7 @@ import (
 	"github.com/pinpt/agent/pkg/expsessions"
 	"github.com/pinpt/agent/pkg/fsconf"
 	"github.com/pinpt/agent/pkg/jsonstore"
+	"github.com/pinpt/agent/pkg/reqstats"
 
 	"github.com/pinpt/agent/pkg/gitclone"
 	"github.com/pinpt/agent/slimrippy/exportrepo"
@@ -241,15 +244,17 @@ var cmdBuild = &cobra.Command{
 		onlyAgent, _ := cmd.Flags().GetBool("only-agent")
 		onlyUpload, _ := cmd.Flags().GetBool("only-upload")
 		skipArchives, _ := cmd.Flags().GetBool("skip-archives")
+		signingKeyFile, _ := cmd.Flags().GetString("signing-key-file")
 
 		cmdbuild.Run(cmdbuild.Opts{
-			BuildDir:     "./dist",
-			Version:      version,
-			Upload:       upload,
-			OnlyUpload:   onlyUpload,
-			OnlyPlatform: platform,
-			OnlyAgent:    onlyAgent,
-			SkipArchives: skipArchives,
+			BuildDir:       "./dist",
+			Version:        version,
+			Upload:         upload,
+			OnlyUpload:     onlyUpload,
+			OnlyPlatform:   platform,
+			OnlyAgent:      onlyAgent,
+			SkipArchives:   skipArchives,
+			SigningKeyFile: signingKeyFile,
 		})
 	},
 }
@@ -262,6 +267,35 @@ func init() {
 	cmd.Flags().String("platform", "all", "Limit to specific platform")
 	cmd.Flags().Bool("only-agent", false, "Only build agent and skip the rest (for developement)")
 	cmd.Flags().Bool("skip-archives", false, "Skip creating zips and gzips (faster builds)")
+	cmd.Flags().String("signing-key-file", "", "ed25519 private key used to sign the release manifest, required for upload")
+	cmdRoot.AddCommand(cmd)
+}
+
+var cmdGenSigningKey = &cobra.Command{
+	Use:   "gen-signing-key <file>",
+	Short: "Generate ed25519 key for signing releases",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cmdbuild.GenerateSigningKey(args[0])
+	},
+}
+
+func init() {
+	cmd := cmdGenSigningKey
+	cmdRoot.AddCommand(cmd)
+}
+
+var cmdSignPlugin = &cobra.Command{
+	Use:   "sign-plugin <plugin-dir> <key-file>",
+	Short: "Set binary checksum in third-party plugin manifest and sign it",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		cmdbuild.SignPlugin(args[0], args[1])
+	},
+}
+
+func init() {
+	cmd := cmdSignPlugin
 	cmdRoot.AddCommand(cmd)
 }
 
@@ -348,6 +382,51 @@ func init() {
 	cmdRoot.AddCommand(cmd)
 }
 
+var cmdReplay = &cobra.Command{
+	Use:   "replay",
+	Short: "Run integrations against recorded http responses and compare exported objects with golden files",
+	Long:  "Run integrations against recorded http responses and compare exported objects with golden files. Record responses by running export with " + reqstats.EnvRecordDir + " env variable set.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := defaultLogger()
+		opts := cmdreplay.Opts{}
+		opts.Logger = logger
+		opts.CustomerID, _ = cmd.Flags().GetString("customer-id")
+		opts.IntegrationsDir, _ = cmd.Flags().GetString("integrations-dir")
+		opts.FixtureDir, _ = cmd.Flags().GetString("fixture-dir")
+		opts.GoldenDir, _ = cmd.Flags().GetString("golden-dir")
+		opts.Update, _ = cmd.Flags().GetBool("update")
+		opts.IgnoreFields, _ = cmd.Flags().GetStringSlice("ignore-fields")
+
+		integrationsFile, _ := cmd.Flags().GetString("integrations-file")
+		b, err := ioutil.ReadFile(integrationsFile)
+		if err != nil {
+			exitWithErr(logger, fmt.Errorf("integrations-file does not point to a correct file, err %v", err))
+		}
+		err = json.Unmarshal(b, &opts.Integrations)
+		if err != nil {
+			exitWithErr(logger, fmt.Errorf("integrations-file contains invalid json: %v", err))
+		}
+
+		err = cmdreplay.Run(opts)
+		if err != nil {
+			exitWithErr(logger, err)
+		}
+	},
+}
+
+func init() {
+	cmd := cmdReplay
+	cmd.Flags().String("customer-id", "c1", "Customer ID used when creating golden files")
+	cmd.Flags().String("integrations-file", "", "Integrations config json as file, same as for export command")
+	cmd.Flags().String("integrations-dir", "", "Integrations dir")
+	cmd.Flags().String("fixture-dir", "", "Dir with recorded http responses")
+	cmd.Flags().String("golden-dir", "", "Dir with expected objects")
+	cmd.Flags().Bool("update", false, "Set to true to overwrite golden files with current output")
+	cmd.Flags().StringSlice("ignore-fields", []string{"updated_ts"}, "Fields to ignore when comparing objects")
+	cmdRoot.AddCommand(cmd)
+}
+
 var cmdDate = &cobra.Command{
 	Use:   "date",
 	Short: "Print current date in a our format as sson",
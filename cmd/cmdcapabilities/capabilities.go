@@ -0,0 +1,58 @@
+// Package cmdcapabilities loads the integration and prints capabilities manifest returned on init. Agent reads capabilities when integration is loaded for export, this command is used for debugging.
+package cmdcapabilities
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pinpt/agent/cmd/cmdintegration"
+	"github.com/pinpt/agent/rpcdef"
+)
+
+type Opts struct {
+	cmdintegration.Opts
+	Output io.Writer
+}
+
+type Result struct {
+	Capabilities rpcdef.Capabilities `json:"capabilities"`
+	Error        string              `json:"error"`
+}
+
+func Run(opts Opts) error {
+	if len(opts.Integrations) != 1 {
+		panic("pass exactly 1 integration")
+	}
+
+	command, err := cmdintegration.NewCommand(opts.Opts)
+	if err != nil {
+		return err
+	}
+
+	res := Result{}
+	// capabilities are returned in plugin handshake, SetupIntegrations returns after it completes
+	err = command.SetupIntegrations(nil)
+	if err != nil {
+		res.Error = err.Error()
+	} else {
+		integration := command.OnlyIntegration()
+		res.Capabilities = integration.ILoader.Capabilities()
+		err := command.CloseOnlyIntegrationAndHandlePanic(integration.ILoader)
+		if err != nil {
+			res.Error = err.Error()
+		}
+	}
+	command.FlushOutbox(cmdintegration.FlushOutboxTimeout)
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	_, err = opts.Output.Write(b)
+	if err != nil {
+		return err
+	}
+
+	command.Logger.Info("capabilities completed", "err", res.Error)
+	return nil
+}
@@ -48,12 +48,12 @@ func (s agentDelegate) ExportGitRepo(fetch rpcdef.GitRepoFetch) error {
 	fetch2 := gitRepoFetch{}
 	fetch2.GitRepoFetch = fetch
 	fetch2.exp = s.expin
-	s.export.gitProcessingRepos <- fetch2
+	s.export.queueGitRepo(fetch2)
 	return nil
 }
 
 func (s agentDelegate) SessionStart(isTracking bool, name string, parentSessionID int, parentObjectID, parentObjectName string) (sessionID int, lastProcessed interface{}, _ error) {
-	id, lastProcessed, err := s.expsession.SessionFlex(s.expin, isTracking, name, expsessions.ID(parentSessionID), parentObjectID, parentObjectName)
+	id, lastProcessed, err := s.export.sessions.SessionStart(s.expin, isTracking, name, expsessions.ID(parentSessionID), parentObjectID, parentObjectName)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -80,3 +80,8 @@ func (s agentDelegate) SendPauseEvent(msg string, resumeDate time.Time) error {
 func (s agentDelegate) SendResumeEvent(msg string) error {
 	return s.export.SendResumeEvent(s.expin, msg)
 }
+
+func (s agentDelegate) Heartbeat() error {
+	s.export.heartbeat(s.expin)
+	return nil
+}
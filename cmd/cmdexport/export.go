@@ -18,6 +18,8 @@ import (
 	"github.com/pinpt/agent/pkg/expin"
 	"github.com/pinpt/agent/pkg/expsessions"
 	"github.com/pinpt/agent/pkg/fs"
+	"github.com/pinpt/agent/pkg/hangdetect"
+	"github.com/pinpt/agent/pkg/iloader"
 	"github.com/pinpt/agent/pkg/memorylogs"
 
 	plugin "github.com/hashicorp/go-plugin"
@@ -50,6 +52,8 @@ func Run(opts Opts) error {
 		return err
 	}
 
+	exp.FlushOutbox(cmdintegration.FlushOutboxTimeout)
+
 	exportResults.Log(opts.Logger)
 
 	if opts.Output != nil {
@@ -87,6 +91,13 @@ type export struct {
 	gitResults map[expin.Export]map[string]error
 
 	isIncremental map[expin.Export]bool
+
+	hangDetectors   map[expin.Export]*hangdetect.Detector
+	hangDetectorsMu sync.Mutex
+
+	// map[integration.ID]map[repoID]bool
+	gitQueued   map[expin.Export]map[string]bool
+	gitQueuedMu sync.Mutex
 }
 
 type gitRepoFetch struct {
@@ -199,6 +210,12 @@ func (s *export) Run() (_ Result, rerr error) {
 		<-gitProcessingDone
 	}
 
+	err = s.sessions.WriteIssueLinks()
+	if err != nil {
+		// links are optional, do not fail the export when all integrations succeeded
+		s.Logger.Error("could not write issue links", "err", err)
+	}
+
 	err = s.updateLastProcessedTimestampsForIncrementalCheck(startTime)
 	if err != nil {
 		rerr = err
@@ -221,6 +238,18 @@ func (s *export) Run() (_ Result, rerr error) {
 
 	s.handleIntegrationPanics(runResult)
 
+	for exp, res := range runResult {
+		if res.Err != nil {
+			continue
+		}
+		err := s.sessions.CheckValidation(exp)
+		if err != nil {
+			s.Logger.Error("Export failed validation", "integration", exp.String(), "err", err)
+			res.Err = err
+			runResult[exp] = res
+		}
+	}
+
 	tempFiles, err := s.tempFilesInUploads()
 	if err != nil {
 		s.Logger.Error("could not check uploads dir for errors", "err", err)
@@ -280,6 +309,10 @@ func (s *export) discardIncrementalData() error {
 	if err != nil {
 		return err
 	}
+	err = os.RemoveAll(s.Locs.IntegrationsState)
+	if err != nil {
+		return err
+	}
 	return os.RemoveAll(s.Locs.RipsrcCheckpoints)
 }
 
@@ -324,6 +357,7 @@ type runResult struct {
 	Err      error
 	Duration time.Duration
 	Res      rpcdef.ExportResult
+	Restarts int
 }
 
 func (s *export) runExports() map[expin.Export]runResult {
@@ -333,6 +367,9 @@ func (s *export) runExports() map[expin.Export]runResult {
 	res := map[expin.Export]runResult{}
 	resMu := sync.Mutex{}
 
+	// integrations restarted after crash, s.Integrations is updated after all exports finish, since it is read concurrently
+	restarted := map[expin.Export]*iloader.Integration{}
+
 	for exp, integration := range s.Integrations {
 		wg.Add(1)
 		exp := exp
@@ -340,12 +377,17 @@ func (s *export) runExports() map[expin.Export]runResult {
 		go func() {
 			defer wg.Done()
 			start := time.Now()
+			restarts := 0
 			ret := func(err error, exportRes rpcdef.ExportResult) {
 				resMu.Lock()
 				res[exp] = runResult{
 					Duration: time.Since(start),
 					Err:      err,
 					Res:      exportRes,
+					Restarts: restarts,
+				}
+				if restarts != 0 {
+					restarted[exp] = integration.ILoader
 				}
 				resMu.Unlock()
 				if err != nil {
@@ -357,11 +399,48 @@ func (s *export) runExports() map[expin.Export]runResult {
 
 			s.Logger.Info("Export starting", "integration", exp.String())
 
-			exportRes, err := integration.ILoader.RPCClient().Export(ctx, integration.ExportConfig)
+			run := func() (rpcdef.ExportResult, error, bool) {
+				return s.runExport(ctx, exp, integration)
+			}
+			restart := func(attempt int, crashErr error) error {
+				restarts = attempt
+				loader, err := s.restartIntegration(exp, integration, attempt, crashErr)
+				if err != nil {
+					return err
+				}
+				integration.ILoader = loader
+				return nil
+			}
+			exportRes, err := runWithRestarts(s.maxRestarts(), run, restart)
 			ret(err, exportRes)
 		}()
 	}
 	wg.Wait()
 
+	for exp, loader := range restarted {
+		integration := s.Integrations[exp]
+		integration.ILoader = loader
+		s.Integrations[exp] = integration
+	}
+
 	return res
 }
+
+// runExport calls export on integration. Returns crashed = true if integration process exited during export and can be restarted.
+func (s *export) runExport(ctx context.Context, exp expin.Export, integration cmdintegration.Integration) (_ rpcdef.ExportResult, _ error, crashed bool) {
+	detector := s.newHangDetector(exp, integration)
+	exportCtx := detector.Start(ctx)
+	exportRes, err := integration.ILoader.RPCClient().Export(exportCtx, integration.ExportConfig)
+	detector.Stop()
+	if err == nil {
+		return exportRes, nil, false
+	}
+	if hang := detector.Hang(); hang != nil {
+		return exportRes, s.handleHang(exp, integration, hang), false
+	}
+	if violation := integration.ILoader.LimitViolation(); violation != nil {
+		// integration killed by sandbox shows up as a generic rpc error
+		return exportRes, fmt.Errorf("%v, rpc error: %v", violation, err), false
+	}
+	return exportRes, err, s.integrationExited(integration, err)
+}
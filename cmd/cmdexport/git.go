@@ -43,6 +43,25 @@ func (s *export) gitSessionsClose(logger hclog.Logger) error {
 	return nil
 }
 
+// queueGitRepo adds repo for git processing. Repos already queued by the same integration are skipped, these are sent again when integration is restarted after crash.
+func (s *export) queueGitRepo(fetch gitRepoFetch) {
+	s.gitQueuedMu.Lock()
+	if s.gitQueued == nil {
+		s.gitQueued = map[expin.Export]map[string]bool{}
+	}
+	if s.gitQueued[fetch.exp] == nil {
+		s.gitQueued[fetch.exp] = map[string]bool{}
+	}
+	queued := s.gitQueued[fetch.exp][fetch.RepoID]
+	s.gitQueued[fetch.exp][fetch.RepoID] = true
+	s.gitQueuedMu.Unlock()
+	if queued {
+		s.Logger.Debug("repo already queued for git processing, skipping", "integration", fetch.exp.String(), "repo", fetch.UniqueName)
+		return
+	}
+	s.gitProcessingRepos <- fetch
+}
+
 func (s *export) gitSetResult(exp expin.Export, repoID string, err error) {
 	if s.gitResults == nil {
 		s.gitResults = map[expin.Export]map[string]error{}
@@ -0,0 +1,84 @@
+package cmdexport
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pinpt/agent/cmd/cmdintegration"
+	"github.com/pinpt/agent/pkg/expin"
+	"github.com/pinpt/agent/pkg/hangdetect"
+)
+
+// newHangDetector creates hang detector for integration export. Heartbeats received by agent delegate are forwarded to it, integrations only send them while export makes progress.
+func (s *export) newHangDetector(exp expin.Export, integration cmdintegration.Integration) *hangdetect.Detector {
+	opts := hangdetect.Opts{}
+	opts.Logger = s.Logger.With("integration", exp.String())
+	opts.Config = s.Opts.AgentConfig.HangDetection
+	opts.Heartbeats = integration.ILoader.Capabilities().SupportsHeartbeat()
+	res := hangdetect.New(opts)
+
+	s.hangDetectorsMu.Lock()
+	defer s.hangDetectorsMu.Unlock()
+	if s.hangDetectors == nil {
+		s.hangDetectors = map[expin.Export]*hangdetect.Detector{}
+	}
+	s.hangDetectors[exp] = res
+	return res
+}
+
+func (s *export) heartbeat(exp expin.Export) {
+	s.hangDetectorsMu.Lock()
+	detector := s.hangDetectors[exp]
+	s.hangDetectorsMu.Unlock()
+	if detector == nil {
+		return
+	}
+	detector.Beat()
+}
+
+// handleHang saves goroutine dump of hanging integration, kills it and rolls back the open sessions it started, so that other integrations can finish and uploads do not contain partial data. Git sessions created by agent for repos of the integration are not affected. Returns the error to use as export result.
+func (s *export) handleHang(exp expin.Export, integration cmdintegration.Integration, hang error) error {
+	logger := s.Logger.With("integration", exp.String())
+
+	dumpLoc := ""
+	{
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		dump, err := integration.ILoader.GoroutineDump(ctx)
+		if err != nil {
+			logger.Error("could not get goroutine dump from integration", "err", err)
+		} else {
+			dumpLoc = filepath.Join(s.Locs.LogsIntegrations, exp.String()+".goroutines")
+			err := os.MkdirAll(s.Locs.LogsIntegrations, 0777)
+			if err == nil {
+				err = ioutil.WriteFile(dumpLoc, dump, 0644)
+			}
+			if err != nil {
+				logger.Error("could not save goroutine dump", "err", err)
+				dumpLoc = ""
+			} else {
+				logger.Info("saved goroutine dump of hanging integration", "file", dumpLoc)
+			}
+		}
+	}
+
+	err := integration.ILoader.Kill()
+	if err != nil {
+		logger.Error("could not kill hanging integration", "err", err)
+	}
+
+	n, err := s.sessions.RollbackOpen(exp)
+	if err != nil {
+		logger.Error("could not rollback sessions of hanging integration", "err", err)
+	}
+	logger.Info("killed hanging integration and rolled back open sessions", "sessions", n)
+
+	if dumpLoc != "" {
+		return fmt.Errorf("integration stopped responding and was killed: %v, goroutine dump: %v", hang, dumpLoc)
+	}
+	return fmt.Errorf("integration stopped responding and was killed: %v", hang)
+}
@@ -5,13 +5,15 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/pinpt/agent/pkg/commitusers"
 	"github.com/pinpt/go-common/hash"
 	"github.com/pinpt/integration-sdk/sourcecode"
 )
 
 type CommitUsers struct {
-	data map[string]bool
-	mu   sync.Mutex
+	data    map[string]bool
+	mu      sync.Mutex
+	aliases commitusers.Aliases
 }
 
 func NewCommitUsers() *CommitUsers {
@@ -20,6 +22,20 @@ func NewCommitUsers() *CommitUsers {
 	return s
 }
 
+// SetAliases sets the agent level email aliases, which are applied to all users passed to Transform.
+func (s *CommitUsers) SetAliases(aliases commitusers.Aliases) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aliases = aliases
+}
+
+// ResolveAlias returns canonical email based on agent level aliases. Returns passed email if there is no alias for it.
+func (s *CommitUsers) ResolveAlias(email string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.aliases.Resolve(email)
+}
+
 func (s *CommitUsers) Transform(data map[string]interface{}) (_ map[string]interface{}, _ error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -34,6 +50,9 @@ func (s *CommitUsers) Transform(data map[string]interface{}) (_ map[string]inter
 		return nil, errors.New("email is required")
 	}
 
+	// use the same email for all aliases of the user
+	email = s.aliases.Resolve(email)
+
 	// always convert email to lowercase
 	email = strings.ToLower(email)
 
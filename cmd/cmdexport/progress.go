@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 
-	"github.com/pinpt/agent/pkg/aevent"
 	"github.com/pinpt/integration-sdk/agent"
 
 	"github.com/pinpt/go-common/event"
@@ -38,5 +37,6 @@ func (s *export) sendProgress(ctx context.Context, progressData []byte) error {
 			"uuid": s.EnrollConf.DeviceID,
 		},
 	}
-	return aevent.Publish(ctx, publishEvent, s.EnrollConf.Channel, s.EnrollConf.APIKey)
+	// only the latest progress is useful, replace pending one if not sent yet
+	return s.Outbox.SendLatest(publishEvent, "progress/"+jobID)
 }
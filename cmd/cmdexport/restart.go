@@ -0,0 +1,95 @@
+package cmdexport
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pinpt/agent/cmd/cmdintegration"
+	"github.com/pinpt/agent/pkg/expin"
+	"github.com/pinpt/agent/pkg/iloader"
+	"github.com/pinpt/agent/rpcdef"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultMaxRestarts is used when max_integration_restarts is not set in agent config
+const defaultMaxRestarts = 2
+
+// maxRestarts returns how many times crashed integration is restarted in one export
+func (s *export) maxRestarts() int {
+	v := s.Opts.AgentConfig.MaxIntegrationRestarts
+	if v == 0 {
+		return defaultMaxRestarts
+	}
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// runWithRestarts calls run and restarts integration if it crashed, up to maxRestarts times.
+func runWithRestarts(maxRestarts int, run func() (_ rpcdef.ExportResult, _ error, crashed bool), restart func(attempt int, crashErr error) error) (rpcdef.ExportResult, error) {
+	restarts := 0
+	for {
+		res, err, crashed := run()
+		if !crashed || restarts >= maxRestarts {
+			if crashed && restarts != 0 {
+				err = fmt.Errorf("integration crashed after %v restarts: %v", restarts, err)
+			}
+			return res, err
+		}
+		restarts++
+		err = restart(restarts, err)
+		if err != nil {
+			return res, err
+		}
+	}
+}
+
+// integrationExited returns true if integration process exited. The rpc error could be returned before go-plugin notices process exit, so wait a bit if connection was lost.
+func (s *export) integrationExited(integration cmdintegration.Integration, rpcErr error) bool {
+	if status.Code(rpcErr) != codes.Unavailable {
+		return integration.ILoader.Exited()
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if integration.ILoader.Exited() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// restartIntegration closes crashed integration, reporting the panic, rolls back its unfinished sessions and starts a new integration process. Completed sessions are kept, so that export resumes from their last processed checkpoints.
+func (s *export) restartIntegration(exp expin.Export, integration cmdintegration.Integration, attempt int, crashErr error) (*iloader.Integration, error) {
+	logger := s.Logger.With("integration", exp.String())
+	logger.Warn("Integration crashed, restarting", "attempt", attempt, "err", crashErr)
+
+	err := s.Command.CloseOnlyIntegrationAndHandlePanic(integration.ILoader)
+	if err != nil {
+		logger.Error("could not close crashed integration", "err", err)
+	}
+
+	// keep log of crashed process, new process uses the same log file
+	logFile := integration.ILoader.LogFile()
+	err = os.Rename(logFile, fmt.Sprintf("%v.crash-%v", logFile, attempt))
+	if err != nil {
+		logger.Error("could not rename log file of crashed integration", "err", err)
+	}
+
+	n, err := s.sessions.RollbackOpen(exp)
+	if err != nil {
+		return nil, fmt.Errorf("integration crashed, could not rollback sessions before restart: %v, crash: %v", err, crashErr)
+	}
+	logger.Info("rolled back unfinished sessions of crashed integration", "sessions", n)
+
+	res, err := s.Command.LoadIntegration(exp)
+	if err != nil {
+		return nil, fmt.Errorf("integration crashed, could not restart: %v, crash: %v", err, crashErr)
+	}
+	return res, nil
+}
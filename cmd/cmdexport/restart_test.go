@@ -0,0 +1,131 @@
+package cmdexport
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pinpt/agent/cmd/cmdintegration"
+	"github.com/pinpt/agent/rpcdef"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxRestarts(t *testing.T) {
+	cases := []struct {
+		Config int
+		Want   int
+	}{
+		{0, defaultMaxRestarts},
+		{-1, 0},
+		{5, 5},
+	}
+	for _, c := range cases {
+		s := &export{Command: &cmdintegration.Command{}}
+		s.Opts.AgentConfig.MaxIntegrationRestarts = c.Config
+		assert.Equal(t, c.Want, s.maxRestarts(), "max_integration_restarts: %v", c.Config)
+	}
+}
+
+// testRunner returns crash for the first crashes calls of run, success after that
+type testRunner struct {
+	crashes  int
+	runs     int
+	restarts []int
+}
+
+func (s *testRunner) run() (rpcdef.ExportResult, error, bool) {
+	s.runs++
+	if s.runs <= s.crashes {
+		return rpcdef.ExportResult{}, errors.New("crash"), true
+	}
+	return rpcdef.ExportResult{}, nil, false
+}
+
+func (s *testRunner) restart(attempt int, crashErr error) error {
+	s.restarts = append(s.restarts, attempt)
+	return nil
+}
+
+func TestRunWithRestartsSuccess(t *testing.T) {
+	r := &testRunner{crashes: 2}
+	_, err := runWithRestarts(2, r.run, r.restart)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, r.runs)
+	assert.Equal(t, []int{1, 2}, r.restarts)
+}
+
+func TestRunWithRestartsLimit(t *testing.T) {
+	r := &testRunner{crashes: 10}
+	_, err := runWithRestarts(2, r.run, r.restart)
+	assert.EqualError(t, err, "integration crashed after 2 restarts: crash")
+	assert.Equal(t, 3, r.runs)
+	assert.Equal(t, []int{1, 2}, r.restarts)
+}
+
+func TestRunWithRestartsDisabled(t *testing.T) {
+	s := &export{Command: &cmdintegration.Command{}}
+	s.Opts.AgentConfig.MaxIntegrationRestarts = -1
+	r := &testRunner{crashes: 1}
+	_, err := runWithRestarts(s.maxRestarts(), r.run, r.restart)
+	assert.EqualError(t, err, "crash")
+	assert.Equal(t, 1, r.runs)
+	assert.Empty(t, r.restarts)
+}
+
+func TestRunWithRestartsRestartFailed(t *testing.T) {
+	r := &testRunner{crashes: 1}
+	restart := func(attempt int, crashErr error) error {
+		return errors.New("could not restart")
+	}
+	_, err := runWithRestarts(2, r.run, restart)
+	assert.EqualError(t, err, "could not restart")
+	assert.Equal(t, 1, r.runs)
+}
+
+// TestRestartResumeFromCompletedSessions checks that after rolling back sessions of crashed integration, restarted integration gets last processed of completed sessions and the previous last processed for rolled back ones.
+func TestRestartResumeFromCompletedSessions(t *testing.T) {
+	lastProcessed := lastProcessedMock{}
+	s := newTestSessions(lastProcessed)
+
+	// state from previous export
+	done, _, err := s.new(testIn, "m2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.ExportDone(done, "m2-old")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// export before crash, m1 completed, m2 in progress
+	done, _, err = s.new(testIn, "m1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.ExportDone(done, "m1-new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, lp, err := s.new(testIn, "m2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "m2-old", lp)
+
+	n, err := s.RollbackOpen(testIn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, n)
+
+	// restarted integration
+	_, lp, err = s.new(testIn, "m1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "m1-new", lp)
+	_, lp, err = s.new(testIn, "m2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "m2-old", lp)
+}
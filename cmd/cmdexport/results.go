@@ -18,12 +18,18 @@ type Result struct {
 
 type ResultIntegration struct {
 	// for sorting to maintain the same order as in passed integration list
-	index       int
-	ID          string          `json:"id"`
+	index int
+	ID    string `json:"id"`
+	// Name is the name of integration binary
+	Name        string          `json:"name"`
 	Error       string          `json:"error"`
 	Projects    []ResultProject `json:"projects"`
 	Duration    time.Duration   `json:"duration"`
 	Incremental bool            `json:"incremental"`
+	// Restarts is the number of times integration was restarted after crash
+	Restarts int `json:"restarts"`
+	// Capabilities is the manifest returned by integration on init, nil if integration was not loaded
+	Capabilities *rpcdef.Capabilities `json:"capabilities,omitempty"`
 }
 
 type ResultProject struct {
@@ -57,11 +63,17 @@ func (s *export) formatResults(runResult map[expin.Export]runResult, startTime t
 		res := ResultIntegration{}
 		res.index = exp.Index
 		res.ID = exp.String()
+		res.Name = exp.IntegrationDef.Name
 		if res0.Err != nil {
 			res.Error = res0.Err.Error()
 		}
 		res.Duration = res0.Duration
 		res.Incremental = s.isIncremental[exp]
+		res.Restarts = res0.Restarts
+		if integration, ok := s.Integrations[exp]; ok && integration.ILoader != nil {
+			capabilities := integration.ILoader.Capabilities()
+			res.Capabilities = &capabilities
+		}
 		for _, project0 := range res0.Res.Projects {
 			project := ResultProject{}
 			project.ExportProject = project0
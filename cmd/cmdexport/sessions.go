@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
@@ -13,6 +15,9 @@ import (
 	"github.com/pinpt/agent/pkg/commitusers"
 	"github.com/pinpt/agent/pkg/expin"
 	"github.com/pinpt/agent/pkg/expsessions"
+	"github.com/pinpt/agent/pkg/issuelinks"
+	"github.com/pinpt/agent/pkg/jsonstore"
+	"github.com/pinpt/agent/pkg/objvalidate"
 	"github.com/pinpt/agent/rpcdef"
 )
 
@@ -26,7 +31,19 @@ type sessions struct {
 
 	dedupStore expsessions.DedupStore
 
+	issueLinks *issuelinks.Linker
+
+	quarantine *objvalidate.Quarantine
+
 	trackProgress bool
+
+	// open contains sessions started by integrations that are not done yet. Used to rollback sessions of crashed or hanging integrations. Does not include git sessions created by agent.
+	open   map[expsessions.ID]expin.Export
+	openMu sync.Mutex
+
+	// lastProcessed is saved together with dedup store on checkpoint, nil skips checkpoints
+	lastProcessed *jsonstore.Store
+	checkpointMu  sync.Mutex
 }
 
 func newSessions(logger hclog.Logger, export *export, trackProgress bool) (_ *sessions, rerr error) {
@@ -35,7 +52,10 @@ func newSessions(logger hclog.Logger, export *export, trackProgress bool) (_ *se
 	s.logger = logger
 	s.export = export
 	s.commitUsers = process.NewCommitUsers()
+	s.commitUsers.SetAliases(commitusers.NewAliases(export.Opts.AgentConfig.CommitUserAliases))
 	s.trackProgress = trackProgress
+	s.open = map[expsessions.ID]expin.Export{}
+	s.lastProcessed = export.lastProcessed
 
 	if s.trackProgress {
 		s.progressTracker = expsessions.NewProgressTracker()
@@ -59,6 +79,39 @@ func newSessions(logger hclog.Logger, export *export, trackProgress bool) (_ *se
 		}
 	}
 
+	{
+		var err error
+		s.issueLinks, err = issuelinks.New(issuelinks.Opts{
+			Logger:     logger,
+			CustomerID: export.Opts.AgentConfig.CustomerID,
+			Config:     export.Opts.AgentConfig.IssueLinks,
+		})
+		if err != nil {
+			rerr = err
+			return
+		}
+		// scan all objects before dedup, since unchanged objects are still needed for linking
+		newWriterPrev := newWriter
+		newWriter = func(modelName string, id expsessions.ID) expsessions.Writer {
+			wr := newWriterPrev(modelName, id)
+			return s.issueLinks.NewWriter(wr, modelName)
+		}
+	}
+
+	{
+		s.quarantine = objvalidate.New(objvalidate.Opts{
+			Logger:     logger,
+			UploadsDir: export.Locs.Uploads,
+			Config:     export.Opts.AgentConfig.Validation,
+		})
+		// validate first, so that invalid objects are not used for links or saved in dedup store
+		newWriterPrev := newWriter
+		newWriter = func(modelName string, id expsessions.ID) expsessions.Writer {
+			wr := newWriterPrev(modelName, id)
+			return s.quarantine.NewWriter(wr, modelName, s.expsession.GetExport(id).String(), id)
+		}
+	}
+
 	s.expsession = expsessions.New(expsessions.Opts{
 		Logger:        logger,
 		LastProcessed: export.lastProcessed,
@@ -122,6 +175,34 @@ func (s *sessions) sendProgress() {
 	}
 }
 
+// WriteIssueLinks writes links between sourcecode objects and issues found in this export. Call after all integrations and git processing finished.
+func (s *sessions) WriteIssueLinks() error {
+	links := s.issueLinks.Links()
+	if len(links) == 0 {
+		return nil
+	}
+	// links are not specific to one integration, use separate root session
+	id, _, err := s.expsession.SessionRootAgent("issuelinks", issuelinks.TableName)
+	if err != nil {
+		return err
+	}
+	var data []map[string]interface{}
+	for _, link := range links {
+		data = append(data, link.ToMap())
+	}
+	err = s.expsession.Write(id, data)
+	if err != nil {
+		s.expsession.Rollback(id)
+		return err
+	}
+	return s.expsession.Done(id, nil)
+}
+
+// CheckValidation returns an error if integration exported more invalid objects than allowed by config.
+func (s *sessions) CheckValidation(exp expin.Export) error {
+	return s.quarantine.CheckThresholds(exp.String())
+}
+
 func (s *sessions) Close() error {
 
 	if s.trackProgress {
@@ -138,7 +219,8 @@ func (s *sessions) Close() error {
 			return err
 		}
 	}
-	return nil
+
+	return s.quarantine.WriteSummary()
 }
 
 func (s *sessions) new(export expin.Export, modelType string) (
@@ -148,18 +230,93 @@ func (s *sessions) new(export expin.Export, modelType string) (
 	if err != nil {
 		return "", nil, err
 	}
+	s.setOpen(id, export)
 	return idToString(id), lastProcessed, nil
 }
 
+// SessionStart creates a session for integration.
+func (s *sessions) SessionStart(export expin.Export, isTracking bool, name string, parentSessionID expsessions.ID, parentObjectID, parentObjectName string) (_ expsessions.ID, lastProcessed interface{}, _ error) {
+	id, lastProcessed, err := s.expsession.SessionFlex(export, isTracking, name, parentSessionID, parentObjectID, parentObjectName)
+	if err != nil {
+		return 0, nil, err
+	}
+	s.setOpen(id, export)
+	return id, lastProcessed, nil
+}
+
 func (s *sessions) ExportDone(sessionID string, lastProcessed interface{}) error {
 	id := idFromString(sessionID)
-	return s.expsession.Done(id, lastProcessed)
+	s.setDone(id)
+	err := s.expsession.Done(id, lastProcessed)
+	if err != nil {
+		return err
+	}
+	s.checkpoint()
+	return nil
+}
+
+// checkpoint saves last processed values and dedup state of completed sessions, so that export interrupted by agent restart resumes from them. Output files of completed sessions are already in uploads dir at this point. Saved after every completed session, since output of completed sessions is kept on resume and would be exported again if not included in the checkpoint.
+func (s *sessions) checkpoint() {
+	if s.lastProcessed == nil {
+		return
+	}
+	s.checkpointMu.Lock()
+	defer s.checkpointMu.Unlock()
+
+	err := s.lastProcessed.Save()
+	if err != nil {
+		s.logger.Error("could not save last processed checkpoint", "err", err)
+		return
+	}
+	if s.dedupStore != nil {
+		err := s.dedupStore.Save()
+		if err != nil {
+			s.logger.Error("could not save dedup checkpoint", "err", err)
+		}
+	}
 }
 
 func (s *sessions) Rollback(id expsessions.ID) error {
+	s.setDone(id)
 	return s.expsession.Rollback(id)
 }
 
+func (s *sessions) setOpen(id expsessions.ID, export expin.Export) {
+	s.openMu.Lock()
+	defer s.openMu.Unlock()
+	s.open[id] = export
+}
+
+func (s *sessions) setDone(id expsessions.ID) {
+	s.openMu.Lock()
+	defer s.openMu.Unlock()
+	delete(s.open, id)
+}
+
+// RollbackOpen rolls back all sessions started by integration that are not done. Completed sessions and their last processed checkpoints are kept. Returns the number of sessions rolled back.
+func (s *sessions) RollbackOpen(export expin.Export) (int, error) {
+	var ids []expsessions.ID
+	s.openMu.Lock()
+	for id, exp := range s.open {
+		if exp == export {
+			ids = append(ids, id)
+		}
+	}
+	s.openMu.Unlock()
+
+	// rollback child sessions before parents
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] > ids[j]
+	})
+	for _, id := range ids {
+		err := s.Rollback(id)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
 func idToString(id expsessions.ID) string {
 	return strconv.Itoa(int(id))
 }
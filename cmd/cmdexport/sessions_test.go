@@ -0,0 +1,94 @@
+package cmdexport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/cmd/cmdrunnorestarts/inconfig"
+	"github.com/pinpt/agent/pkg/expin"
+	"github.com/pinpt/agent/pkg/expsessions"
+	"github.com/stretchr/testify/assert"
+)
+
+type lastProcessedMock map[string]interface{}
+
+func (s lastProcessedMock) Get(key ...string) interface{} {
+	return s[strings.Join(key, "@")]
+}
+
+func (s lastProcessedMock) Set(value interface{}, key ...string) error {
+	s[strings.Join(key, "@")] = value
+	return nil
+}
+
+var testIn = expin.Export{IntegrationID: "1", IntegrationDef: inconfig.IntegrationDef{Name: "in1"}}
+var testIn2 = expin.Export{IntegrationID: "2", IntegrationDef: inconfig.IntegrationDef{Name: "in2"}}
+
+func newTestSessions(lastProcessed lastProcessedMock) *sessions {
+	s := &sessions{}
+	s.logger = hclog.NewNullLogger()
+	s.open = map[expsessions.ID]expin.Export{}
+	s.expsession = expsessions.New(expsessions.Opts{
+		Logger:        s.logger,
+		LastProcessed: lastProcessed,
+		NewWriter: func(modelName string, id expsessions.ID) expsessions.Writer {
+			return expsessions.NewMockWriter()
+		},
+	})
+	return s
+}
+
+func TestSessionsRollbackOpen(t *testing.T) {
+	s := newTestSessions(lastProcessedMock{})
+
+	// completed session
+	done, _, err := s.new(testIn, "m1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.ExportDone(done, "lp1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// open sessions of integration
+	root, _, err := s.new(testIn, "m2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, _, err := s.SessionStart(testIn, false, "m3", idFromString(root), "o1", "o1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// git session created by agent for the same integration
+	git, _, err := s.expsession.SessionRootTracking(testIn, "git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitChild, _, err := s.expsession.Session("commits", git, "r1", "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// session of other integration
+	other, _, err := s.new(testIn2, "m1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := s.RollbackOpen(testIn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, n)
+	assert.True(t, s.expsession.GetExport(child).Empty(), "child session was rolled back")
+	assert.True(t, s.expsession.GetExport(idFromString(root)).Empty(), "root session was rolled back")
+	assert.Equal(t, testIn, s.expsession.GetExport(git), "git session is kept")
+	assert.Equal(t, testIn, s.expsession.GetExport(gitChild), "git child session is kept")
+	assert.Equal(t, testIn2, s.expsession.GetExport(idFromString(other)), "other integration session is kept")
+
+	n, err = s.RollbackOpen(testIn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, n)
+}
@@ -23,6 +23,7 @@ func Run(opts Opts) error {
 	if err != nil {
 		return err
 	}
+	exp.FlushOutbox(cmdintegration.FlushOutboxTimeout)
 	return exp.Destroy()
 }
 
@@ -91,6 +92,11 @@ func (s *export) runExport() (data interface{}, _ error) {
 	ctx := context.Background()
 	client := s.integration.ILoader.RPCClient()
 
+	if !s.integration.ILoader.Capabilities().SupportsOnboard(s.Opts.ExportType) {
+		_ = s.CloseOnlyIntegrationAndHandlePanic(s.integration.ILoader)
+		return nil, fmt.Errorf("could not retrive data for onboard type: %v integration: %v err: %v", s.Opts.ExportType, s.integration.Export.String(), rpcdef.ErrOnboardExportNotSupported)
+	}
+
 	start := time.Now()
 	res, err := client.OnboardExport(ctx, s.Opts.ExportType, s.integration.ExportConfig)
 	if err != nil {
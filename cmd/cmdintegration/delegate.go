@@ -66,3 +66,7 @@ func (s agentDelegate) SendResumeEvent(msg string) error {
 
 	return nil
 }
+
+func (s agentDelegate) Heartbeat() error {
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"syscall"
 	"time"
@@ -21,7 +22,17 @@ import (
 	"github.com/pinpt/agent/pkg/agentconf"
 	"github.com/pinpt/agent/pkg/deviceinfo"
 	"github.com/pinpt/agent/pkg/fsconf"
+	"github.com/pinpt/agent/pkg/gitclone"
+	"github.com/pinpt/agent/pkg/hangdetect"
 	"github.com/pinpt/agent/pkg/iloader"
+	"github.com/pinpt/agent/pkg/issuelinks"
+	"github.com/pinpt/agent/pkg/istate"
+	"github.com/pinpt/agent/pkg/netconf"
+	"github.com/pinpt/agent/pkg/objvalidate"
+	"github.com/pinpt/agent/pkg/plugins"
+	"github.com/pinpt/agent/pkg/pushevents"
+	"github.com/pinpt/agent/pkg/ratelimit"
+	"github.com/pinpt/agent/pkg/sandbox"
 	"github.com/pinpt/agent/rpcdef"
 	"github.com/pinpt/go-common/datamodel"
 	"github.com/pinpt/go-common/event"
@@ -45,6 +56,33 @@ type AgentConfig struct {
 	// DevUseCompiledIntegrations set to true to use compiled integrations in dev build. They are used by default in prod builds.
 	DevUseCompiledIntegrations bool `json:"dev_use_compiled_integrations"`
 
+	// CommitUserAliases maps alternative commit emails to the canonical email of the same person. Applied to commit users from all integrations, after repo .mailmap.
+	CommitUserAliases map[string]string `json:"commit_user_aliases"`
+
+	// IssueLinks configures linking of commits, branches and pull requests to work issues. Project keys from work integrations exported in the same run are always used.
+	IssueLinks issuelinks.Config `json:"issue_links"`
+
+	// Validation configures datamodel validation of exported objects. Invalid objects are always moved to quarantine, thresholds define when integration fails.
+	Validation objvalidate.Config `json:"validation"`
+
+	// Plugins configures trusted keys and allowlist for third-party integration plugins.
+	Plugins plugins.Config `json:"plugins"`
+
+	// Sandbox configures resource limits and filesystem restrictions for integration processes. Only supported on linux.
+	Sandbox sandbox.Config `json:"sandbox"`
+
+	// HangDetection configures export timeout and heartbeat timeout. Hanging integrations are killed and their open sessions rolled back.
+	HangDetection hangdetect.Config `json:"hang_detection"`
+
+	// MaxIntegrationRestarts is the number of times crashed integration is restarted during export. Defaults to 2, set to -1 to disable.
+	MaxIntegrationRestarts int `json:"max_integration_restarts"`
+
+	// Network configures proxy, additional CAs and client certificates for outbound connections. Passed to integrations and git using env variables.
+	Network netconf.Config `json:"network"`
+
+	// RateLimits configures max concurrent requests per host. Passed to integrations using env variable, concurrency is reduced further when servers throttle requests.
+	RateLimits ratelimit.Config `json:"rate_limits"`
+
 	Backend struct {
 		// Enable enables calls to pinpoint backend. It is disabled by default, but is required for the following features:
 		// - sending progress data to backend
@@ -93,8 +131,13 @@ type Command struct {
 	EnrollConf agentconf.Config
 	Deviceinfo deviceinfo.CommonInfo
 
+	// Outbox is used to send events to backend, only set if Backend.Enable is true
+	Outbox *aevent.Outbox
+
 	integrationsDir            string
 	devUseCompiledIntegrations bool
+
+	agentDelegates func(ind expin.Export) rpcdef.Agent
 }
 
 func NewCommand(opts Opts) (*Command, error) {
@@ -112,6 +155,31 @@ func NewCommand(opts Opts) (*Command, error) {
 		return nil, err
 	}
 
+	// before loading integrations, so that they inherit the env variables
+	err = netconf.Setup(opts.AgentConfig.Network, s.Locs.CABundle)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network config: %v", err)
+	}
+	err = ratelimit.SetEnv(opts.AgentConfig.RateLimits)
+	if err != nil {
+		return nil, err
+	}
+	// integrations reading artifacts from cloned repos, such as sarif, use the same cache as ripsrc
+	err = os.Setenv(gitclone.EnvCacheRoot, s.Locs.RepoCache)
+	if err != nil {
+		return nil, err
+	}
+	// integrations keeping state that does not fit in last processed, such as sarif issues
+	err = os.Setenv(istate.EnvDir, s.Locs.IntegrationsState)
+	if err != nil {
+		return nil, err
+	}
+	// push integration exports events received by push endpoint of the service
+	err = os.Setenv(pushevents.EnvSpoolDir, s.Locs.PushSpool)
+	if err != nil {
+		return nil, err
+	}
+
 	s.integrationsDir = opts.AgentConfig.IntegrationsDir
 	s.devUseCompiledIntegrations = opts.AgentConfig.DevUseCompiledIntegrations
 	if s.integrationsDir == "" {
@@ -143,6 +211,17 @@ func NewCommand(opts Opts) (*Command, error) {
 		if err != nil {
 			return nil, err
 		}
+		// subcommands can run at the same time, each process uses its own dir
+		s.Outbox, err = aevent.NewOutbox(aevent.OutboxOpts{
+			Logger:  s.Logger,
+			Dir:     OutboxDir(s.Locs, os.Getpid()),
+			Channel: s.EnrollConf.Channel,
+			APIKey:  s.EnrollConf.APIKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		go s.Outbox.Run(context.Background())
 	}
 
 	return s, nil
@@ -206,20 +285,14 @@ func (s *Command) SetupIntegrations(
 	if agentDelegates == nil {
 		agentDelegates = AgentDelegateMinFactory(s.Logger, s)
 	}
+	s.agentDelegates = agentDelegates
 
 	var ins []expin.Export
 	for _, in := range s.Integrations {
 		ins = append(ins, in.Export)
 	}
 
-	opts := iloader.Opts{}
-	opts.Logger = s.Logger
-	opts.Locs = s.Locs
-	opts.AgentDelegates = agentDelegates
-	opts.IntegrationsDir = s.integrationsDir
-	opts.DevUseCompiledIntegrations = s.devUseCompiledIntegrations
-	loader := iloader.New(opts)
-	res, err := loader.Load(ins)
+	res, err := s.newLoader().Load(ins)
 	if err != nil {
 		return err
 	}
@@ -237,6 +310,30 @@ func (s *Command) SetupIntegrations(
 	return nil
 }
 
+func (s *Command) newLoader() *iloader.Loader {
+	opts := iloader.Opts{}
+	opts.Logger = s.Logger
+	opts.Locs = s.Locs
+	opts.AgentDelegates = s.agentDelegates
+	opts.IntegrationsDir = s.integrationsDir
+	opts.DevUseCompiledIntegrations = s.devUseCompiledIntegrations
+	opts.Plugins = s.Opts.AgentConfig.Plugins
+	opts.Sandbox = s.Opts.AgentConfig.Sandbox
+	return iloader.New(opts)
+}
+
+// LoadIntegration starts a new integration process for export. Used to restart crashed integrations. Does not modify Integrations, caller needs to close the previous process and update ILoader when safe.
+func (s *Command) LoadIntegration(exp expin.Export) (*iloader.Integration, error) {
+	if s.agentDelegates == nil {
+		panic("call SetupIntegrations before LoadIntegration")
+	}
+	res, err := s.newLoader().Load([]expin.Export{exp})
+	if err != nil {
+		return nil, err
+	}
+	return res[exp], nil
+}
+
 func (s *Command) CloseOnlyIntegrationAndHandlePanic(integration *iloader.Integration) error {
 	panicOut, err := integration.CloseAndDetectPanic()
 	if panicOut != "" {
@@ -315,5 +412,36 @@ func (s *Command) sendEvent(data datamodel.Model) error {
 			"job_id":      s.Opts.AgentConfig.Backend.ExportJobID,
 		},
 	}
-	return aevent.Publish(context.Background(), publishEvent, s.EnrollConf.Channel, s.EnrollConf.APIKey)
+	return s.Outbox.Send(publishEvent, "")
+}
+
+// OutboxDirs returns the dir containing outbox dirs of subcommand processes.
+func OutboxDirs(locs fsconf.Locs) string {
+	return filepath.Join(locs.Outbox, "subcommands")
+}
+
+// OutboxDir returns the outbox dir of subcommand process. Run command adopts events left in these dirs after subcommand exits.
+func OutboxDir(locs fsconf.Locs, pid int) string {
+	return filepath.Join(OutboxDirs(locs), strconv.Itoa(pid))
+}
+
+// FlushOutboxTimeout is the time subcommands wait for events to be sent before exiting.
+const FlushOutboxTimeout = 30 * time.Second
+
+// FlushOutbox waits until events are sent to backend and deletes outbox dir. Call at the end of every subcommand. Events not sent in timeout are kept and sent by run command.
+func (s *Command) FlushOutbox(timeout time.Duration) {
+	if s.Outbox == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := s.Outbox.Flush(ctx)
+	if err != nil {
+		s.Logger.Warn("could not send all events to backend, will retry from run command", "err", err)
+		return
+	}
+	err = os.RemoveAll(OutboxDir(s.Locs, os.Getpid()))
+	if err != nil {
+		s.Logger.Error("could not delete outbox dir", "err", err)
+	}
 }
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pinpt/agent/integrations/pkg/mutate"
 	"github.com/pinpt/agent/rpcdef"
 
 	"github.com/pinpt/agent/cmd/cmdintegration"
@@ -39,6 +40,7 @@ func Run(opts Opts) error {
 	if err != nil {
 		return err
 	}
+	exp.FlushOutbox(cmdintegration.FlushOutboxTimeout)
 	return exp.Destroy()
 }
 
@@ -121,6 +123,11 @@ func (s *export) run() (_ rpcdef.MutateResult, rerr error) {
 	ctx := context.Background()
 	client := s.integration.ILoader.RPCClient()
 
+	if !s.integration.ILoader.Capabilities().SupportsMutation(s.Opts.Mutation.Fn) {
+		_ = s.CloseOnlyIntegrationAndHandlePanic(s.integration.ILoader)
+		return mutate.ResultNotSupported(s.Opts.Mutation.Fn), nil
+	}
+
 	data, err := json.Marshal(s.Opts.Mutation.Data)
 	if err != nil {
 		rerr = err
@@ -0,0 +1,58 @@
+// Package cmdplugins contains commands for managing third-party integration plugins.
+package cmdplugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pinpt/agent/pkg/plugins"
+)
+
+type Opts struct {
+	// IntegrationsDir is the integrations dir containing plugins subdir
+	IntegrationsDir string
+	Config          plugins.Config
+	// JSON outputs plugins as json instead of table
+	JSON   bool
+	Output io.Writer
+}
+
+// List prints installed plugins with verification status.
+func List(opts Opts) error {
+	res, err := plugins.List(opts.IntegrationsDir, opts.Config)
+	if err != nil {
+		return err
+	}
+	if opts.JSON {
+		if res == nil {
+			res = []plugins.Plugin{}
+		}
+		b, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = opts.Output.Write(append(b, '\n'))
+		return err
+	}
+	if len(res) == 0 {
+		_, err := fmt.Fprintln(opts.Output, "No plugins installed in", plugins.Dir(opts.IntegrationsDir))
+		return err
+	}
+	wr := tabwriter.NewWriter(opts.Output, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(wr, "NAME\tVERSION\tTYPES\tSTATUS")
+	for _, p := range res {
+		status := "verified (" + p.TrustedBy + ")"
+		if !p.Verified() {
+			status = "error: " + p.Error
+		}
+		name := p.Manifest.Name
+		if name == "" {
+			name = p.Dir
+		}
+		fmt.Fprintf(wr, "%v\t%v\t%v\t%v\n", name, p.Manifest.Version, strings.Join(p.Manifest.Types, ","), status)
+	}
+	return wr.Flush()
+}
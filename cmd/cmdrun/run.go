@@ -101,12 +101,17 @@ func (s *runner) runService(ctx context.Context) error {
 	defer errFile.Close()
 	stderr := io.MultiWriter(os.Stderr, errFile)
 
+	ctx, watch := s.watchPendingUpdate(ctx)
+
 	cmd := exec.CommandContext(ctx, os.Args[0], "run", "--no-restarts",
 		"--pinpoint-root", s.opts.PinpointRoot)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = stderr
 	runErr := cmd.Run()
-	if runErr.Error() == "exit status 2" {
+	if watch != nil {
+		watch.done(runErr)
+	}
+	if runErr != nil && runErr.Error() == "exit status 2" {
 		s.logger.Info("exited from run --no-restarts")
 		return runErr
 	}
@@ -0,0 +1,94 @@
+package cmdrun
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/pinpt/agent/cmd/cmdrunnorestarts/updater"
+)
+
+// updateGracePeriod is the time new version has to confirm start, overridden in tests
+var updateGracePeriod = updater.GracePeriod
+
+// updateWatch rolls back the agent update if the new version crashes or does not send the enabled event in updater.GracePeriod. Service runner is still running the previous version code, so it can restore it even if the new version is broken.
+type updateWatch struct {
+	runner   *runner
+	cancel   context.CancelFunc
+	timer    *time.Timer
+	timedOut int32
+}
+
+// watchPendingUpdate checks for pending update before starting the agent. Returns context that is cancelled when the new version does not confirm start in grace period. Returns nil watch if there is no update to check.
+func (s *runner) watchPendingUpdate(ctx context.Context) (context.Context, *updateWatch) {
+	pending, err := updater.LoadPending(s.fsconf)
+	if err != nil {
+		s.logger.Error("could not load pending update", "err", err)
+		return ctx, nil
+	}
+	if pending == nil || pending.RolledBack {
+		return ctx, nil
+	}
+	if pending.Started {
+		// new version was started before, but service runner exited before it confirmed
+		s.rollbackUpdate(pending, "new version did not confirm successful start before service restart")
+		return ctx, nil
+	}
+	pending.Started = true
+	err = updater.SavePending(s.fsconf, *pending)
+	if err != nil {
+		s.logger.Error("could not save pending update", "err", err)
+		return ctx, nil
+	}
+
+	s.logger.Info("starting updated agent, waiting for enabled event", "from_version", pending.FromVersion, "to_version", pending.ToVersion, "grace_period", updateGracePeriod.String())
+
+	ctx, cancel := context.WithCancel(ctx)
+	w := &updateWatch{runner: s, cancel: cancel}
+	w.timer = time.AfterFunc(updateGracePeriod, func() {
+		p, err := updater.LoadPending(s.fsconf)
+		if err != nil {
+			s.logger.Error("could not load pending update", "err", err)
+			return
+		}
+		if p == nil || !p.Started {
+			// confirmed, or confirmed and updated again
+			return
+		}
+		atomic.StoreInt32(&w.timedOut, 1)
+		s.logger.Error("updated agent did not send enabled event in grace period, stopping it", "to_version", p.ToVersion)
+		cancel()
+	})
+	return ctx, w
+}
+
+// done is called after the agent process exits. Rolls back the update if it was not confirmed.
+func (s *updateWatch) done(runErr error) {
+	s.timer.Stop()
+	s.cancel()
+	pending, err := updater.LoadPending(s.runner.fsconf)
+	if err != nil {
+		s.runner.logger.Error("could not load pending update", "err", err)
+		return
+	}
+	if pending == nil || pending.RolledBack || !pending.Started {
+		// confirmed, or confirmed and updated again
+		return
+	}
+	reason := fmt.Sprintf("new version exited before sending enabled event, err: %v", runErr)
+	if atomic.LoadInt32(&s.timedOut) == 1 {
+		reason = fmt.Sprintf("new version did not send enabled event in %v", updateGracePeriod)
+	}
+	s.runner.rollbackUpdate(pending, reason)
+}
+
+func (s *runner) rollbackUpdate(pending *updater.PendingUpdate, reason string) {
+	s.logger.Error("rolling back agent update", "from_version", pending.FromVersion, "to_version", pending.ToVersion, "reason", reason)
+	err := pending.Rollback(s.fsconf, reason)
+	if err != nil {
+		s.logger.Error("could not roll back agent update", "err", err)
+		return
+	}
+	s.logger.Info("rolled back agent update", "version", pending.FromVersion)
+}
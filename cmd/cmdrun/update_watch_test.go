@@ -0,0 +1,163 @@
+package cmdrun
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/cmd/cmdrunnorestarts/updater"
+	"github.com/pinpt/agent/pkg/fsconf"
+	"github.com/stretchr/testify/assert"
+)
+
+type updateWatchTest struct {
+	t      *testing.T
+	dir    string
+	runner *runner
+}
+
+func newUpdateWatchTest(t *testing.T) *updateWatchTest {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &updateWatchTest{t: t, dir: dir}
+	s.runner = &runner{logger: hclog.NewNullLogger(), fsconf: fsconf.New(dir)}
+	return s
+}
+
+func (s *updateWatchTest) Close() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *updateWatchTest) write(loc, data string) {
+	err := ioutil.WriteFile(filepath.Join(s.dir, loc), []byte(data), 0666)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+func (s *updateWatchTest) read(loc string) string {
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, loc))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return string(b)
+}
+
+// savePending simulates updater replacing agent binary from v1 to v2
+func (s *updateWatchTest) savePending(p updater.PendingUpdate) {
+	s.write("agent", "v2")
+	s.write("agent.old0", "v1")
+	p.FromVersion = "v1"
+	p.ToVersion = "v2"
+	p.Replaced = []updater.Replaced{{Loc: filepath.Join(s.dir, "agent"), Backup: filepath.Join(s.dir, "agent.old0")}}
+	err := updater.SavePending(s.runner.fsconf, p)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+func (s *updateWatchTest) pending() *updater.PendingUpdate {
+	res, err := updater.LoadPending(s.runner.fsconf)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return res
+}
+
+func TestUpdateWatchNoPendingUpdate(t *testing.T) {
+	s := newUpdateWatchTest(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	ctx2, w := s.runner.watchPendingUpdate(ctx)
+	assert.Nil(t, w)
+	assert.Equal(t, ctx, ctx2)
+}
+
+func TestUpdateWatchConfirmed(t *testing.T) {
+	s := newUpdateWatchTest(t)
+	defer s.Close()
+	s.savePending(updater.PendingUpdate{})
+
+	ctx, w := s.runner.watchPendingUpdate(context.Background())
+	if !assert.NotNil(t, w) {
+		return
+	}
+	assert.True(t, s.pending().Started, "version change starts the watch")
+
+	// new version sent enabled event
+	err := updater.RemovePending(s.runner.fsconf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.done(nil)
+	assert.Error(t, ctx.Err(), "context is cancelled after the process exits")
+	assert.Nil(t, s.pending())
+	assert.Equal(t, "v2", s.read("agent"))
+}
+
+func TestUpdateWatchRollbackOnExit(t *testing.T) {
+	s := newUpdateWatchTest(t)
+	defer s.Close()
+	s.savePending(updater.PendingUpdate{})
+
+	_, w := s.runner.watchPendingUpdate(context.Background())
+	if !assert.NotNil(t, w) {
+		return
+	}
+	w.done(errors.New("exit status 1"))
+
+	p := s.pending()
+	assert.True(t, p.RolledBack)
+	assert.Contains(t, p.Error, "exit status 1")
+	assert.Equal(t, "v1", s.read("agent"))
+	assert.Equal(t, "v2", s.read("agent.failed"))
+
+	// previous version is started without watch until it reports failed update
+	_, w = s.runner.watchPendingUpdate(context.Background())
+	assert.Nil(t, w)
+}
+
+func TestUpdateWatchRollbackOnTimeout(t *testing.T) {
+	s := newUpdateWatchTest(t)
+	defer s.Close()
+	s.savePending(updater.PendingUpdate{})
+	defer func(v time.Duration) { updateGracePeriod = v }(updateGracePeriod)
+	updateGracePeriod = 10 * time.Millisecond
+
+	ctx, w := s.runner.watchPendingUpdate(context.Background())
+	if !assert.NotNil(t, w) {
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context was not cancelled after grace period")
+	}
+	w.done(errors.New("signal: killed"))
+
+	p := s.pending()
+	assert.True(t, p.RolledBack)
+	assert.Contains(t, p.Error, "did not send enabled event in")
+	assert.Equal(t, "v1", s.read("agent"))
+}
+
+func TestUpdateWatchRollbackStartedBeforeRestart(t *testing.T) {
+	s := newUpdateWatchTest(t)
+	defer s.Close()
+	// service runner exited while new version was running
+	s.savePending(updater.PendingUpdate{Started: true})
+
+	_, w := s.runner.watchPendingUpdate(context.Background())
+	assert.Nil(t, w)
+	p := s.pending()
+	assert.True(t, p.RolledBack)
+	assert.Equal(t, "v1", s.read("agent"))
+}
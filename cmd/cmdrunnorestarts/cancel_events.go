@@ -61,7 +61,7 @@ func (s *runner) handleCancelEvents(ctx context.Context) (closefunc, error) {
 				s.logger.Error("error processing cancel request", "err", err.Error())
 			}
 		}
-		return datamodel.NewModelSendEvent(resp), nil
+		return s.sendResponse(resp, ev.ID)
 	}
 
 	sub, err := action.Register(ctx, action.NewAction(cb), actionConfig)
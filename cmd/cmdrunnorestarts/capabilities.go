@@ -0,0 +1,81 @@
+package cmdrunnorestarts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/cmd/cmdcapabilities"
+	"github.com/pinpt/agent/rpcdef"
+)
+
+// capabilities stores capabilities manifests of integrations by integration binary name. Integrations are not started only to read capabilities, manifests are saved when integration is loaded for export. Saved manifests are reported in enabled event and kept in a file, so that they are available after restart.
+type capabilities struct {
+	logger hclog.Logger
+	loc    string
+
+	mu   sync.Mutex
+	data map[string]cmdcapabilities.Result
+}
+
+func newCapabilities(logger hclog.Logger, loc string) *capabilities {
+	s := &capabilities{}
+	s.logger = logger
+	s.loc = loc
+	s.data = map[string]cmdcapabilities.Result{}
+	b, err := ioutil.ReadFile(loc)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.logger.Error("could not read saved integration capabilities", "err", err)
+		}
+		return s
+	}
+	err = json.Unmarshal(b, &s.data)
+	if err != nil {
+		s.logger.Error("saved integration capabilities are not valid, ignoring", "err", err)
+		s.data = map[string]cmdcapabilities.Result{}
+	}
+	return s
+}
+
+// Set saves capabilities of integration. Returns true if they are different from saved.
+func (s *capabilities) Set(name string, c rpcdef.Capabilities) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if prev, ok := s.data[name]; ok && reflect.DeepEqual(prev.Capabilities, c) {
+		return false
+	}
+	s.data[name] = cmdcapabilities.Result{Capabilities: c}
+	err := s.save()
+	if err != nil {
+		s.logger.Error("could not save integration capabilities", "err", err)
+	}
+	return true
+}
+
+func (s *capabilities) save() error {
+	b, err := json.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(s.loc), 0777)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.loc, b, 0666)
+}
+
+// JSON returns capabilities of all integrations loaded so far as json to be included in enabled event.
+func (s *capabilities) JSON() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := json.Marshal(s.data)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
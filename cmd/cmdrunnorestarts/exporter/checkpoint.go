@@ -0,0 +1,86 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pinpt/agent/cmd/cmdexport"
+	"github.com/pinpt/agent/pkg/fs"
+)
+
+// exportCheckpoint is saved in state dir while export request is processed. If agent is restarted in the middle of export, the request stays in fsqueue and is processed again. In that case export resumes from the sessions completed by the previous run instead of restoring the state backup and starting from scratch.
+type exportCheckpoint struct {
+	JobID string `json:"job_id"`
+
+	// Exported is true when export finished and only upload is remaining
+	Exported bool             `json:"exported"`
+	Result   cmdexport.Result `json:"result"`
+
+	// Uploaded is true when upload finished
+	Uploaded         bool  `json:"uploaded"`
+	UploadPartsCount int   `json:"upload_parts_count"`
+	UploadFileSize   int64 `json:"upload_file_size"`
+}
+
+func (s *Exporter) readCheckpoint() (*exportCheckpoint, error) {
+	b, err := ioutil.ReadFile(s.opts.FSConf.ExportCheckpointFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	res := &exportCheckpoint{}
+	err = json.Unmarshal(b, res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *Exporter) saveCheckpoint(cp *exportCheckpoint) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return fs.WriteToTempAndRename(bytes.NewReader(b), s.opts.FSConf.ExportCheckpointFile)
+}
+
+func (s *Exporter) deleteCheckpoint() error {
+	return os.RemoveAll(s.opts.FSConf.ExportCheckpointFile)
+}
+
+// prepareResume removes output of sessions that were not completed by interrupted export and upload zips that were not uploaded. Files of completed sessions are kept and uploaded together with the data exported after resume.
+func (s *Exporter) prepareResume() error {
+	locs := s.opts.FSConf
+	if err := os.RemoveAll(locs.UploadZips); err != nil {
+		return err
+	}
+	exists, err := fs.Exists(locs.Uploads)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	removed := 0
+	err = filepath.Walk(locs.Uploads, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(p, ".temp.gz") {
+			return nil
+		}
+		removed++
+		return os.Remove(p)
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.Info("removed output of unfinished sessions", "files", removed)
+	return nil
+}
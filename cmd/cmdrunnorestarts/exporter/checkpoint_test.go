@@ -0,0 +1,53 @@
+package exporter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/pkg/fsconf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &Exporter{}
+	s.logger = hclog.NewNullLogger()
+	s.opts.FSConf = fsconf.New(dir)
+	locs := s.opts.FSConf
+
+	cp, err := s.readCheckpoint()
+	assert.NoError(t, err)
+	assert.Nil(t, cp)
+
+	assert.NoError(t, os.MkdirAll(locs.State, 0777))
+	assert.NoError(t, s.saveCheckpoint(&exportCheckpoint{JobID: "j1", Exported: true}))
+	cp, err = s.readCheckpoint()
+	assert.NoError(t, err)
+	assert.Equal(t, "j1", cp.JobID)
+	assert.True(t, cp.Exported)
+
+	done := filepath.Join(locs.Uploads, "model", "1_1.json.gz")
+	temp := filepath.Join(locs.Uploads, "model", "1_2.json.gz.temp.gz")
+	for _, f := range []string{done, temp} {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(f), 0777))
+		assert.NoError(t, ioutil.WriteFile(f, nil, 0644))
+	}
+	assert.NoError(t, s.prepareResume())
+	_, err = os.Stat(done)
+	assert.NoError(t, err)
+	_, err = os.Stat(temp)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, s.deleteCheckpoint())
+	cp, err = s.readCheckpoint()
+	assert.NoError(t, err)
+	assert.Nil(t, cp)
+}
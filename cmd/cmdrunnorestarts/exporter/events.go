@@ -1,11 +1,9 @@
 package exporter
 
 import (
-	"context"
 	"fmt"
 	"time"
 
-	"github.com/pinpt/agent/pkg/aevent"
 	"github.com/pinpt/agent/pkg/date"
 	"github.com/pinpt/go-common/event"
 	"github.com/pinpt/integration-sdk/agent"
@@ -103,7 +101,7 @@ func (s *Exporter) sendExportEvent(jobID string, data agent.ExportResponse) erro
 			"uuid": s.conf.DeviceID,
 		},
 	}
-	// wait longer for export events, since if those are missed, processing will not continue normally
-	deadline := time.Now().Add(15 * time.Minute)
-	return aevent.Publish(context.Background(), publishEvent, s.conf.Channel, s.conf.APIKey, event.WithDeadline(deadline))
+	// export events are retried by outbox until sent, since if those are missed, processing will not continue normally
+	key := fmt.Sprintf("%v/%v/%v", agent.ExportResponseModelName, jobID, data.State)
+	return s.opts.Outbox.Send(publishEvent, key)
 }
@@ -13,13 +13,16 @@ import (
 	"github.com/pinpt/agent/cmd/cmdintegration"
 	"github.com/pinpt/agent/cmd/cmdrunnorestarts/exporter/fsqueue"
 	"github.com/pinpt/agent/cmd/cmdrunnorestarts/inconfig"
+	"github.com/pinpt/agent/cmd/cmdrunnorestarts/logsender"
 	"github.com/pinpt/agent/cmd/cmdrunnorestarts/subcommand"
 	"github.com/pinpt/agent/cmd/cmdupload"
 
+	"github.com/pinpt/agent/pkg/aevent"
 	"github.com/pinpt/agent/pkg/agentconf"
 	"github.com/pinpt/agent/pkg/deviceinfo"
 	"github.com/pinpt/agent/pkg/fsconf"
 	"github.com/pinpt/agent/pkg/logutils"
+	"github.com/pinpt/agent/rpcdef"
 
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/pinpt/integration-sdk/agent"
@@ -39,6 +42,13 @@ type Opts struct {
 
 	PPEncryptionKey string
 	AgentConfig     cmdintegration.AgentConfig
+
+	// Outbox is used to send export events to backend
+	Outbox *aevent.Outbox
+	// LogSpool is used to upload export logs, optional
+	LogSpool *logsender.Spool
+	// OnCapabilities is called with capabilities manifest of each integration loaded for export, optional
+	OnCapabilities func(name string, capabilities rpcdef.Capabilities)
 }
 
 // Exporter schedules and executes exports
@@ -72,6 +82,9 @@ func New(opts Opts) (*Exporter, error) {
 	if opts.PPEncryptionKey == "" {
 		return nil, errors.New(`opts.PPEncryptionKey == ""`)
 	}
+	if opts.Outbox == nil {
+		return nil, errors.New(`opts.Outbox == nil`)
+	}
 	s := &Exporter{}
 	s.opts = opts
 	s.conf = opts.Conf
@@ -189,6 +202,9 @@ func (s *Exporter) doExport(data *agent.ExportRequest, messageID string) (res ex
 	res.UploadFileSize = fileSize
 	res.Integrations = map[string]exportResultIntegration{}
 	for _, in0 := range res0.Integrations {
+		if in0.Capabilities != nil && s.opts.OnCapabilities != nil {
+			s.opts.OnCapabilities(in0.Name, *in0.Capabilities)
+		}
 		in := exportResultIntegration{}
 		in.ID = in0.ID
 		in.Incremental = in0.Incremental
@@ -214,53 +230,94 @@ func (s *Exporter) doExport(data *agent.ExportRequest, messageID string) (res ex
 func (s *Exporter) doExport2(data *agent.ExportRequest, messageID string) (partsCount int, fileSize int64, res cmdexport.Result, rerr error) {
 	s.logger.Info("processing export request", "job_id", data.JobID, "request_date", data.RequestDate.Rfc3339, "reprocess_historical", data.ReprocessHistorical)
 
-	err := s.backupRestoreStateDir()
+	cp, err := s.readCheckpoint()
 	if err != nil {
-		rerr = fmt.Errorf("could not manage backup dir for export: %v", err)
+		rerr = fmt.Errorf("could not read export checkpoint: %v", err)
 		return
 	}
+	resume := cp != nil && cp.JobID == data.JobID
 
-	integrations := s.conf.ExtraIntegrations
-
-	for _, integration := range data.Integrations {
-		s.logger.Info("exporting integration", "name", integration.Name, "len(exclusions)", len(integration.Exclusions), "len(inclusions)", len(integration.Inclusions))
+	fsconf := s.opts.FSConf
 
-		conf, err := inconfig.AuthFromEvent(integration.ToMap(), s.opts.PPEncryptionKey)
+	if resume {
+		s.logger.Info("resuming export interrupted by agent restart", "job_id", data.JobID, "exported", cp.Exported, "uploaded", cp.Uploaded)
+		err := s.prepareResume()
+		if err != nil {
+			rerr = fmt.Errorf("could not prepare state dir to resume export: %v", err)
+			return
+		}
+	} else {
+		err := s.backupRestoreStateDir()
 		if err != nil {
+			rerr = fmt.Errorf("could not manage backup dir for export: %v", err)
+			return
+		}
+		// delete existing uploads
+		if err = os.RemoveAll(fsconf.Uploads); err != nil {
 			rerr = err
 			return
 		}
-		if conf.ID == "" || conf.Name == "" || len(conf.Config.Inclusions) == 0 {
-			err = errors.New("id, name and inclusions are required in export requests")
+		cp = &exportCheckpoint{JobID: data.JobID}
+		err = s.saveCheckpoint(cp)
+		if err != nil {
+			rerr = fmt.Errorf("could not save export checkpoint: %v", err)
 			return
 		}
-		conf.Type = inconfig.IntegrationType(integration.SystemType)
-
-		integrations = append(integrations, conf)
 	}
 
-	fsconf := s.opts.FSConf
-	// delete existing uploads
-	if err = os.RemoveAll(fsconf.Uploads); err != nil {
-		rerr = err
-		return
-	}
+	logFile := ""
+	if cp.Exported {
+		s.logger.Info("export already finished before restart, skipping to upload")
+		res = cp.Result
+	} else {
+		integrations := s.conf.ExtraIntegrations
 
-	integrations = dedupInclusionsAndMergeUsers(s.logger, integrations)
+		for _, integration := range data.Integrations {
+			s.logger.Info("exporting integration", "name", integration.Name, "len(exclusions)", len(integration.Exclusions), "len(inclusions)", len(integration.Inclusions))
 
-	logFile := ""
-	res, logFile, err = s.execExport(integrations, data.ReprocessHistorical, messageID, data.JobID)
-	if logFile != "" {
-		defer os.Remove(logFile)
-	}
-	if err != nil {
-		rerr = err
-		return
-	}
+			conf, err := inconfig.AuthFromEvent(integration.ToMap(), s.opts.PPEncryptionKey)
+			if err != nil {
+				rerr = err
+				return
+			}
+			if conf.ID == "" || conf.Name == "" || len(conf.Config.Inclusions) == 0 {
+				err = errors.New("id, name and inclusions are required in export requests")
+				return
+			}
+			conf.Type = inconfig.IntegrationType(integration.SystemType)
+
+			integrations = append(integrations, conf)
+		}
+
+		integrations = dedupInclusionsAndMergeUsers(s.logger, integrations)
+
+		// incremental data was already discarded by interrupted export, do not discard progress made after that
+		reprocessHistorical := data.ReprocessHistorical && !resume
+
+		res, logFile, err = s.execExport(integrations, reprocessHistorical, messageID, data.JobID)
+		if logFile != "" {
+			defer os.Remove(logFile)
+		}
+		if err != nil {
+			rerr = err
+			return
+		}
 
-	s.logger.Info("export finished")
+		s.logger.Info("export finished")
 
-	if s.conf.Channel != "dev" {
+		cp.Exported = true
+		cp.Result = res
+		err = s.saveCheckpoint(cp)
+		if err != nil {
+			rerr = fmt.Errorf("could not save export checkpoint: %v", err)
+			return
+		}
+	}
+
+	if cp.Uploaded {
+		s.logger.Info("upload already finished before restart")
+		partsCount, fileSize = cp.UploadPartsCount, cp.UploadFileSize
+	} else if s.conf.Channel != "dev" {
 
 		s.logger.Info("running upload")
 
@@ -274,6 +331,15 @@ func (s *Exporter) doExport2(data *agent.ExportRequest, messageID string) (parts
 				return
 			}
 		}
+
+		cp.Uploaded = true
+		cp.UploadPartsCount = partsCount
+		cp.UploadFileSize = fileSize
+		err = s.saveCheckpoint(cp)
+		if err != nil {
+			rerr = fmt.Errorf("could not save export checkpoint: %v", err)
+			return
+		}
 	} else {
 		s.logger.Info("skipped upload")
 	}
@@ -284,6 +350,12 @@ func (s *Exporter) doExport2(data *agent.ExportRequest, messageID string) (parts
 		return
 	}
 
+	err = s.deleteCheckpoint()
+	if err != nil {
+		rerr = fmt.Errorf("could not delete export checkpoint: %v", err)
+		return
+	}
+
 	return
 }
 
@@ -378,6 +450,8 @@ func (s *Exporter) execExport(integrations []inconfig.IntegrationAgent, reproces
 		AgentConfig:       s.conf,
 		Integrations:      integrations,
 		DeviceInfo:        s.deviceInfo,
+		Outbox:            s.opts.Outbox,
+		LogSpool:          s.opts.LogSpool,
 	})
 	if err != nil {
 		rerr = err
@@ -44,6 +44,17 @@ func (s *Exporter) backupRestoreStateDir() error {
 			}
 		}
 
+		if err := os.RemoveAll(locs.DedupFile); err != nil {
+			return err
+		}
+
+		if err := fs.CopyFile(locs.DedupFileBackup, locs.DedupFile); err != nil {
+			// dedup is updated during export, restore it to not skip objects that were not uploaded
+			if !os.IsNotExist(err) {
+				return err
+			}
+		}
+
 		if err := os.RemoveAll(locs.RipsrcCheckpoints); err != nil {
 			return err
 		}
@@ -55,6 +66,17 @@ func (s *Exporter) backupRestoreStateDir() error {
 			}
 		}
 
+		if err := os.RemoveAll(locs.IntegrationsState); err != nil {
+			return err
+		}
+
+		if err := fs.CopyDir(locs.IntegrationsStateBackup, locs.IntegrationsState); err != nil {
+			// would happen if no integration saved state before
+			if !os.IsNotExist(err) {
+				return err
+			}
+		}
+
 		return nil
 	}
 
@@ -72,6 +94,13 @@ func (s *Exporter) backupRestoreStateDir() error {
 		}
 	}
 
+	if err := fs.CopyFile(locs.DedupFile, locs.DedupFileBackup); err != nil {
+		// would happen before first export
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+
 	if err := fs.CopyDir(locs.RipsrcCheckpoints, locs.RipsrcCheckpointsBackup); err != nil {
 		// would happen if export did not have any ripsrc data
 		if !os.IsNotExist(err) {
@@ -79,6 +108,13 @@ func (s *Exporter) backupRestoreStateDir() error {
 		}
 	}
 
+	if err := fs.CopyDir(locs.IntegrationsState, locs.IntegrationsStateBackup); err != nil {
+		// would happen if no integration saved state
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -68,6 +68,8 @@ type IntegrationConfigAgent struct {
 	AccessToken string `json:"access_token"`
 	// RefreshToken Refresh token
 	RefreshToken string `json:"refresh_token"`
+	// Options integration specific settings, used by integrations configured manually in extra_integrations
+	Options map[string]interface{} `json:"options,omitempty"`
 }
 
 // IntegrationDef defines a unique integration.
@@ -48,8 +48,7 @@ func (s *runner) handleIntegrationEvents(ctx context.Context) (closefunc, error)
 		// TODO: add connection validation
 
 		sendEvent := func(resp *agent.IntegrationResponse) (datamodel.ModelSendEvent, error) {
-			s.deviceInfo.AppendCommonInfo(resp)
-			return datamodel.NewModelSendEvent(resp), nil
+			return s.sendResponse(resp, req.ID)
 		}
 
 		resp := &agent.IntegrationResponse{}
@@ -0,0 +1,52 @@
+package logsender
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// redacted replaces secrets found in log lines
+const redacted = "[REDACTED]"
+
+// secretPatterns match secrets that could be logged by agent or integrations. The first group is kept, the rest of the match is replaced.
+var secretPatterns = []*regexp.Regexp{
+	// key value pairs in json, urls and hclog output
+	regexp.MustCompile(`(?i)((?:api[_-]?key|apitoken|api[_-]?token|access[_-]?token|refresh[_-]?token|private[_-]?token|client[_-]?secret|password|passwd|secret)\\?"?\s*[:=]\s*\\?"?)[^"\\\s,&}]+`),
+	// authorization headers
+	regexp.MustCompile(`(?i)(authorization\\?"?\s*[:=]\s*\\?"?(?:bearer |basic |token )?)[^"\\\s,}]+`),
+	// credentials in urls
+	regexp.MustCompile(`([a-z][a-z0-9+.-]*://[^:/@\s"]+:)[^@/\s"]+(@)`),
+	// github, gitlab, slack and aws tokens
+	regexp.MustCompile(`()\b(?:gh[pousr]_[A-Za-z0-9]{30,}|glpat-[A-Za-z0-9_-]{20,}|xox[abprs]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16})\b`),
+	// jwt
+	regexp.MustCompile(`()\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+}
+
+// redactor removes secrets from log lines before they are saved to spool or uploaded
+type redactor struct {
+	// known contains secrets from agent config, these are replaced anywhere in the line
+	known [][]byte
+}
+
+func newRedactor(known ...string) *redactor {
+	s := &redactor{}
+	for _, v := range known {
+		// short values would match unrelated text
+		if len(v) < 8 {
+			continue
+		}
+		s.known = append(s.known, []byte(v))
+	}
+	return s
+}
+
+// Line returns the line with secrets replaced.
+func (s *redactor) Line(b []byte) []byte {
+	for _, v := range s.known {
+		b = bytes.ReplaceAll(b, v, []byte(redacted))
+	}
+	for _, re := range secretPatterns {
+		b = re.ReplaceAll(b, []byte("${1}"+redacted+"${2}"))
+	}
+	return b
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/pinpt/agent/pkg/agentconf"
+	"github.com/pinpt/agent/pkg/netconf"
 	"github.com/pinpt/go-common/api"
 	pos "github.com/pinpt/go-common/os"
 	"github.com/pinpt/httpclient"
@@ -27,6 +28,8 @@ type Opts struct {
 	MessageID       string
 	URL             string
 	JSONLineConvert func([]byte) ([]byte, error)
+	// Spool saves log batches to disk before upload, so that they are not lost when backend is not reachable. Optional, logs are uploaded directly if not set.
+	Spool *Spool
 }
 
 // logSenderTimeout is the timeout before giving up on log upload
@@ -39,7 +42,75 @@ type Sender struct {
 	ch     chan []byte
 	buf    []byte
 	closed chan bool
-	client httpclient.Client
+	client *client
+	redact *redactor
+}
+
+// client uploads log batches to backend
+type client struct {
+	conf agentconf.Config
+	url  string
+	http httpclient.Client
+}
+
+func newClient(conf agentconf.Config, url string) *client {
+	return &client{conf: conf, url: url, http: newHTTPAPIClientDefault()}
+}
+
+// upload sends gzipped log lines for messageID
+func (s *client) upload(messageID string, data []byte) error {
+	url := api.BackendURL(api.EventService, s.conf.Channel)
+	if s.url != "" {
+		url = s.url
+	}
+
+	url = ps.JoinURL(url, "log/agent/"+s.conf.DeviceID+"/"+messageID)
+
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	api.SetAuthorization(req, s.conf.APIKey)
+	api.SetUserAgent(req)
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		buf, _ := ioutil.ReadAll(resp.Body)
+		return &statusError{StatusCode: resp.StatusCode, Response: string(buf)}
+	}
+	io.Copy(ioutil.Discard, resp.Body) // must always read body to prevent leak
+	return nil
+}
+
+// statusError is returned by upload when backend responds with unexpected status code
+type statusError struct {
+	StatusCode int
+	Response   string
+}
+
+func (s *statusError) Error() string {
+	return fmt.Sprintf("invalid status code: %v, response: %v", s.StatusCode, s.Response)
+}
+
+// isRejected returns true if backend rejected the upload with client error, retrying it would fail again. Request timeout and rate limit errors are retried.
+func isRejected(err error) bool {
+	serr, ok := err.(*statusError)
+	if !ok {
+		return false
+	}
+	switch serr.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return serr.StatusCode >= 400 && serr.StatusCode < 500
 }
 
 func newHTTPAPIClientDefault() httpclient.Client {
@@ -47,11 +118,11 @@ func newHTTPAPIClientDefault() httpclient.Client {
 	if err != nil {
 		panic(fmt.Sprintf("invalid parse duration (%s). error: %s", logSenderTimeout, err))
 	}
-	cl, err := api.NewHTTPAPIClientDefaultWithTimeout(dur)
+	transport, err := netconf.NewTransport(false)
 	if err != nil {
 		panic(err)
 	}
-	return cl
+	return &http.Client{Transport: transport, Timeout: dur}
 }
 
 const maxBufBytes = 10 * 1024 * 1024
@@ -101,7 +172,10 @@ func New(opts Opts) *Sender {
 	s.logger = opts.Logger.Named("log-sender")
 	s.ch = make(chan []byte, 10000)
 	s.closed = make(chan bool)
-	s.client = newHTTPAPIClientDefault()
+	if s.opts.Spool == nil {
+		s.client = newClient(opts.Conf, opts.URL)
+	}
+	s.redact = newRedactor(opts.Conf.APIKey, opts.Conf.PPEncryptionKey)
 
 	maxDelayBetweenSends := 1 * time.Second
 
@@ -127,15 +201,6 @@ func (s *Sender) upload() {
 		s.logger.Error("could not upload export log", "err", err)
 	}
 
-	url := api.BackendURL(api.EventService, s.opts.Conf.Channel)
-	if s.opts.URL != "" {
-		url = s.opts.URL
-	}
-
-	url = ps.JoinURL(url, "log/agent/"+s.opts.Conf.DeviceID+"/"+s.opts.MessageID)
-
-	//s.logger.Debug("uploading log", "size", len(s.buf), "url", url)
-
 	// only send full lines
 	lines := bytes.Split(s.buf, []byte("\n"))
 	last := lines[len(lines)-1]
@@ -168,7 +233,7 @@ func (s *Sender) upload() {
 			s.logger.Error("could not convert keys for log message", "err", err, "v", string(v))
 			continue
 		}
-		toSend2 = append(toSend2, v2)
+		toSend2 = append(toSend2, s.redact.Line(v2))
 	}
 	toSend = toSend2
 
@@ -196,31 +261,22 @@ func (s *Sender) upload() {
 		return
 	}
 
-	req, err := http.NewRequest(http.MethodPut, url, buf)
-	if err != nil {
-		perr(err)
-		return
+	if s.opts.Spool != nil {
+		err := s.opts.Spool.add(s.opts.MessageID, buf.Bytes())
+		if err == nil {
+			return
+		}
+		s.logger.Error("could not save log batch to spool, uploading directly", "err", err)
+		if s.client == nil {
+			s.client = newClient(s.opts.Conf, s.opts.URL)
+		}
 	}
 
-	api.SetAuthorization(req, s.opts.Conf.APIKey)
-	api.SetUserAgent(req)
-
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Encoding", "gzip")
-	resp, err := s.client.Do(req)
+	err = s.client.upload(s.opts.MessageID, buf.Bytes())
 	if err != nil {
 		perr(err)
 		return
 	}
-
-	if resp.StatusCode != http.StatusAccepted {
-		buf, _ := ioutil.ReadAll(resp.Body)
-		s.logger.Error("error sending log", "err", err, "response", string(buf), "req", string(reqData))
-		resp.Body.Close()
-		return
-	}
-	io.Copy(ioutil.Discard, resp.Body) // must always read body to prevent leak
-	resp.Body.Close()
 }
 
 // Write implements write interface that can be used by logger.
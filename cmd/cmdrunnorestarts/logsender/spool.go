@@ -0,0 +1,306 @@
+package logsender
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/pkg/agentconf"
+	"github.com/pinpt/agent/pkg/fs"
+)
+
+const (
+	// spoolDefaultMaxBytes is the default max size of all batches in spool
+	spoolDefaultMaxBytes = 100 * 1024 * 1024
+	// spoolDefaultMaxAge is the default max age of batch in spool
+	spoolDefaultMaxAge = 7 * 24 * time.Hour
+	// spoolMinBackoff is the delay before retrying after the first failed upload. Doubled on each consecutive failure.
+	spoolMinBackoff = time.Second
+	// spoolMaxBackoff is the max delay between retries
+	spoolMaxBackoff = 5 * time.Minute
+)
+
+// SpoolOpts are options for NewSpool.
+type SpoolOpts struct {
+	Logger hclog.Logger
+	Conf   agentconf.Config
+	// URL overrides backend url, used in tests
+	URL string
+	// Dir stores log batches that were not uploaded yet
+	Dir string
+	// MaxBytes is the max size of all batches in spool. When exceeded oldest batches are dropped. Defaults to 100MB.
+	MaxBytes int64
+	// MaxAge is the max age of batch. Older batches are dropped. Defaults to 7 days.
+	MaxAge time.Duration
+}
+
+// Spool saves gzipped log batches to disk and uploads them in order in the background, retrying with backoff when backend is not reachable. Batches rejected by backend with client error are dropped. Batches are kept across agent restarts. A single Spool should be used per dir.
+type Spool struct {
+	opts   SpoolOpts
+	logger hclog.Logger
+	client *client
+
+	minBackoff time.Duration
+
+	mu      sync.Mutex
+	batches []*spoolBatch
+	size    int64
+	lastSeq int64
+
+	notify  chan bool
+	stop    chan bool
+	stopped chan bool
+}
+
+type spoolBatch struct {
+	seq       int64
+	messageID string
+	size      int64
+	created   time.Time
+}
+
+// NewSpool creates spool, loads batches not uploaded by previous run and starts uploading them.
+func NewSpool(opts SpoolOpts) (*Spool, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("provide spool dir")
+	}
+	if opts.MaxBytes == 0 {
+		opts.MaxBytes = spoolDefaultMaxBytes
+	}
+	if opts.MaxAge == 0 {
+		opts.MaxAge = spoolDefaultMaxAge
+	}
+	s := &Spool{}
+	s.opts = opts
+	s.logger = opts.Logger.Named("log-spool")
+	s.client = newClient(opts.Conf, opts.URL)
+	s.minBackoff = spoolMinBackoff
+	s.notify = make(chan bool, 1)
+	s.stop = make(chan bool)
+	s.stopped = make(chan bool)
+
+	err := os.MkdirAll(opts.Dir, 0777)
+	if err != nil {
+		return nil, err
+	}
+	err = s.load()
+	if err != nil {
+		return nil, fmt.Errorf("could not load log spool: %v", err)
+	}
+	s.mu.Lock()
+	s.retention()
+	if len(s.batches) != 0 {
+		s.logger.Info("loaded log batches not uploaded by previous run", "count", len(s.batches), "bytes", s.size)
+	}
+	s.mu.Unlock()
+
+	go s.run()
+	return s, nil
+}
+
+func (s *Spool) load() error {
+	items, err := ioutil.ReadDir(s.opts.Dir)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		loc := filepath.Join(s.opts.Dir, item.Name())
+		b, err := parseBatchName(item.Name())
+		if err != nil {
+			// leftover temp file from interrupted write
+			s.logger.Debug("removing unexpected file from spool", "file", item.Name())
+			err := os.Remove(loc)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		b.size = item.Size()
+		b.created = item.ModTime()
+		s.batches = append(s.batches, b)
+		s.size += b.size
+		if b.seq > s.lastSeq {
+			s.lastSeq = b.seq
+		}
+	}
+	sort.Slice(s.batches, func(i, j int) bool {
+		return s.batches[i].seq < s.batches[j].seq
+	})
+	return nil
+}
+
+func parseBatchName(name string) (*spoolBatch, error) {
+	if !strings.HasSuffix(name, ".gz") {
+		return nil, errors.New("not a batch file")
+	}
+	name = strings.TrimSuffix(name, ".gz")
+	parts := strings.SplitN(name, "_", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("not a batch file")
+	}
+	seq, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	messageID, err := url.PathUnescape(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	return &spoolBatch{seq: seq, messageID: messageID}, nil
+}
+
+func (s *Spool) file(b *spoolBatch) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%020d_%v.gz", b.seq, url.PathEscape(b.messageID)))
+}
+
+// add saves gzipped log batch for messageID to be uploaded.
+func (s *Spool) add(messageID string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastSeq++
+	b := &spoolBatch{}
+	b.seq = s.lastSeq
+	b.messageID = messageID
+	b.size = int64(len(data))
+	b.created = time.Now()
+	err := fs.WriteToTempAndRename(bytes.NewReader(data), s.file(b))
+	if err != nil {
+		return err
+	}
+	s.batches = append(s.batches, b)
+	s.size += b.size
+	s.retention()
+
+	select {
+	case s.notify <- true:
+	default:
+	}
+	return nil
+}
+
+// retention drops oldest batches exceeding max size or max age. Must be called with mu held.
+func (s *Spool) retention() {
+	dropped := 0
+	for len(s.batches) != 0 {
+		b := s.batches[0]
+		if s.size <= s.opts.MaxBytes && time.Since(b.created) <= s.opts.MaxAge {
+			break
+		}
+		s.removeFirst()
+		dropped++
+	}
+	if dropped != 0 {
+		s.logger.Warn("dropped log batches exceeding spool limits", "count", dropped, "max_bytes", s.opts.MaxBytes, "max_age", s.opts.MaxAge.String())
+	}
+}
+
+// removeFirst deletes the oldest batch. Must be called with mu held.
+func (s *Spool) removeFirst() {
+	b := s.batches[0]
+	s.batches = s.batches[1:]
+	s.size -= b.size
+	err := os.Remove(s.file(b))
+	if err != nil && !os.IsNotExist(err) {
+		s.logger.Error("could not delete log batch", "err", err)
+	}
+}
+
+func (s *Spool) first() *spoolBatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.batches) == 0 {
+		return nil
+	}
+	return s.batches[0]
+}
+
+// done removes the uploaded batch, unless it was already dropped by retention
+func (s *Spool) done(b *spoolBatch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.batches) == 0 || s.batches[0] != b {
+		return
+	}
+	s.removeFirst()
+}
+
+func (s *Spool) uploadBatch(b *spoolBatch) error {
+	data, err := ioutil.ReadFile(s.file(b))
+	if err != nil {
+		if os.IsNotExist(err) {
+			// dropped by retention while uploading
+			return nil
+		}
+		return err
+	}
+	return s.client.upload(b.messageID, data)
+}
+
+func (s *Spool) run() {
+	defer close(s.stopped)
+	backoff := s.minBackoff
+	for {
+		b := s.first()
+		if b == nil {
+			select {
+			case <-s.notify:
+				continue
+			case <-s.stop:
+				return
+			}
+		}
+		err := s.uploadBatch(b)
+		if err != nil && isRejected(err) {
+			// do not block later batches until max age
+			s.logger.Error("dropping log batch rejected by backend", "message_id", b.messageID, "err", err)
+			s.done(b)
+			continue
+		}
+		if err != nil {
+			s.logger.Warn("could not upload log batch, will retry", "retry_in", backoff.String(), "pending", s.Pending(), "err", err)
+			select {
+			case <-time.After(backoff):
+			case <-s.stop:
+				return
+			}
+			backoff *= 2
+			if backoff > spoolMaxBackoff {
+				backoff = spoolMaxBackoff
+			}
+			continue
+		}
+		backoff = s.minBackoff
+		s.done(b)
+	}
+}
+
+// Pending returns the number of batches not uploaded yet.
+func (s *Spool) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.batches)
+}
+
+// Close waits up to timeout for pending batches to be uploaded and stops the upload. Batches that were not uploaded stay on disk and are uploaded on next start.
+func (s *Spool) Close(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for s.Pending() != 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if n := s.Pending(); n != 0 {
+		s.logger.Info("log batches left in spool for next run", "count", n)
+	}
+	close(s.stop)
+	<-s.stopped
+}
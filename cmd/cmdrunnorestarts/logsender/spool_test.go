@@ -0,0 +1,171 @@
+package logsender
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+type spoolServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	down     bool
+	received []string
+	// reject responds with status code to uploads with matching body
+	reject map[string]int
+}
+
+func newSpoolServer() *spoolServer {
+	s := &spoolServer{}
+	s.reject = map[string]int{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		if code := s.reject[string(b)]; code != 0 {
+			w.WriteHeader(code)
+			return
+		}
+		s.received = append(s.received, r.URL.Path+" "+string(b))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	return s
+}
+
+func (s *spoolServer) setDown(down bool) {
+	s.mu.Lock()
+	s.down = down
+	s.mu.Unlock()
+}
+
+func (s *spoolServer) Received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.received...)
+}
+
+func TestSpoolUploadsInOrderAfterRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ts := newSpoolServer()
+	defer ts.Close()
+	ts.setDown(true)
+
+	opts := SpoolOpts{}
+	opts.Logger = hclog.NewNullLogger()
+	opts.Conf.DeviceID = "d1"
+	opts.URL = ts.URL
+	opts.Dir = dir
+	spool, err := NewSpool(opts)
+	assert.NoError(t, err)
+	assert.NoError(t, spool.add("m1", []byte("a")))
+	assert.NoError(t, spool.add("m/2", []byte("b")))
+	spool.Close(0)
+	assert.Equal(t, 2, spool.Pending())
+
+	ts.setDown(false)
+	spool, err = NewSpool(opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, spool.Pending())
+	assert.NoError(t, spool.add("m1", []byte("c")))
+	spool.Close(5 * time.Second)
+	assert.Equal(t, 0, spool.Pending())
+
+	assert.Equal(t, []string{
+		"/log/agent/d1/m1 a",
+		"/log/agent/d1/m/2 b",
+		"/log/agent/d1/m1 c",
+	}, ts.Received())
+
+	items, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, items, 0)
+}
+
+func TestSpoolMaxBytes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ts := newSpoolServer()
+	defer ts.Close()
+	ts.setDown(true)
+
+	opts := SpoolOpts{}
+	opts.Logger = hclog.NewNullLogger()
+	opts.Conf.DeviceID = "d1"
+	opts.URL = ts.URL
+	opts.Dir = dir
+	opts.MaxBytes = 5
+	spool, err := NewSpool(opts)
+	assert.NoError(t, err)
+	assert.NoError(t, spool.add("m1", []byte("aaa")))
+	assert.NoError(t, spool.add("m1", []byte("bbb")))
+	assert.NoError(t, spool.add("m1", []byte("cc")))
+	assert.Equal(t, 2, spool.Pending())
+
+	ts.setDown(false)
+	spool.Close(0)
+
+	spool, err = NewSpool(opts)
+	assert.NoError(t, err)
+	spool.Close(5 * time.Second)
+	assert.Equal(t, []string{
+		"/log/agent/d1/m1 bbb",
+		"/log/agent/d1/m1 cc",
+	}, ts.Received())
+}
+
+func TestSpoolDropsRejectedBatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ts := newSpoolServer()
+	defer ts.Close()
+	ts.reject["a"] = http.StatusBadRequest
+
+	opts := SpoolOpts{}
+	opts.Logger = hclog.NewNullLogger()
+	opts.Conf.DeviceID = "d1"
+	opts.URL = ts.URL
+	opts.Dir = dir
+	spool, err := NewSpool(opts)
+	assert.NoError(t, err)
+	assert.NoError(t, spool.add("m1", []byte("a")))
+	assert.NoError(t, spool.add("m1", []byte("b")))
+	spool.Close(5 * time.Second)
+	assert.Equal(t, 0, spool.Pending())
+	assert.Equal(t, []string{
+		"/log/agent/d1/m1 b",
+	}, ts.Received())
+}
+
+func TestIsRejected(t *testing.T) {
+	assert.True(t, isRejected(&statusError{StatusCode: http.StatusBadRequest}))
+	assert.True(t, isRejected(&statusError{StatusCode: http.StatusRequestEntityTooLarge}))
+	assert.False(t, isRejected(&statusError{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, isRejected(&statusError{StatusCode: http.StatusRequestTimeout}))
+	assert.False(t, isRejected(&statusError{StatusCode: http.StatusServiceUnavailable}))
+	assert.False(t, isRejected(errors.New("connection refused")))
+}
@@ -42,8 +42,7 @@ func (s *runner) handleMutationEvents(ctx context.Context) (closefunc, error) {
 			s.logger.Info("processed mutation req", "dur", time.Since(start).String())
 			resp.JobID = req.JobID
 			date.ConvertToModel(time.Now(), &resp.EventDate)
-			s.deviceInfo.AppendCommonInfo(resp)
-			return datamodel.NewModelSendEvent(resp), nil
+			return s.sendResponse(resp, req.ID)
 		}
 
 		sendError := func(errorCode string, err error) (datamodel.ModelSendEvent, error) {
@@ -132,6 +131,8 @@ func (s *runner) execMutate(ctx context.Context, config inconfig.IntegrationAgen
 		AgentConfig:       s.conf,
 		Integrations:      integrations,
 		DeviceInfo:        s.deviceInfo,
+		Outbox:            s.outbox,
+		LogSpool:          s.logSpool,
 	})
 
 	if err != nil {
@@ -89,7 +89,7 @@ func (s *runner) handleOnboardingEvents(ctx context.Context) (closefunc, error)
 			}
 		}
 		s.deviceInfo.AppendCommonInfo(resp)
-		return datamodel.NewModelSendEvent(resp), nil
+		return s.sendResponse(resp, req.ID)
 	}
 
 	cbRepo := func(instance datamodel.ModelReceiveEvent) (_ datamodel.ModelSendEvent, _ error) {
@@ -132,7 +132,7 @@ func (s *runner) handleOnboardingEvents(ctx context.Context) (closefunc, error)
 		}
 
 		s.deviceInfo.AppendCommonInfo(resp)
-		return datamodel.NewModelSendEvent(resp), nil
+		return s.sendResponse(resp, req.ID)
 	}
 
 	cbProject := func(instance datamodel.ModelReceiveEvent) (_ datamodel.ModelSendEvent, _ error) {
@@ -173,7 +173,7 @@ func (s *runner) handleOnboardingEvents(ctx context.Context) (closefunc, error)
 			}
 		}
 		s.deviceInfo.AppendCommonInfo(resp)
-		return datamodel.NewModelSendEvent(resp), nil
+		return s.sendResponse(resp, req.ID)
 	}
 
 	cbWorkconfig := func(instance datamodel.ModelReceiveEvent) (_ datamodel.ModelSendEvent, _ error) {
@@ -214,7 +214,7 @@ func (s *runner) handleOnboardingEvents(ctx context.Context) (closefunc, error)
 
 		s.deviceInfo.AppendCommonInfo(resp)
 
-		return datamodel.NewModelSendEvent(resp), nil
+		return s.sendResponse(resp, req.ID)
 	}
 
 	usub, err := action.Register(ctx, action.NewAction(cbUser), s.newSubConfig(agent.UserRequestModelName.String()))
@@ -262,6 +262,8 @@ func (s *runner) getOnboardData(ctx context.Context, config inconfig.Integration
 		AgentConfig:       s.conf,
 		Integrations:      integrations,
 		DeviceInfo:        s.deviceInfo,
+		Outbox:            s.outbox,
+		LogSpool:          s.logSpool,
 	})
 
 	if err != nil {
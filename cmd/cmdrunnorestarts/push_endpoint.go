@@ -0,0 +1,35 @@
+package cmdrunnorestarts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pinpt/agent/pkg/pushevents"
+)
+
+// runPushEndpoint starts local http endpoint receiving deploy and incident events, if enabled in config. Events are saved to spool and exported by push integration on the next export.
+func (s *runner) runPushEndpoint(ctx context.Context) (closefunc, error) {
+	if !s.conf.Push.Enabled() {
+		return func() {}, nil
+	}
+	spool, err := pushevents.NewSpool(s.fsconf.PushSpool)
+	if err != nil {
+		return nil, fmt.Errorf("could not create push spool: %v", err)
+	}
+	server, err := pushevents.NewServer(pushevents.ServerOpts{
+		Logger: s.logger,
+		Config: s.conf.Push,
+		Spool:  spool,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		err := server.Run(ctx)
+		if err != nil {
+			s.logger.Error("push endpoint stopped", "err", err)
+		}
+	}()
+	return func() { cancel() }, nil
+}
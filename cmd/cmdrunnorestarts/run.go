@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sync/atomic"
 	"time"
@@ -19,6 +21,7 @@ import (
 
 	"github.com/pinpt/agent/pkg/agentconf"
 	"github.com/pinpt/agent/pkg/fsconf"
+	"github.com/pinpt/agent/pkg/netconf"
 	"github.com/pinpt/go-common/event"
 	"github.com/pinpt/integration-sdk/agent"
 
@@ -33,6 +36,7 @@ import (
 	"github.com/pinpt/agent/cmd/cmdrunnorestarts/exporter"
 	"github.com/pinpt/agent/cmd/cmdrunnorestarts/logsender"
 	"github.com/pinpt/agent/cmd/cmdrunnorestarts/updater"
+	"github.com/pinpt/agent/rpcdef"
 )
 
 type Opts struct {
@@ -78,6 +82,10 @@ type runner struct {
 	deviceInfo  deviceinfo.CommonInfo
 
 	logSender *logsender.Sender
+	logSpool  *logsender.Spool
+	outbox    *aevent.Outbox
+
+	capabilities *capabilities
 
 	onboardingInProgress int64
 }
@@ -97,28 +105,80 @@ func newRunner(opts Opts) (*runner, error) {
 
 	s.conf = opts.AgentConf
 
+	err := netconf.Setup(s.conf.Network, s.fsconf.CABundle)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network config: %v", err)
+	}
+
 	s.agentConfig = s.getAgentConfig()
 	s.deviceInfo = s.getDeviceInfoOpts()
 
+	s.logSpool, err = logsender.NewSpool(logsender.SpoolOpts{
+		Logger: s.opts.Logger,
+		Conf:   s.conf,
+		Dir:    s.fsconf.LogSpool,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create log spool: %v", err)
+	}
+
 	{
 		opts := logsender.Opts{}
 		opts.Logger = s.opts.Logger
 		opts.Conf = s.conf
 		opts.CmdName = "run"
 		opts.MessageID = hash.Values(time.Now())
+		opts.Spool = s.logSpool
 		s.logSender = logsender.New(opts)
 	}
 	s.logger = s.opts.Logger.AddWriter(s.logSender)
 
+	s.outbox, err = aevent.NewOutbox(aevent.OutboxOpts{
+		Logger:  s.logger,
+		Dir:     filepath.Join(s.fsconf.Outbox, "run"),
+		Channel: s.conf.Channel,
+		APIKey:  s.conf.APIKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create outbox: %v", err)
+	}
+
+	s.capabilities = newCapabilities(s.logger, s.fsconf.CapabilitiesFile)
+	s.adoptSubcommandOutboxes()
+
 	return s, nil
 }
 
+// adoptSubcommandOutboxes moves events not sent by subcommands of previous run to run outbox.
+func (s *runner) adoptSubcommandOutboxes() {
+	dir := cmdintegration.OutboxDirs(s.fsconf)
+	items, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.logger.Error("could not read subcommand outbox dirs", "err", err)
+		}
+		return
+	}
+	for _, item := range items {
+		n, err := s.outbox.Adopt(filepath.Join(dir, item.Name()))
+		if err != nil {
+			s.logger.Error("could not adopt events of subcommand", "dir", item.Name(), "err", err)
+			continue
+		}
+		if n != 0 {
+			s.logger.Info("adopted events not sent by subcommand", "count", n)
+		}
+	}
+}
+
 type closefunc func()
 
 func (s *runner) close() {
 	if err := s.logSender.Close(); err != nil {
 		s.logger.Error("error closing log sender", "err", err)
 	}
+	// batches not uploaded here are uploaded after restart
+	s.logSpool.Close(5 * time.Second)
 }
 
 func (s *runner) Run(ctx context.Context) error {
@@ -132,11 +192,18 @@ func (s *runner) Run(ctx context.Context) error {
 
 	s.logger.Debug("Debug log level enabled")
 
+	go s.outbox.Run(ctx)
+
 	if build.IsProduction() &&
 		(runtime.GOOS == "linux" || runtime.GOOS == "windows") {
 		toVersion := os.Getenv("PP_AGENT_UPDATE_VERSION")
+		if toVersion != "" && s.updateRolledBack(toVersion) {
+			// do not retry until failed update is reported, otherwise we would update and roll back in a loop
+			s.logger.Error("Skipping update from PP_AGENT_UPDATE_VERSION, previous update to this version was rolled back", "version", toVersion)
+			toVersion = ""
+		}
 		if toVersion != "" && toVersion != "dev" {
-			_, updated, err := s.updateTo(toVersion)
+			_, updated, err := s.updateTo(toVersion, "")
 			if err != nil {
 				return fmt.Errorf("Could not self-update: %v", err)
 			}
@@ -172,6 +239,11 @@ func (s *runner) Run(ctx context.Context) error {
 
 	s.logger.Info("Sent enabled event")
 
+	err = s.handlePendingUpdate(ctx)
+	if err != nil {
+		return fmt.Errorf("could not handle pending update, err: %v", err)
+	}
+
 	err = s.sendStart(ctx)
 	if err != nil {
 		return fmt.Errorf("could not send start event, err: %v", err)
@@ -181,6 +253,12 @@ func (s *runner) Run(ctx context.Context) error {
 			s.logger.Error("Could not send stop event", err, "err")
 			return
 		}
+		// pending events are sent after restart if not sent here
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := s.outbox.Flush(ctx); err != nil {
+			s.logger.Warn("Could not send all events before exit", "err", err)
+		}
 	}()
 	s.exporter, err = exporter.New(exporter.Opts{
 		Logger:              s.logger,
@@ -190,6 +268,17 @@ func (s *runner) Run(ctx context.Context) error {
 		FSConf:              s.fsconf,
 		PPEncryptionKey:     s.conf.PPEncryptionKey,
 		AgentConfig:         s.agentConfig,
+		Outbox:              s.outbox,
+		LogSpool:            s.logSpool,
+		OnCapabilities: func(name string, c rpcdef.Capabilities) {
+			if !s.capabilities.Set(name, c) {
+				return
+			}
+			s.logger.Info("Integration capabilities changed, sending enabled event", "integration", name)
+			if err := s.sendEnabled(ctx); err != nil {
+				s.logger.Error("Could not send enabled event", "err", err)
+			}
+		},
 	})
 	if err != nil {
 		return fmt.Errorf("could not initialize exporter, err: %v", err)
@@ -247,6 +336,14 @@ func (s *runner) Run(ctx context.Context) error {
 		closers = append(closers, close)
 	}
 
+	{
+		close, err := s.runPushEndpoint(ctx)
+		if err != nil {
+			return fmt.Errorf("error starting push endpoint, err: %v", err)
+		}
+		closers = append(closers, close)
+	}
+
 	finishMain := make(chan bool, 1)
 	{
 		close, err := s.handleUninstallEvents(ctx, finishMain)
@@ -295,7 +392,13 @@ func (s *runner) sendEnabled(ctx context.Context) error {
 	}
 	data.Success = true
 	data.Error = nil
-	data.Data = nil
+
+	// data contains capabilities manifest for each integration loaded for export so far
+	capabilities, err := s.capabilities.JSON()
+	if err != nil {
+		return err
+	}
+	data.Data = &capabilities
 
 	s.deviceInfo.AppendCommonInfo(&data)
 
@@ -306,7 +409,7 @@ func (s *runner) sendEnabled(ctx context.Context) error {
 		},
 	}
 
-	err := aevent.Publish(ctx, publishEvent, s.conf.Channel, s.conf.APIKey)
+	err = aevent.Publish(ctx, publishEvent, s.conf.Channel, s.conf.APIKey)
 	if err != nil {
 		return err
 	}
@@ -338,33 +441,29 @@ func (s *runner) newSubConfig(topic string) action.Config {
 	}
 }
 
+// sendPings sends ping every 30 seconds. Pings are queued in outbox, so failures do not block or restart the service, instead changes in delivery health are logged.
 func (s *runner) sendPings() {
 	ctx := context.Background()
-	s.sendPing(ctx) // always send ping immediately upon start
-
-	var failedPings []time.Time
+	prevState := aevent.HealthOK
 	for {
-		select {
-		case <-time.After(30 * time.Second):
-			err := s.sendPing(ctx)
-			if err != nil {
-				s.logger.Error("could not send ping", "err", err.Error())
-				var pastHour []time.Time
-				cutoff := time.Now().Add(-time.Hour)
-				for _, p := range failedPings {
-					if p.After(cutoff) {
-						pastHour = append(pastHour, p)
-					}
-				}
-				pastHour = append(pastHour, time.Now())
-				failedPings = pastHour
-				if len(failedPings) > 5 {
-					panic("more than 5 pings failed in the past hour, exiting to restart")
-				}
-			} else {
-				s.logger.Info("sent ping")
+		err := s.sendPing(ctx)
+		if err != nil {
+			s.logger.Error("could not queue ping", "err", err)
+		}
+		health := s.outbox.Health()
+		state := s.outbox.HealthState()
+		if state != prevState {
+			switch state {
+			case aevent.HealthOK:
+				s.logger.Info("Connection to backend restored")
+			case aevent.HealthDegraded:
+				s.logger.Warn("Could not send events to backend, retrying", "failures", health.ConsecutiveFailures, "pending", health.Pending, "err", health.LastError)
+			case aevent.HealthOffline:
+				s.logger.Error("Could not send events to backend for a long time, events will be sent when connection is restored", "last_success", health.LastSuccess, "pending", health.Pending, "err", health.LastError)
 			}
+			prevState = state
 		}
+		time.Sleep(30 * time.Second)
 	}
 }
 
@@ -385,7 +484,8 @@ func (s *runner) sendStop(ctx context.Context) error {
 }
 
 func (s *runner) sendPing(ctx context.Context) error {
-	return s.sendEvent(ctx, s.getPing(), "", nil)
+	// only the latest ping is useful, replace pending one if not sent yet
+	return s.outbox.SendLatest(s.publishEvent(s.getPing(), "", nil), "ping")
 }
 
 func (s *runner) getPing() *agent.Ping {
@@ -406,7 +506,7 @@ func (s *runner) getPing() *agent.Ping {
 	return ev
 }
 
-func (s *runner) sendEvent(ctx context.Context, agentEvent datamodel.Model, jobID string, extraHeaders map[string]string) error {
+func (s *runner) publishEvent(agentEvent datamodel.Model, jobID string, extraHeaders map[string]string) event.PublishEvent {
 	s.deviceInfo.AppendCommonInfo(agentEvent)
 	headers := map[string]string{
 		"uuid":        s.conf.DeviceID,
@@ -418,11 +518,29 @@ func (s *runner) sendEvent(ctx context.Context, agentEvent datamodel.Model, jobI
 	for k, v := range extraHeaders {
 		headers[k] = v
 	}
-	e := event.PublishEvent{
+	return event.PublishEvent{
 		Object:  agentEvent,
 		Headers: headers,
 	}
-	return aevent.Publish(ctx, e, s.conf.Channel, s.conf.APIKey)
+}
+
+// sendEvent queues the event in outbox.
+func (s *runner) sendEvent(ctx context.Context, agentEvent datamodel.Model, jobID string, extraHeaders map[string]string) error {
+	return s.outbox.Send(s.publishEvent(agentEvent, jobID, extraHeaders), "")
+}
+
+// sendResponse queues response to a request received in action callback in outbox, key is used to avoid sending multiple responses to the same request. If response could not be queued, it is returned for action to publish directly.
+func (s *runner) sendResponse(resp datamodel.Model, requestID string) (datamodel.ModelSendEvent, error) {
+	key := ""
+	if requestID != "" {
+		key = resp.GetModelName().String() + "/" + requestID
+	}
+	err := s.outbox.Send(s.publishEvent(resp, "", nil), key)
+	if err != nil {
+		s.logger.Error("could not queue response in outbox, sending directly", "err", err)
+		return datamodel.NewModelSendEvent(resp), nil
+	}
+	return nil, nil
 }
 
 func (s *runner) sendEventAppendingDeviceInfo(ctx context.Context, event datamodel.Model, jobID string, extraHeaders map[string]string) error {
@@ -439,6 +557,15 @@ func (s *runner) getAgentConfig() (res cmdintegration.AgentConfig) {
 	res.CustomerID = s.conf.CustomerID
 	res.PinpointRoot = s.opts.PinpointRoot
 	res.IntegrationsDir = s.conf.IntegrationsDir
+	res.CommitUserAliases = s.conf.CommitUserAliases
+	res.IssueLinks = s.conf.IssueLinks
+	res.Validation = s.conf.Validation
+	res.Plugins = s.conf.Plugins
+	res.Sandbox = s.conf.Sandbox
+	res.HangDetection = s.conf.HangDetection
+	res.MaxIntegrationRestarts = s.conf.MaxIntegrationRestarts
+	res.Network = s.conf.Network
+	res.RateLimits = s.conf.RateLimits
 	res.Backend.Enable = true
 	return
 }
@@ -27,6 +27,7 @@ import (
 	"github.com/pinpt/agent/pkg/agentconf"
 	"github.com/pinpt/agent/pkg/date"
 	"github.com/pinpt/agent/pkg/deviceinfo"
+	"github.com/pinpt/agent/pkg/fsconf"
 )
 
 // Cancelled implementation of error.
@@ -46,6 +47,10 @@ type Opts struct {
 	AgentConfig       agentconf.Config
 	Integrations      []inconfig.IntegrationAgent
 	DeviceInfo        deviceinfo.CommonInfo
+	// Outbox is used to send crash events, optional
+	Outbox *aevent.Outbox
+	// LogSpool is used to upload logs of subcommands, optional
+	LogSpool *logsender.Spool
 }
 
 // Command is struct for executing cmdintegration based commands
@@ -56,6 +61,8 @@ type Command struct {
 	agentConfig  agentconf.Config
 	integrations []inconfig.IntegrationAgent
 	deviceInfo   deviceinfo.CommonInfo
+	outbox       *aevent.Outbox
+	logSpool     *logsender.Spool
 }
 
 // New creates a command
@@ -88,6 +95,8 @@ func New(opts Opts) (*Command, error) {
 	s.agentConfig = opts.AgentConfig
 	s.integrations = opts.Integrations
 	s.deviceInfo = opts.DeviceInfo
+	s.outbox = opts.Outbox
+	s.logSpool = opts.LogSpool
 	return s, nil
 }
 
@@ -176,6 +185,7 @@ func (c *Command) RunKeepLogFile(ctx context.Context, cmdname string, messageID
 		opts.Conf = c.agentConfig
 		opts.CmdName = cmdname
 		opts.MessageID = messageID
+		opts.Spool = c.logSpool
 		ls := logsender.New(opts)
 		defer func() {
 			err := ls.Close()
@@ -212,6 +222,7 @@ func (c *Command) RunKeepLogFile(ctx context.Context, cmdname string, messageID
 	}
 
 	err = cmd.Wait()
+	c.adoptOutbox(cmd.Process.Pid)
 
 	if err != nil {
 		if cmdname == "export" {
@@ -254,6 +265,21 @@ func (c *Command) RunKeepLogFile(ctx context.Context, cmdname string, messageID
 	return
 }
 
+// adoptOutbox moves events that subcommand could not send before exiting to run outbox.
+func (c *Command) adoptOutbox(pid int) {
+	if c.outbox == nil {
+		return
+	}
+	n, err := c.outbox.Adopt(cmdintegration.OutboxDir(fsconf.New(c.config.PinpointRoot), pid))
+	if err != nil {
+		c.logger.Error("could not adopt events of subcommand", "err", err)
+		return
+	}
+	if n != 0 {
+		c.logger.Info("adopted events not sent by subcommand", "count", n)
+	}
+}
+
 func (c *Command) handlePanic(filename, cmdname string) error {
 	b, err := ioutil.ReadFile(filename)
 	if err != nil {
@@ -279,6 +305,12 @@ func (c *Command) handlePanic(filename, cmdname string) error {
 			"job_id":      c.config.Backend.ExportJobID,
 		},
 	}
+	if c.outbox != nil {
+		if err := c.outbox.Send(publishEvent, ""); err != nil {
+			return fmt.Errorf("error queuing agent.Crash in outbox, err: %v", err)
+		}
+		return nil
+	}
 	if err := aevent.Publish(context.Background(), publishEvent, c.agentConfig.Channel, c.agentConfig.APIKey); err != nil {
 		return fmt.Errorf("error sending agent.Crash to backend, err: %v", err)
 	}
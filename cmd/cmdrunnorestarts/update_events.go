@@ -47,11 +47,10 @@ func (s *runner) handleUpdateEvents(ctx context.Context) (closefunc, error) {
 			resp.RequestID = req.ID
 			resp.UUID = s.conf.DeviceID
 			date.ConvertToModel(time.Now(), &resp.EventDate)
-			s.deviceInfo.AppendCommonInfo(resp)
-			return datamodel.NewModelSendEvent(resp), nil
+			return s.sendResponse(resp, req.ID)
 		}
 
-		oldVersion, updated, err := s.updateTo(version)
+		oldVersion, updated, err := s.updateTo(version, req.ID)
 
 		if err != nil {
 			s.logger.Error("Update failed", "err", err)
@@ -90,7 +89,7 @@ func (s *runner) handleUpdateEvents(ctx context.Context) (closefunc, error) {
 	return func() { sub.Close() }, nil
 }
 
-func (s *runner) updateTo(version string) (oldVersion string, updated bool, rerr error) {
+func (s *runner) updateTo(version string, requestID string) (oldVersion string, updated bool, rerr error) {
 	if !build.IsProduction() {
 		rerr = errors.New("Automatic update is only supported for production builds")
 		return
@@ -147,7 +146,7 @@ func (s *runner) updateTo(version string) (oldVersion string, updated bool, rerr
 	}
 
 	upd := updater.New(s.logger, s.fsconf, s.conf)
-	err := upd.Update(version)
+	err := upd.Update(version, requestID)
 	if err != nil {
 		rerr = fmt.Errorf("Could not update: %v", err)
 		return
@@ -157,3 +156,43 @@ func (s *runner) updateTo(version string) (oldVersion string, updated bool, rerr
 
 	return
 }
+
+// handlePendingUpdate is called after sending enabled event. If agent was just updated it confirms that the new version started successfully. If the update was rolled back by service runner, reports the failure to the backend.
+func (s *runner) handlePendingUpdate(ctx context.Context) error {
+	pending, err := updater.LoadPending(s.fsconf)
+	if err != nil {
+		return err
+	}
+	if pending == nil {
+		return nil
+	}
+	if !pending.RolledBack {
+		s.logger.Info("Confirming successful update", "from_version", pending.FromVersion, "to_version", pending.ToVersion)
+		return updater.RemovePending(s.fsconf)
+	}
+
+	s.logger.Error("Update failed and was rolled back", "from_version", pending.FromVersion, "to_version", pending.ToVersion, "err", pending.Error)
+
+	resp := &agent.UpdateResponse{}
+	resp.RequestID = pending.RequestID
+	resp.UUID = s.conf.DeviceID
+	resp.FromVersion = pending.FromVersion
+	resp.ToVersion = pending.ToVersion
+	resp.Error = pstrings.Pointer(fmt.Sprintf("Update to %v failed and was rolled back to %v: %v", pending.ToVersion, pending.FromVersion, pending.Error))
+	date.ConvertToModel(time.Now(), &resp.EventDate)
+	err = s.sendEventAppendingDeviceInfoDefault(ctx, resp)
+	if err != nil {
+		return fmt.Errorf("could not report failed update: %v", err)
+	}
+	return updater.RemovePending(s.fsconf)
+}
+
+// updateRolledBack returns true if there is a failed update to version which was not reported yet.
+func (s *runner) updateRolledBack(version string) bool {
+	pending, err := updater.LoadPending(s.fsconf)
+	if err != nil {
+		s.logger.Error("Could not load pending update", "err", err)
+		return false
+	}
+	return pending != nil && pending.RolledBack && pending.ToVersion == version
+}
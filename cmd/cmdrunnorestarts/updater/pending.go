@@ -0,0 +1,115 @@
+package updater
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/pinpt/agent/pkg/fs"
+	"github.com/pinpt/agent/pkg/fsconf"
+)
+
+// GracePeriod is the time new version has to send enabled event after update. Service runner rolls back the update if the new version does not confirm start in this time.
+const GracePeriod = 5 * time.Minute
+
+// PendingUpdate is saved after replacing binaries and removed when the new version successfully sends the enabled event.
+//
+// The flow is the following:
+// - updater replaces binaries and saves PendingUpdate
+// - service runner (cmdrun) marks it as Started when starting the new version
+// - new version sends enabled event and removes the file, or
+// - new version crashes or does not confirm in GracePeriod, service runner calls Rollback and starts the previous version
+// - previous version reports failed update to the backend and removes the file
+type PendingUpdate struct {
+	FromVersion string    `json:"from_version"`
+	ToVersion   string    `json:"to_version"`
+	RequestID   string    `json:"request_id"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Replaced are the locations replaced in update in order, with backups of previous version
+	Replaced []Replaced `json:"replaced"`
+	// Started is set when service runner started the new version
+	Started bool `json:"started"`
+	// RolledBack is set after restoring previous version
+	RolledBack bool `json:"rolled_back"`
+	// Error is the reason for rollback
+	Error string `json:"error"`
+}
+
+// Replaced is a file or dir replaced in update.
+type Replaced struct {
+	Loc    string `json:"loc"`
+	Backup string `json:"backup"`
+}
+
+// LoadPending returns pending update. Returns nil if there is no pending update.
+func LoadPending(locs fsconf.Locs) (*PendingUpdate, error) {
+	b, err := ioutil.ReadFile(locs.UpdatePendingFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := &PendingUpdate{}
+	err = json.Unmarshal(b, res)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse pending update file: %v", err)
+	}
+	return res, nil
+}
+
+// SavePending saves pending update.
+func SavePending(locs fsconf.Locs, pending PendingUpdate) error {
+	b, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	return fs.WriteToTempAndRename(bytes.NewReader(b), locs.UpdatePendingFile)
+}
+
+// RemovePending removes pending update. Called after the new version started successfully or the failed update was reported.
+func RemovePending(locs fsconf.Locs) error {
+	err := os.Remove(locs.UpdatePendingFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Rollback restores binaries from previous version and saves the pending update with the reason, so that previous version could report it.
+func (s *PendingUpdate) Rollback(locs fsconf.Locs, reason string) error {
+	if s.RolledBack {
+		return nil
+	}
+	err := s.restore()
+	if err != nil {
+		return err
+	}
+	s.RolledBack = true
+	s.Error = reason
+	return SavePending(locs, *s)
+}
+
+// restore moves backups back into place in reverse order. New binaries are kept with .failed suffix for debugging.
+func (s *PendingUpdate) restore() error {
+	for i := len(s.Replaced) - 1; i >= 0; i-- {
+		r := s.Replaced[i]
+		failed := r.Loc + ".failed"
+		err := os.RemoveAll(failed)
+		if err != nil {
+			return err
+		}
+		err = os.Rename(r.Loc, failed)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not move new version out of the way: %v", err)
+		}
+		err = os.Rename(r.Backup, r.Loc)
+		if err != nil {
+			return fmt.Errorf("could not restore backup: %v", err)
+		}
+	}
+	return nil
+}
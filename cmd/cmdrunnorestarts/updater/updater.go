@@ -2,10 +2,16 @@
 // on provided version for both agent and integrations and replaces
 // them in place.
 // It also downloads built-in integrations if only agent binary is present.
+//
+// All downloaded binaries are checked against the release manifest signed
+// with ed25519 before replacing anything. After replacing binaries the
+// update is stored as pending, see PendingUpdate, and rolled back if the
+// new version does not start successfully.
 package updater
 
 import (
 	"compress/gzip"
+	"crypto/ed25519"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -15,6 +21,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
+	"time"
 
 	pstrings "github.com/pinpt/go-common/strings"
 
@@ -23,6 +31,7 @@ import (
 	"github.com/pinpt/agent/pkg/build"
 	"github.com/pinpt/agent/pkg/fs"
 	"github.com/pinpt/agent/pkg/fsconf"
+	"github.com/pinpt/agent/pkg/plugins"
 	"github.com/pinpt/go-common/api"
 )
 
@@ -34,6 +43,11 @@ type Updater struct {
 
 	integrationsParentDir string
 	integrationsSubDir    string
+
+	// binariesPrefix overrides the location of releases, used in tests
+	binariesPrefix string
+	// publicKey overrides the key used to verify manifest, used in tests
+	publicKey ed25519.PublicKey
 }
 
 // New creates updater
@@ -57,7 +71,7 @@ func New(logger hclog.Logger, fslocs fsconf.Locs, conf agentconf.Config) *Update
 // are not present in integrations dir. This would happen
 // if use only downloaded the agent binary.
 func (s *Updater) DownloadIntegrationsIfMissing() error {
-	exists, err := fs.Exists(s.integrationsParentDir)
+	exists, err := s.integrationsInstalled()
 	if err != nil {
 		return fmt.Errorf("Could not read integration dir: %v", err)
 	}
@@ -80,12 +94,17 @@ func (s *Updater) DownloadIntegrationsIfMissing() error {
 	}
 	defer os.RemoveAll(downloadDir)
 
-	err = s.downloadIntegrations(version, downloadDir)
+	manifest, err := s.downloadManifest(version)
 	if err != nil {
 		return err
 	}
 
-	err = s.updateIntegrations(version, downloadDir)
+	err = s.downloadIntegrations(version, downloadDir, manifest)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.updateIntegrations(version, downloadDir)
 	if err != nil {
 		return err
 	}
@@ -94,8 +113,25 @@ func (s *Updater) DownloadIntegrationsIfMissing() error {
 	return nil
 }
 
-// Update updates both the agent and integrations to the specified version.
-func (s *Updater) Update(version string) error {
+// integrationsInstalled returns true if integrations dir contains anything except third-party plugins
+func (s *Updater) integrationsInstalled() (bool, error) {
+	items, err := ioutil.ReadDir(s.integrationsParentDir)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, item := range items {
+		if item.Name() != plugins.DirName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Update updates both the agent and integrations to the specified version. Binaries are replaced only if all of them match the signed manifest. Previous binaries are kept and the update is saved as pending until the new version confirms successful start. requestID is the id of update request, used when reporting failed update after rollback.
+func (s *Updater) Update(version string, requestID string) error {
 	err := os.MkdirAll(s.fsconf.Temp, 0777)
 	if err != nil {
 		return err
@@ -107,26 +143,57 @@ func (s *Updater) Update(version string) error {
 	}
 	defer os.RemoveAll(downloadDir)
 
-	_, err = s.downloadBinary("pinpoint-agent", version, downloadDir)
+	manifest, err := s.downloadManifest(version)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.downloadBinary("pinpoint-agent", version, downloadDir, manifest)
 	if err != nil {
 		return err
 	}
 
-	err = s.downloadIntegrations(version, downloadDir)
+	err = s.downloadIntegrations(version, downloadDir, manifest)
 	if err != nil {
 		return err
 	}
 
+	s.logger.Info("All binaries match signed manifest")
+
+	pending := PendingUpdate{}
+	pending.FromVersion = os.Getenv("PP_AGENT_VERSION")
+	pending.ToVersion = version
+	pending.RequestID = requestID
+	pending.UpdatedAt = time.Now()
+
 	s.logger.Info("Replacing agent binary")
-	err = s.updateAgent(version, downloadDir)
+	repl, err := s.updateAgent(version, downloadDir)
 	if err != nil {
 		return err
 	}
+	pending.Replaced = append(pending.Replaced, repl)
 
 	s.logger.Info("Replacing integration binaries")
-	err = s.updateIntegrations(version, downloadDir)
+	repl, err = s.updateIntegrations(version, downloadDir)
 	if err != nil {
-		return fmt.Errorf("updateIntegrations: %v", err)
+		err = fmt.Errorf("updateIntegrations: %v", err)
+		// do not leave new agent with old integrations
+		err2 := pending.restore()
+		if err2 != nil {
+			return fmt.Errorf("%v, could not restore agent binary: %v", err, err2)
+		}
+		return err
+	}
+	pending.Replaced = append(pending.Replaced, repl)
+
+	err = SavePending(s.fsconf, pending)
+	if err != nil {
+		err = fmt.Errorf("could not save pending update: %v", err)
+		err2 := pending.restore()
+		if err2 != nil {
+			return fmt.Errorf("%v, could not restore previous version: %v", err, err2)
+		}
+		return err
 	}
 
 	s.logger.Info("Updated both agent and integrations")
@@ -135,7 +202,7 @@ func (s *Updater) Update(version string) error {
 
 const distBinaryName = "pinpoint-agent"
 
-func (s *Updater) downloadIntegrations(version string, dir string) error {
+func (s *Updater) downloadIntegrations(version string, dir string, manifest build.Manifest) error {
 
 	bins := build.BuiltinIntegrationBinaries()
 	if len(bins) == 0 {
@@ -149,7 +216,7 @@ func (s *Updater) downloadIntegrations(version string, dir string) error {
 	}
 
 	for _, bin := range bins {
-		_, err := s.downloadBinary("integrations/"+bin, version, integrationsDir)
+		_, err := s.downloadBinary("integrations/"+bin, version, integrationsDir, manifest)
 		if err != nil {
 			return err
 		}
@@ -157,40 +224,76 @@ func (s *Updater) downloadIntegrations(version string, dir string) error {
 	return nil
 }
 
-func (s *Updater) updateAgent(version, downloadDir string) error {
+func (s *Updater) updateAgent(version, downloadDir string) (res Replaced, _ error) {
 	loc, err := os.Executable()
 	if err != nil {
-		return err
+		return res, err
 	}
 	repl := filepath.Join(downloadDir, distBinaryName)
 	if runtime.GOOS == "windows" {
 		repl += ".exe"
 	}
 
-	err = replaceRestoringIfFailed(loc, repl, s.fsconf.Temp)
+	backup, err := replaceRestoringIfFailed(loc, repl, s.fsconf.Temp)
 	if err != nil {
-		return fmt.Errorf("failed to replace agent: %v", err)
+		return res, fmt.Errorf("failed to replace agent: %v", err)
 	}
-	return nil
+	return Replaced{Loc: loc, Backup: backup}, nil
 }
 
-func (s *Updater) updateIntegrations(version string, downloadDir string) error {
+func (s *Updater) updateIntegrations(version string, downloadDir string) (res Replaced, _ error) {
 	downloadedIntegrations := filepath.Join(downloadDir, "integrations")
 	ok, err := fs.Exists(s.integrationsSubDir)
 	if err != nil {
-		return err
+		return res, err
 	}
 	if !ok {
 		// integration dir did not exist, create an empty one, so that we can use replaceRestoringIfFailed
 		err = os.MkdirAll(s.integrationsSubDir, 0777)
 		if err != nil {
-			return fmt.Errorf("could not create integrations dir: %v", err)
+			return res, fmt.Errorf("could not create integrations dir: %v", err)
 		}
 	}
 
-	err = replaceRestoringIfFailed(s.integrationsSubDir, downloadedIntegrations, s.fsconf.Temp)
+	err = s.keepCustomBinaries(downloadedIntegrations)
+	if err != nil {
+		return res, err
+	}
+
+	backup, err := replaceRestoringIfFailed(s.integrationsSubDir, downloadedIntegrations, s.fsconf.Temp)
 	if err != nil {
-		return fmt.Errorf("failed to replace integrations: %v", err)
+		return res, fmt.Errorf("failed to replace integrations: %v", err)
+	}
+	return Replaced{Loc: s.integrationsSubDir, Backup: backup}, nil
+}
+
+// keepCustomBinaries copies binaries that are not part of the release from current bin dir into the downloaded dir, so that custom integrations placed there manually are not removed on update. Third-party plugins are stored in a separate dir, see plugins package.
+func (s *Updater) keepCustomBinaries(downloadedIntegrations string) error {
+	items, err := ioutil.ReadDir(s.integrationsSubDir)
+	if err != nil {
+		return err
+	}
+	builtin := map[string]bool{}
+	for _, bin := range build.BuiltinIntegrationBinaries() {
+		builtin[bin] = true
+	}
+	for _, item := range items {
+		if item.IsDir() || builtin[strings.TrimSuffix(item.Name(), ".exe")] {
+			continue
+		}
+		repl := filepath.Join(downloadedIntegrations, item.Name())
+		exists, err := fs.Exists(repl)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		s.logger.Info("keeping custom integration binary", "bin", item.Name())
+		err = fs.CopyFile(filepath.Join(s.integrationsSubDir, item.Name()), repl)
+		if err != nil {
+			return fmt.Errorf("could not copy custom integration binary: %v", err)
+		}
 	}
 	return nil
 }
@@ -214,82 +317,150 @@ func backupLoc(loc string) (backupLoc string, _ error) {
 	}
 }
 
-func replaceRestoringIfFailed(loc string, repl string, tmpDir string) error {
+func replaceRestoringIfFailed(loc string, repl string, tmpDir string) (backup string, _ error) {
 	repl2 := loc + ".new"
 	backup, err := backupLoc(loc)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// copy from loc to new to allow the files being on different drives, happens in make docker-dev
 	err = os.RemoveAll(repl2)
 	if err != nil {
-		return err
+		return "", err
 	}
 	err = fs.Copy(repl, repl2)
 	if err != nil {
-		return fmt.Errorf("could not copy new download, err: %v", err)
+		return "", fmt.Errorf("could not copy new download, err: %v", err)
 	}
 	fi, err := os.Stat(repl2)
 	if err != nil {
-		return fmt.Errorf("could not stat download copy, err: %v", err)
+		return "", fmt.Errorf("could not stat download copy, err: %v", err)
 	}
 	if fi.IsDir() {
 		err := fs.ChmodFilesInDir(repl2, 0777)
 		if err != nil {
-			return fmt.Errorf("could not chmod new binaries in dir, err: %v", err)
+			return "", fmt.Errorf("could not chmod new binaries in dir, err: %v", err)
 		}
 	} else {
 		err := os.Chmod(repl2, 0777)
 		if err != nil {
-			return fmt.Errorf("could not chmod new binary, err: %v", err)
+			return "", fmt.Errorf("could not chmod new binary, err: %v", err)
 		}
 	}
 	err = os.Rename(loc, backup)
 	if err != nil {
-		return fmt.Errorf("could not rename curr to backup, err: %v", err)
+		return "", fmt.Errorf("could not rename curr to backup, err: %v", err)
 	}
 	err = os.Rename(repl2, loc)
-	if err != nil {
-		return fmt.Errorf("could move new into place, err: %v", err)
-	}
 	if err != nil {
 		// rename failed, restore prev
 		err2 := os.Rename(backup, loc)
 		if err2 != nil {
-			return fmt.Errorf("failed to replace: %v and failed to restore: %v", err, err2)
+			return "", fmt.Errorf("could not move new into place: %v and failed to restore: %v", err, err2)
 		}
-		return fmt.Errorf("failed to replace: %v", err)
+		return "", fmt.Errorf("could not move new into place, err: %v", err)
 	}
-	return nil
+	return backup, nil
 }
 
-func (s *Updater) downloadBinary(urlPath string, version string, tmpDir string) (loc string, rerr error) {
-	platformArch := runtime.GOOS + "-" + runtime.GOARCH
+func platformArch() (string, error) {
+	res := runtime.GOOS + "-" + runtime.GOARCH
 	switch runtime.GOOS {
 	case "windows", "linux":
 	default:
-		rerr = errors.New("platform not supported: " + platformArch)
-		return
+		return "", errors.New("platform not supported: " + res)
 	}
 	if runtime.GOARCH != "amd64" {
-		rerr = errors.New("platform not supported: " + platformArch)
+		return "", errors.New("platform not supported: " + res)
+	}
+	return res, nil
+}
+
+func (s *Updater) releaseURL(version string, platformArch string, file string) string {
+	s3BinariesPrefix := s.binariesPrefix
+	if s3BinariesPrefix == "" {
+		if os.Getenv("PP_AGENT_USE_DIRECT_UPDATE_URL") != "" {
+			s3BinariesPrefix = "https://pinpoint-agent.s3.amazonaws.com/releases"
+		} else {
+			s3BinariesPrefix = pstrings.JoinURL(api.BackendURL(api.EventService, s.channel), "agent", "download")
+		}
+	}
+	return pstrings.JoinURL(s3BinariesPrefix, version, "bin-gz", platformArch, file)
+}
+
+func (s *Updater) getPublicKey() (ed25519.PublicKey, error) {
+	if s.publicKey != nil {
+		return s.publicKey, nil
+	}
+	return build.UpdatePublicKey()
+}
+
+// downloadManifest downloads and verifies the signed manifest for version and current platform.
+func (s *Updater) downloadManifest(version string) (res build.Manifest, rerr error) {
+	platformArch, err := platformArch()
+	if err != nil {
+		rerr = err
 		return
 	}
+	key, err := s.getPublicKey()
+	if err != nil {
+		rerr = err
+		return
+	}
+	data, err := s.get(s.releaseURL(version, platformArch, build.ManifestFile))
+	if err != nil {
+		rerr = fmt.Errorf("could not download manifest: %v", err)
+		return
+	}
+	sig, err := s.get(s.releaseURL(version, platformArch, build.ManifestSigFile))
+	if err != nil {
+		rerr = fmt.Errorf("could not download manifest signature: %v", err)
+		return
+	}
+	res, err = build.VerifyManifest(data, sig, key)
+	if err != nil {
+		rerr = err
+		return
+	}
+	// signature is valid, but make sure it's not a manifest for a different release
+	if res.Version != version {
+		rerr = fmt.Errorf("manifest version %v does not match requested version %v", res.Version, version)
+		return
+	}
+	if res.Platform != platformArch {
+		rerr = fmt.Errorf("manifest platform %v does not match %v", res.Platform, platformArch)
+		return
+	}
+	s.logger.Info("verified release manifest signature", "version", version, "files", len(res.Files))
+	return
+}
 
-	s3BinariesPrefix := ""
-	if os.Getenv("PP_AGENT_USE_DIRECT_UPDATE_URL") != "" {
-		s3BinariesPrefix = "https://pinpoint-agent.s3.amazonaws.com/releases"
-	} else {
+func (s *Updater) get(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("status code: %v url: %v", resp.StatusCode, url)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
 
-		s3BinariesPrefix = pstrings.JoinURL(api.BackendURL(api.EventService, s.channel), "agent", "download")
+// downloadBinary downloads binary and checks that it matches the manifest. The binary is deleted if the checksum does not match.
+func (s *Updater) downloadBinary(urlPath string, version string, tmpDir string, manifest build.Manifest) (loc string, rerr error) {
+	platformArch, err := platformArch()
+	if err != nil {
+		rerr = err
+		return
 	}
 
-	url := pstrings.JoinURL(s3BinariesPrefix, version, "bin-gz", platformArch, urlPath)
+	file := urlPath
 	if runtime.GOOS == "windows" {
-		url += ".exe"
+		file += ".exe"
 	}
-	url += ".gz"
+	url := s.releaseURL(version, platformArch, file+".gz")
 
 	bin := path.Base(urlPath)
 
@@ -322,6 +493,14 @@ func (s *Updater) downloadBinary(urlPath string, version string, tmpDir string)
 		rerr = err
 		return
 	}
+
+	err = manifest.VerifyFile(file, loc)
+	if err != nil {
+		os.Remove(loc)
+		rerr = err
+		return
+	}
+
 	s.logger.Info("downloaded binary", "bin", bin)
 
 	return
@@ -0,0 +1,308 @@
+package updater
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/pkg/build"
+	"github.com/pinpt/agent/pkg/fsconf"
+	"github.com/stretchr/testify/assert"
+)
+
+// testServer is a stand-in for the update server, serving files for one version and platform
+type testServer struct {
+	t       *testing.T
+	key     ed25519.PrivateKey
+	pub     ed25519.PublicKey
+	version string
+	// pathVersion is the version in url, defaults to version
+	pathVersion string
+	bins        map[string][]byte
+	// tamper replaces binary content after manifest is signed
+	tamper map[string][]byte
+}
+
+func newTestServer(t *testing.T) *testServer {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testServer{
+		t:       t,
+		key:     key,
+		pub:     pub,
+		version: "v1.0.1",
+		bins: map[string][]byte{
+			"pinpoint-agent":          []byte("agent"),
+			"integrations/github":     []byte("github"),
+			"integrations/jira-cloud": []byte("jira"),
+		},
+		tamper: map[string][]byte{},
+	}
+}
+
+func (s *testServer) exe(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+func (s *testServer) Start() *httptest.Server {
+	platform := runtime.GOOS + "-" + runtime.GOARCH
+	m := build.Manifest{Version: s.version, Platform: platform, Files: map[string]string{}}
+	for n, b := range s.bins {
+		h := sha256.Sum256(b)
+		m.Files[s.exe(n)] = hex.EncodeToString(h[:])
+	}
+	data, sig, err := build.SignManifest(m, s.key)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	pathVersion := s.pathVersion
+	if pathVersion == "" {
+		pathVersion = s.version
+	}
+	prefix := "/" + pathVersion + "/bin-gz/" + platform + "/"
+	files[prefix+build.ManifestFile] = data
+	files[prefix+build.ManifestSigFile] = sig
+	for n, b := range s.bins {
+		if v, ok := s.tamper[n]; ok {
+			b = v
+		}
+		buf := &bytes.Buffer{}
+		gw := gzip.NewWriter(buf)
+		gw.Write(b)
+		gw.Close()
+		files[prefix+s.exe(n)+".gz"] = buf.Bytes()
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, ok := files[r.URL.Path]
+		if !ok {
+			w.WriteHeader(404)
+			return
+		}
+		w.Write(b)
+	}))
+}
+
+func newTestUpdater(t *testing.T, srv *httptest.Server, pub ed25519.PublicKey) (*Updater, func()) {
+	dir, err := ioutil.TempDir("", "updater")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Updater{}
+	s.logger = hclog.NewNullLogger()
+	s.fsconf = fsconf.New(dir)
+	s.integrationsParentDir = filepath.Join(dir, "integrations")
+	s.integrationsSubDir = filepath.Join(s.integrationsParentDir, "bin")
+	s.binariesPrefix = srv.URL
+	s.publicKey = pub
+	return s, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func skipUnsupportedPlatform(t *testing.T) {
+	if _, err := platformArch(); err != nil {
+		t.Skip(err)
+	}
+}
+
+func TestDownloadIntegrationsVerified(t *testing.T) {
+	skipUnsupportedPlatform(t)
+	os.Setenv("PP_INTEGRATION_BINARIES_ALL", "github,jira-cloud")
+	os.Setenv("PP_AGENT_VERSION", "v1.0.1")
+	ts := newTestServer(t)
+	srv := ts.Start()
+	defer srv.Close()
+	upd, cleanup := newTestUpdater(t, srv, ts.pub)
+	defer cleanup()
+
+	err := upd.DownloadIntegrationsIfMissing()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(upd.integrationsSubDir, ts.exe("github")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "github", string(b))
+}
+
+func TestDownloadIntegrationsTampered(t *testing.T) {
+	skipUnsupportedPlatform(t)
+	os.Setenv("PP_INTEGRATION_BINARIES_ALL", "github,jira-cloud")
+	os.Setenv("PP_AGENT_VERSION", "v1.0.1")
+	ts := newTestServer(t)
+	ts.tamper["integrations/jira-cloud"] = []byte("evil")
+	srv := ts.Start()
+	defer srv.Close()
+	upd, cleanup := newTestUpdater(t, srv, ts.pub)
+	defer cleanup()
+
+	err := upd.DownloadIntegrationsIfMissing()
+	assert.Error(t, err)
+	// nothing replaced
+	_, err = os.Stat(upd.integrationsSubDir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDownloadManifestInvalidSignature(t *testing.T) {
+	skipUnsupportedPlatform(t)
+	ts := newTestServer(t)
+	srv := ts.Start()
+	defer srv.Close()
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upd, cleanup := newTestUpdater(t, srv, otherPub)
+	defer cleanup()
+
+	_, err = upd.downloadManifest("v1.0.1")
+	assert.EqualError(t, err, "invalid manifest signature")
+}
+
+func TestDownloadManifestVersionMismatch(t *testing.T) {
+	skipUnsupportedPlatform(t)
+	ts := newTestServer(t)
+	// serve valid manifest for older version under the requested version path
+	ts.version = "v1.0.0"
+	ts.pathVersion = "v1.0.1"
+	srv := ts.Start()
+	defer srv.Close()
+	upd, cleanup := newTestUpdater(t, srv, ts.pub)
+	defer cleanup()
+
+	_, err := upd.downloadManifest("v1.0.1")
+	assert.EqualError(t, err, "manifest version v1.0.0 does not match requested version v1.0.1")
+}
+
+func TestDownloadManifestNoPublicKey(t *testing.T) {
+	skipUnsupportedPlatform(t)
+	ts := newTestServer(t)
+	srv := ts.Start()
+	defer srv.Close()
+	// dev build, key not set in ldflags
+	os.Setenv("PP_AGENT_UPDATE_PUBLIC_KEY", "")
+	upd, cleanup := newTestUpdater(t, srv, nil)
+	defer cleanup()
+
+	_, err := upd.downloadManifest("v1.0.1")
+	assert.Equal(t, build.ErrNoUpdatePublicKey, err)
+}
+
+func TestPendingRollback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "updater")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	locs := fsconf.New(dir)
+
+	write := func(loc, data string) {
+		err := ioutil.WriteFile(filepath.Join(dir, loc), []byte(data), 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(loc string) string {
+		b, err := ioutil.ReadFile(filepath.Join(dir, loc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	write("agent", "new")
+	write("agent.old0", "old")
+
+	p := PendingUpdate{FromVersion: "v1", ToVersion: "v2", RequestID: "r1"}
+	p.Replaced = []Replaced{{Loc: filepath.Join(dir, "agent"), Backup: filepath.Join(dir, "agent.old0")}}
+	err = SavePending(locs, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadPending(locs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = loaded.Rollback(locs, "crashed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "old", read("agent"))
+	assert.Equal(t, "new", read("agent.failed"))
+
+	loaded, err = LoadPending(locs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, loaded.RolledBack)
+	assert.Equal(t, "crashed", loaded.Error)
+	assert.Equal(t, "r1", loaded.RequestID)
+
+	err = RemovePending(locs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = LoadPending(locs)
+	assert.NoError(t, err)
+	assert.Nil(t, loaded)
+}
+
+func TestUpdateIntegrationsKeepsCustom(t *testing.T) {
+	os.Setenv("PP_INTEGRATION_BINARIES_ALL", "github,mock")
+	ts := newTestServer(t)
+	srv := ts.Start()
+	defer srv.Close()
+	upd, cleanup := newTestUpdater(t, srv, ts.pub)
+	defer cleanup()
+
+	write := func(loc, data string) {
+		err := os.MkdirAll(filepath.Dir(loc), 0777)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(loc, []byte(data), 0777)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(upd.integrationsSubDir, "github"), "old")
+	write(filepath.Join(upd.integrationsSubDir, "mock"), "removed in new version")
+	write(filepath.Join(upd.integrationsSubDir, "custom"), "custom")
+	write(filepath.Join(upd.integrationsParentDir, "plugins", "p1", "plugin.json"), "{}")
+	downloadDir := filepath.Join(upd.fsconf.Temp, "download")
+	write(filepath.Join(downloadDir, "integrations", "github"), "new")
+
+	_, err := upd.updateIntegrations("v1.0.1", downloadDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := func(loc string) string {
+		b, err := ioutil.ReadFile(loc)
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+	assert.Equal(t, "new", read(filepath.Join(upd.integrationsSubDir, "github")))
+	assert.Equal(t, "custom", read(filepath.Join(upd.integrationsSubDir, "custom")))
+	assert.Equal(t, "", read(filepath.Join(upd.integrationsSubDir, "mock")))
+	assert.Equal(t, "{}", read(filepath.Join(upd.integrationsParentDir, "plugins", "p1", "plugin.json")))
+}
@@ -32,6 +32,8 @@ func (s *runner) validate(ctx context.Context, messageID string, integration inc
 		AgentConfig:       s.conf,
 		Integrations:      []inconfig.IntegrationAgent{integration},
 		DeviceInfo:        s.deviceInfo,
+		Outbox:            s.outbox,
+		LogSpool:          s.logSpool,
 	})
 	if err != nil {
 		return res, err
@@ -25,6 +25,7 @@ func Run(opts Opts) error {
 	if err != nil {
 		return err
 	}
+	exp.FlushOutbox(cmdintegration.FlushOutboxTimeout)
 	return exp.Destroy()
 }
 
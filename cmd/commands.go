@@ -7,10 +7,12 @@ import (
 	"fmt"
 
 	pservice "github.com/kardianos/service"
+	"github.com/pinpt/agent/cmd/cmdcapabilities"
 	"github.com/pinpt/agent/cmd/cmdenroll"
 	"github.com/pinpt/agent/cmd/cmdexport"
 	"github.com/pinpt/agent/cmd/cmdexportonboarddata"
 	"github.com/pinpt/agent/cmd/cmdmutate"
+	"github.com/pinpt/agent/cmd/cmdplugins"
 	"github.com/pinpt/agent/cmd/cmdrun"
 	"github.com/pinpt/agent/cmd/cmdrunnorestarts"
 	"github.com/pinpt/agent/cmd/cmdserviceinstall"
@@ -19,6 +21,7 @@ import (
 	"github.com/pinpt/agent/cmd/pkg/cmdlogger"
 	"github.com/pinpt/agent/pkg/agentconf"
 	"github.com/pinpt/agent/pkg/fsconf"
+	"github.com/pinpt/agent/pkg/sandbox"
 	"github.com/pinpt/agent/pkg/service"
 	"github.com/pinpt/agent/rpcdef"
 	pos "github.com/pinpt/go-common/os"
@@ -220,6 +223,34 @@ func init() {
 	cmdRoot.AddCommand(cmd)
 }
 
+var cmdCapabilities = &cobra.Command{
+	Use:    "capabilities",
+	Hidden: true,
+	Short:  "Prints capabilities manifest of the integration",
+	Args:   cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger, baseOpts := integrationCommandOpts(cmd)
+		opts := cmdcapabilities.Opts{}
+		opts.Opts = baseOpts
+
+		outputFile := newOutputFile(logger, cmd)
+		defer outputFile.Close()
+		opts.Output = outputFile.Writer
+
+		err := cmdcapabilities.Run(opts)
+		if err != nil {
+			exitWithErr(logger, err)
+		}
+	},
+}
+
+func init() {
+	cmd := cmdCapabilities
+	integrationCommandFlags(cmd)
+	flagOutputFile(cmd)
+	cmdRoot.AddCommand(cmd)
+}
+
 var cmdMutate = &cobra.Command{
 	Use:    "mutate",
 	Hidden: true,
@@ -265,6 +296,86 @@ func init() {
 	cmdRoot.AddCommand(cmd)
 }
 
+var cmdPlugins = &cobra.Command{
+	Use:   "plugins",
+	Short: "Manage third-party integration plugins",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var cmdPluginsList = &cobra.Command{
+	Use:   "list",
+	Short: "List installed plugins and their verification status",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := cmdlogger.NewLogger(cmd)
+		pinpointRoot, err := getPinpointRoot(cmd)
+		if err != nil {
+			exitWithErr(logger, err)
+		}
+		locs := fsconf.New(pinpointRoot)
+
+		opts := cmdplugins.Opts{}
+		// agent config is optional, without it plugins are listed, but can't be verified using trusted keys or allowlist
+		agentConf, err := agentconf.Load(locs.Config2)
+		if err == nil {
+			opts.Config = agentConf.Plugins
+			opts.IntegrationsDir = agentConf.IntegrationsDir
+		}
+		if v, _ := cmd.Flags().GetString("integrations-dir"); v != "" {
+			opts.IntegrationsDir = v
+		}
+		if opts.IntegrationsDir == "" {
+			opts.IntegrationsDir = locs.IntegrationsDefaultDir
+		}
+		opts.JSON, _ = cmd.Flags().GetBool("json")
+
+		outputFile := newOutputFile(logger, cmd)
+		defer outputFile.Close()
+		opts.Output = outputFile.Writer
+
+		err = cmdplugins.List(opts)
+		if err != nil {
+			exitWithErr(logger, err)
+		}
+	},
+}
+
+func init() {
+	cmd := cmdPluginsList
+	flagsLogger(cmd)
+	flagPinpointRoot(cmd)
+	flagOutputFile(cmd)
+	cmd.Flags().String("integrations-dir", defaultIntegrationsDir(), "Integrations dir containing plugins")
+	cmd.Flags().Bool("json", false, "Output as json")
+	cmdPlugins.AddCommand(cmd)
+	cmdRoot.AddCommand(cmdPlugins)
+}
+
+var cmdSandboxExec = &cobra.Command{
+	Use:    sandbox.ShimCommandName + " <opts json> <binary> [args...]",
+	Hidden: true,
+	Short:  "Start integration binary with sandbox limits, used internally by agent",
+	// integration args are passed as is
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// stdout is used for plugin handshake, only write errors to stderr
+		opts, bin, binArgs, err := sandbox.ParseShimArgs(args)
+		if err != nil {
+			exitWithErr2(err)
+		}
+		err = sandbox.RunShim(opts, bin, binArgs)
+		if err != nil {
+			exitWithErr2(err)
+		}
+	},
+}
+
+func init() {
+	cmdRoot.AddCommand(cmdSandboxExec)
+}
+
 func envBasedOnAgentConfig(cmd *cobra.Command) (_ cmdlogger.Logger, _ agentconf.Config, pinpointRoot string) {
 	pinpointRoot, err := getPinpointRoot(cmd)
 	if err != nil {
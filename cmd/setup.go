@@ -28,6 +28,8 @@ var (
 	Version                = "dev"
 	Commit                 = "head"
 	IntegrationBinariesAll = ""
+	// UpdatePublicKey is the base64 encoded ed25519 key used to verify update manifests
+	UpdatePublicKey = ""
 )
 
 func Execute() {
@@ -45,6 +47,9 @@ func Execute() {
 		}
 	}
 
+	// not allowing override from environment, updates must be verified using the key set at build time
+	os.Setenv("PP_AGENT_UPDATE_PUBLIC_KEY", UpdatePublicKey)
+
 	cmdRoot.Execute()
 }
 
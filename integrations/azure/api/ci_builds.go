@@ -0,0 +1,259 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+)
+
+// repoTypeAzure is the repository type of repos hosted in azure devops and tfs, only these repos are exported by the integration
+const repoTypeAzure = "TfsGit"
+
+// FetchBuildDefinitions returns pipelines in project. Pipelines of azure repos not in repoIDs are skipped, pipelines of external repos are always returned.
+func (api *API) FetchBuildDefinitions(projid string, repoIDs map[string]bool) (res []extmodels.Pipeline, _ error) {
+	defs, err := api.fetchBuildDefinitions(projid)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range defs {
+		if d.Repository.Type == repoTypeAzure && !repoIDs[d.Repository.ID] {
+			continue
+		}
+		item := extmodels.Pipeline{}
+		item.CustomerID = api.customerid
+		item.RefType = api.reftype
+		item.RefID = strconv.FormatInt(d.ID, 10)
+		if d.Repository.Type == repoTypeAzure {
+			item.RepoID = api.IDs.CodeRepo(d.Repository.ID)
+		}
+		item.Name = d.Name
+		item.Path = d.Path
+		switch d.Process.Type {
+		case 1:
+			item.Kind = "designer"
+		case 2:
+			item.Kind = "yaml"
+		}
+		item.URL = d.Links.Web.Href
+		item.CreatedDate = extmodels.NewDate(d.CreatedDate)
+		res = append(res, item)
+	}
+	return
+}
+
+// FetchBuilds returns builds in project completed after fromdate and all builds that are still running. Builds of azure repos not in repoIDs are skipped.
+func (api *API) FetchBuilds(projid string, repoIDs map[string]bool, fromdate time.Time) (res []extmodels.Build, _ error) {
+	completed, err := api.fetchBuilds(projid, "completed", fromdate)
+	if err != nil {
+		return nil, err
+	}
+	// running builds are exported on every export until completed, so that the status is updated
+	inProgress, err := api.fetchBuilds(projid, "inProgress", time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	notStarted, err := api.fetchBuilds(projid, "notStarted", time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	all := append(append(completed, inProgress...), notStarted...)
+	seen := map[int64]bool{}
+	for _, b := range all {
+		if seen[b.ID] {
+			continue
+		}
+		seen[b.ID] = true
+		if b.Repository.Type == repoTypeAzure && !repoIDs[b.Repository.ID] {
+			continue
+		}
+		res = append(res, api.convertBuild(b))
+	}
+	return
+}
+
+var pullRequestBranchReg = regexp.MustCompile(`^refs/pull/(\d+)/merge$`)
+
+func (api *API) convertBuild(b buildResponse) extmodels.Build {
+	item := extmodels.Build{}
+	item.CustomerID = api.customerid
+	item.RefType = api.reftype
+	item.RefID = strconv.FormatInt(b.ID, 10)
+	item.PipelineID = extmodels.PipelineID(api.customerid, api.reftype, strconv.FormatInt(b.Definition.ID, 10))
+	item.Number = b.BuildNumber
+	item.Status = buildStatus(b.Status, b.Result)
+	item.SourceStatus = b.Status
+	if b.Result != "" {
+		item.SourceStatus += " (" + b.Result + ")"
+	}
+	item.Trigger = b.Reason
+	item.Branch = strings.TrimPrefix(b.SourceBranch, "refs/heads/")
+	item.CommitSHA = b.SourceVersion
+	var prID string
+	if b.Reason == "pullRequest" {
+		prID = b.TriggerInfo["pr.number"]
+		if prID == "" {
+			if m := pullRequestBranchReg.FindStringSubmatch(b.SourceBranch); m != nil {
+				prID = m[1]
+			}
+		}
+		// source version of pull request builds is the merge commit created by azure, use the pull request head instead
+		if v := b.TriggerInfo["pr.sourceSha"]; v != "" {
+			item.CommitSHA = v
+		}
+		if v := b.TriggerInfo["pr.sourceBranch"]; v != "" {
+			item.Branch = strings.TrimPrefix(v, "refs/heads/")
+		}
+	}
+	if b.Repository.Type == repoTypeAzure {
+		item.RepoID = api.IDs.CodeRepo(b.Repository.ID)
+		if item.CommitSHA != "" {
+			item.CommitID = api.IDs.CodeCommit(item.RepoID, item.CommitSHA)
+		}
+		if prID != "" {
+			item.PullRequestID = api.IDs.CodePullRequest(item.RepoID, prID)
+		}
+	}
+	item.RequestedByRefID = b.RequestedFor.ID
+	item.URL = b.Links.Web.Href
+	item.QueuedDate = extmodels.NewDate(b.QueueTime)
+	item.StartedDate = extmodels.NewDate(b.StartTime)
+	if b.Status == "completed" {
+		item.FinishedDate = extmodels.NewDate(b.FinishTime)
+		item.Duration = extmodels.Duration(b.StartTime, b.FinishTime)
+	}
+	return item
+}
+
+func buildStatus(status, result string) string {
+	switch status {
+	case "notStarted", "postponed":
+		return extmodels.BuildStatusQueued
+	case "inProgress", "cancelling":
+		return extmodels.BuildStatusRunning
+	}
+	return resultStatus(result)
+}
+
+// resultStatus converts result of completed build or timeline record
+func resultStatus(result string) string {
+	switch result {
+	case "succeeded":
+		return extmodels.BuildStatusSuccess
+	case "partiallySucceeded", "succeededWithIssues":
+		return extmodels.BuildStatusPartial
+	case "canceled", "abandoned":
+		return extmodels.BuildStatusCanceled
+	case "skipped":
+		return extmodels.BuildStatusSkipped
+	}
+	return extmodels.BuildStatusFailure
+}
+
+// FetchBuildSteps returns stages and jobs of the build from the timeline. Pipelines without stages only have jobs.
+func (api *API) FetchBuildSteps(projid string, build extmodels.Build) ([]extmodels.BuildStep, error) {
+	res, err := api.fetchBuildTimeline(projid, build.RefID)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return api.convertTimeline(build, res[0].Records), nil
+}
+
+func (api *API) convertTimeline(build extmodels.Build, records []timelineRecordResponse) (res []extmodels.BuildStep) {
+	byID := map[string]timelineRecordResponse{}
+	for _, r := range records {
+		byID[r.ID] = r
+	}
+	// stageOf returns the stage containing the record, jobs are in phases, which are in stages
+	stageOf := func(r timelineRecordResponse) string {
+		for i := 0; i < len(records) && r.ParentID != ""; i++ {
+			parent, ok := byID[r.ParentID]
+			if !ok {
+				return ""
+			}
+			if parent.Type == "Stage" {
+				return parent.ID
+			}
+			r = parent
+		}
+		return ""
+	}
+	for _, r := range records {
+		item := extmodels.BuildStep{}
+		switch r.Type {
+		case "Stage":
+			item.Kind = extmodels.BuildStepKindStage
+		case "Job":
+			item.Kind = extmodels.BuildStepKindJob
+			if stage := stageOf(r); stage != "" {
+				item.ParentID = extmodels.BuildStepID(api.customerid, api.reftype, build.RefID+"/"+stage)
+			}
+		default:
+			continue
+		}
+		item.CustomerID = api.customerid
+		item.RefType = api.reftype
+		// record ids are only unique within the build
+		item.RefID = build.RefID + "/" + r.ID
+		item.BuildID = build.ID()
+		item.Name = r.Name
+		item.Order = r.Order
+		item.Attempt = r.Attempt
+		switch r.State {
+		case "pending":
+			item.Status = extmodels.BuildStatusQueued
+		case "inProgress":
+			item.Status = extmodels.BuildStatusRunning
+		default:
+			item.Status = resultStatus(r.Result)
+		}
+		item.StartedDate = extmodels.NewDate(r.StartTime)
+		item.FinishedDate = extmodels.NewDate(r.FinishTime)
+		item.Duration = extmodels.Duration(r.StartTime, r.FinishTime)
+		res = append(res, item)
+	}
+	return
+}
+
+func (api *API) fetchBuildDefinitions(projid string) ([]buildDefinitionResponse, error) {
+	u := fmt.Sprintf(`%s/_apis/build/definitions`, url.PathEscape(projid))
+	var res []buildDefinitionResponse
+	if err := api.getRequest(u, stringmap{"includeAllProperties": "true"}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (api *API) fetchBuilds(projid string, status string, fromdate time.Time) ([]buildResponse, error) {
+	u := fmt.Sprintf(`%s/_apis/build/builds`, url.PathEscape(projid))
+	params := stringmap{"statusFilter": status}
+	if !fromdate.IsZero() {
+		if api.tfs {
+			params["minFinishTime"] = fromdate.Format(time.RFC3339)
+		} else {
+			params["minTime"] = fromdate.Format(time.RFC3339)
+			params["queryOrder"] = "finishTimeAscending"
+		}
+	}
+	var res []buildResponse
+	if err := api.getRequest(u, params, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (api *API) fetchBuildTimeline(projid string, buildid string) ([]timelineResponse, error) {
+	u := fmt.Sprintf(`%s/_apis/build/builds/%s/timeline`, url.PathEscape(projid), url.PathEscape(buildid))
+	var res []timelineResponse
+	if err := api.getRequest(u, stringmap{"pagingoff": "true"}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/agent/pkg/ids2"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAPI() *API {
+	return &API{
+		customerid: "c1",
+		reftype:    "azure",
+		creds:      &Creds{URL: "https://dev.azure.com", Organization: "org"},
+		IDs:        ids2.New("c1", "azure"),
+	}
+}
+
+func TestConvertBuildPullRequest(t *testing.T) {
+	api := testAPI()
+	b := buildResponse{}
+	b.ID = 42
+	b.Status = "completed"
+	b.Result = "partiallySucceeded"
+	b.Reason = "pullRequest"
+	b.SourceBranch = "refs/pull/7/merge"
+	b.SourceVersion = "merge-sha"
+	b.TriggerInfo = map[string]string{"pr.sourceSha": "head-sha", "pr.sourceBranch": "refs/heads/feature"}
+	b.Repository = buildRepositoryResponse{ID: "r1", Type: repoTypeAzure}
+	b.StartTime = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.FinishTime = b.StartTime.Add(90 * time.Second)
+
+	got := api.convertBuild(b)
+	repoID := api.IDs.CodeRepo("r1")
+	assert.Equal(t, "42", got.RefID)
+	assert.Equal(t, extmodels.BuildStatusPartial, got.Status)
+	assert.Equal(t, "completed (partiallySucceeded)", got.SourceStatus)
+	assert.Equal(t, "feature", got.Branch)
+	assert.Equal(t, "head-sha", got.CommitSHA)
+	assert.Equal(t, api.IDs.CodeCommit(repoID, "head-sha"), got.CommitID)
+	assert.Equal(t, api.IDs.CodePullRequest(repoID, "7"), got.PullRequestID)
+	assert.Equal(t, int64(90000), got.Duration)
+}
+
+func TestConvertBuildExternalRepo(t *testing.T) {
+	api := testAPI()
+	b := buildResponse{}
+	b.ID = 1
+	b.Status = "inProgress"
+	b.SourceBranch = "refs/heads/master"
+	b.SourceVersion = "sha"
+	b.Repository = buildRepositoryResponse{ID: "org/repo", Type: "GitHub"}
+
+	got := api.convertBuild(b)
+	assert.Equal(t, extmodels.BuildStatusRunning, got.Status)
+	assert.Equal(t, "master", got.Branch)
+	assert.Equal(t, "", got.RepoID)
+	assert.Equal(t, "", got.CommitID)
+	assert.True(t, got.FinishedDate.Time().IsZero())
+}
+
+func TestConvertTimeline(t *testing.T) {
+	api := testAPI()
+	build := extmodels.Build{CustomerID: "c1", RefType: "azure", RefID: "42"}
+	records := []timelineRecordResponse{
+		{ID: "s1", Type: "Stage", Name: "Build", State: "completed", Result: "succeeded"},
+		{ID: "p1", ParentID: "s1", Type: "Phase", Name: "phase"},
+		{ID: "j1", ParentID: "p1", Type: "Job", Name: "Linux", State: "completed", Result: "failed"},
+		{ID: "t1", ParentID: "j1", Type: "Task", Name: "checkout"},
+		{ID: "j2", ParentID: "p2", Type: "Job", Name: "classic", State: "inProgress"},
+	}
+	got := api.convertTimeline(build, records)
+	assert.Len(t, got, 3)
+	assert.Equal(t, extmodels.BuildStepKindStage, got[0].Kind)
+	assert.Equal(t, "42/s1", got[0].RefID)
+	assert.Equal(t, build.ID(), got[0].BuildID)
+	assert.Equal(t, extmodels.BuildStepKindJob, got[1].Kind)
+	assert.Equal(t, got[0].ID(), got[1].ParentID)
+	assert.Equal(t, extmodels.BuildStatusFailure, got[1].Status)
+	assert.Equal(t, "", got[2].ParentID, "jobs of pipelines without stages have no parent")
+	assert.Equal(t, extmodels.BuildStatusRunning, got[2].Status)
+}
+
+func TestReleaseURL(t *testing.T) {
+	api := testAPI()
+	assert.Equal(t, "https://vsrm.dev.azure.com/org/p/_apis/release/deployments", api.releaseURL("p/_apis/release/deployments"))
+	api.creds.URL = "https://org.visualstudio.com"
+	assert.Equal(t, "https://org.vsrm.visualstudio.com/org/p", api.releaseURL("p"))
+}
+
+func TestConvertReleaseDeployment(t *testing.T) {
+	api := testAPI()
+	d := releaseDeploymentResponse{}
+	d.ID = 5
+	d.DeploymentStatus = "succeeded"
+	d.ReleaseDefinition.Name = "api"
+	d.ReleaseEnvironment.Name = "prod"
+	d.CompletedOn = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := releaseArtifactResponse{Type: "Build", IsPrimary: true}
+	a.DefinitionReference = map[string]artifactSourceReference{
+		"repository":          {ID: "r1"},
+		"repository.provider": {ID: repoTypeAzure},
+		"sourceVersion":       {ID: "sha"},
+		"branch":              {ID: "refs/heads/master"},
+	}
+	d.Release.Artifacts = []releaseArtifactResponse{a}
+
+	got := api.convertReleaseDeployment(d, map[string]bool{"r1": true})
+	repoID := api.IDs.CodeRepo("r1")
+	assert.Equal(t, "SUCCESS", got.State)
+	assert.Equal(t, repoID, got.RepoID)
+	assert.Equal(t, api.IDs.CodeCommit(repoID, "sha"), got.CommitID)
+	assert.Equal(t, "master", got.Ref)
+	assert.Equal(t, "api", got.Service)
+	assert.False(t, got.FinishedDate.Time().IsZero())
+
+	got = api.convertReleaseDeployment(d, map[string]bool{})
+	assert.Equal(t, "", got.RepoID, "repos that are not exported are not linked")
+}
+
+func TestIsAccessDenied(t *testing.T) {
+	assert.True(t, IsAccessDenied(&StatusError{StatusCode: http.StatusUnauthorized}))
+	assert.True(t, IsAccessDenied(fmt.Errorf("could not fetch builds: %w", &StatusError{StatusCode: http.StatusForbidden})))
+	assert.False(t, IsAccessDenied(&StatusError{StatusCode: http.StatusNotFound}))
+	assert.False(t, IsAccessDenied(errors.New("invalid response code: 403")))
+}
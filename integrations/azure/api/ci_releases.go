@@ -0,0 +1,126 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	pstrings "github.com/pinpt/go-common/strings"
+)
+
+// FetchReleaseDeployments returns deployments of classic releases to environments, modified after fromdate. Deployments are linked to the repo and commit of the primary build artifact, if the repo is in repoIDs.
+func (api *API) FetchReleaseDeployments(projid string, repoIDs map[string]bool, fromdate time.Time) (res []extmodels.Deployment, _ error) {
+	deployments, err := api.fetchReleaseDeployments(projid, fromdate)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deployments {
+		res = append(res, api.convertReleaseDeployment(d, repoIDs))
+	}
+	return
+}
+
+func (api *API) convertReleaseDeployment(d releaseDeploymentResponse, repoIDs map[string]bool) extmodels.Deployment {
+	item := extmodels.Deployment{}
+	item.CustomerID = api.customerid
+	item.RefType = api.reftype
+	item.RefID = strconv.FormatInt(d.ID, 10)
+	item.Service = d.ReleaseDefinition.Name
+	item.Environment = d.ReleaseEnvironment.Name
+
+	if a, ok := primaryArtifact(d.Release.Artifacts); ok {
+		ref := a.DefinitionReference
+		item.Ref = strings.TrimPrefix(ref["branch"].ID, "refs/heads/")
+		item.CommitSHA = ref["sourceVersion"].ID
+		repoID := ref["repository"].ID
+		provider := ref["repository.provider"].ID
+		if a.Type == "Git" {
+			// git artifacts reference the repo directly
+			repoID = ref["definition"].ID
+			if item.CommitSHA == "" {
+				item.CommitSHA = ref["version"].ID
+			}
+		}
+		if repoID != "" && (provider == "" || provider == repoTypeAzure) && repoIDs[repoID] {
+			item.RepoID = api.IDs.CodeRepo(repoID)
+			if item.CommitSHA != "" {
+				item.CommitID = api.IDs.CodeCommit(item.RepoID, item.CommitSHA)
+			}
+		}
+	}
+	item.EnvironmentID = extmodels.EnvironmentID(api.customerid, api.reftype, item.RepoID, item.Environment)
+	item.CreatorRefID = d.RequestedFor.ID
+	item.Description = d.Release.Name
+	item.State = releaseDeploymentState(d.DeploymentStatus, d.OperationStatus)
+	item.URL = d.Release.Links.Web.Href
+	item.CreatedDate = extmodels.NewDate(d.QueuedOn)
+	item.UpdatedDate = extmodels.NewDate(d.LastModifiedOn)
+	if extmodels.IsDeploymentFinished(item.State) {
+		item.FinishedDate = extmodels.NewDate(d.CompletedOn)
+	}
+	return item
+}
+
+// primaryArtifact returns the artifact marked as primary, or the first build or git artifact
+func primaryArtifact(artifacts []releaseArtifactResponse) (res releaseArtifactResponse, ok bool) {
+	for _, a := range artifacts {
+		if a.IsPrimary {
+			return a, true
+		}
+	}
+	for _, a := range artifacts {
+		if a.Type == "Build" || a.Type == "Git" {
+			return a, true
+		}
+	}
+	return
+}
+
+func releaseDeploymentState(status, operationStatus string) string {
+	if strings.HasPrefix(strings.ToLower(operationStatus), "cancel") {
+		return extmodels.DeploymentStateCanceled
+	}
+	switch status {
+	case "inProgress":
+		return extmodels.DeploymentStateRunning
+	case "succeeded", "partiallySucceeded":
+		return extmodels.DeploymentStateSuccess
+	case "failed":
+		return extmodels.DeploymentStateFailure
+	}
+	return extmodels.DeploymentStatePending
+}
+
+// releaseURL returns the url of release management api, which uses a separate host in azure
+func (api *API) releaseURL(endPoint string) string {
+	if api.tfs {
+		return pstrings.JoinURL(api.creds.URL, api.creds.CollectionName, endPoint)
+	}
+	base := api.creds.URL
+	if u, err := url.Parse(base); err == nil {
+		switch {
+		case u.Host == "dev.azure.com":
+			u.Host = "vsrm.dev.azure.com"
+		case strings.HasSuffix(u.Host, ".visualstudio.com") && !strings.HasSuffix(u.Host, ".vsrm.visualstudio.com"):
+			u.Host = strings.TrimSuffix(u.Host, ".visualstudio.com") + ".vsrm.visualstudio.com"
+		}
+		base = u.String()
+	}
+	return pstrings.JoinURL(base, api.creds.Organization, endPoint)
+}
+
+func (api *API) fetchReleaseDeployments(projid string, fromdate time.Time) ([]releaseDeploymentResponse, error) {
+	u := api.releaseURL(fmt.Sprintf(`%s/_apis/release/deployments`, url.PathEscape(projid)))
+	params := stringmap{"queryOrder": "ascending", "$top": "100"}
+	if !fromdate.IsZero() {
+		params["minModifiedTime"] = fromdate.Format(time.RFC3339)
+	}
+	var res []releaseDeploymentResponse
+	if err := api.getRequest(u, params, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,120 @@
+package api
+
+import "time"
+
+type linksResponse struct {
+	Web struct {
+		Href string `json:"href"`
+	} `json:"web"`
+}
+
+// used in ci_builds.go - fetchBuildDefinitions
+type buildDefinitionResponse struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	CreatedDate time.Time `json:"createdDate"`
+	Process     struct {
+		// Type is 1 for designer and 2 for yaml pipelines
+		Type int `json:"type"`
+	} `json:"process"`
+	Repository buildRepositoryResponse `json:"repository"`
+	Links      linksResponse           `json:"_links"`
+}
+
+type buildRepositoryResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Type is TfsGit for azure repos, other values for external repos, such as GitHub or TfsVersionControl
+	Type string `json:"type"`
+}
+
+// used in ci_builds.go - fetchBuilds
+type buildResponse struct {
+	ID          int64  `json:"id"`
+	BuildNumber string `json:"buildNumber"`
+	// Status is notStarted, inProgress, cancelling, postponed or completed
+	Status string `json:"status"`
+	// Result is succeeded, partiallySucceeded, failed or canceled, only set for completed builds
+	Result     string    `json:"result"`
+	QueueTime  time.Time `json:"queueTime"`
+	StartTime  time.Time `json:"startTime"`
+	FinishTime time.Time `json:"finishTime"`
+	// Reason is manual, individualCI, batchedCI, schedule, pullRequest, buildCompletion or resourceTrigger
+	Reason        string            `json:"reason"`
+	SourceBranch  string            `json:"sourceBranch"`
+	SourceVersion string            `json:"sourceVersion"`
+	TriggerInfo   map[string]string `json:"triggerInfo"` // not in TFS
+	Definition    struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"definition"`
+	Repository   buildRepositoryResponse `json:"repository"`
+	RequestedFor usersResponse           `json:"requestedFor"`
+	Links        linksResponse           `json:"_links"`
+}
+
+// used in ci_builds.go - fetchBuildTimeline
+type timelineResponse struct {
+	Records []timelineRecordResponse `json:"records"`
+}
+
+type timelineRecordResponse struct {
+	ID       string `json:"id"`
+	ParentID string `json:"parentId"`
+	// Type is Stage, Phase, Job, Task or Checkpoint
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Order   int    `json:"order"`
+	Attempt int    `json:"attempt"`
+	// State is pending, inProgress or completed
+	State string `json:"state"`
+	// Result is succeeded, succeededWithIssues, failed, canceled, skipped or abandoned
+	Result     string    `json:"result"`
+	StartTime  time.Time `json:"startTime"`
+	FinishTime time.Time `json:"finishTime"`
+}
+
+// used in ci_releases.go - fetchReleaseDeployments
+type releaseDeploymentResponse struct {
+	ID      int64  `json:"id"`
+	Attempt int    `json:"attempt"`
+	Reason  string `json:"reason"`
+	// DeploymentStatus is notDeployed, inProgress, succeeded, partiallySucceeded or failed
+	DeploymentStatus string `json:"deploymentStatus"`
+	// OperationStatus has more details, for example Canceled or Queued
+	OperationStatus string `json:"operationStatus"`
+	Release         struct {
+		ID        int64                     `json:"id"`
+		Name      string                    `json:"name"`
+		Artifacts []releaseArtifactResponse `json:"artifacts"`
+		Links     linksResponse             `json:"_links"`
+	} `json:"release"`
+	ReleaseDefinition struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"releaseDefinition"`
+	ReleaseEnvironment struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"releaseEnvironment"`
+	RequestedFor   usersResponse `json:"requestedFor"`
+	QueuedOn       time.Time     `json:"queuedOn"`
+	StartedOn      time.Time     `json:"startedOn"`
+	CompletedOn    time.Time     `json:"completedOn"`
+	LastModifiedOn time.Time     `json:"lastModifiedOn"`
+}
+
+type releaseArtifactResponse struct {
+	Alias     string `json:"alias"`
+	IsPrimary bool   `json:"isPrimary"`
+	// Type is Build, Git, GitHub and others
+	Type string `json:"type"`
+	// DefinitionReference contains source details keyed by name, such as repository, branch and sourceVersion for build artifacts
+	DefinitionReference map[string]artifactSourceReference `json:"definitionReference"`
+}
+
+type artifactSourceReference struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	pjson "github.com/pinpt/go-common/json"
 
@@ -17,7 +19,7 @@ import (
 
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/pinpt/agent/pkg/ids2"
-	"github.com/pinpt/go-common/httpdefaults"
+	"github.com/pinpt/agent/pkg/reqstats"
 
 	pstrings "github.com/pinpt/go-common/strings"
 	"github.com/pinpt/httpclient"
@@ -53,10 +55,10 @@ type API struct {
 	IDs ids2.Gen
 }
 
-// NewAPI initializer
-func NewAPI(ctx context.Context, logger hclog.Logger, concurrency int, customerid, reftype string, creds *Creds, istfs bool) *API {
+// NewAPI initializer, clients are from reqstats so that rate limits and record/replay are applied
+func NewAPI(ctx context.Context, logger hclog.Logger, clients reqstats.Clients, concurrency int, customerid, reftype string, creds *Creds, istfs bool) *API {
 	client := &http.Client{
-		Transport: httpdefaults.DefaultTransport(),
+		Transport: clients.Default.Transport,
 		Timeout:   10 * time.Minute,
 	}
 	conf := &httpclient.Config{
@@ -116,7 +118,10 @@ func (api *API) getRequest(endPoint string, params stringmap, out interface{}) e
 func (api *API) doRequest(method, endPoint string, params stringmap, reader io.Reader, out interface{}) error {
 
 	var rawurl string
-	if api.tfs {
+	if strings.HasPrefix(endPoint, "https://") || strings.HasPrefix(endPoint, "http://") {
+		// apis on other hosts, such as release management
+		rawurl = endPoint
+	} else if api.tfs {
 		rawurl = pstrings.JoinURL(api.creds.URL, api.creds.CollectionName, endPoint)
 	} else {
 		rawurl = pstrings.JoinURL(api.creds.URL, api.creds.Organization, endPoint)
@@ -164,7 +169,26 @@ func (api *API) doRequest(method, endPoint string, params stringmap, reader io.R
 		}
 		return nil
 	}
-	return fmt.Errorf("invalid response code: %v request url: %v", res.StatusCode, res.Request.URL)
+	return &StatusError{StatusCode: res.StatusCode, URL: res.Request.URL.String()}
+}
+
+// StatusError is returned when api responds with unexpected status code
+type StatusError struct {
+	StatusCode int
+	URL        string
+}
+
+func (s *StatusError) Error() string {
+	return fmt.Sprintf("invalid response code: %v request url: %v", s.StatusCode, s.URL)
+}
+
+// IsAccessDenied returns true if api responded with 401 or 403, which happens when api key does not have permissions for the api or the feature is disabled in project.
+func IsAccessDenied(err error) bool {
+	var serr *StatusError
+	if !errors.As(err, &serr) {
+		return false
+	}
+	return serr.StatusCode == http.StatusUnauthorized || serr.StatusCode == http.StatusForbidden
 }
 
 // some util functions
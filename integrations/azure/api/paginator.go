@@ -40,6 +40,17 @@ func (p tfsPaginator) HasMore(page int, req *http.Request, resp *http.Response)
 		body = append([]byte{','}, body...)
 	}
 	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	// build and release apis do not support $skip, they return continuation token when there are more results
+	if token := resp.Header.Get("x-ms-continuationtoken"); token != "" {
+		urlquery := req.URL.Query()
+		urlquery.Set("continuationToken", token)
+		req.URL.RawQuery = urlquery.Encode()
+		newreq, _ := http.NewRequest(req.Method, req.URL.String(), nil)
+		if user, pass, ok := req.BasicAuth(); ok {
+			newreq.SetBasicAuth(user, pass)
+		}
+		return true, newreq
+	}
 	if mapBody.Count == int64(maxResults) {
 		urlquery := req.URL.Query()
 		if urlquery.Get("pagingoff") != "" {
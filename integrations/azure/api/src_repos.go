@@ -36,6 +36,26 @@ func (api *API) FetchAllRepos(includedByName []string, excludedids []string, inc
 		projectidmap[repo.Project.ID] = true
 	}
 
+	repos = api.filterRepos(allRepos, includedByName, excludedids, includedids)
+
+	for projid := range projectidmap {
+		projectids = append(projectids, projid)
+	}
+	return
+}
+
+func (api *API) fetchRepos(projid string) ([]reposResponse, error) {
+	// projid is optional, can be ""
+	u := fmt.Sprintf(`%s/_apis/git/repositories/`, url.PathEscape(projid))
+	var res []reposResponse
+	if err := api.getRequest(u, nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// filterRepos returns repos matching names or included and not excluded ids. Returns all repos if no filters are set.
+func (api *API) filterRepos(allRepos []*sourcecode.Repo, includedByName []string, excludedids []string, includedids []string) (repos []*sourcecode.Repo) {
 	if len(includedByName) != 0 {
 		onlyInclude := includedByName
 
@@ -88,19 +108,5 @@ func (api *API) FetchAllRepos(includedByName []string, excludedids []string, inc
 			repos = append(repos, repo)
 		}
 	}
-
-	for projid := range projectidmap {
-		projectids = append(projectids, projid)
-	}
 	return
 }
-
-func (api *API) fetchRepos(projid string) ([]reposResponse, error) {
-	// projid is optional, can be ""
-	u := fmt.Sprintf(`%s/_apis/git/repositories/`, url.PathEscape(projid))
-	var res []reposResponse
-	if err := api.getRequest(u, nil, &res); err != nil {
-		return nil, err
-	}
-	return res, nil
-}
@@ -183,3 +183,49 @@ type singleCommitResponse struct {
 	} `json:"push"`
 	RemoteURL string `json:"remoteUrl"`
 }
+
+// used in src_tfvc.go - fetchTfvcItems
+type tfvcItemResponse struct {
+	Path     string `json:"path"`
+	IsFolder bool   `json:"isFolder"`
+}
+
+// used in src_tfvc.go - fetchTfvcChangesets
+type tfvcChangesetResponse struct {
+	ChangesetID      int64         `json:"changesetId"`
+	Author           usersResponse `json:"author"`
+	CheckedInBy      usersResponse `json:"checkedInBy"`
+	CreatedDate      time.Time     `json:"createdDate"`
+	Comment          string        `json:"comment"`
+	CommentTruncated bool          `json:"commentTruncated"`
+}
+
+// used in src_tfvc.go - fetchTfvcChanges and fetchShelvesetChanges
+type tfvcChangeResponse struct {
+	Item tfvcItemResponse `json:"item"`
+	// ChangeType is a comma separated list, for example "add, edit, encoding" or "rename, edit"
+	ChangeType string `json:"changeType"`
+}
+
+// used in src_tfvc.go - fetchTfvcBranches
+type tfvcBranchResponse struct {
+	Path        string        `json:"path"`
+	Description string        `json:"description"`
+	Owner       usersResponse `json:"owner"`
+	CreatedDate time.Time     `json:"createdDate"`
+	IsDeleted   bool          `json:"isDeleted"`
+	Parent      *struct {
+		Path string `json:"path"`
+	} `json:"parent"`
+	Children []tfvcBranchResponse `json:"children"`
+}
+
+// used in src_tfvc.go - fetchShelvesets
+type tfvcShelvesetResponse struct {
+	// ID is name and owner unique name separated by ;
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Owner       usersResponse `json:"owner"`
+	CreatedDate time.Time     `json:"createdDate"`
+	Comment     string        `json:"comment"`
+}
@@ -0,0 +1,323 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/agent/pkg/date"
+	"github.com/pinpt/go-common/hash"
+	pstrings "github.com/pinpt/go-common/strings"
+	"github.com/pinpt/integration-sdk/sourcecode"
+)
+
+// tfvcRefIDPrefix is added to project id to create the ref id of tfvc repo. Every project has at most one tfvc repo, located at $/<project name>.
+const tfvcRefIDPrefix = "tfvc:"
+
+// FetchTfvcRepos returns a repo for every project using tfvc. Repo name is the server path of the project, for example $/Project, and ref id is tfvc:<project id>. Repos are filtered the same way as in FetchAllRepos.
+func (api *API) FetchTfvcRepos(includedByName []string, excludedids []string, includedids []string) ([]*sourcecode.Repo, error) {
+	items, err := api.fetchTfvcItems("$/")
+	if err != nil {
+		return nil, err
+	}
+	roots := map[string]bool{}
+	for _, item := range items {
+		if item.IsFolder && item.Path != "$/" {
+			roots[strings.ToLower(item.Path)] = true
+		}
+	}
+	if len(roots) == 0 {
+		return nil, nil
+	}
+	var projects []projectResponse
+	if err := api.getRequest(`_apis/projects/`, nil, &projects); err != nil {
+		return nil, err
+	}
+	var allRepos []*sourcecode.Repo
+	for _, p := range projects {
+		name := "$/" + p.Name
+		if !roots[strings.ToLower(name)] {
+			continue
+		}
+		allRepos = append(allRepos, &sourcecode.Repo{
+			Active:     true,
+			CustomerID: api.customerid,
+			Name:       name,
+			RefID:      tfvcRefIDPrefix + p.ID,
+			RefType:    api.reftype,
+			URL:        api.webURL(url.PathEscape(p.Name), "_versionControl"),
+		})
+	}
+	return api.filterRepos(allRepos, includedByName, excludedids, includedids), nil
+}
+
+// TfvcChangeset is a changeset converted to commit, with commit users for author and committer.
+type TfvcChangeset struct {
+	ID     int64
+	Commit *sourcecode.Commit
+	Users  []*sourcecode.User
+}
+
+// FetchTfvcChangesets returns changesets of the tfvc repo with id greater than fromID, ordered by id. Pass 0 as fromID to fetch all changesets.
+func (api *API) FetchTfvcChangesets(repo *sourcecode.Repo, fromID int64) (res []TfvcChangeset, _ error) {
+	changesets, err := api.fetchTfvcChangesets(repo.Name, fromID+1)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range changesets {
+		res = append(res, api.convertChangeset(repo, c))
+	}
+	// $orderby is ignored by older tfs versions
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return
+}
+
+func (api *API) convertChangeset(repo *sourcecode.Repo, c tfvcChangesetResponse) TfvcChangeset {
+	id := strconv.FormatInt(c.ChangesetID, 10)
+	author := tfvcEmail(c.Author)
+	committer := tfvcEmail(c.CheckedInBy)
+	commit := &sourcecode.Commit{
+		RefID:          id,
+		RefType:        api.reftype,
+		CustomerID:     api.customerid,
+		RepoID:         api.IDs.CodeRepo(repo.RefID),
+		Sha:            id,
+		Message:        c.Comment,
+		URL:            pstrings.JoinURL(repo.URL, "changeset", id),
+		AuthorRefID:    api.IDs.CodeCommitEmail(author),
+		CommitterRefID: api.IDs.CodeCommitEmail(committer),
+		Identifier:     repo.Name + "#C" + id,
+	}
+	date.ConvertToModel(c.CreatedDate, &commit.CreatedDate)
+	res := TfvcChangeset{ID: c.ChangesetID, Commit: commit}
+	if author != "" {
+		res.Users = append(res.Users, api.commitUser(author, c.Author))
+	}
+	if committer != "" && committer != author {
+		res.Users = append(res.Users, api.commitUser(committer, c.CheckedInBy))
+	}
+	return res
+}
+
+// tfvcEmail returns the value used as commit user email. Unique name is the email in azure and domain\user in tfs with active directory.
+func tfvcEmail(user usersResponse) string {
+	return strings.ToLower(user.UniqueName)
+}
+
+// commitUser returns the user in the same format as created by git processing, linked to the azure user
+func (api *API) commitUser(email string, user usersResponse) *sourcecode.User {
+	obj := &sourcecode.User{}
+	obj.CustomerID = api.customerid
+	obj.RefType = "git"
+	obj.RefID = api.IDs.CodeCommitEmail(email)
+	obj.Email = pstrings.Pointer(email)
+	obj.Name = user.DisplayName
+	if user.ID == "" {
+		obj.ID = hash.Values("User", obj.CustomerID, email, "git")
+	} else {
+		obj.ID = hash.Values("User", obj.CustomerID, email, "git", user.ID)
+		obj.AssociatedRefID = pstrings.Pointer(user.ID)
+	}
+	return obj
+}
+
+// FetchTfvcChangesetFiles returns the files changed in changeset. Folders are not included.
+func (api *API) FetchTfvcChangesetFiles(repo *sourcecode.Repo, changesetID int64) (res []extmodels.CommitFile, _ error) {
+	changes, err := api.fetchTfvcChanges(changesetID)
+	if err != nil {
+		return nil, err
+	}
+	repoID := api.IDs.CodeRepo(repo.RefID)
+	commitID := api.IDs.CodeCommit(repoID, strconv.FormatInt(changesetID, 10))
+	for _, ch := range changes {
+		if ch.Item.IsFolder {
+			continue
+		}
+		res = append(res, extmodels.CommitFile{
+			CustomerID:       api.customerid,
+			RefType:          api.reftype,
+			RepoID:           repoID,
+			CommitID:         commitID,
+			Path:             ch.Item.Path,
+			ChangeType:       tfvcChangeType(ch.ChangeType),
+			SourceChangeType: ch.ChangeType,
+		})
+	}
+	return
+}
+
+// tfvcChangeType converts the comma separated list of tfvc change types to commit file change type
+func tfvcChangeType(changeType string) string {
+	types := map[string]bool{}
+	for _, t := range strings.Split(changeType, ",") {
+		types[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	switch {
+	case types["delete"]:
+		return extmodels.CommitFileDeleted
+	case types["rename"]:
+		return extmodels.CommitFileRenamed
+	case types["add"], types["branch"], types["undelete"]:
+		return extmodels.CommitFileAdded
+	}
+	return extmodels.CommitFileModified
+}
+
+// FetchTfvcBranches returns the branches in the tfvc repo. Branches without a parent are marked as default.
+func (api *API) FetchTfvcBranches(repo *sourcecode.Repo) (res []*sourcecode.Branch, _ error) {
+	branches, err := api.fetchTfvcBranches(TfvcProjectID(repo.RefID))
+	if err != nil {
+		return nil, err
+	}
+	return api.convertTfvcBranches(repo, branches), nil
+}
+
+func (api *API) convertTfvcBranches(repo *sourcecode.Repo, branches []tfvcBranchResponse) (res []*sourcecode.Branch) {
+	prefix := strings.ToLower(repo.Name + "/")
+	var add func(branches []tfvcBranchResponse)
+	add = func(branches []tfvcBranchResponse) {
+		for _, b := range branches {
+			add(b.Children)
+			if b.IsDeleted || !strings.HasPrefix(strings.ToLower(b.Path), prefix) {
+				continue
+			}
+			res = append(res, &sourcecode.Branch{
+				RefID:      b.Path,
+				RefType:    api.reftype,
+				CustomerID: api.customerid,
+				RepoID:     api.IDs.CodeRepo(repo.RefID),
+				Name:       b.Path,
+				URL:        repo.URL + "?path=" + url.QueryEscape(b.Path),
+				Default:    b.Parent == nil,
+			})
+		}
+	}
+	add(branches)
+	return
+}
+
+// FetchTfvcShelvesets returns shelvesets as branches, keyed by ref id of the tfvc repo. Shelvesets are assigned to repos based on the path of the first changed file, shelvesets without changes in the passed repos are skipped.
+func (api *API) FetchTfvcShelvesets(repos []*sourcecode.Repo) (map[string][]*sourcecode.Branch, error) {
+	shelvesets, err := api.fetchShelvesets()
+	if err != nil {
+		return nil, err
+	}
+	res := map[string][]*sourcecode.Branch{}
+	for _, ss := range shelvesets {
+		changes, err := api.fetchShelvesetChanges(ss.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		repo := tfvcRepoForPath(repos, changes[0].Item.Path)
+		if repo == nil {
+			continue
+		}
+		res[repo.RefID] = append(res[repo.RefID], api.convertShelveset(repo, ss))
+	}
+	return res, nil
+}
+
+func (api *API) convertShelveset(repo *sourcecode.Repo, ss tfvcShelvesetResponse) *sourcecode.Branch {
+	return &sourcecode.Branch{
+		RefID:      "shelveset/" + ss.ID,
+		RefType:    api.reftype,
+		CustomerID: api.customerid,
+		RepoID:     api.IDs.CodeRepo(repo.RefID),
+		Name:       "shelveset/" + ss.ID,
+		URL:        pstrings.JoinURL(repo.URL, "shelveset") + "?ss=" + url.QueryEscape(ss.ID),
+	}
+}
+
+// tfvcRepoForPath returns the repo containing the server path, or nil if none of the repos contain it
+func tfvcRepoForPath(repos []*sourcecode.Repo, path string) *sourcecode.Repo {
+	path = strings.ToLower(path)
+	for _, repo := range repos {
+		name := strings.ToLower(repo.Name)
+		if path == name || strings.HasPrefix(path, name+"/") {
+			return repo
+		}
+	}
+	return nil
+}
+
+// TfvcProjectID returns the id of the project containing the tfvc repo
+func TfvcProjectID(refID string) string {
+	return strings.TrimPrefix(refID, tfvcRefIDPrefix)
+}
+
+// webURL returns the url of a page in the web ui of organization or collection
+func (api *API) webURL(parts ...string) string {
+	name := api.creds.Organization
+	if api.tfs {
+		name = api.creds.CollectionName
+	}
+	return pstrings.JoinURL(append([]string{api.creds.URL, name}, parts...)...)
+}
+
+func (api *API) fetchTfvcItems(scopePath string) ([]tfvcItemResponse, error) {
+	var res []tfvcItemResponse
+	params := stringmap{"scopePath": scopePath, "recursionLevel": "OneLevel", "pagingoff": "true"}
+	if err := api.getRequest(`_apis/tfvc/items`, params, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (api *API) fetchTfvcChangesets(itemPath string, fromID int64) ([]tfvcChangesetResponse, error) {
+	params := stringmap{
+		"searchCriteria.itemPath": itemPath,
+		"searchCriteria.fromId":   strconv.FormatInt(fromID, 10),
+		"$orderby":                "id asc",
+		"maxCommentLength":        "2000",
+	}
+	var res []tfvcChangesetResponse
+	if err := api.getRequest(`_apis/tfvc/changesets`, params, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (api *API) fetchTfvcChanges(changesetID int64) ([]tfvcChangeResponse, error) {
+	u := fmt.Sprintf(`_apis/tfvc/changesets/%d/changes`, changesetID)
+	var res []tfvcChangeResponse
+	if err := api.getRequest(u, nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (api *API) fetchTfvcBranches(projid string) ([]tfvcBranchResponse, error) {
+	u := fmt.Sprintf(`%s/_apis/tfvc/branches`, url.PathEscape(projid))
+	params := stringmap{"includeChildren": "true", "pagingoff": "true"}
+	var res []tfvcBranchResponse
+	if err := api.getRequest(u, params, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (api *API) fetchShelvesets() ([]tfvcShelvesetResponse, error) {
+	var res []tfvcShelvesetResponse
+	if err := api.getRequest(`_apis/tfvc/shelvesets`, nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (api *API) fetchShelvesetChanges(shelvesetID string) ([]tfvcChangeResponse, error) {
+	// only the first change is needed to find the project
+	params := stringmap{"shelvesetId": shelvesetID, "$top": "1", "pagingoff": "true"}
+	var res []tfvcChangeResponse
+	if err := api.getRequest(`_apis/tfvc/shelvesets/changes`, params, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/integration-sdk/sourcecode"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTfvcRepo() *sourcecode.Repo {
+	return &sourcecode.Repo{
+		Name:  "$/Proj",
+		RefID: tfvcRefIDPrefix + "p1",
+		URL:   "https://dev.azure.com/org/Proj/_versionControl",
+	}
+}
+
+func TestTfvcChangeType(t *testing.T) {
+	cases := map[string]string{
+		"add, edit, encoding": extmodels.CommitFileAdded,
+		"edit":                extmodels.CommitFileModified,
+		"merge, edit":         extmodels.CommitFileModified,
+		"rename, edit":        extmodels.CommitFileRenamed,
+		"delete":              extmodels.CommitFileDeleted,
+		"branch":              extmodels.CommitFileAdded,
+		"undelete, edit":      extmodels.CommitFileAdded,
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, tfvcChangeType(in), in)
+	}
+}
+
+func TestConvertChangeset(t *testing.T) {
+	api := testAPI()
+	repo := testTfvcRepo()
+	c := tfvcChangesetResponse{
+		ChangesetID: 12,
+		Author:      usersResponse{ID: "u1", DisplayName: "User 1", UniqueName: "User1@Example.com"},
+		CheckedInBy: usersResponse{ID: "u1", DisplayName: "User 1", UniqueName: "User1@Example.com"},
+		CreatedDate: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Comment:     "fix",
+	}
+	got := api.convertChangeset(repo, c)
+	assert.Equal(t, int64(12), got.ID)
+	assert.Equal(t, "12", got.Commit.Sha)
+	assert.Equal(t, api.IDs.CodeRepo(repo.RefID), got.Commit.RepoID)
+	assert.Equal(t, "https://dev.azure.com/org/Proj/_versionControl/changeset/12", got.Commit.URL)
+	assert.Equal(t, "$/Proj#C12", got.Commit.Identifier)
+	assert.Equal(t, api.IDs.CodeCommitEmail("user1@example.com"), got.Commit.AuthorRefID)
+	assert.Equal(t, got.Commit.AuthorRefID, got.Commit.CommitterRefID)
+	assert.Len(t, got.Users, 1, "author and committer are the same user")
+	assert.Equal(t, "u1", *got.Users[0].AssociatedRefID)
+}
+
+func TestConvertTfvcBranches(t *testing.T) {
+	api := testAPI()
+	repo := testTfvcRepo()
+	child := tfvcBranchResponse{Path: "$/Proj/Dev"}
+	child.Parent = &struct {
+		Path string `json:"path"`
+	}{Path: "$/Proj/Main"}
+	deleted := child
+	deleted.Path = "$/Proj/Old"
+	deleted.IsDeleted = true
+	branches := []tfvcBranchResponse{
+		{Path: "$/Proj/Main", Children: []tfvcBranchResponse{child, deleted}},
+		{Path: "$/Other/Main"},
+	}
+	got := api.convertTfvcBranches(repo, branches)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "$/Proj/Dev", got[0].Name)
+	assert.False(t, got[0].Default)
+	assert.Equal(t, "$/Proj/Main", got[1].Name)
+	assert.True(t, got[1].Default)
+}
+
+func TestTfvcRepoForPath(t *testing.T) {
+	repo := testTfvcRepo()
+	repos := []*sourcecode.Repo{repo}
+	assert.Equal(t, repo, tfvcRepoForPath(repos, "$/proj/Main/a.cs"))
+	assert.Nil(t, tfvcRepoForPath(repos, "$/Project2/a.cs"))
+}
@@ -9,16 +9,17 @@ import (
 
 	"github.com/pinpt/go-common/datetime"
 
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
 	"github.com/pinpt/agent/pkg/date"
 	"github.com/pinpt/agent/pkg/structmarshal"
 	"github.com/pinpt/integration-sdk/work"
 )
 
-func (api *API) fetchChangeLog(itemtype, projid, issueid string) (changelogs []work.IssueChangeLog, latestChange time.Time, err error) {
+func (api *API) fetchChangeLog(itemtype, projid, issueid string) (changelogs []work.IssueChangeLog, extraChangelogs []extmodels.IssueChangeLog, latestChange time.Time, err error) {
 	var res []changelogResponse
 	url := fmt.Sprintf(`%s/_apis/wit/workItems/%s/updates`, projid, issueid)
 	if err := api.getRequest(url, stringmap{"$top": "200"}, &res); err != nil {
-		return nil, time.Time{}, err
+		return nil, nil, time.Time{}, err
 	}
 	if len(res) == 0 {
 		return
@@ -31,8 +32,30 @@ func (api *API) fetchChangeLog(itemtype, projid, issueid string) (changelogs []w
 		// check if there is a parent
 		changelogCreateParentField(&changelog)
 		// get the created date, if any. Some changelogs don't have this
-		createdDate := changeLogExtractCreatedDate(changelog)
+		created := changeLogExtractCreatedDate(changelog)
+		var createdDate work.IssueChangeLogCreatedDate
+		date.ConvertToModel(created, &createdDate)
 		for field, values := range changelog.Fields {
+			if field == "System.AreaPath" {
+				from := changelogToString(values.OldValue)
+				to := changelogToString(values.NewValue)
+				// area is set when the item is created, only record moves
+				if from == "" || to == from {
+					continue
+				}
+				extraChangelogs = append(extraChangelogs, extmodels.IssueChangeLog{
+					RefID:       fmt.Sprintf("%d", changelog.ID),
+					CreatedDate: extmodels.NewDate(created),
+					Field:       extmodels.ChangeLogFieldAreaPath,
+					From:        from,
+					FromString:  from,
+					Ordinal:     int64(i),
+					To:          to,
+					ToString:    to,
+					UserID:      changelog.RevisedBy.ID,
+				})
+				continue
+			}
 			if extractor, ok := changelogFields[field]; ok {
 
 				if i == 0 && changelogToString(values.OldValue) == "" {
@@ -82,29 +105,38 @@ func (api *API) fetchChangeLog(itemtype, projid, issueid string) (changelogs []w
 	sort.Slice(changelogs, func(i int, j int) bool {
 		return changelogs[i].CreatedDate.Epoch < changelogs[j].CreatedDate.Epoch
 	})
+	sort.Slice(extraChangelogs, func(i int, j int) bool {
+		return extraChangelogs[i].CreatedDate.Epoch < extraChangelogs[j].CreatedDate.Epoch
+	})
 	if len(changelogs) > 0 {
 		last := changelogs[len(changelogs)-1]
 		latestChange = datetime.DateFromEpoch(last.CreatedDate.Epoch)
 	}
+	if len(extraChangelogs) > 0 {
+		last := extraChangelogs[len(extraChangelogs)-1]
+		if t := datetime.DateFromEpoch(last.CreatedDate.Epoch); t.After(latestChange) {
+			latestChange = t
+		}
+	}
 	return
 }
 
-func changeLogExtractCreatedDate(changelog changelogResponse) work.IssueChangeLogCreatedDate {
-	var createdDate work.IssueChangeLogCreatedDate
+func changeLogExtractCreatedDate(changelog changelogResponse) time.Time {
+	var created time.Time
 	// This field is always there
 	// System.ChangedDate is the created date if there is only one changelog
 	if field, ok := changelog.Fields["System.ChangedDate"]; ok {
-		created, err := time.Parse(time.RFC3339, fmt.Sprint(field.NewValue))
+		t, err := time.Parse(time.RFC3339, fmt.Sprint(field.NewValue))
 		if err == nil {
-			date.ConvertToModel(created, &createdDate)
+			created = t
 		}
 	} else {
-		date.ConvertToModel(changelog.RevisedDate, &createdDate)
+		created = changelog.RevisedDate
 	}
-	if createdDate.Epoch < 0 {
-		return work.IssueChangeLogCreatedDate{}
+	if created.Unix() < 0 {
+		return time.Time{}
 	}
-	return createdDate
+	return created
 }
 
 func changelogCreateParentField(changelog *changelogResponse) {
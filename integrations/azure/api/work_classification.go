@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+)
+
+// FetchClassificationNodes returns the area and iteration trees of the project. Paths are built from node names, in the same format as System.AreaPath and System.IterationPath fields.
+func (api *API) FetchClassificationNodes(projid string) (res []extmodels.ClassificationNode, _ error) {
+	for _, group := range []string{"areas", "iterations"} {
+		roots, err := api.fetchClassificationNodes(projid, group)
+		if err != nil {
+			return nil, err
+		}
+		for _, root := range roots {
+			res = append(res, api.convertClassificationNodes(projid, root)...)
+		}
+	}
+	return
+}
+
+func (api *API) convertClassificationNodes(projid string, root classificationNodeResponse) (res []extmodels.ClassificationNode) {
+	kind := extmodels.ClassificationNodeKindArea
+	if root.StructureType == "iteration" {
+		kind = extmodels.ClassificationNodeKindIteration
+	}
+	var add func(node classificationNodeResponse, parent *extmodels.ClassificationNode)
+	add = func(node classificationNodeResponse, parent *extmodels.ClassificationNode) {
+		item := extmodels.ClassificationNode{}
+		item.CustomerID = api.customerid
+		item.RefType = api.reftype
+		item.RefID = strconv.FormatInt(node.ID, 10)
+		item.ProjectID = api.IDs.WorkProject(projid)
+		item.Kind = kind
+		item.Name = node.Name
+		item.Path = node.Name
+		if parent != nil {
+			item.ParentID = parent.ID()
+			item.Path = parent.Path + `\` + node.Name
+			// root iteration is the project, not a sprint
+			if kind == extmodels.ClassificationNodeKindIteration {
+				item.SprintID = api.IDs.WorkSprintID(item.Path)
+			}
+		}
+		item.StartDate = extmodels.NewDate(node.Attributes.StartDate)
+		item.FinishDate = extmodels.NewDate(node.Attributes.FinishDate)
+		res = append(res, item)
+		for _, child := range node.Children {
+			add(child, &item)
+		}
+	}
+	add(root, nil)
+	return
+}
+
+func (api *API) fetchClassificationNodes(projid string, group string) ([]classificationNodeResponse, error) {
+	u := fmt.Sprintf(`%s/_apis/wit/classificationnodes/%s`, url.PathEscape(projid), group)
+	var res []classificationNodeResponse
+	if err := api.getRequest(u, stringmap{"$depth": "100", "pagingoff": "true"}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
 	"github.com/pinpt/agent/pkg/date"
 	"github.com/pinpt/agent/pkg/ids2"
 	pnumbers "github.com/pinpt/go-common/number"
@@ -44,14 +45,14 @@ func (api *API) fetchItemIDs(projid string, fromdate time.Time) ([]string, error
 var pullRequestFromIssue = regexp.MustCompile(`PullRequestId\/(.*?)%2F(.*?)%2F(.*?)$`)
 
 // FetchWorkItemsByIDs used by onboard and export
-func (api *API) FetchWorkItemsByIDs(projid string, ids []string) ([]WorkItemResponse, []*work.Issue, error) {
+func (api *API) FetchWorkItemsByIDs(projid string, ids []string) ([]WorkItemResponse, []*extmodels.WorkIssue, error) {
 	url := fmt.Sprintf(`%s/_apis/wit/workitems?ids=%s`, projid, strings.Join(ids, ","))
 	var err error
 	var res []WorkItemResponse
 	if err = api.getRequest(url, stringmap{"pagingoff": "true", "$expand": "all"}, &res); err != nil {
 		return nil, nil, err
 	}
-	var res2 []*work.Issue
+	var res2 []*extmodels.WorkIssue
 	for _, each := range res {
 		fields := each.Fields
 
@@ -74,7 +75,12 @@ func (api *API) FetchWorkItemsByIDs(projid string, ids []string) ([]WorkItemResp
 
 		issue, err := azureIssueToPinpointIssue(each, projid, api.customerid, api.reftype, api.IDs)
 		var updatedDate time.Time
-		if issue.ChangeLog, updatedDate, err = api.fetchChangeLog(fields.WorkItemType, projid, issue.RefID); err != nil {
+		item := &extmodels.WorkIssue{Issue: &issue}
+		item.AreaPath = fields.AreaPath
+		if fields.AreaID != 0 {
+			item.AreaID = extmodels.ClassificationNodeID(api.customerid, api.reftype, fmt.Sprintf("%d", fields.AreaID))
+		}
+		if issue.ChangeLog, item.ExtraChangeLog, updatedDate, err = api.fetchChangeLog(fields.WorkItemType, projid, issue.RefID); err != nil {
 			return nil, nil, err
 		}
 		// this should only happen if the changelog is empty, which should never happen anyway,
@@ -84,7 +90,7 @@ func (api *API) FetchWorkItemsByIDs(projid string, ids []string) ([]WorkItemResp
 		}
 		date.ConvertToModel(updatedDate, &issue.UpdatedDate)
 
-		res2 = append(res2, &issue)
+		res2 = append(res2, item)
 	}
 	return res, res2, nil
 }
@@ -80,6 +80,8 @@ type WorkItemResponse struct {
 		// there are more here, fields, self, workItemComments, workItemRevisions, workItemType, and workItemUpdates
 	} `json:"_links"`
 	Fields struct {
+		AreaID         int64         `json:"System.AreaId"`
+		AreaPath       string        `json:"System.AreaPath"`
 		AssignedTo     usersResponse `json:"System.AssignedTo"`
 		ChangedDate    time.Time     `json:"System.ChangedDate"`
 		CreatedDate    time.Time     `json:"System.CreatedDate"`
@@ -118,3 +120,63 @@ type sprintsResponse struct {
 	Path string `json:"path"`
 	URL  string `json:"url"`
 }
+
+// used in work_classification.go - fetchClassificationNodes
+type classificationNodeResponse struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// StructureType is area or iteration
+	StructureType string `json:"structureType"`
+	Attributes    struct {
+		StartDate  time.Time `json:"startDate"`
+		FinishDate time.Time `json:"finishDate"`
+	} `json:"attributes"`
+	Children []classificationNodeResponse `json:"children"`
+}
+
+// used in work_teams.go - fetchTeamSettings
+type teamSettingsResponse struct {
+	BacklogIteration struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Path string `json:"path"`
+	} `json:"backlogIteration"`
+	DefaultIteration struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Path string `json:"path"`
+	} `json:"defaultIteration"`
+	WorkingDays []string `json:"workingDays"`
+}
+
+// used in work_teams.go - fetchTeamFieldValues
+type teamFieldValuesResponse struct {
+	Field struct {
+		ReferenceName string `json:"referenceName"`
+	} `json:"field"`
+	DefaultValue string `json:"defaultValue"`
+	Values       []struct {
+		Value           string `json:"value"`
+		IncludeChildren bool   `json:"includeChildren"`
+	} `json:"values"`
+}
+
+type dateRangeResponse struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// used in work_teams.go - fetchCapacities
+type capacityResponse struct {
+	TeamMember usersResponse `json:"teamMember"`
+	Activities []struct {
+		Name           string  `json:"name"`
+		CapacityPerDay float64 `json:"capacityPerDay"`
+	} `json:"activities"`
+	DaysOff []dateRangeResponse `json:"daysOff"`
+}
+
+// used in work_teams.go - fetchTeamDaysOff
+type teamDaysOffResponse struct {
+	DaysOff []dateRangeResponse `json:"daysOff"`
+}
@@ -8,9 +8,8 @@ import (
 )
 
 func (api *API) FetchSprint(projid string, teamid string) (sprints []*work.Sprint, err error) {
-	url := fmt.Sprintf(`%s/%s/_apis/work/teamsettings/iterations`, projid, teamid)
-	var res []sprintsResponse
-	if err = api.getRequest(url, nil, &res); err != nil {
+	res, err := api.fetchTeamIterations(projid, teamid)
+	if err != nil {
 		return nil, err
 	}
 	for _, r := range res {
@@ -38,3 +37,12 @@ func (api *API) FetchSprint(projid string, teamid string) (sprints []*work.Sprin
 	}
 	return sprints, err
 }
+
+func (api *API) fetchTeamIterations(projid string, teamid string) ([]sprintsResponse, error) {
+	url := fmt.Sprintf(`%s/%s/_apis/work/teamsettings/iterations`, projid, teamid)
+	var res []sprintsResponse
+	if err := api.getRequest(url, nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
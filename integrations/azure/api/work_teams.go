@@ -0,0 +1,155 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+)
+
+// FetchTeams returns the teams of the project with area and iteration settings
+func (api *API) FetchTeams(projid string) (res []extmodels.Team, _ error) {
+	teams, err := api.fetchTeams(projid)
+	if err != nil {
+		return nil, err
+	}
+	for _, team := range teams {
+		settings, err := api.fetchTeamSettings(projid, team.ID)
+		if err != nil {
+			return nil, err
+		}
+		fieldValues, err := api.fetchTeamFieldValues(projid, team.ID)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, api.convertTeam(projid, team, settings, fieldValues))
+	}
+	return
+}
+
+func (api *API) convertTeam(projid string, team teamsResponse, settings teamSettingsResponse, fieldValues teamFieldValuesResponse) extmodels.Team {
+	item := extmodels.Team{}
+	item.CustomerID = api.customerid
+	item.RefType = api.reftype
+	item.RefID = team.ID
+	item.ProjectID = api.IDs.WorkProject(projid)
+	item.Name = team.Name
+	// teams can be configured to use a custom field instead of area
+	if fieldValues.Field.ReferenceName == "" || fieldValues.Field.ReferenceName == "System.AreaPath" {
+		item.DefaultAreaPath = fieldValues.DefaultValue
+		for _, v := range fieldValues.Values {
+			item.AreaPaths = append(item.AreaPaths, extmodels.TeamAreaPath{
+				Path:            v.Value,
+				IncludeChildren: v.IncludeChildren,
+			})
+		}
+	}
+	item.BacklogIterationPath = settings.BacklogIteration.Path
+	item.DefaultIterationPath = settings.DefaultIteration.Path
+	item.WorkingDays = settings.WorkingDays
+	return item
+}
+
+// FetchTeamCapacities returns capacity and days off of the team for iterations that finished after fromdate or are not finished. Pass zero fromdate to fetch all iterations.
+func (api *API) FetchTeamCapacities(projid string, teamid string, fromdate time.Time) (res []extmodels.TeamCapacity, _ error) {
+	iterations, err := api.fetchTeamIterations(projid, teamid)
+	if err != nil {
+		return nil, err
+	}
+	for _, it := range iterations {
+		finish := it.Attributes.FinishDate
+		if !fromdate.IsZero() && !finish.IsZero() && finish.Before(fromdate) {
+			continue
+		}
+		capacities, err := api.fetchCapacities(projid, teamid, it.ID)
+		if err != nil {
+			return nil, err
+		}
+		daysOff, err := api.fetchTeamDaysOff(projid, teamid, it.ID)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, api.convertTeamCapacity(projid, teamid, it, capacities, daysOff))
+	}
+	return
+}
+
+func (api *API) convertTeamCapacity(projid string, teamid string, iteration sprintsResponse, capacities []capacityResponse, daysOff teamDaysOffResponse) extmodels.TeamCapacity {
+	item := extmodels.TeamCapacity{}
+	item.CustomerID = api.customerid
+	item.RefType = api.reftype
+	item.RefID = teamid + "/" + iteration.ID
+	item.ProjectID = api.IDs.WorkProject(projid)
+	item.TeamID = extmodels.TeamID(api.customerid, api.reftype, teamid)
+	item.SprintID = api.IDs.WorkSprintID(iteration.Path)
+	item.TeamDaysOff = convertDateRanges(daysOff.DaysOff)
+	for _, c := range capacities {
+		member := extmodels.MemberCapacity{}
+		member.UserRefID = c.TeamMember.ID
+		for _, a := range c.Activities {
+			member.Activities = append(member.Activities, extmodels.Activity{
+				Name:           a.Name,
+				CapacityPerDay: a.CapacityPerDay,
+			})
+		}
+		member.DaysOff = convertDateRanges(c.DaysOff)
+		item.Members = append(item.Members, member)
+	}
+	return item
+}
+
+func convertDateRanges(ranges []dateRangeResponse) (res []extmodels.DateRange) {
+	for _, r := range ranges {
+		res = append(res, extmodels.DateRange{
+			Start: extmodels.NewDate(r.Start),
+			End:   extmodels.NewDate(r.End),
+		})
+	}
+	return
+}
+
+func (api *API) fetchTeamSettings(projid string, teamid string) (res teamSettingsResponse, _ error) {
+	u := fmt.Sprintf(`%s/%s/_apis/work/teamsettings`, url.PathEscape(projid), url.PathEscape(teamid))
+	var r []teamSettingsResponse
+	if err := api.getRequest(u, stringmap{"pagingoff": "true"}, &r); err != nil {
+		return res, err
+	}
+	if len(r) != 0 {
+		res = r[0]
+	}
+	return res, nil
+}
+
+func (api *API) fetchTeamFieldValues(projid string, teamid string) (res teamFieldValuesResponse, _ error) {
+	u := fmt.Sprintf(`%s/%s/_apis/work/teamsettings/teamfieldvalues`, url.PathEscape(projid), url.PathEscape(teamid))
+	var r []teamFieldValuesResponse
+	if err := api.getRequest(u, stringmap{"pagingoff": "true"}, &r); err != nil {
+		return res, err
+	}
+	if len(r) != 0 {
+		res = r[0]
+	}
+	return res, nil
+}
+
+func (api *API) fetchCapacities(projid string, teamid string, iterationid string) ([]capacityResponse, error) {
+	u := fmt.Sprintf(`%s/%s/_apis/work/teamsettings/iterations/%s/capacities`, url.PathEscape(projid), url.PathEscape(teamid), url.PathEscape(iterationid))
+	var res []capacityResponse
+	if err := api.getRequest(u, stringmap{"pagingoff": "true"}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (api *API) fetchTeamDaysOff(projid string, teamid string, iterationid string) (res teamDaysOffResponse, _ error) {
+	u := fmt.Sprintf(`%s/%s/_apis/work/teamsettings/iterations/%s/teamdaysoff`, url.PathEscape(projid), url.PathEscape(teamid), url.PathEscape(iterationid))
+	var r []teamDaysOffResponse
+	if err := api.getRequest(u, stringmap{"pagingoff": "true"}, &r); err != nil {
+		return res, err
+	}
+	if len(r) != 0 {
+		res = r[0]
+	}
+	return res, nil
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertClassificationNodes(t *testing.T) {
+	api := testAPI()
+	root := classificationNodeResponse{ID: 1, Name: "Proj", StructureType: "iteration"}
+	sprint := classificationNodeResponse{ID: 3, Name: "Sprint 1"}
+	sprint.Attributes.StartDate = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	release := classificationNodeResponse{ID: 2, Name: "Release 1", Children: []classificationNodeResponse{sprint}}
+	root.Children = []classificationNodeResponse{release}
+
+	got := api.convertClassificationNodes("p1", root)
+	assert.Len(t, got, 3)
+	assert.Equal(t, "Proj", got[0].Path)
+	assert.Equal(t, "", got[0].ParentID)
+	assert.Equal(t, "", got[0].SprintID, "root iteration is not a sprint")
+	assert.Equal(t, `Proj\Release 1\Sprint 1`, got[2].Path)
+	assert.Equal(t, got[1].ID(), got[2].ParentID)
+	assert.Equal(t, extmodels.ClassificationNodeKindIteration, got[2].Kind)
+	assert.Equal(t, api.IDs.WorkSprintID(`Proj\Release 1\Sprint 1`), got[2].SprintID)
+	assert.False(t, got[2].StartDate.Time().IsZero())
+
+	root = classificationNodeResponse{ID: 10, Name: "Proj", StructureType: "area"}
+	got = api.convertClassificationNodes("p1", root)
+	assert.Equal(t, extmodels.ClassificationNodeKindArea, got[0].Kind)
+}
+
+func TestConvertTeam(t *testing.T) {
+	api := testAPI()
+	settings := teamSettingsResponse{WorkingDays: []string{"monday", "tuesday"}}
+	settings.BacklogIteration.Path = "Proj"
+	fieldValues := teamFieldValuesResponse{DefaultValue: `Proj\Web`}
+	fieldValues.Field.ReferenceName = "System.AreaPath"
+	fieldValues.Values = append(fieldValues.Values, struct {
+		Value           string `json:"value"`
+		IncludeChildren bool   `json:"includeChildren"`
+	}{Value: `Proj\Web`, IncludeChildren: true})
+
+	got := api.convertTeam("p1", teamsResponse{ID: "t1", Name: "Web"}, settings, fieldValues)
+	assert.Equal(t, `Proj\Web`, got.DefaultAreaPath)
+	assert.Equal(t, []extmodels.TeamAreaPath{{Path: `Proj\Web`, IncludeChildren: true}}, got.AreaPaths)
+	assert.Equal(t, "Proj", got.BacklogIterationPath)
+
+	fieldValues.Field.ReferenceName = "Custom.Team"
+	got = api.convertTeam("p1", teamsResponse{ID: "t1", Name: "Web"}, settings, fieldValues)
+	assert.Empty(t, got.AreaPaths, "teams using custom field have no areas")
+}
+
+func TestConvertTeamCapacity(t *testing.T) {
+	api := testAPI()
+	iteration := sprintsResponse{ID: "i1", Path: `Proj\Sprint 1`}
+	day := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	c := capacityResponse{TeamMember: usersResponse{ID: "u1"}}
+	c.Activities = append(c.Activities, struct {
+		Name           string  `json:"name"`
+		CapacityPerDay float64 `json:"capacityPerDay"`
+	}{Name: "Development", CapacityPerDay: 6})
+	c.DaysOff = []dateRangeResponse{{Start: day, End: day}}
+	daysOff := teamDaysOffResponse{DaysOff: []dateRangeResponse{{Start: day, End: day.AddDate(0, 0, 1)}}}
+
+	got := api.convertTeamCapacity("p1", "t1", iteration, []capacityResponse{c}, daysOff)
+	assert.Equal(t, "t1/i1", got.RefID)
+	assert.Equal(t, extmodels.TeamID("c1", "azure", "t1"), got.TeamID)
+	assert.Equal(t, api.IDs.WorkSprintID(`Proj\Sprint 1`), got.SprintID)
+	assert.Len(t, got.TeamDaysOff, 1)
+	assert.Len(t, got.Members, 1)
+	assert.Equal(t, "u1", got.Members[0].UserRefID)
+	assert.Equal(t, 6.0, got.Members[0].Activities[0].CapacityPerDay)
+	assert.Equal(t, day, got.Members[0].DaysOff[0].Start.Time())
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"github.com/pinpt/agent/integrations/azure/api"
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+)
+
+// processPipelines exports build definitions, builds with stages and jobs and classic release deployments for every project. Only pipelines of exported repos are included, pipelines of external repos are included in all projects.
+func (s *Integration) processPipelines(projectids []string, repoids map[string]bool) error {
+	for _, projid := range projectids {
+		if err := s.processBuildDefinitions(projid, repoids); err != nil {
+			return err
+		}
+		if err := s.processBuilds(projid, repoids); err != nil {
+			return err
+		}
+		if err := s.processReleaseDeployments(projid, repoids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Integration) processBuildDefinitions(projid string, repoids map[string]bool) error {
+	sender, err := s.orgSession.Session(extmodels.PipelineModelName, projid, projid)
+	if err != nil {
+		return err
+	}
+	defs, err := s.api.FetchBuildDefinitions(projid, repoids)
+	if err != nil {
+		if api.IsAccessDenied(err) {
+			// pipelines can be disabled in project or api key may not have build permissions
+			s.logger.Warn("no access to build definitions, skipping", "project", projid, "err", err)
+			return sender.Rollback()
+		}
+		return err
+	}
+	if err := sender.SetTotal(len(defs)); err != nil {
+		return err
+	}
+	for _, def := range defs {
+		if err := sender.Send(def); err != nil {
+			return err
+		}
+	}
+	return sender.Done()
+}
+
+func (s *Integration) processBuilds(projid string, repoids map[string]bool) error {
+	sender, err := s.orgSession.Session(extmodels.BuildModelName, projid, projid)
+	if err != nil {
+		return err
+	}
+	stepSender, err := s.orgSession.Session(extmodels.BuildStepModelName, projid, projid)
+	if err != nil {
+		return err
+	}
+	builds, err := s.api.FetchBuilds(projid, repoids, sender.LastProcessedTime())
+	if err != nil {
+		if api.IsAccessDenied(err) {
+			s.logger.Warn("no access to builds, skipping", "project", projid, "err", err)
+			if err := stepSender.Rollback(); err != nil {
+				return err
+			}
+			return sender.Rollback()
+		}
+		return err
+	}
+	s.logger.Info("exporting builds", "project", projid, "count", len(builds))
+	if err := sender.SetTotal(len(builds)); err != nil {
+		return err
+	}
+	for _, build := range builds {
+		if err := sender.Send(build); err != nil {
+			return err
+		}
+		steps, err := s.api.FetchBuildSteps(projid, build)
+		if err != nil {
+			// timeline is not available for builds that were deleted or never started
+			s.logger.Warn("could not fetch build timeline, skipping stages and jobs", "build", build.RefID, "err", err)
+			continue
+		}
+		for _, step := range steps {
+			if err := stepSender.Send(step); err != nil {
+				return err
+			}
+		}
+	}
+	if err := stepSender.Done(); err != nil {
+		return err
+	}
+	return sender.Done()
+}
+
+func (s *Integration) processReleaseDeployments(projid string, repoids map[string]bool) error {
+	sender, err := s.orgSession.Session(extmodels.DeploymentModelName, projid, projid)
+	if err != nil {
+		return err
+	}
+	deployments, err := s.api.FetchReleaseDeployments(projid, repoids, sender.LastProcessedTime())
+	if err != nil {
+		// release management is optional in tfs and requires separate permissions
+		s.logger.Warn("could not fetch release deployments, skipping", "project", projid, "err", err)
+		return sender.Rollback()
+	}
+	if err := sender.SetTotal(len(deployments)); err != nil {
+		return err
+	}
+	for _, d := range deployments {
+		if err := sender.Send(d); err != nil {
+			return err
+		}
+	}
+	return sender.Done()
+}
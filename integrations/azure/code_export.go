@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/pinpt/agent/cmd/cmdrunnorestarts/inconfig"
+	"github.com/pinpt/agent/integrations/azure/api"
 	"golang.org/x/exp/errors/fmt"
 
 	"github.com/pinpt/agent/integrations/pkg/repoprojects"
@@ -15,7 +16,7 @@ import (
 
 func (s *Integration) exportCode() (exportResults []rpcdef.ExportProject, rerr error) {
 	s.logger.Info("exporting code")
-	projectids, exportResults, err := s.processRepos()
+	projectids, repoids, exportResults, err := s.processRepos()
 	if err != nil {
 		rerr = err
 		return
@@ -24,6 +25,10 @@ func (s *Integration) exportCode() (exportResults []rpcdef.ExportProject, rerr e
 		rerr = err
 		return
 	}
+	if err = s.processPipelines(projectids, repoids); err != nil {
+		rerr = err
+		return
+	}
 
 	return exportResults, nil
 }
@@ -46,6 +51,7 @@ func stringify(i interface{}) string {
 
 func (s *Integration) processRepos() (
 	projectIDs []string,
+	repoIDs map[string]bool,
 	exportResults []rpcdef.ExportProject,
 	rerr error) {
 	s.logger.Info("processing repos, fetching all repos")
@@ -57,6 +63,24 @@ func (s *Integration) processRepos() (
 	s.logger.Info("done fetching all repos")
 	projectIDs = ids
 
+	tfvcRepos, err := s.api.FetchTfvcRepos(s.Repos, s.ExcludedRepoIDs, s.IncludedRepoIDs)
+	if err != nil {
+		// tfvc is optional, do not fail git export if it is not available
+		s.logger.Warn("could not fetch tfvc repos, skipping", "err", err)
+	}
+	// include projects that only use tfvc for users and pipelines
+	hasProject := map[string]bool{}
+	for _, id := range projectIDs {
+		hasProject[id] = true
+	}
+	for _, repo := range tfvcRepos {
+		projid := api.TfvcProjectID(repo.RefID)
+		if !hasProject[projid] {
+			hasProject[projid] = true
+			projectIDs = append(projectIDs, projid)
+		}
+	}
+
 	var orgname string
 	if s.Creds.Organization != "" {
 		orgname = s.Creds.Organization
@@ -70,7 +94,7 @@ func (s *Integration) processRepos() (
 		return
 	}
 
-	if err := sender.SetTotal(len(reposDetails)); err != nil {
+	if err := sender.SetTotal(len(reposDetails) + len(tfvcRepos)); err != nil {
 		rerr = err
 		return
 	}
@@ -83,10 +107,18 @@ func (s *Integration) processRepos() (
 			return
 		}
 	}
+	for _, repo := range tfvcRepos {
+		if err = sender.Send(repo); err != nil {
+			rerr = err
+			return
+		}
+	}
 
 	var repos []Repo
+	repoIDs = map[string]bool{}
 	for _, repo := range reposDetails {
 		repos = append(repos, Repo{repo})
+		repoIDs[repo.RefID] = true
 	}
 	var reposIface []repoprojects.RepoProject
 	for _, repo := range repos {
@@ -116,6 +148,14 @@ func (s *Integration) processRepos() (
 		rerr = err
 		return
 	}
+	if len(tfvcRepos) != 0 {
+		tfvcResults, err := s.processTfvcRepos(tfvcRepos, sender)
+		if err != nil {
+			rerr = err
+			return
+		}
+		exportResults = append(exportResults, tfvcResults...)
+	}
 	if err = sender.Done(); err != nil {
 		rerr = err
 		return
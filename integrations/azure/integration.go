@@ -11,6 +11,7 @@ import (
 	"github.com/pinpt/agent/integrations/azure/api"
 	"github.com/pinpt/agent/integrations/pkg/ibase"
 	"github.com/pinpt/agent/integrations/pkg/objsender"
+	"github.com/pinpt/agent/pkg/reqstats"
 	"github.com/pinpt/agent/pkg/structmarshal"
 	"github.com/pinpt/agent/rpcdef"
 )
@@ -65,9 +66,13 @@ type Integration struct {
 }
 
 // Init the init function
-func (s *Integration) Init(agent rpcdef.Agent) error {
+func (s *Integration) Init(agent rpcdef.Agent) (rpcdef.Capabilities, error) {
 	s.agent = agent
-	return nil
+	return rpcdef.Capabilities{
+		IntegrationTypes: []string{"SOURCECODE", "WORK"},
+		OnboardKinds:     []rpcdef.OnboardExportType{rpcdef.OnboardExportTypeRepos, rpcdef.OnboardExportTypeProjects, rpcdef.OnboardExportTypeWorkConfig},
+		Streaming:        true,
+	}, nil
 }
 
 func (s *Integration) Export(ctx context.Context, config rpcdef.ExportConfig) (res rpcdef.ExportResult, rerr error) {
@@ -203,7 +208,11 @@ func (s *Integration) initConfig(ctx context.Context, config rpcdef.ExportConfig
 	}
 	s.Concurrency = 10
 	s.customerid = config.Pinpoint.CustomerID
-	s.api = api.NewAPI(ctx, s.logger, s.Concurrency, s.customerid, s.RefType.String(), s.Creds, s.RefType == RefTypeTFS)
+	clients := reqstats.New(reqstats.Opts{
+		Logger: s.logger,
+		Agent:  s.agent,
+	}).Clients
+	s.api = api.NewAPI(ctx, s.logger, clients, s.Concurrency, s.customerid, s.RefType.String(), s.Creds, s.RefType == RefTypeTFS)
 	return nil
 }
 
@@ -14,6 +14,12 @@ func (s *Integration) onboardExportRepos() (res rpcdef.OnboardExportResult, err
 		s.logger.Error("error fetching repos for onboard export repos")
 		return
 	}
+	tfvcRepos, err := s.api.FetchTfvcRepos([]string{}, []string{}, []string{})
+	if err != nil {
+		s.logger.Warn("could not fetch tfvc repos for onboard export repos", "err", err)
+		err = nil
+	}
+	repos = append(repos, tfvcRepos...)
 	var records []map[string]interface{}
 	for _, repo := range repos {
 		r := &agent.RepoResponseRepos{
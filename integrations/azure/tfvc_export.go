@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/pinpt/agent/cmd/cmdrunnorestarts/inconfig"
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/agent/integrations/pkg/objsender"
+	"github.com/pinpt/agent/integrations/pkg/repoprojects"
+	"github.com/pinpt/agent/rpcdef"
+	"github.com/pinpt/go-common/datamodel"
+	"github.com/pinpt/integration-sdk/sourcecode"
+)
+
+// processTfvcRepos exports changesets, changed files, branches and shelvesets of tfvc repos. Tfvc repos are not cloned, all data is retrieved using the api.
+func (s *Integration) processTfvcRepos(repos []*sourcecode.Repo, sender *objsender.Session) (exportResults []rpcdef.ExportProject, rerr error) {
+	shelvesets, err := s.api.FetchTfvcShelvesets(repos)
+	if err != nil {
+		// shelvesets require read permission on all workspaces, export the rest of the data without them
+		s.logger.Warn("could not fetch tfvc shelvesets, skipping", "err", err)
+	}
+
+	var reposIface []repoprojects.RepoProject
+	for _, repo := range repos {
+		reposIface = append(reposIface, Repo{repo})
+	}
+	processOpts := repoprojects.ProcessOpts{}
+	processOpts.Logger = s.logger
+	processOpts.ProjectFn = func(ctx *repoprojects.ProjectCtx) error {
+		repo := ctx.Project.(Repo)
+		return s.exportTfvcRepo(ctx, repo.Repo, shelvesets[repo.RefID])
+	}
+	processOpts.Concurrency = s.Concurrency
+	processOpts.Projects = reposIface
+	processOpts.IntegrationType = inconfig.IntegrationTypeSourcecode
+	processOpts.CustomerID = s.customerid
+	processOpts.RefType = s.RefType.String()
+	processOpts.Sender = sender
+
+	processor := repoprojects.NewProcess(processOpts)
+	return processor.Run()
+}
+
+func (s *Integration) exportTfvcRepo(ctx *repoprojects.ProjectCtx, repo *sourcecode.Repo, shelvesets []*sourcecode.Branch) error {
+	logger := ctx.Logger.With("repo", repo.Name)
+
+	commitSender, err := ctx.Session(sourcecode.CommitModelName)
+	if err != nil {
+		return err
+	}
+	fileSender, err := ctx.Session(datamodel.ModelNameType(extmodels.CommitFileModelName))
+	if err != nil {
+		return err
+	}
+	userSender, err := ctx.Session(sourcecode.UserModelName)
+	if err != nil {
+		return err
+	}
+	branchSender, err := ctx.Session(sourcecode.BranchModelName)
+	if err != nil {
+		return err
+	}
+
+	// changesets are incremental by id, last processed is the last exported changeset id
+	var fromID int64
+	if v := commitSender.LastProcessedString(); v != "" {
+		fromID, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+	}
+	changesets, err := s.api.FetchTfvcChangesets(repo, fromID)
+	if err != nil {
+		return err
+	}
+	logger.Info("exporting tfvc changesets", "from_id", fromID, "count", len(changesets))
+	if err := commitSender.SetTotal(len(changesets)); err != nil {
+		return err
+	}
+	users := map[string]bool{}
+	for _, c := range changesets {
+		if err := commitSender.Send(c.Commit); err != nil {
+			return err
+		}
+		for _, u := range c.Users {
+			if users[u.ID] {
+				continue
+			}
+			users[u.ID] = true
+			if err := userSender.Send(u); err != nil {
+				return err
+			}
+		}
+		files, err := s.api.FetchTfvcChangesetFiles(repo, c.ID)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if err := fileSender.Send(f); err != nil {
+				return err
+			}
+		}
+		fromID = c.ID
+	}
+	commitSender.SetLastProcessed(strconv.FormatInt(fromID, 10))
+
+	branches, err := s.api.FetchTfvcBranches(repo)
+	if err != nil {
+		return err
+	}
+	branches = append(branches, shelvesets...)
+	if err := branchSender.SetTotal(len(branches)); err != nil {
+		return err
+	}
+	for _, b := range branches {
+		if err := branchSender.Send(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
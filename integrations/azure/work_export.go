@@ -5,8 +5,10 @@ import (
 
 	"github.com/pinpt/agent/cmd/cmdrunnorestarts/inconfig"
 
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
 	"github.com/pinpt/agent/integrations/pkg/repoprojects"
 	"github.com/pinpt/agent/rpcdef"
+	"github.com/pinpt/go-common/datamodel"
 
 	"github.com/pinpt/integration-sdk/work"
 )
@@ -70,6 +72,12 @@ func (s *Integration) exportWork() (exportResults []rpcdef.ExportProject, rerr e
 		if err = s.processSprints(ctx, proj, teamids); err != nil {
 			return err
 		}
+		if err = s.processClassificationNodes(ctx, proj); err != nil {
+			return err
+		}
+		if err = s.processTeams(ctx, proj); err != nil {
+			return err
+		}
 		return nil
 	}
 
@@ -189,3 +197,63 @@ func (s *Integration) processSprints(ctx *repoprojects.ProjectCtx, proj Project,
 	}
 	return nil
 }
+
+func (s *Integration) processClassificationNodes(ctx *repoprojects.ProjectCtx, proj Project) error {
+	sender, err := ctx.Session(datamodel.ModelNameType(extmodels.ClassificationNodeModelName))
+	if err != nil {
+		return err
+	}
+	nodes, err := s.api.FetchClassificationNodes(proj.RefID)
+	if err != nil {
+		return err
+	}
+	if err := sender.SetTotal(len(nodes)); err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if err := sender.Send(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Integration) processTeams(ctx *repoprojects.ProjectCtx, proj Project) error {
+	sender, err := ctx.Session(datamodel.ModelNameType(extmodels.TeamModelName))
+	if err != nil {
+		return err
+	}
+	capacitySender, err := ctx.Session(datamodel.ModelNameType(extmodels.TeamCapacityModelName))
+	if err != nil {
+		return err
+	}
+	teams, err := s.api.FetchTeams(proj.RefID)
+	if err != nil {
+		return err
+	}
+	if err := sender.SetTotal(len(teams)); err != nil {
+		return err
+	}
+	capacityTotal := 0
+	for _, team := range teams {
+		if err := sender.Send(team); err != nil {
+			return err
+		}
+		// capacity of iterations that finished before the last export is not fetched again
+		capacities, err := s.api.FetchTeamCapacities(proj.RefID, team.RefID, capacitySender.LastProcessedTime())
+		if err != nil {
+			return err
+		}
+		// the number of capacities is only known per team, grow the total as teams are processed
+		capacityTotal += len(capacities)
+		if err := capacitySender.SetTotal(capacityTotal); err != nil {
+			return err
+		}
+		for _, c := range capacities {
+			if err := capacitySender.Send(c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -2,12 +2,12 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"reflect"
 	"strings"
-	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/pinpt/agent/pkg/oauthtoken"
@@ -32,6 +32,8 @@ type internalRequest struct {
 	Pageable bool
 	Response interface{}
 	PageInfo PageInfo
+	// throttledRetries is the number of retries after rate limited responses, these do not count towards general retries
+	throttledRetries int
 }
 
 func NewRequester(opts RequesterOpts) *Requester {
@@ -72,6 +74,11 @@ func (e *Requester) Request(url string, params url.Values, pageable bool, respon
 
 const maxGeneralRetries = 2
 
+// maxThrottledRetries is the number of retries after rate limited response. Wait time is handled by rate limiter in http client.
+const maxThrottledRetries = 10
+
+var errRateLimited = errors.New("rate limit hit")
+
 func (e *Requester) makeRequestRetry(req *internalRequest, generalRetry int) (pageInfo PageInfo, err error) {
 	var isRetryable bool
 	isRetryable, pageInfo, err = e.request(req, generalRetry+1)
@@ -79,6 +86,10 @@ func (e *Requester) makeRequestRetry(req *internalRequest, generalRetry int) (pa
 		if !isRetryable {
 			return pageInfo, err
 		}
+		if err == errRateLimited && req.throttledRetries < maxThrottledRetries {
+			req.throttledRetries++
+			return e.makeRequestRetry(req, generalRetry)
+		}
 		if generalRetry >= maxGeneralRetries {
 			return pageInfo, fmt.Errorf(`can't retry request, too many retries, err: %v`, err)
 		}
@@ -131,13 +142,8 @@ func (e *Requester) request(r *internalRequest, retryThrottled int) (isErrorRetr
 		}
 
 		if resp.StatusCode == http.StatusTooManyRequests {
-			// 1 minute wait time was causing constant throttles, try sleeping for longer and see if that helps
-			waitTime := 15 * time.Minute
-			paused := time.Now()
-			e.opts.Agent.SendPauseEvent("", paused.Add(waitTime))
-			time.Sleep(waitTime)
-			e.opts.Agent.SendResumeEvent("")
-			return true, pi, fmt.Errorf("rate limit hit")
+			e.logger.Warn("api request failed due to throttling, will retry", "retryThrottled", r.throttledRetries)
+			return true, pi, errRateLimited
 		}
 
 		if resp.StatusCode == http.StatusNotFound {
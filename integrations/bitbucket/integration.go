@@ -67,7 +67,7 @@ type Integration struct {
 	clientManager *reqstats.ClientManager
 }
 
-func (s *Integration) Init(agent rpcdef.Agent) error {
+func (s *Integration) Init(agent rpcdef.Agent) (rpcdef.Capabilities, error) {
 	s.agent = agent
 	s.refType = "bitbucket"
 
@@ -75,7 +75,11 @@ func (s *Integration) Init(agent rpcdef.Agent) error {
 		Logger: s.logger,
 	}
 
-	return nil
+	return rpcdef.Capabilities{
+		IntegrationTypes: []string{"SOURCECODE"},
+		OnboardKinds:     []rpcdef.OnboardExportType{rpcdef.OnboardExportTypeRepos},
+		Streaming:        true,
+	}, nil
 }
 
 func (s *Integration) ValidateConfig(ctx context.Context, exportConfig rpcdef.ExportConfig) (res rpcdef.ValidationResult, _ error) {
@@ -169,6 +173,7 @@ func (s *Integration) initWithConfig(config rpcdef.ExportConfig) error {
 	s.clientManager = reqstats.New(reqstats.Opts{
 		Logger:                s.logger,
 		TLSInsecureSkipVerify: s.config.InsecureSkipVerify,
+		Agent:                 s.agent,
 	})
 
 	{
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pinpt/agent/integrations/coverage/reports"
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/agent/integrations/pkg/objsender"
+	"github.com/pinpt/agent/integrations/pkg/reportfiles"
+	"github.com/pinpt/agent/pkg/date"
+	"github.com/pinpt/agent/pkg/gitclone"
+	"github.com/pinpt/agent/pkg/ids"
+	"github.com/pinpt/agent/rpcdef"
+	"github.com/pinpt/go-common/hash"
+	"github.com/pinpt/integration-sdk/codequality"
+)
+
+// commitReport is coverage of repo at one commit, merged from all reports uploaded for the commit
+type commitReport struct {
+	// Key is the commit sha, or report date if commit is not known
+	Key       string
+	CommitSHA string
+	Date      time.Time
+	Formats   []string
+	Files     [][]*reports.File
+}
+
+type project struct {
+	*codequality.Project
+	// Repo is the cloned repo, nil if repo is not known
+	Repo    *gitclone.CachedRepo
+	Reports map[string]*commitReport
+}
+
+// target is the repo and commit report belongs to
+type target struct {
+	Name   string
+	Repo   *gitclone.CachedRepo
+	Commit string
+}
+
+var shaRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// parseDropPath returns repo name and commit from path of report in drop dir, in repo_name/commit_sha/ format. Repo name can contain slashes, for example org/repo. Commit is empty for reports in repo_name/ folder.
+func parseDropPath(p string) (repoName string, commit string) {
+	dir := path.Dir(p)
+	if dir == "." {
+		return "", ""
+	}
+	parts := strings.Split(dir, "/")
+	for i, part := range parts {
+		if i > 0 && shaRe.MatchString(part) {
+			return strings.Join(parts[:i], "/"), part
+		}
+	}
+	return dir, ""
+}
+
+func (s *Integration) target(doc reportfiles.Document, cached []gitclone.CachedRepo) (res target, ok bool) {
+	if doc.Repo != nil {
+		_, res.Name = gitclone.RepoNameFromURL(doc.RepoURL)
+		if res.Name == "" {
+			res.Name = filepath.Base(doc.Repo.Dir)
+		}
+		res.Repo = doc.Repo
+		res.Commit = doc.Commit
+		return res, true
+	}
+	res.Name, res.Commit = parseDropPath(doc.RelPath)
+	if res.Name == "" {
+		s.logger.Warn("skipping coverage report not in repo_name/commit_sha/ subfolder of drop dir", "path", doc.Path)
+		return res, false
+	}
+	for i, repo := range cached {
+		if !repo.MatchesName(res.Name) {
+			continue
+		}
+		if res.Repo != nil {
+			s.logger.Warn("multiple cloned repos match the name of repo in drop dir, using the first one", "name", res.Name)
+			break
+		}
+		res.Repo = &cached[i]
+	}
+	if res.Repo == nil {
+		s.logger.Warn("repo of coverage report was not cloned by sourcecode integration, coverage will not be linked to commit", "name", res.Name)
+	}
+	return res, true
+}
+
+func (s *Integration) documents(ctx context.Context, since time.Time) (res []reportfiles.Document, _ error) {
+	if len(s.config.RepoPaths) != 0 {
+		docs, err := reportfiles.FromRepos(ctx, s.logger, s.repoCache, reportfiles.MatchPatterns(s.config.RepoPaths), since)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, docs...)
+	}
+	if s.config.DropDir != "" {
+		// drop dir is only used for coverage reports, format is detected from content
+		docs, err := reportfiles.FromDropDir(s.config.DropDir, func(p string) bool {
+			return !strings.HasPrefix(path.Base(p), ".")
+		})
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, docs...)
+	}
+	return
+}
+
+func (s *Integration) exportAll(ctx context.Context) (exported []rpcdef.ExportProject, _ error) {
+	session, err := objsender.Root(s.agent, codequality.ProjectModelName.String())
+	if err != nil {
+		s.logger.Error("error creating project session", "err", err)
+		return nil, err
+	}
+	docs, err := s.documents(ctx, session.LastProcessedTime())
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("found coverage reports", "count", len(docs))
+
+	var cached []gitclone.CachedRepo
+	if s.repoCache != "" {
+		cached, err = gitclone.CachedRepos(s.repoCache)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// go module paths by repo dir
+	modules := map[string]string{}
+
+	projects := map[string]*project{}
+	var processed []reportfiles.Document
+	for _, doc := range docs {
+		format, files, err := reports.Parse(doc.Data)
+		if err != nil {
+			s.logger.Warn("skipping invalid coverage report", "path", doc.Path, "err", err)
+			continue
+		}
+		t, ok := s.target(doc, cached)
+		if !ok {
+			continue
+		}
+		processed = append(processed, doc)
+
+		if format == reports.FormatGo && t.Repo != nil {
+			module, ok := modules[t.Repo.Dir]
+			if !ok {
+				if b, err := reportfiles.ReadFile(ctx, t.Repo.Dir, "go.mod"); err == nil {
+					module = reports.GoModulePath(b)
+				}
+				modules[t.Repo.Dir] = module
+			}
+			reports.TrimPrefix(files, module)
+		}
+		for _, prefix := range s.config.PathPrefixes {
+			reports.TrimPrefix(files, prefix)
+		}
+
+		refID := t.Name
+		if t.Repo != nil {
+			refID = t.Repo.RepoID
+		}
+		p := projects[refID]
+		if p == nil {
+			p = &project{
+				Project: &codequality.Project{
+					CustomerID: s.customerID,
+					Identifier: t.Name,
+					Name:       t.Name,
+					RefID:      refID,
+					RefType:    refType,
+				},
+				Repo:    t.Repo,
+				Reports: map[string]*commitReport{},
+			}
+			projects[refID] = p
+		}
+		modified := doc.Modified.UTC()
+		key := t.Commit
+		if key == "" {
+			key = modified.Format(time.RFC3339)
+		}
+		r := p.Reports[key]
+		if r == nil {
+			r = &commitReport{Key: key, CommitSHA: t.Commit}
+			p.Reports[key] = r
+		}
+		if modified.After(r.Date) {
+			r.Date = modified
+		}
+		r.Formats = appendUnique(r.Formats, string(format))
+		r.Files = append(r.Files, files)
+	}
+
+	var refIDs []string
+	for k := range projects {
+		refIDs = append(refIDs, k)
+	}
+	sort.Strings(refIDs)
+	if err := session.SetTotal(len(refIDs)); err != nil {
+		return nil, err
+	}
+	for _, refID := range refIDs {
+		p := projects[refID]
+		if err := session.Send(p.Project); err != nil {
+			s.logger.Error("error sending project to agent", "err", err, "id", p.RefID)
+			return nil, err
+		}
+		if err := s.exportProject(session, p); err != nil {
+			return nil, err
+		}
+		exported = append(exported, rpcdef.ExportProject{
+			ID:         p.ID,
+			RefID:      p.RefID,
+			ReadableID: p.Identifier,
+		})
+	}
+	if err := session.Done(); err != nil {
+		return nil, err
+	}
+	reportfiles.MarkProcessed(s.logger, processed)
+	return exported, nil
+}
+
+func appendUnique(list []string, v string) []string {
+	for _, item := range list {
+		if item == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
+
+func (s *Integration) exportProject(projectSession *objsender.Session, p *project) error {
+	var commitReports []*commitReport
+	for _, r := range p.Reports {
+		commitReports = append(commitReports, r)
+	}
+	sort.Slice(commitReports, func(i, j int) bool {
+		return commitReports[i].Date.Before(commitReports[j].Date)
+	})
+
+	metricSession, err := projectSession.Session(codequality.MetricModelName.String(), p.RefID, p.Name)
+	if err != nil {
+		s.logger.Error("error creating metric session", "err", err)
+		return err
+	}
+	coverageSession, err := projectSession.Session(extmodels.CoverageModelName, p.RefID, p.Name)
+	if err != nil {
+		s.logger.Error("error creating coverage session", "err", err)
+		return err
+	}
+	for _, r := range commitReports {
+		files := reports.Merge(r.Files...)
+		total := reports.Total(files)
+		for _, metric := range s.metrics(p, r, total) {
+			if err := metricSession.Send(metric); err != nil {
+				s.logger.Error("error sending metric to agent", "err", err, "id", metric.RefID)
+				return err
+			}
+		}
+		if err := coverageSession.Send(s.coverage(p, r, "", total)); err != nil {
+			return err
+		}
+		dirs := reports.ByDirectory(files)
+		var names []string
+		for dir := range dirs {
+			names = append(names, dir)
+		}
+		sort.Strings(names)
+		for _, dir := range names {
+			if err := coverageSession.Send(s.coverage(p, r, dir, dirs[dir])); err != nil {
+				return err
+			}
+		}
+	}
+	if err := metricSession.Done(); err != nil {
+		return err
+	}
+	return coverageSession.Done()
+}
+
+func formatPercent(v float64) string {
+	return fmt.Sprintf("%.1f", v)
+}
+
+// metrics returns repo coverage metrics, using the same names as sonarqube
+func (s *Integration) metrics(p *project, r *commitReport, total reports.Summary) (res []*codequality.Metric) {
+	values := []struct {
+		Name  string
+		Value string
+	}{
+		{"coverage", formatPercent(total.Coverage())},
+		{"line_coverage", formatPercent(total.LineCoverage())},
+		{"branch_coverage", formatPercent(total.BranchCoverage())},
+		{"lines_to_cover", strconv.FormatInt(total.LinesToCover, 10)},
+		{"uncovered_lines", strconv.FormatInt(total.LinesToCover-total.CoveredLines, 10)},
+		{"conditions_to_cover", strconv.FormatInt(total.BranchesToCover, 10)},
+		{"uncovered_conditions", strconv.FormatInt(total.BranchesToCover-total.CoveredBranches, 10)},
+	}
+	for _, v := range values {
+		metric := &codequality.Metric{
+			CustomerID: s.customerID,
+			Name:       v.Name,
+			Value:      v.Value,
+			RefID:      hash.Values(p.ID, r.Key, v.Name),
+			RefType:    refType,
+			ProjectID:  p.ID,
+		}
+		date.ConvertToModel(r.Date, &metric.CreatedDate)
+		res = append(res, metric)
+	}
+	return
+}
+
+// coverage returns coverage of directory, or the whole repo if dir is empty
+func (s *Integration) coverage(p *project, r *commitReport, dir string, sum reports.Summary) *extmodels.Coverage {
+	res := &extmodels.Coverage{
+		CustomerID:      s.customerID,
+		RefType:         refType,
+		RefID:           hash.Values(p.RefID, r.Key, dir),
+		ProjectID:       p.ID,
+		CommitSHA:       r.CommitSHA,
+		Directory:       dir,
+		Format:          strings.Join(r.Formats, ","),
+		LinesToCover:    sum.LinesToCover,
+		CoveredLines:    sum.CoveredLines,
+		BranchesToCover: sum.BranchesToCover,
+		CoveredBranches: sum.CoveredBranches,
+		Coverage:        sum.Coverage(),
+		LineCoverage:    sum.LineCoverage(),
+		BranchCoverage:  sum.BranchCoverage(),
+		CreatedDate:     extmodels.NewDate(r.Date),
+	}
+	if p.Repo != nil {
+		res.RepoID = p.Repo.RepoID
+		if r.CommitSHA != "" {
+			res.CommitID = ids.CodeCommit(s.customerID, p.Repo.RefType, p.Repo.RepoID, r.CommitSHA)
+		}
+	}
+	return res
+}
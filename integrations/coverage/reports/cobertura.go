@@ -0,0 +1,60 @@
+package reports
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+type coberturaReport struct {
+	Packages []struct {
+		Classes []struct {
+			Filename string `xml:"filename,attr"`
+			Lines    []struct {
+				Number            int64  `xml:"number,attr"`
+				Hits              string `xml:"hits,attr"`
+				Branch            bool   `xml:"branch,attr"`
+				ConditionCoverage string `xml:"condition-coverage,attr"`
+			} `xml:"lines>line"`
+		} `xml:"classes>class"`
+	} `xml:"packages>package"`
+}
+
+// conditionCoverageRe matches condition-coverage attribute, for example 50% (1/2)
+var conditionCoverageRe = regexp.MustCompile(`\((\d+)/(\d+)\)`)
+
+// ParseCobertura parses cobertura xml report. Uses class lines, method lines are the same lines listed again. Paths are relative to one of the sources in report.
+func ParseCobertura(data []byte) (res []*File, _ error) {
+	var report coberturaReport
+	err := xml.Unmarshal(data, &report)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cobertura xml: %v", err)
+	}
+	for _, pkg := range report.Packages {
+		for _, class := range pkg.Classes {
+			file := newFile(class.Filename)
+			for _, line := range class.Lines {
+				// hits can exceed int range in long running tests
+				hits, err := strconv.ParseFloat(line.Hits, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid hits on line %v of %v: %v", line.Number, class.Filename, err)
+				}
+				file.addLine(line.Number, hits > 0)
+				if !line.Branch {
+					continue
+				}
+				m := conditionCoverageRe.FindStringSubmatch(line.ConditionCoverage)
+				if m == nil {
+					continue
+				}
+				covered, _ := strconv.ParseInt(m[1], 10, 64)
+				total, _ := strconv.ParseInt(m[2], 10, 64)
+				file.addBranches(line.Number, Branches{Total: total, Covered: covered})
+			}
+			res = append(res, file)
+		}
+	}
+	// inner classes of the same file are listed separately
+	return Merge(res), nil
+}
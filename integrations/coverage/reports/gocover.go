@@ -0,0 +1,63 @@
+package reports
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// goBlockRe matches coverprofile block, file:startLine.startCol,endLine.endCol numStmts count
+var goBlockRe = regexp.MustCompile(`^(.+):(\d+)\.\d+,(\d+)\.\d+ (\d+) (\d+)$`)
+
+// ParseGoCoverProfile parses go test -coverprofile output. Go coverage is reported for statement blocks, every line of the block is counted as a line to cover. Line is covered if any block containing it was executed. Paths are import paths, use TrimPrefix with module path to get paths relative to repo.
+func ParseGoCoverProfile(r io.Reader) (res []*File, _ error) {
+	byPath := map[string]*File{}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		// profiles merged from multiple packages contain multiple mode lines
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		m := goBlockRe.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("invalid coverprofile block on line %v: %q", lineNum, line)
+		}
+		start, _ := strconv.ParseInt(m[2], 10, 64)
+		end, _ := strconv.ParseInt(m[3], 10, 64)
+		stmts, _ := strconv.ParseInt(m[4], 10, 64)
+		count, _ := strconv.ParseInt(m[5], 10, 64)
+		if stmts == 0 {
+			continue
+		}
+		file := byPath[m[1]]
+		if file == nil {
+			file = newFile(m[1])
+			byPath[m[1]] = file
+			res = append(res, file)
+		}
+		for n := start; n <= end; n++ {
+			file.addLine(n, count > 0)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return Merge(res), nil
+}
+
+// GoModulePath returns module path from go.mod file. Returns empty string if go.mod does not contain module directive.
+func GoModulePath(gomod []byte) string {
+	for _, line := range strings.Split(string(gomod), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
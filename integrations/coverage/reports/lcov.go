@@ -0,0 +1,102 @@
+package reports
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseLCOV parses lcov tracefile. Uses DA records for lines and BRDA records for branches.
+// http://ltp.sourceforge.net/coverage/lcov/geninfo.1.php
+func ParseLCOV(r io.Reader) (res []*File, _ error) {
+	var file *File
+	// taken branches by line, to count branches of the same line reported in multiple blocks
+	var branches map[int64]Branches
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		i := strings.Index(line, ":")
+		var key, value string
+		if i < 0 {
+			key = line
+		} else {
+			key, value = line[:i], line[i+1:]
+		}
+		invalid := func(err error) error {
+			return fmt.Errorf("invalid lcov record on line %v: %q %v", lineNum, line, err)
+		}
+		switch key {
+		case "SF":
+			file = newFile(value)
+			branches = map[int64]Branches{}
+			res = append(res, file)
+		case "DA":
+			if file == nil {
+				return nil, invalid(nil)
+			}
+			// DA:<line number>,<execution count>[,<checksum>]
+			parts := strings.Split(value, ",")
+			if len(parts) < 2 {
+				return nil, invalid(nil)
+			}
+			n, err := strconv.ParseInt(parts[0], 10, 64)
+			if err != nil {
+				return nil, invalid(err)
+			}
+			// some tools write counts as floats
+			hits, err := strconv.ParseFloat(parts[1], 64)
+			if err != nil {
+				return nil, invalid(err)
+			}
+			file.addLine(n, hits > 0)
+		case "BRDA":
+			if file == nil {
+				return nil, invalid(nil)
+			}
+			// BRDA:<line number>,<block number>,<branch number>,<taken>, taken is - if the block was not executed
+			parts := strings.Split(value, ",")
+			if len(parts) != 4 {
+				return nil, invalid(nil)
+			}
+			n, err := strconv.ParseInt(parts[0], 10, 64)
+			if err != nil {
+				return nil, invalid(err)
+			}
+			b := branches[n]
+			b.Total++
+			if parts[3] != "-" {
+				taken, err := strconv.ParseFloat(parts[3], 64)
+				if err != nil {
+					return nil, invalid(err)
+				}
+				if taken > 0 {
+					b.Covered++
+				}
+			}
+			branches[n] = b
+		case "end_of_record":
+			if file != nil {
+				for n, b := range branches {
+					file.addBranches(n, b)
+				}
+			}
+			file = nil
+			branches = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if file != nil {
+		for n, b := range branches {
+			file.addBranches(n, b)
+		}
+	}
+	// the same file can be listed in multiple records, for example for different test names
+	return Merge(res), nil
+}
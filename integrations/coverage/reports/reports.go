@@ -0,0 +1,192 @@
+// Package reports parses test coverage reports in lcov, cobertura and go coverprofile formats.
+package reports
+
+import (
+	"bytes"
+	"errors"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Format is the format of coverage report.
+type Format string
+
+const (
+	// FormatLCOV is lcov tracefile, produced by lcov, istanbul/nyc, jest and many other tools
+	FormatLCOV Format = "lcov"
+	// FormatCobertura is cobertura xml, produced by coverage.py, jacoco converters, cobertura and others
+	FormatCobertura Format = "cobertura"
+	// FormatGo is go test -coverprofile output
+	FormatGo Format = "go"
+)
+
+// File is coverage of one source file.
+type File struct {
+	// Path is the file path as written in report
+	Path string
+	// Lines maps line number to true if the line was executed. Contains only lines with code.
+	Lines map[int64]bool
+	// Branches maps line number to branches on the line. Contains only lines with branches.
+	Branches map[int64]Branches
+}
+
+// Branches are conditions on one line.
+type Branches struct {
+	Total   int64
+	Covered int64
+}
+
+func newFile(p string) *File {
+	return &File{Path: p, Lines: map[int64]bool{}, Branches: map[int64]Branches{}}
+}
+
+func (s *File) addLine(line int64, covered bool) {
+	s.Lines[line] = s.Lines[line] || covered
+}
+
+func (s *File) addBranches(line int64, b Branches) {
+	v := s.Branches[line]
+	if b.Total > v.Total {
+		v.Total = b.Total
+	}
+	if b.Covered > v.Covered {
+		v.Covered = b.Covered
+	}
+	s.Branches[line] = v
+}
+
+// Detect returns the format of report based on content.
+func Detect(data []byte) (Format, bool) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte("mode:")):
+		return FormatGo, true
+	case bytes.HasPrefix(data, []byte("<")) && bytes.Contains(data, []byte("<coverage")):
+		return FormatCobertura, true
+	case bytes.HasPrefix(data, []byte("TN:")) || bytes.HasPrefix(data, []byte("SF:")):
+		return FormatLCOV, true
+	}
+	return "", false
+}
+
+// Parse detects the format of report and parses it.
+func Parse(data []byte) (Format, []*File, error) {
+	format, ok := Detect(data)
+	if !ok {
+		return "", nil, errors.New("unknown coverage report format, supported formats are lcov, cobertura xml and go coverprofile")
+	}
+	var files []*File
+	var err error
+	switch format {
+	case FormatLCOV:
+		files, err = ParseLCOV(bytes.NewReader(data))
+	case FormatCobertura:
+		files, err = ParseCobertura(data)
+	case FormatGo:
+		files, err = ParseGoCoverProfile(bytes.NewReader(data))
+	}
+	return format, files, err
+}
+
+// Merge combines coverage of the same files from multiple reports. Line is covered if it's covered in any report.
+func Merge(lists ...[]*File) (res []*File) {
+	byPath := map[string]*File{}
+	for _, files := range lists {
+		for _, f := range files {
+			m := byPath[f.Path]
+			if m == nil {
+				m = newFile(f.Path)
+				byPath[f.Path] = m
+				res = append(res, m)
+			}
+			for line, covered := range f.Lines {
+				m.addLine(line, covered)
+			}
+			for line, b := range f.Branches {
+				m.addBranches(line, b)
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Path < res[j].Path
+	})
+	return
+}
+
+// TrimPrefix removes prefix from file paths, used to convert go import paths or absolute paths to paths relative to repo root.
+func TrimPrefix(files []*File, prefix string) {
+	if prefix == "" {
+		return
+	}
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	for _, f := range files {
+		f.Path = strings.TrimPrefix(f.Path, prefix)
+	}
+}
+
+// Summary is coverage of a set of files.
+type Summary struct {
+	LinesToCover    int64
+	CoveredLines    int64
+	BranchesToCover int64
+	CoveredBranches int64
+}
+
+func (s *Summary) add(f *File) {
+	for _, covered := range f.Lines {
+		s.LinesToCover++
+		if covered {
+			s.CoveredLines++
+		}
+	}
+	for _, b := range f.Branches {
+		s.BranchesToCover += b.Total
+		s.CoveredBranches += b.Covered
+	}
+}
+
+func percent(covered, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) * 100 / float64(total)
+}
+
+// LineCoverage returns percent of covered lines.
+func (s Summary) LineCoverage() float64 {
+	return percent(s.CoveredLines, s.LinesToCover)
+}
+
+// BranchCoverage returns percent of covered branches.
+func (s Summary) BranchCoverage() float64 {
+	return percent(s.CoveredBranches, s.BranchesToCover)
+}
+
+// Coverage returns combined line and branch coverage in percent, calculated the same way as sonarqube coverage metric.
+func (s Summary) Coverage() float64 {
+	return percent(s.CoveredLines+s.CoveredBranches, s.LinesToCover+s.BranchesToCover)
+}
+
+// Total returns coverage of all files.
+func Total(files []*File) (res Summary) {
+	for _, f := range files {
+		res.add(f)
+	}
+	return
+}
+
+// ByDirectory returns coverage of every directory containing files, including files in subdirectories. Files in root are only included in Total.
+func ByDirectory(files []*File) map[string]Summary {
+	res := map[string]Summary{}
+	for _, f := range files {
+		dir := path.Dir(strings.TrimPrefix(f.Path, "/"))
+		for dir != "." && dir != "/" && dir != "" {
+			s := res[dir]
+			s.add(f)
+			res[dir] = s
+			dir = path.Dir(dir)
+		}
+	}
+	return res
+}
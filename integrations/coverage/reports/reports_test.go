@@ -0,0 +1,110 @@
+package reports
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testLCOV = `TN:
+SF:src/a/x.js
+DA:1,1
+DA:2,0
+DA:3,5
+BRDA:3,0,0,1
+BRDA:3,0,1,0
+BRDA:3,1,0,-
+end_of_record
+TN:other
+SF:src/a/x.js
+DA:2,1
+end_of_record
+SF:src/b.js
+DA:1,0
+end_of_record
+`
+
+func TestParseLCOV(t *testing.T) {
+	format, files, err := Parse([]byte(testLCOV))
+	assert.NoError(t, err)
+	assert.Equal(t, FormatLCOV, format)
+	assert.Len(t, files, 2)
+	assert.Equal(t, "src/a/x.js", files[0].Path)
+	assert.Equal(t, map[int64]bool{1: true, 2: true, 3: true}, files[0].Lines, "line covered in any record is covered")
+	assert.Equal(t, Branches{Total: 3, Covered: 1}, files[0].Branches[3])
+
+	total := Total(files)
+	assert.Equal(t, Summary{LinesToCover: 4, CoveredLines: 3, BranchesToCover: 3, CoveredBranches: 1}, total)
+	assert.Equal(t, 75.0, total.LineCoverage())
+	assert.Equal(t, 4*100/7.0, total.Coverage())
+
+	dirs := ByDirectory(files)
+	assert.Equal(t, Summary{LinesToCover: 4, CoveredLines: 3, BranchesToCover: 3, CoveredBranches: 1}, dirs["src"])
+	assert.Equal(t, Summary{LinesToCover: 3, CoveredLines: 3, BranchesToCover: 3, CoveredBranches: 1}, dirs["src/a"])
+	assert.Len(t, dirs, 2)
+}
+
+const testCobertura = `<?xml version="1.0" ?>
+<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">
+<coverage branch-rate="0.5" line-rate="0.66" version="5.0">
+	<sources><source>/build/src</source></sources>
+	<packages>
+		<package name="app">
+			<classes>
+				<class filename="app/main.py" name="main.py">
+					<methods/>
+					<lines>
+						<line hits="1" number="1"/>
+						<line branch="true" condition-coverage="50% (1/2)" hits="3" number="2"/>
+						<line hits="0" number="3"/>
+					</lines>
+				</class>
+			</classes>
+		</package>
+	</packages>
+</coverage>`
+
+func TestParseCobertura(t *testing.T) {
+	format, files, err := Parse([]byte(testCobertura))
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCobertura, format)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "app/main.py", files[0].Path)
+	assert.Equal(t, Summary{LinesToCover: 3, CoveredLines: 2, BranchesToCover: 2, CoveredBranches: 1}, Total(files))
+}
+
+const testGo = `mode: set
+github.com/org/repo/pkg/a.go:3.20,5.2 2 1
+github.com/org/repo/pkg/a.go:5.2,7.3 1 0
+github.com/org/repo/pkg/a.go:9.1,9.10 0 0
+mode: set
+github.com/org/repo/main.go:1.1,1.5 1 0
+`
+
+func TestParseGoCoverProfile(t *testing.T) {
+	format, files, err := Parse([]byte(testGo))
+	assert.NoError(t, err)
+	assert.Equal(t, FormatGo, format)
+	TrimPrefix(files, GoModulePath([]byte("// comment\nmodule github.com/org/repo\n\ngo 1.13\n")))
+	assert.Len(t, files, 2)
+	assert.Equal(t, "main.go", files[0].Path)
+	assert.Equal(t, "pkg/a.go", files[1].Path)
+	assert.Equal(t, map[int64]bool{3: true, 4: true, 5: true, 6: false, 7: false}, files[1].Lines)
+}
+
+func TestMerge(t *testing.T) {
+	a := newFile("a.go")
+	a.addLine(1, true)
+	a.addLine(2, false)
+	b := newFile("a.go")
+	b.addLine(2, true)
+	b.addLine(3, false)
+	files := Merge([]*File{a}, []*File{b})
+	assert.Len(t, files, 1)
+	assert.Equal(t, map[int64]bool{1: true, 2: true, 3: false}, files[0].Lines)
+}
+
+func TestDetectUnknown(t *testing.T) {
+	_, _, err := Parse([]byte(`{"version":"2.1.0"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,342 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/agent/pkg/ids"
+	"github.com/pinpt/agent/pkg/requests"
+	pjson "github.com/pinpt/go-common/json"
+	pstrings "github.com/pinpt/go-common/strings"
+)
+
+// Deployment is a deployment together with all of its statuses
+type Deployment struct {
+	extmodels.Deployment
+	Statuses []extmodels.DeploymentStatus
+}
+
+const deploymentFieldsGraphql = `
+id
+createdAt
+updatedAt
+environment
+description
+# latest state, uses ACTIVE for the latest successful deployment to environment
+state
+ref { name }
+commitOid
+creator { login }
+commit {
+	associatedPullRequests(first: 10) {
+		nodes {
+			id
+			merged
+		}
+	}
+}
+statuses(first: 100) {
+	nodes {
+		id
+		state
+		description
+		environmentUrl
+		logUrl
+		createdAt
+		creator { login }
+	}
+}
+`
+
+type deploymentGraphql struct {
+	ID          string    `json:"id"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Environment string    `json:"environment"`
+	Description string    `json:"description"`
+	State       string    `json:"state"`
+	Ref         struct {
+		Name string `json:"name"`
+	} `json:"ref"`
+	CommitOID string `json:"commitOid"`
+	Creator   struct {
+		Login string `json:"login"`
+	} `json:"creator"`
+	Commit struct {
+		AssociatedPullRequests struct {
+			Nodes []struct {
+				ID     string `json:"id"`
+				Merged bool   `json:"merged"`
+			} `json:"nodes"`
+		} `json:"associatedPullRequests"`
+	} `json:"commit"`
+	Statuses struct {
+		Nodes []deploymentStatusGraphql `json:"nodes"`
+	} `json:"statuses"`
+}
+
+type deploymentStatusGraphql struct {
+	ID             string    `json:"id"`
+	State          string    `json:"state"`
+	Description    string    `json:"description"`
+	EnvironmentURL string    `json:"environmentUrl"`
+	LogURL         string    `json:"logUrl"`
+	CreatedAt      time.Time `json:"createdAt"`
+	Creator        struct {
+		Login string `json:"login"`
+	} `json:"creator"`
+}
+
+// DeploymentState converts github deployment or deployment status state to extmodels deployment state
+func DeploymentState(state string) string {
+	switch state {
+	case "PENDING", "QUEUED", "WAITING":
+		return extmodels.DeploymentStatePending
+	case "IN_PROGRESS":
+		return extmodels.DeploymentStateRunning
+	case "SUCCESS", "ACTIVE":
+		return extmodels.DeploymentStateSuccess
+	case "FAILURE", "ERROR":
+		return extmodels.DeploymentStateFailure
+	case "INACTIVE", "DESTROYED":
+		return extmodels.DeploymentStateInactive
+	}
+	return ""
+}
+
+func (qc QueryContext) userRefID(login string, repoName string) string {
+	if qc.UserLoginToRefID == nil || login == "" {
+		return ""
+	}
+	refID, err := qc.UserLoginToRefID(login)
+	if err != nil {
+		qc.Logger.Error("could not resolve deployment creator", "login", login, "repo", repoName)
+	}
+	return refID
+}
+
+func convertDeployment(qc QueryContext, repo Repo, data deploymentGraphql) Deployment {
+	repoID := qc.RepoID(repo.ID)
+	item := extmodels.Deployment{}
+	item.CustomerID = qc.CustomerID
+	item.RefType = qc.RefType
+	item.RefID = data.ID
+	item.RepoID = repoID
+	item.Environment = data.Environment
+	item.EnvironmentID = extmodels.EnvironmentID(qc.CustomerID, qc.RefType, repoID, data.Environment)
+	item.Ref = data.Ref.Name
+	item.CommitSHA = data.CommitOID
+	if data.CommitOID != "" {
+		item.CommitID = ids.CodeCommit(qc.CustomerID, qc.RefType, repoID, data.CommitOID)
+	}
+	for _, pr := range data.Commit.AssociatedPullRequests.Nodes {
+		if !pr.Merged {
+			continue
+		}
+		item.PullRequestIDs = append(item.PullRequestIDs, qc.PullRequestID(repoID, pr.ID))
+	}
+	item.Description = data.Description
+	item.CreatorRefID = qc.userRefID(data.Creator.Login, repo.NameWithOwner)
+	item.CreatedDate = extmodels.NewDate(data.CreatedAt)
+	item.UpdatedDate = extmodels.NewDate(data.UpdatedAt)
+
+	statuses := data.Statuses.Nodes
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].CreatedAt.Before(statuses[j].CreatedAt)
+	})
+
+	res := Deployment{}
+	// deployment without statuses was created, but not started
+	item.State = extmodels.DeploymentStatePending
+	for _, data := range statuses {
+		status := extmodels.DeploymentStatus{}
+		status.CustomerID = qc.CustomerID
+		status.RefType = qc.RefType
+		status.RefID = data.ID
+		status.RepoID = repoID
+		status.DeploymentID = item.ID()
+		status.SourceState = data.State
+		status.State = DeploymentState(data.State)
+		status.Description = data.Description
+		status.EnvironmentURL = data.EnvironmentURL
+		status.LogURL = data.LogURL
+		status.CreatorRefID = qc.userRefID(data.Creator.Login, repo.NameWithOwner)
+		status.CreatedDate = extmodels.NewDate(data.CreatedAt)
+		res.Statuses = append(res.Statuses, status)
+		if status.LogURL != "" {
+			// deployment does not have its own url, use the log of the latest status
+			item.URL = status.LogURL
+		}
+
+		// github sets older deployments to environment as inactive after successful deployment, keep the final state for those
+		if status.State == "" || status.State == extmodels.DeploymentStateInactive && extmodels.IsDeploymentFinished(item.State) {
+			continue
+		}
+		item.State = status.State
+		if extmodels.IsDeploymentFinished(status.State) && item.FinishedDate.Epoch == 0 {
+			item.FinishedDate = status.CreatedDate
+		}
+	}
+	res.Deployment = item
+	return res
+}
+
+// DeploymentsPage returns a page of deployments in repo, ordered by creation date, newest first. Deployments created before stopOnCreatedAt are not returned and pagination stops.
+func DeploymentsPage(
+	qc QueryContext,
+	repo Repo,
+	queryParams string, stopOnCreatedAt time.Time) (pi PageInfo, res []Deployment, totalCount int, rerr error) {
+
+	qc.Logger.Debug("deployments request", "repo", repo.NameWithOwner, "q", queryParams)
+
+	query := `
+	query {
+		node (id: "` + repo.ID + `") {
+			... on Repository {
+				deployments(` + queryParams + ` orderBy: {field:CREATED_AT, direction: DESC}) {
+					totalCount
+					pageInfo {
+						hasNextPage
+						endCursor
+						hasPreviousPage
+						startCursor
+					}
+					nodes {
+						` + deploymentFieldsGraphql + `
+					}
+				}
+			}
+		}
+	}
+	`
+
+	var requestRes struct {
+		Data struct {
+			Node struct {
+				Deployments struct {
+					TotalCount int                 `json:"totalCount"`
+					PageInfo   PageInfo            `json:"pageInfo"`
+					Nodes      []deploymentGraphql `json:"nodes"`
+				} `json:"deployments"`
+			} `json:"node"`
+		} `json:"data"`
+	}
+
+	err := qc.Request(query, nil, &requestRes)
+	if err != nil {
+		rerr = err
+		return
+	}
+
+	deployments := requestRes.Data.Node.Deployments
+	for _, data := range deployments.Nodes {
+		if data.CreatedAt.Before(stopOnCreatedAt) {
+			return
+		}
+		res = append(res, convertDeployment(qc, repo, data))
+	}
+
+	return deployments.PageInfo, res, deployments.TotalCount, nil
+}
+
+// DeploymentsByID returns deployments with node ids, used to get status changes of deployments from previous exports. Deleted deployments are not returned. Pass at most 100 ids.
+func DeploymentsByID(qc QueryContext, repo Repo, nodeIDs []string) (res []Deployment, rerr error) {
+	qc.Logger.Debug("deployments by id request", "repo", repo.NameWithOwner, "count", len(nodeIDs))
+
+	query := `
+	query {
+		nodes (ids: ` + pjson.Stringify(nodeIDs) + `) {
+			... on Deployment {
+				` + deploymentFieldsGraphql + `
+			}
+		}
+	}
+	`
+
+	var requestRes struct {
+		Data struct {
+			Nodes []*deploymentGraphql `json:"nodes"`
+		} `json:"data"`
+	}
+
+	err := qc.Request(query, nil, &requestRes)
+	if err != nil {
+		rerr = err
+		return
+	}
+
+	for _, data := range requestRes.Data.Nodes {
+		if data == nil || data.ID == "" {
+			continue
+		}
+		res = append(res, convertDeployment(qc, repo, *data))
+	}
+	return
+}
+
+// Environment is a deployment environment configured in repo settings
+type Environment struct {
+	NodeID    string    `json:"node_id"`
+	Name      string    `json:"name"`
+	HTMLURL   string    `json:"html_url"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EnvironmentsPage returns a page of environments in repo using REST API. Returns ok false if environments are not supported by the server, which is the case for older github enterprise versions.
+func EnvironmentsPage(qc QueryContext, repo Repo, u string) (res []Environment, header http.Header, ok bool, rerr error) {
+	if u == "" {
+		u = pstrings.JoinURL(qc.APIURL3, "repos", repo.NameWithOwner, "environments") + "?per_page=100"
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		rerr = err
+		return
+	}
+	req.Header.Set("Authorization", "token "+qc.AuthToken)
+
+	reqs := requests.New(qc.Logger, qc.Clients.TLSInsecure)
+	resp, logError, err := reqs.Do(context.TODO(), req)
+	if err != nil {
+		rerr = err
+		return
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, resp.Header, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		rerr = logError(fmt.Errorf(`wanted status code 200, got %v`, resp.StatusCode))
+		return
+	}
+
+	var respJSON struct {
+		Environments []Environment `json:"environments"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&respJSON)
+	if err != nil {
+		rerr = logError(err)
+		return
+	}
+
+	return respJSON.Environments, resp.Header, true, nil
+}
+
+// ConvertEnvironment converts environment returned by REST API to extmodels.Environment
+func ConvertEnvironment(qc QueryContext, repo Repo, data Environment) extmodels.Environment {
+	item := extmodels.Environment{}
+	item.CustomerID = qc.CustomerID
+	item.RefType = qc.RefType
+	item.RefID = data.NodeID
+	item.RepoID = qc.RepoID(repo.ID)
+	item.Name = data.Name
+	item.URL = data.HTMLURL
+	item.CreatedDate = extmodels.NewDate(data.CreatedAt)
+	item.UpdatedDate = extmodels.NewDate(data.UpdatedAt)
+	return item
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/agent/pkg/ids"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDeployment = `{
+	"id": "D1",
+	"createdAt": "2020-01-02T10:00:00Z",
+	"updatedAt": "2020-01-02T12:00:00Z",
+	"environment": "production",
+	"state": "INACTIVE",
+	"ref": { "name": "master" },
+	"commitOid": "c1",
+	"commit": {
+		"associatedPullRequests": {
+			"nodes": [{"id": "PR1", "merged": true}, {"id": "PR2", "merged": false}]
+		}
+	},
+	"statuses": {
+		"nodes": [
+			{"id": "S3", "state": "INACTIVE", "createdAt": "2020-01-02T12:00:00Z"},
+			{"id": "S1", "state": "IN_PROGRESS", "createdAt": "2020-01-02T10:01:00Z"},
+			{"id": "S2", "state": "SUCCESS", "logUrl": "https://ci/1", "createdAt": "2020-01-02T10:05:00Z"}
+		]
+	}
+}`
+
+func TestConvertDeployment(t *testing.T) {
+	var data deploymentGraphql
+	err := json.Unmarshal([]byte(testDeployment), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qc := QueryContext{Logger: hclog.NewNullLogger(), CustomerID: "c", RefType: "github"}
+	res := convertDeployment(qc, Repo{ID: "R1", NameWithOwner: "org/repo"}, data)
+
+	repoID := qc.RepoID("R1")
+	assert.Equal(t, repoID, res.RepoID)
+	assert.Equal(t, extmodels.EnvironmentID("c", "github", repoID, "production"), res.EnvironmentID)
+	assert.Equal(t, ids.CodeCommit("c", "github", repoID, "c1"), res.CommitID)
+	assert.Equal(t, []string{qc.PullRequestID(repoID, "PR1")}, res.PullRequestIDs, "only merged pull requests are linked")
+	assert.Equal(t, extmodels.DeploymentStateSuccess, res.State, "deployment replaced by newer deployment keeps success state")
+	assert.Equal(t, "2020-01-02T10:05:00Z", res.FinishedDate.Time().Format("2006-01-02T15:04:05Z"))
+	assert.Equal(t, "https://ci/1", res.URL)
+
+	assert.Len(t, res.Statuses, 3)
+	assert.Equal(t, "S1", res.Statuses[0].RefID, "statuses are sorted by date")
+	assert.Equal(t, extmodels.DeploymentStateRunning, res.Statuses[0].State)
+	assert.Equal(t, extmodels.DeploymentStateInactive, res.Statuses[2].State)
+	assert.Equal(t, res.ID(), res.Statuses[2].DeploymentID)
+}
+
+func TestConvertDeploymentNoStatuses(t *testing.T) {
+	var data deploymentGraphql
+	data.ID = "D1"
+	data.Environment = "staging"
+	qc := QueryContext{Logger: hclog.NewNullLogger(), CustomerID: "c", RefType: "github"}
+	res := convertDeployment(qc, Repo{ID: "R1"}, data)
+	assert.Equal(t, extmodels.DeploymentStatePending, res.State)
+	assert.Equal(t, int64(0), res.FinishedDate.Epoch)
+	assert.Len(t, res.Statuses, 0)
+}
+
+func TestDeploymentsByID(t *testing.T) {
+	qc := QueryContext{Logger: hclog.NewNullLogger(), CustomerID: "c", RefType: "github"}
+	var query string
+	qc.Request = func(q string, vars map[string]interface{}, res interface{}) error {
+		query = q
+		// deleted deployment is returned as null
+		return json.Unmarshal([]byte(`{"data":{"nodes":[`+testDeployment+`,null]}}`), res)
+	}
+	res, err := DeploymentsByID(qc, Repo{ID: "R1", NameWithOwner: "org/repo"}, []string{"D1", "D2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, query, `"D1"`)
+	assert.Contains(t, query, `"D2"`)
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, "D1", res[0].RefID)
+		assert.Len(t, res[0].Statuses, 3)
+	}
+}
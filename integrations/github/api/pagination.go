@@ -137,6 +137,10 @@ func PaginateV3(fn PaginateV3Fn) error {
 }
 
 func getNextFromLinkHeader(link string) (string, error) {
+	// link header is not set when all results fit into one page
+	if strings.TrimSpace(link) == "" {
+		return "", nil
+	}
 	links := strings.Split(link, ",")
 	for _, link := range links {
 		link = strings.TrimSpace(link)
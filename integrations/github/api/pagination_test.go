@@ -16,3 +16,13 @@ func TestGetNextFromLinkHeader(t *testing.T) {
 		t.Errorf("invalid result %v", res)
 	}
 }
+
+func TestGetNextFromLinkHeaderEmpty(t *testing.T) {
+	res, err := getNextFromLinkHeader("")
+	if err != nil {
+		t.Error(err)
+	}
+	if res != "" {
+		t.Errorf("invalid result %v", res)
+	}
+}
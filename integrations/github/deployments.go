@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/integrations/github/api"
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/agent/integrations/pkg/objsender"
+	"github.com/pinpt/agent/integrations/pkg/repoprojects"
+	"github.com/pinpt/agent/pkg/fs"
+	"github.com/pinpt/agent/pkg/istate"
+	"github.com/pinpt/go-common/datamodel"
+	"github.com/pinpt/go-common/hash"
+)
+
+// deploymentsMaxPending is how long deployments that are not finished are fetched again in next exports to get status changes
+const deploymentsMaxPending = 30 * 24 * time.Hour
+
+// deploymentsState is saved in integration state dir for each repo. Deployments can only be ordered by creation date, so deployments that were not finished are fetched again by id in the next export to get status changes.
+type deploymentsState struct {
+	// Pending are node ids of deployments that were not finished, with creation date
+	Pending map[string]time.Time `json:"pending"`
+}
+
+func readDeploymentsState(loc string) (res deploymentsState, _ error) {
+	res.Pending = map[string]time.Time{}
+	b, err := ioutil.ReadFile(loc)
+	if os.IsNotExist(err) {
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+	err = json.Unmarshal(b, &res)
+	if err != nil {
+		return res, err
+	}
+	if res.Pending == nil {
+		res.Pending = map[string]time.Time{}
+	}
+	return res, nil
+}
+
+func (s deploymentsState) save(loc string) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(loc), 0777)
+	if err != nil {
+		return err
+	}
+	return fs.WriteToTempAndRename(bytes.NewReader(b), loc)
+}
+
+func (s *Integration) exportDeployments(ctx *repoprojects.ProjectCtx, repo Repo) error {
+	logger := ctx.Logger.With("repo", repo.NameWithOwner)
+
+	deploymentSender, err := ctx.Session(datamodel.ModelNameType(extmodels.DeploymentModelName))
+	if err != nil {
+		return err
+	}
+	statusSender, err := ctx.Session(datamodel.ModelNameType(extmodels.DeploymentStatusModelName))
+	if err != nil {
+		return err
+	}
+	environmentSender, err := ctx.Session(datamodel.ModelNameType(extmodels.EnvironmentModelName))
+	if err != nil {
+		return err
+	}
+
+	stateDir, err := istate.Dir(s.refType)
+	if err != nil {
+		return err
+	}
+	stateLoc := filepath.Join(stateDir, "deployments", hash.Values(repo.ID)+".json")
+	state, err := readDeploymentsState(stateLoc)
+	if err != nil {
+		logger.Warn("could not read deployments state, status changes of deployments from previous exports will not be exported", "err", err)
+		state.Pending = map[string]time.Time{}
+	}
+
+	logger.Info("exporting deployments")
+
+	// environment names used in deployments, used when environments api is not available
+	envNames := map[string]bool{}
+	seen := map[string]bool{}
+	send := func(deployment api.Deployment) error {
+		envNames[deployment.Environment] = true
+		seen[deployment.RefID] = true
+		if extmodels.IsDeploymentFinished(deployment.State) {
+			delete(state.Pending, deployment.RefID)
+		} else {
+			state.Pending[deployment.RefID] = deployment.CreatedDate.Time()
+		}
+		err := deploymentSender.Send(deployment.Deployment)
+		if err != nil {
+			return err
+		}
+		for _, status := range deployment.Statuses {
+			err := statusSender.Send(status)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err = api.PaginateRegular(func(query string) (api.PageInfo, error) {
+		pi, res, totalCount, err := api.DeploymentsPage(s.qc.WithLogger(logger), repo.Repo, query, deploymentSender.LastProcessedTime())
+		if err != nil {
+			return pi, err
+		}
+		err = deploymentSender.SetTotal(totalCount)
+		if err != nil {
+			return pi, err
+		}
+		for _, deployment := range res {
+			err := send(deployment)
+			if err != nil {
+				return pi, err
+			}
+		}
+		return pi, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// deployments created before last export, that were not finished
+	var pending []string
+	for id, created := range state.Pending {
+		if seen[id] {
+			continue
+		}
+		if time.Since(created) > deploymentsMaxPending {
+			delete(state.Pending, id)
+			continue
+		}
+		pending = append(pending, id)
+	}
+	sort.Strings(pending)
+	if len(pending) != 0 {
+		logger.Info("exporting status changes of unfinished deployments", "count", len(pending))
+	}
+	for len(pending) != 0 {
+		batch := pending
+		if len(batch) > 100 {
+			batch = batch[:100]
+		}
+		pending = pending[len(batch):]
+		res, err := api.DeploymentsByID(s.qc.WithLogger(logger), repo.Repo, batch)
+		if err != nil {
+			return err
+		}
+		for _, id := range batch {
+			// deleted deployments are not returned
+			delete(state.Pending, id)
+		}
+		for _, deployment := range res {
+			err := send(deployment)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = state.save(stateLoc)
+	if err != nil {
+		return err
+	}
+
+	return s.exportEnvironments(logger, environmentSender, repo, envNames)
+}
+
+func (s *Integration) exportEnvironments(logger hclog.Logger, sender *objsender.Session, repo Repo, envNames map[string]bool) error {
+	supported := true
+	err := api.PaginateV3(func(u string) (responseHeaders http.Header, rerr error) {
+		res, header, ok, err := api.EnvironmentsPage(s.qc.WithLogger(logger), repo.Repo, u)
+		if err != nil {
+			rerr = err
+			return
+		}
+		if !ok {
+			supported = false
+			return header, nil
+		}
+		for _, data := range res {
+			delete(envNames, data.Name)
+			err := sender.Send(api.ConvertEnvironment(s.qc, repo.Repo, data))
+			if err != nil {
+				rerr = err
+				return
+			}
+		}
+		return header, nil
+	})
+	if err != nil {
+		return err
+	}
+	if !supported {
+		logger.Info("environments api is not available, exporting environments based on deployments")
+	}
+	// environments that were deleted from settings or created before environments api was available only exist in deployments
+	for name := range envNames {
+		item := extmodels.Environment{}
+		item.CustomerID = s.customerID
+		item.RefType = s.refType
+		item.RefID = name
+		item.RepoID = s.qc.RepoID(repo.ID)
+		item.Name = name
+		err := sender.Send(item)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
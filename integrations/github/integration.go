@@ -54,7 +54,7 @@ func NewIntegration(logger hclog.Logger) *Integration {
 	return s
 }
 
-func (s *Integration) Init(agent rpcdef.Agent) error {
+func (s *Integration) Init(agent rpcdef.Agent) (rpcdef.Capabilities, error) {
 	s.agent = agent
 	s.refType = "github"
 
@@ -65,7 +65,12 @@ func (s *Integration) Init(agent rpcdef.Agent) error {
 	}
 	s.qc = qc
 
-	return nil
+	return rpcdef.Capabilities{
+		IntegrationTypes: []string{"SOURCECODE"},
+		OnboardKinds:     []rpcdef.OnboardExportType{rpcdef.OnboardExportTypeRepos},
+		MutationActions:  mutationActions,
+		Streaming:        true,
+	}, nil
 }
 
 type IntegrationConfig struct {
@@ -222,6 +227,7 @@ func (s *Integration) initWithConfig(exportConfig rpcdef.ExportConfig) error {
 	s.clientManager = reqstats.New(reqstats.Opts{
 		Logger:                s.logger,
 		TLSInsecureSkipVerify: s.config.TLSInsecureSkipVerify,
+		Agent:                 s.agent,
 	})
 	s.clients = s.clientManager.Clients
 	s.qc.Clients = s.clients
@@ -472,6 +478,11 @@ func (s *Integration) exportRepo(ctx *repoprojects.ProjectCtx, repo Repo) error
 		return err
 	}
 
+	err = s.exportDeployments(ctx, repo)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 
 	"github.com/pinpt/agent/integrations/github/api"
 	"github.com/pinpt/agent/integrations/pkg/mutate"
@@ -42,6 +41,11 @@ func (s *Integration) mutationResult(modelName datamodel.ModelNameType, obj Mode
 	return
 }
 
+// mutationActions are the actions supported in Mutate, advertised in capabilities
+var mutationActions = []string{
+	agent.IntegrationMutationRequestActionIssueSetTitle.String(),
+}
+
 func (s *Integration) Mutate(ctx context.Context, fn, data string, config rpcdef.ExportConfig) (res rpcdef.MutateResult, _ error) {
 
 	rerr := func(err error) {
@@ -78,6 +82,5 @@ func (s *Integration) Mutate(ctx context.Context, fn, data string, config rpcdef
 		return s.returnUpdatedPR(obj.RefID)
 	}
 
-	rerr(fmt.Errorf("mutate fn not supported: %v", fn))
-	return
+	return mutate.ResultNotSupported(fn), nil
 }
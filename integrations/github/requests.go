@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -140,20 +141,14 @@ func (s *Integration) pause(waitTime time.Duration) {
 	if waitTime > maxWaitTime {
 		waitTime = maxWaitTime
 	}
-	paused := time.Now()
-	resumeDate := paused.Add(waitTime)
-
-	err := s.agent.SendPauseEvent("", resumeDate)
-	if err != nil {
-		s.logger.Error("could not send pause event", "err", err)
+	// pause all requests to api host in shared rate limiter, it sends pause and resume events, so these are not duplicated when limiter also pauses on rate limit headers
+	host := ""
+	if u, err := url.Parse(s.config.APIURL); err == nil {
+		host = u.Hostname()
 	}
+	s.clientManager.Pause(host, waitTime)
 
 	time.Sleep(waitTime)
-
-	err = s.agent.SendResumeEvent("")
-	if err != nil {
-		s.logger.Error("could not resume event", "err", err)
-	}
 }
 
 func (s *Integration) makeRequestThrottled(req request, res interface{}) error {
@@ -195,7 +190,9 @@ func (s *Integration) makeRequestRetryThrottled(reqDef request, res interface{},
 	// pullrequest.timelineItems were a preview feature, and need custom accept header to enable
 	// https://developer.github.com/enterprise/2.16/v4/object/pullrequest/
 	// https://developer.github.com/enterprise/2.16/v4/previews/#issues-preview
-	req.Header.Set("Accept", "application/vnd.github.starfire-preview+json")
+	// deployment.state and deployment.statuses need flash preview on older github enterprise versions
+	// https://developer.github.com/enterprise/2.16/v4/previews/#deployments
+	req.Header.Set("Accept", "application/vnd.github.starfire-preview+json, application/vnd.github.flash-preview+json")
 
 	req.Header.Set("Authorization", "bearer "+s.config.Token)
 	resp, err := s.clients.TLSInsecure.Do(req)
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/commonrepo"
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	pstrings "github.com/pinpt/go-common/strings"
+)
+
+// Deployment is a deployment together with its current status. Gitlab does not keep history of deployment statuses, so status changes are only exported when they are seen during export.
+type Deployment struct {
+	extmodels.Deployment
+	Status extmodels.DeploymentStatus
+}
+
+type deploymentREST struct {
+	ID        int64     `json:"id"`
+	IID       int64     `json:"iid"`
+	Ref       string    `json:"ref"`
+	SHA       string    `json:"sha"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Status is created, running, success, failed, canceled or blocked
+	Status string `json:"status"`
+	User   struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Environment struct {
+		Name        string `json:"name"`
+		ExternalURL string `json:"external_url"`
+	} `json:"environment"`
+	Deployable struct {
+		StartedAt  time.Time `json:"started_at"`
+		FinishedAt time.Time `json:"finished_at"`
+		WebURL     string    `json:"web_url"`
+	} `json:"deployable"`
+}
+
+// DeploymentState converts gitlab deployment status to extmodels deployment state
+func DeploymentState(status string) string {
+	switch status {
+	case "created", "blocked":
+		return extmodels.DeploymentStatePending
+	case "running":
+		return extmodels.DeploymentStateRunning
+	case "success":
+		return extmodels.DeploymentStateSuccess
+	case "failed":
+		return extmodels.DeploymentStateFailure
+	case "canceled", "skipped":
+		return extmodels.DeploymentStateCanceled
+	}
+	return ""
+}
+
+func convertDeployment(qc QueryContext, repo commonrepo.Repo, data deploymentREST) Deployment {
+	repoID := qc.IDs.CodeRepo(repo.ID)
+	item := extmodels.Deployment{}
+	item.CustomerID = qc.CustomerID
+	item.RefType = qc.RefType
+	item.RefID = strconv.FormatInt(data.ID, 10)
+	item.RepoID = repoID
+	item.Environment = data.Environment.Name
+	item.EnvironmentID = extmodels.EnvironmentID(qc.CustomerID, qc.RefType, repoID, data.Environment.Name)
+	item.Ref = data.Ref
+	item.CommitSHA = data.SHA
+	if data.SHA != "" {
+		item.CommitID = qc.IDs.CodeCommit(repoID, data.SHA)
+	}
+	item.CreatorRefID = data.User.Username
+	item.State = DeploymentState(data.Status)
+	item.URL = data.Deployable.WebURL
+	item.CreatedDate = extmodels.NewDate(data.CreatedAt)
+	item.UpdatedDate = extmodels.NewDate(data.UpdatedAt)
+
+	// use job times when available, deployment is updated later than job finishes
+	changedAt := data.UpdatedAt
+	switch {
+	case extmodels.IsDeploymentFinished(item.State):
+		if !data.Deployable.FinishedAt.IsZero() {
+			changedAt = data.Deployable.FinishedAt
+		}
+		item.FinishedDate = extmodels.NewDate(changedAt)
+	case item.State == extmodels.DeploymentStateRunning:
+		if !data.Deployable.StartedAt.IsZero() {
+			changedAt = data.Deployable.StartedAt
+		}
+	}
+
+	status := extmodels.DeploymentStatus{}
+	status.CustomerID = qc.CustomerID
+	status.RefType = qc.RefType
+	status.RefID = item.RefID + "-" + data.Status
+	status.RepoID = repoID
+	status.DeploymentID = item.ID()
+	status.State = item.State
+	status.SourceState = data.Status
+	status.EnvironmentURL = data.Environment.ExternalURL
+	status.LogURL = data.Deployable.WebURL
+	status.CreatorRefID = data.User.Username
+	status.CreatedDate = extmodels.NewDate(changedAt)
+
+	return Deployment{Deployment: item, Status: status}
+}
+
+// DeploymentsPage returns a page of deployments in repo, ordered by update date, newest first. Deployments updated before stopOnUpdatedAt are not returned.
+func DeploymentsPage(
+	qc QueryContext,
+	repo commonrepo.Repo,
+	params url.Values,
+	stopOnUpdatedAt time.Time) (pi PageInfo, res []Deployment, err error) {
+
+	qc.Logger.Debug("repo deployments", "repo", repo.NameWithOwner)
+
+	objectPath := pstrings.JoinURL("projects", repo.ID, "deployments")
+	params.Set("order_by", "updated_at")
+	params.Set("sort", "desc")
+
+	var rdeployments []deploymentREST
+
+	pi, err = qc.Request(objectPath, params, &rdeployments)
+	if err != nil {
+		return
+	}
+
+	for _, data := range rdeployments {
+		if data.UpdatedAt.Before(stopOnUpdatedAt) {
+			return pi, res, nil
+		}
+		res = append(res, convertDeployment(qc, repo, data))
+	}
+
+	return
+}
+
+// CommitMergedPullRequests returns ref ids of merged pull requests containing the commit
+func CommitMergedPullRequests(qc QueryContext, repo commonrepo.Repo, sha string) (res []string, _ error) {
+	qc.Logger.Debug("commit merge requests", "repo", repo.NameWithOwner, "sha", sha)
+
+	objectPath := pstrings.JoinURL("projects", repo.ID, "repository", "commits", sha, "merge_requests")
+
+	var rprs []struct {
+		ID    int64  `json:"id"`
+		State string `json:"state"`
+	}
+
+	if _, err := qc.Request(objectPath, nil, &rprs); err != nil {
+		return nil, err
+	}
+
+	for _, rpr := range rprs {
+		if rpr.State != "merged" {
+			continue
+		}
+		res = append(res, strconv.FormatInt(rpr.ID, 10))
+	}
+
+	return
+}
+
+// EnvironmentsPage returns a page of environments in repo
+func EnvironmentsPage(
+	qc QueryContext,
+	repo commonrepo.Repo,
+	params url.Values) (pi PageInfo, res []extmodels.Environment, err error) {
+
+	qc.Logger.Debug("repo environments", "repo", repo.NameWithOwner)
+
+	objectPath := pstrings.JoinURL("projects", repo.ID, "environments")
+	params.Set("per_page", "100")
+
+	var renvs []struct {
+		ID          int64     `json:"id"`
+		Name        string    `json:"name"`
+		ExternalURL string    `json:"external_url"`
+		CreatedAt   time.Time `json:"created_at"`
+		UpdatedAt   time.Time `json:"updated_at"`
+	}
+
+	pi, err = qc.Request(objectPath, params, &renvs)
+	if err != nil {
+		return
+	}
+
+	for _, renv := range renvs {
+		item := extmodels.Environment{}
+		item.CustomerID = qc.CustomerID
+		item.RefType = qc.RefType
+		item.RefID = strconv.FormatInt(renv.ID, 10)
+		item.RepoID = qc.IDs.CodeRepo(repo.ID)
+		item.Name = renv.Name
+		item.URL = renv.ExternalURL
+		item.CreatedDate = extmodels.NewDate(renv.CreatedAt)
+		item.UpdatedDate = extmodels.NewDate(renv.UpdatedAt)
+		res = append(res, item)
+	}
+
+	return
+}
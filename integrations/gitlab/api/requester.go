@@ -1,46 +1,34 @@
 package api
 
 import (
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
 	"sync"
-	"time"
 
 	"github.com/hashicorp/go-hclog"
-	"github.com/pinpt/agent/rpcdef"
-	"github.com/pinpt/go-common/httpdefaults"
 	pstrings "github.com/pinpt/go-common/strings"
 )
 
 // RequesterOpts requester opts
 type RequesterOpts struct {
-	Logger             hclog.Logger
-	APIURL             string
-	APIKey             string
-	AccessToken        string
-	InsecureSkipVerify bool
-	ServerType         ServerType
-	Concurrency        chan bool
-	Client             *http.Client
-	Agent              rpcdef.Agent
+	Logger      hclog.Logger
+	APIURL      string
+	APIKey      string
+	AccessToken string
+	ServerType  ServerType
+	Concurrency chan bool
+	// Client is the http client from reqstats, with rate limits and record/replay applied
+	Client *http.Client
 }
 
 // NewRequester new requester
 func NewRequester(opts RequesterOpts) *Requester {
 	re := &Requester{}
-	{
-		c := &http.Client{}
-		transport := httpdefaults.DefaultTransport()
-		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
-		c.Transport = transport
-		opts.Client = c
-	}
-
 	re.opts = opts
 
 	return re
@@ -51,6 +39,8 @@ type internalRequest struct {
 	Params   url.Values
 	Response interface{}
 	PageInfo PageInfo
+	// throttledRetries is the number of retries after rate limited responses, these do not count towards general retries
+	throttledRetries int
 }
 
 type errorState struct {
@@ -101,6 +91,11 @@ func (e *Requester) makeRequestRetry(req *internalRequest, generalRetry int) (pa
 		if !isRetryable {
 			return pageInfo, err
 		}
+		if err == errRateLimited && req.throttledRetries < maxThrottledRetries {
+			// rate limiter in http client waits before sending the next request
+			req.throttledRetries++
+			return e.makeRequestRetry(req, generalRetry)
+		}
 		if generalRetry >= maxGeneralRetries {
 			return pageInfo, fmt.Errorf(`can't retry request, too many retries, err: %v`, err)
 		}
@@ -117,7 +112,9 @@ func (e *Requester) setAuthHeader(req *http.Request) {
 	}
 }
 
-const maxThrottledRetries = 3
+const maxThrottledRetries = 10
+
+var errRateLimited = errors.New("too many requests")
 
 type errorResponse struct {
 	Error            string `json:"error"`
@@ -149,28 +146,11 @@ func (e *Requester) request(r *internalRequest, retryThrottled int) (isErrorRetr
 		isErrorRetryable = true
 		return
 	}
-	rateLimited := func() (isErrorRetryable bool, pageInfo PageInfo, rerr error) {
-
-		waitTime := time.Minute * 3
-
-		e.opts.Logger.Warn("api request failed due to throttling, the quota of 600 calls has been reached, will sleep for 3m and retry", "retryThrottled", retryThrottled)
-
-		paused := time.Now()
-		resumeDate := paused.Add(waitTime)
-		e.opts.Agent.SendPauseEvent(fmt.Sprintf("gitlab paused, it will resume in %v", waitTime), resumeDate)
-
-		time.Sleep(waitTime)
-
-		e.opts.Agent.SendResumeEvent(fmt.Sprintf("gitlab resumed, time elapsed %v", time.Since(paused)))
-
-		return true, PageInfo{}, fmt.Errorf("Too many requests")
-
-	}
-
 	if resp.StatusCode != http.StatusOK {
 
 		if resp.StatusCode == http.StatusTooManyRequests {
-			return rateLimited()
+			e.opts.Logger.Warn("api request failed due to throttling, will retry", "retryThrottled", r.throttledRetries)
+			return true, PageInfo{}, errRateLimited
 		}
 
 		if resp.StatusCode == http.StatusForbidden {
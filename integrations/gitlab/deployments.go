@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/integrations/gitlab/api"
+	"github.com/pinpt/agent/integrations/pkg/commonrepo"
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/agent/integrations/pkg/repoprojects"
+	"github.com/pinpt/go-common/datamodel"
+)
+
+func (s *Integration) exportDeployments(ctx *repoprojects.ProjectCtx, repo commonrepo.Repo) error {
+	logger := ctx.Logger.With("repo", repo.NameWithOwner)
+
+	environmentSender, err := ctx.Session(datamodel.ModelNameType(extmodels.EnvironmentModelName))
+	if err != nil {
+		return err
+	}
+	deploymentSender, err := ctx.Session(datamodel.ModelNameType(extmodels.DeploymentModelName))
+	if err != nil {
+		return err
+	}
+	statusSender, err := ctx.Session(datamodel.ModelNameType(extmodels.DeploymentStatusModelName))
+	if err != nil {
+		return err
+	}
+
+	logger.Info("exporting environments")
+	err = api.PaginateStartAt(logger, func(log hclog.Logger, paginationParams url.Values) (api.PageInfo, error) {
+		pi, res, err := api.EnvironmentsPage(s.qc, repo, paginationParams)
+		if err != nil {
+			return pi, err
+		}
+		for _, env := range res {
+			err := environmentSender.Send(env)
+			if err != nil {
+				return pi, err
+			}
+		}
+		return pi, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("exporting deployments")
+	repoID := s.qc.IDs.CodeRepo(repo.ID)
+	// merged pull requests by commit sha, the same commit is usually deployed to multiple environments
+	prIDs := map[string][]string{}
+	return api.PaginateNewerThan(logger, deploymentSender.LastProcessedTime(), func(log hclog.Logger, parameters url.Values, stopOnUpdatedAt time.Time) (api.PageInfo, error) {
+		pi, res, err := api.DeploymentsPage(s.qc, repo, parameters, stopOnUpdatedAt)
+		if err != nil {
+			return pi, err
+		}
+		if err := deploymentSender.SetTotal(pi.Total); err != nil {
+			return pi, err
+		}
+		for _, deployment := range res {
+			sha := deployment.CommitSHA
+			if sha != "" {
+				if _, ok := prIDs[sha]; !ok {
+					refIDs, err := api.CommitMergedPullRequests(s.qc, repo, sha)
+					if err != nil {
+						return pi, err
+					}
+					prIDs[sha] = nil
+					for _, refID := range refIDs {
+						prIDs[sha] = append(prIDs[sha], s.qc.IDs.CodePullRequest(repoID, refID))
+					}
+				}
+				deployment.PullRequestIDs = prIDs[sha]
+			}
+			err := deploymentSender.Send(deployment.Deployment)
+			if err != nil {
+				return pi, err
+			}
+			err = statusSender.Send(deployment.Status)
+			if err != nil {
+				return pi, err
+			}
+		}
+		return pi, nil
+	})
+}
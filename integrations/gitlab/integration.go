@@ -18,6 +18,7 @@ import (
 	"github.com/pinpt/agent/integrations/pkg/repoprojects"
 	"github.com/pinpt/agent/pkg/ids"
 	"github.com/pinpt/agent/pkg/ids2"
+	"github.com/pinpt/agent/pkg/reqstats"
 	"github.com/pinpt/agent/pkg/structmarshal"
 	"github.com/pinpt/agent/rpcdef"
 	"github.com/pinpt/integration-sdk/sourcecode"
@@ -59,7 +60,7 @@ func NewIntegration(logger hclog.Logger) *Integration {
 	s.logger = logger
 	return s
 }
-func (s *Integration) Init(agent rpcdef.Agent) error {
+func (s *Integration) Init(agent rpcdef.Agent) (rpcdef.Capabilities, error) {
 	s.agent = agent
 	s.refType = "gitlab"
 
@@ -67,7 +68,11 @@ func (s *Integration) Init(agent rpcdef.Agent) error {
 		Logger: s.logger,
 	}
 
-	return nil
+	return rpcdef.Capabilities{
+		IntegrationTypes: []string{"SOURCECODE"},
+		OnboardKinds:     []rpcdef.OnboardExportType{rpcdef.OnboardExportTypeRepos},
+		Streaming:        true,
+	}, nil
 }
 
 func (s *Integration) ValidateConfig(ctx context.Context,
@@ -154,9 +159,12 @@ func (s *Integration) initWithConfig(config rpcdef.ExportConfig) error {
 		opts.APIURL = s.config.URL + "/api/v4"
 		opts.APIKey = s.config.APIKey
 		opts.AccessToken = s.config.AccessToken
-		opts.InsecureSkipVerify = s.config.InsecureSkipVerify
 		opts.Concurrency = make(chan bool, 10)
-		opts.Agent = s.agent
+		opts.Client = reqstats.New(reqstats.Opts{
+			Logger:                s.logger,
+			TLSInsecureSkipVerify: s.config.InsecureSkipVerify,
+			Agent:                 s.agent,
+		}).Clients.TLSInsecure
 		requester := api.NewRequester(opts)
 
 		s.qc.Request = requester.MakeRequest
@@ -357,6 +365,11 @@ func (s *Integration) exportRepoChildren(ctx *repoprojects.ProjectCtx, repo comm
 		return err
 	}
 
+	err = s.exportDeployments(ctx, repo)
+	if err != nil {
+		return err
+	}
+
 	return s.exportGit(repo, prs)
 }
 
@@ -46,9 +46,14 @@ func NewIntegration(logger hclog.Logger) *Integration {
 	return s
 }
 
-func (s *Integration) Init(agent rpcdef.Agent) error {
+func (s *Integration) Init(agent rpcdef.Agent) (rpcdef.Capabilities, error) {
 	s.agent = agent
-	return nil
+	return rpcdef.Capabilities{
+		IntegrationTypes: []string{"WORK"},
+		OnboardKinds:     []rpcdef.OnboardExportType{rpcdef.OnboardExportTypeProjects, rpcdef.OnboardExportTypeWorkConfig},
+		MutationActions:  mutationActions,
+		Streaming:        true,
+	}, nil
 }
 
 func setConfig(config rpcdef.ExportConfig) (res jiracommon.Config, rerr error) {
@@ -95,6 +100,7 @@ func (s *Integration) initWithConfig(config rpcdef.ExportConfig, retryRequests b
 	s.clientManager = reqstats.New(reqstats.Opts{
 		Logger:                s.logger,
 		TLSInsecureSkipVerify: false,
+		Agent:                 s.agent,
 	})
 	s.clients = s.clientManager.Clients
 
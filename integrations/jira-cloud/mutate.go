@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 
 	"github.com/pinpt/agent/integrations/jira-cloud/api"
 	"github.com/pinpt/agent/integrations/pkg/jiracommonapi"
@@ -42,6 +41,16 @@ func (s *Integration) mutationResult(modelName datamodel.ModelNameType, obj Mode
 	return
 }
 
+// mutationActions are the actions supported in Mutate, advertised in capabilities
+var mutationActions = []string{
+	agent.IntegrationMutationRequestActionIssueAddComment.String(),
+	agent.IntegrationMutationRequestActionIssueSetTitle.String(),
+	agent.IntegrationMutationRequestActionIssueSetStatus.String(),
+	agent.IntegrationMutationRequestActionIssueSetPriority.String(),
+	agent.IntegrationMutationRequestActionIssueSetAssignee.String(),
+	agent.IntegrationMutationRequestActionIssueGetTransitions.String(),
+}
+
 func (s *Integration) Mutate(ctx context.Context, fn, data string, config rpcdef.ExportConfig) (res rpcdef.MutateResult, _ error) {
 
 	rerr := func(err error) {
@@ -156,6 +165,5 @@ func (s *Integration) Mutate(ctx context.Context, fn, data string, config rpcdef
 		return
 	}
 
-	rerr(fmt.Errorf("mutate fn not supported: %v", fn))
-	return
+	return mutate.ResultNotSupported(fn), nil
 }
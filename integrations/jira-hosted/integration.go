@@ -43,9 +43,13 @@ func NewIntegration(logger hclog.Logger) *Integration {
 	return s
 }
 
-func (s *Integration) Init(agent rpcdef.Agent) error {
+func (s *Integration) Init(agent rpcdef.Agent) (rpcdef.Capabilities, error) {
 	s.agent = agent
-	return nil
+	return rpcdef.Capabilities{
+		IntegrationTypes: []string{"WORK"},
+		OnboardKinds:     []rpcdef.OnboardExportType{rpcdef.OnboardExportTypeProjects, rpcdef.OnboardExportTypeWorkConfig},
+		Streaming:        true,
+	}, nil
 }
 
 func ConfigFromMap(data rpcdef.IntegrationConfig) (res jiracommon.Config, rerr error) {
@@ -89,6 +93,7 @@ func (s *Integration) initWithConfig(config rpcdef.ExportConfig, retryRequests b
 	s.clientManager = reqstats.New(reqstats.Opts{
 		Logger:                s.logger,
 		TLSInsecureSkipVerify: true,
+		Agent:                 s.agent,
 	})
 	s.clients = s.clientManager.Clients
 
@@ -2,12 +2,11 @@ package main
 
 import (
 	"context"
-	"errors"
 
+	"github.com/pinpt/agent/integrations/pkg/mutate"
 	"github.com/pinpt/agent/rpcdef"
 )
 
 func (s *Integration) Mutate(ctx context.Context, fn, data string, config rpcdef.ExportConfig) (res rpcdef.MutateResult, rerr error) {
-	rerr = errors.New("mutate not supported")
-	return
+	return mutate.ResultNotSupported(fn), nil
 }
@@ -20,9 +20,13 @@ func NewIntegration(logger hclog.Logger) *Integration {
 	return s
 }
 
-func (s *Integration) Init(agent rpcdef.Agent) error {
+func (s *Integration) Init(agent rpcdef.Agent) (rpcdef.Capabilities, error) {
 	s.agent = agent
-	return nil
+	return rpcdef.Capabilities{
+		IntegrationTypes: []string{"SOURCECODE"},
+		OnboardKinds:     []rpcdef.OnboardExportType{rpcdef.OnboardExportTypeUsers, rpcdef.OnboardExportTypeProjects},
+		Streaming:        true,
+	}, nil
 }
 
 func (s *Integration) Export(ctx context.Context, config rpcdef.ExportConfig) (res rpcdef.ExportResult, _ error) {
@@ -0,0 +1,201 @@
+package extmodels
+
+import (
+	"time"
+
+	"github.com/pinpt/go-common/hash"
+)
+
+// Model names used for exported ci objects.
+const (
+	PipelineModelName  = "cicd.Pipeline"
+	BuildModelName     = "cicd.Build"
+	BuildStepModelName = "cicd.BuildStep"
+)
+
+// Build and build step statuses, normalized from statuses used in source systems.
+const (
+	BuildStatusQueued  = "QUEUED"
+	BuildStatusRunning = "RUNNING"
+	BuildStatusSuccess = "SUCCESS"
+	// BuildStatusPartial is used for builds that succeeded with warnings or failed steps that were allowed to fail
+	BuildStatusPartial  = "PARTIAL"
+	BuildStatusFailure  = "FAILURE"
+	BuildStatusCanceled = "CANCELED"
+	BuildStatusSkipped  = "SKIPPED"
+)
+
+// Kinds of build steps
+const (
+	BuildStepKindStage = "STAGE"
+	BuildStepKindJob   = "JOB"
+)
+
+// Duration returns the duration between started and finished in milliseconds, 0 if any of the times is not set.
+func Duration(started, finished time.Time) int64 {
+	if started.IsZero() || finished.IsZero() || finished.Before(started) {
+		return 0
+	}
+	return int64(finished.Sub(started) / time.Millisecond)
+}
+
+// Pipeline is a build definition.
+type Pipeline struct {
+	CustomerID string
+	RefType    string
+	RefID      string
+	// RepoID is the id of sourcecode.Repo built by the pipeline, empty if the repo is not exported by the integration
+	RepoID string
+	Name   string
+	// Path is the folder of the pipeline
+	Path string
+	// Kind is the type of pipeline in source system, for example yaml or designer
+	Kind        string
+	URL         string
+	CreatedDate Date
+}
+
+// ID returns the id of the pipeline.
+func (s Pipeline) ID() string {
+	return PipelineID(s.CustomerID, s.RefType, s.RefID)
+}
+
+// PipelineID returns the id of the pipeline with refID.
+func PipelineID(customerID, refType, refID string) string {
+	return hash.Values("Pipeline", customerID, refType, refID)
+}
+
+func (s Pipeline) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["repo_id"] = s.RepoID
+	res["name"] = s.Name
+	res["path"] = s.Path
+	res["kind"] = s.Kind
+	res["url"] = s.URL
+	res["created_date"] = s.CreatedDate.ToMap()
+	res["hashcode"] = Hash(s.ID(), s.RepoID, s.Name, s.Path, s.Kind, s.URL, s.CreatedDate.Epoch)
+	return res
+}
+
+// Build is a single run of a pipeline.
+type Build struct {
+	CustomerID string
+	RefType    string
+	RefID      string
+	PipelineID string
+	// RepoID is the id of sourcecode.Repo, empty if the repo is not exported by the integration
+	RepoID string
+	// Number is the build number displayed in source system
+	Number string
+	// Status is one of BuildStatus constants
+	Status string
+	// SourceStatus is the status and result as returned by source system
+	SourceStatus string
+	// Trigger is the reason the build was started, as returned by source system
+	Trigger   string
+	Branch    string
+	CommitSHA string
+	// CommitID is the id of sourcecode.Commit of the built sha
+	CommitID string
+	// PullRequestID is the id of sourcecode.PullRequest for pull request builds
+	PullRequestID    string
+	RequestedByRefID string
+	URL              string
+	QueuedDate       Date
+	StartedDate      Date
+	FinishedDate     Date
+	// Duration is the time from start to finish in milliseconds, 0 for builds that are not finished
+	Duration int64
+}
+
+// ID returns the id of the build.
+func (s Build) ID() string {
+	return BuildID(s.CustomerID, s.RefType, s.RefID)
+}
+
+// BuildID returns the id of the build with refID.
+func BuildID(customerID, refType, refID string) string {
+	return hash.Values("Build", customerID, refType, refID)
+}
+
+func (s Build) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["pipeline_id"] = s.PipelineID
+	res["repo_id"] = s.RepoID
+	res["number"] = s.Number
+	res["status"] = s.Status
+	res["source_status"] = s.SourceStatus
+	res["trigger"] = s.Trigger
+	res["branch"] = s.Branch
+	res["commit_sha"] = s.CommitSHA
+	res["commit_id"] = s.CommitID
+	res["pull_request_id"] = s.PullRequestID
+	res["requested_by_ref_id"] = s.RequestedByRefID
+	res["url"] = s.URL
+	res["queued_date"] = s.QueuedDate.ToMap()
+	res["started_date"] = s.StartedDate.ToMap()
+	res["finished_date"] = s.FinishedDate.ToMap()
+	res["duration"] = s.Duration
+	res["hashcode"] = Hash(s.ID(), s.PipelineID, s.RepoID, s.Number, s.Status, s.SourceStatus, s.Trigger, s.Branch, s.CommitSHA, s.PullRequestID, s.RequestedByRefID, s.URL, s.QueuedDate.Epoch, s.StartedDate.Epoch, s.FinishedDate.Epoch)
+	return res
+}
+
+// BuildStep is a stage or job of a build.
+type BuildStep struct {
+	CustomerID string
+	RefType    string
+	RefID      string
+	BuildID    string
+	// ParentID is the id of the stage containing the job, empty for stages and for jobs of pipelines without stages
+	ParentID string
+	// Kind is one of BuildStepKind constants
+	Kind string
+	Name string
+	// Order is the position of the step within parent
+	Order   int
+	Attempt int
+	// Status is one of BuildStatus constants
+	Status       string
+	StartedDate  Date
+	FinishedDate Date
+	// Duration is the time from start to finish in milliseconds
+	Duration int64
+}
+
+// ID returns the id of the build step.
+func (s BuildStep) ID() string {
+	return BuildStepID(s.CustomerID, s.RefType, s.RefID)
+}
+
+// BuildStepID returns the id of the build step with refID.
+func BuildStepID(customerID, refType, refID string) string {
+	return hash.Values("BuildStep", customerID, refType, refID)
+}
+
+func (s BuildStep) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["build_id"] = s.BuildID
+	res["parent_id"] = s.ParentID
+	res["kind"] = s.Kind
+	res["name"] = s.Name
+	res["order"] = s.Order
+	res["attempt"] = s.Attempt
+	res["status"] = s.Status
+	res["started_date"] = s.StartedDate.ToMap()
+	res["finished_date"] = s.FinishedDate.ToMap()
+	res["duration"] = s.Duration
+	res["hashcode"] = Hash(s.ID(), s.BuildID, s.ParentID, s.Kind, s.Name, s.Order, s.Attempt, s.Status, s.StartedDate.Epoch, s.FinishedDate.Epoch)
+	return res
+}
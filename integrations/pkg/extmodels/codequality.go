@@ -0,0 +1,314 @@
+package extmodels
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pinpt/agent/pkg/ids2"
+	"github.com/pinpt/go-common/hash"
+)
+
+// QualityIssueModelName is the model name used for exported code quality issues.
+const QualityIssueModelName = "codequality.Issue"
+
+// QualityIssue is a bug, vulnerability or code smell found by analysis.
+//
+// It is exported by sonarqube and sarif integrations using the same model name, so that backend handles issues from all tools the same way. Fields that are only set by some tools are documented on the field.
+type QualityIssue struct {
+	CustomerID string
+	RefType    string
+	// RefID is the issue key in sonarqube, or hash of project and fingerprint for sarif
+	RefID     string
+	ProjectID string
+	// Tool is the name of analysis tool, sonarqube for sonarqube issues, sarif tool driver name in lowercase, for example codeql, for sarif issues
+	Tool string
+	// Type is BUG, VULNERABILITY or CODE_SMELL
+	Type string
+	// Severity is BLOCKER, CRITICAL, MAJOR, MINOR or INFO
+	Severity string
+	Rule     string
+	Message  string
+	FilePath string
+	Line     int64
+	// Status is OPEN, CONFIRMED, REOPENED, RESOLVED or CLOSED
+	Status     string
+	Resolution string
+	// Effort is the estimated time to fix, for example 2h1min
+	Effort string
+	Author string
+	Tags   []string
+	// Fingerprint identifies the same issue across analyses. Only set for sarif, sonarqube tracks issues itself and RefID is used instead.
+	Fingerprint string
+	CreatedDate Date
+	// UpdatedDate is the date of the last change of the issue. Tools must only change it when issue changes, since it is part of hashcode.
+	UpdatedDate Date
+	ClosedDate  Date
+}
+
+// ID returns the id of the issue.
+func (s QualityIssue) ID() string {
+	return hash.Values("Issue", s.CustomerID, s.RefType, s.RefID)
+}
+
+func (s QualityIssue) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["project_id"] = s.ProjectID
+	res["tool"] = s.Tool
+	res["type"] = s.Type
+	res["severity"] = s.Severity
+	res["rule"] = s.Rule
+	res["message"] = s.Message
+	res["file_path"] = s.FilePath
+	res["line"] = s.Line
+	res["status"] = s.Status
+	res["resolution"] = s.Resolution
+	res["effort"] = s.Effort
+	res["author"] = s.Author
+	res["tags"] = s.Tags
+	res["fingerprint"] = s.Fingerprint
+	res["created_date"] = s.CreatedDate.ToMap()
+	res["updated_date"] = s.UpdatedDate.ToMap()
+	res["closed_date"] = s.ClosedDate.ToMap()
+	res["hashcode"] = Hash(s.ID(), s.ProjectID, s.Tool, s.Type, s.Severity, s.Rule, s.Message, s.FilePath, s.Line, s.Status, s.Resolution, s.Effort, s.Author, strings.Join(s.Tags, ","), s.Fingerprint, s.UpdatedDate.Epoch, s.ClosedDate.Epoch)
+	return res
+}
+
+// CoverageModelName is the model name used for exported test coverage.
+const CoverageModelName = "codequality.Coverage"
+
+// Coverage is test coverage of repo or directory in repo at commit.
+type Coverage struct {
+	CustomerID string
+	RefType    string
+	RefID      string
+	ProjectID  string
+	// RepoID is the id of sourcecode.Repo, empty if repo is not known
+	RepoID string
+	// CommitID is the id of sourcecode.Commit, empty if commit is not known
+	CommitID  string
+	CommitSHA string
+	// Directory is the path relative to repo root, empty for the whole repo
+	Directory string
+	// Format is the format of reports, lcov, cobertura or go. Comma separated if multiple reports were merged.
+	Format          string
+	LinesToCover    int64
+	CoveredLines    int64
+	BranchesToCover int64
+	CoveredBranches int64
+	// Coverage is the combined line and branch coverage in percent, same as sonarqube coverage metric
+	Coverage       float64
+	LineCoverage   float64
+	BranchCoverage float64
+	CreatedDate    Date
+}
+
+// ID returns the id of the coverage.
+func (s Coverage) ID() string {
+	return hash.Values("Coverage", s.CustomerID, s.RefType, s.RefID)
+}
+
+func (s Coverage) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["project_id"] = s.ProjectID
+	res["repo_id"] = s.RepoID
+	res["commit_id"] = s.CommitID
+	res["commit_sha"] = s.CommitSHA
+	res["directory"] = s.Directory
+	res["format"] = s.Format
+	res["lines_to_cover"] = s.LinesToCover
+	res["covered_lines"] = s.CoveredLines
+	res["branches_to_cover"] = s.BranchesToCover
+	res["covered_branches"] = s.CoveredBranches
+	res["coverage"] = s.Coverage
+	res["line_coverage"] = s.LineCoverage
+	res["branch_coverage"] = s.BranchCoverage
+	res["created_date"] = s.CreatedDate.ToMap()
+	res["hashcode"] = Hash(s.ID(), s.ProjectID, s.RepoID, s.CommitID, s.Format, s.LinesToCover, s.CoveredLines, s.BranchesToCover, s.CoveredBranches, s.CreatedDate.Epoch)
+	return res
+}
+
+// AnalysisModelName is the model name used for exported branch and pull request analyses.
+const AnalysisModelName = "codequality.Analysis"
+
+const (
+	// AnalysisKindBranch is the kind of branch analysis
+	AnalysisKindBranch = "branch"
+	// AnalysisKindPullRequest is the kind of pull request analysis
+	AnalysisKindPullRequest = "pull_request"
+)
+
+// Analysis contains measures and quality gate status of the last analysis of branch or pull request.
+type Analysis struct {
+	CustomerID string
+	RefType    string
+	RefID      string
+	ProjectID  string
+	// Kind is AnalysisKindBranch or AnalysisKindPullRequest
+	Kind string
+	// Branch is the branch name, or the source branch of pull request
+	Branch string
+	// Main is true for the main branch of project
+	Main bool
+	// PullRequest is the pull request key, the pull request number for github, gitlab, azure and bitbucket
+	PullRequest      string
+	PullRequestTitle string
+	PullRequestURL   string
+	// BaseBranch is the target branch of pull request
+	BaseBranch string
+	// QualityGateStatus is OK, WARN or ERROR
+	QualityGateStatus string
+	AnalysisDate      Date
+	// Measures contain values of configured metrics by metric key. For metrics on new code, such as new_coverage, the value is for new code period of branch or for pull request changes.
+	Measures map[string]string
+	// Repo is the repo bound to project, nil if not known
+	Repo *AnalysisRepo
+}
+
+// AnalysisRepo is the source repo of analyzed project, configured in sonarqube ALM integration settings.
+type AnalysisRepo struct {
+	// RefType is the ref type of the repo, same as used by sourcecode integrations: github, gitlab, azure or bitbucket
+	RefType string
+	// Name is the repo name in the same format as exported by sourcecode integrations, for example org/repo for github or project/repo for azure
+	Name string
+	// RefID is the repo ref id, only known for gitlab where the binding contains the project id
+	RefID string
+	URL   string
+}
+
+// ID returns the id of the analysis.
+func (s Analysis) ID() string {
+	return hash.Values("Analysis", s.CustomerID, s.RefType, s.RefID)
+}
+
+func (s Analysis) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["project_id"] = s.ProjectID
+	res["kind"] = s.Kind
+	res["branch"] = s.Branch
+	res["main"] = s.Main
+	res["pull_request"] = s.PullRequest
+	res["pull_request_title"] = s.PullRequestTitle
+	res["pull_request_url"] = s.PullRequestURL
+	res["base_branch"] = s.BaseBranch
+	res["quality_gate_status"] = s.QualityGateStatus
+	res["analysis_date"] = s.AnalysisDate.ToMap()
+	res["measures"] = s.Measures
+	if s.Repo != nil {
+		res["repo_ref_type"] = s.Repo.RefType
+		res["repo_name"] = s.Repo.Name
+		res["repo_ref_id"] = s.Repo.RefID
+		if s.Repo.RefID != "" {
+			res["repo_id"] = ids2.New(s.CustomerID, s.Repo.RefType).CodeRepo(s.Repo.RefID)
+		}
+	}
+	var measures []string
+	for k, v := range s.Measures {
+		measures = append(measures, k+"="+v)
+	}
+	sort.Strings(measures)
+	res["hashcode"] = Hash(s.ID(), s.ProjectID, s.Branch, s.PullRequestTitle, s.BaseBranch, s.QualityGateStatus, s.AnalysisDate.Epoch, strings.Join(measures, ","), res["repo_name"], res["repo_ref_id"])
+	return res
+}
+
+// QualityGateStatusModelName is the model name used for exported quality gate statuses.
+const QualityGateStatusModelName = "codequality.QualityGateStatus"
+
+// QualityGateStatus is the quality gate result of one project analysis.
+type QualityGateStatus struct {
+	CustomerID string
+	RefType    string
+	RefID      string
+	ProjectID  string
+	// QualityGate is the name of the quality gate currently used by project
+	QualityGate string
+	// Status is OK, WARN or ERROR
+	Status string
+	Passed bool
+	// CreatedDate is the date of analysis
+	CreatedDate Date
+}
+
+// ID returns the id of the quality gate status.
+func (s QualityGateStatus) ID() string {
+	return hash.Values("QualityGateStatus", s.CustomerID, s.RefType, s.RefID)
+}
+
+func (s QualityGateStatus) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["project_id"] = s.ProjectID
+	res["quality_gate"] = s.QualityGate
+	res["status"] = s.Status
+	res["passed"] = s.Passed
+	res["created_date"] = s.CreatedDate.ToMap()
+	res["hashcode"] = Hash(s.ID(), s.ProjectID, s.QualityGate, s.Status)
+	return res
+}
+
+// SecurityHotspotModelName is the model name used for exported security hotspots.
+const SecurityHotspotModelName = "codequality.SecurityHotspot"
+
+// SecurityHotspot is a security sensitive piece of code that needs to be reviewed.
+type SecurityHotspot struct {
+	CustomerID string
+	RefType    string
+	// RefID is the hotspot key
+	RefID            string
+	ProjectID        string
+	Rule             string
+	SecurityCategory string
+	// VulnerabilityProbability is HIGH, MEDIUM or LOW
+	VulnerabilityProbability string
+	Message                  string
+	FilePath                 string
+	Line                     int64
+	// Status is TO_REVIEW or REVIEWED
+	Status string
+	// Resolution is FIXED, SAFE or ACKNOWLEDGED for reviewed hotspots
+	Resolution  string
+	Author      string
+	CreatedDate Date
+	UpdatedDate Date
+}
+
+// ID returns the id of the hotspot.
+func (s SecurityHotspot) ID() string {
+	return hash.Values("Hotspot", s.CustomerID, s.RefType, s.RefID)
+}
+
+func (s SecurityHotspot) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["project_id"] = s.ProjectID
+	res["rule"] = s.Rule
+	res["security_category"] = s.SecurityCategory
+	res["vulnerability_probability"] = s.VulnerabilityProbability
+	res["message"] = s.Message
+	res["file_path"] = s.FilePath
+	res["line"] = s.Line
+	res["status"] = s.Status
+	res["resolution"] = s.Resolution
+	res["author"] = s.Author
+	res["created_date"] = s.CreatedDate.ToMap()
+	res["updated_date"] = s.UpdatedDate.ToMap()
+	res["hashcode"] = Hash(s.ID(), s.ProjectID, s.Rule, s.SecurityCategory, s.VulnerabilityProbability, s.Message, s.FilePath, s.Line, s.Status, s.Resolution, s.Author, s.UpdatedDate.Epoch)
+	return res
+}
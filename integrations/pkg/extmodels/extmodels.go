@@ -0,0 +1,89 @@
+// Package extmodels contains models shared by integrations, that are not available in integration-sdk. Models are grouped by integration-sdk package in separate files, model names use the same package prefixes.
+package extmodels
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pinpt/agent/pkg/date"
+	"github.com/pinpt/go-common/hash"
+)
+
+// Model is implemented by all models in this package.
+type Model interface {
+	// ID returns the id of the object, created from customer id, ref type and ref id or other fields identifying the object.
+	ID() string
+	// ToMap returns the object in the format sent to backend. Result includes hashcode created using Hash, which is used by dedup store to skip objects that did not change since the last export, so it must include all fields that can change.
+	ToMap() map[string]interface{}
+}
+
+// models are empty instances of all models in this package, by model name. work.Issue is not included, since WorkIssue only adds fields to integration-sdk model.
+var models = map[string]Model{
+	QualityIssueModelName:       QualityIssue{},
+	CoverageModelName:           Coverage{},
+	AnalysisModelName:           Analysis{},
+	QualityGateStatusModelName:  QualityGateStatus{},
+	SecurityHotspotModelName:    SecurityHotspot{},
+	EnvironmentModelName:        Environment{},
+	DeploymentModelName:         Deployment{},
+	DeploymentStatusModelName:   DeploymentStatus{},
+	CommitFileModelName:         CommitFile{},
+	PipelineModelName:           Pipeline{},
+	BuildModelName:              Build{},
+	BuildStepModelName:          BuildStep{},
+	ClassificationNodeModelName: ClassificationNode{},
+	TeamModelName:               Team{},
+	TeamCapacityModelName:       TeamCapacity{},
+	VersionModelName:            Version{},
+	ComponentModelName:          Component{},
+	IncidentModelName:           Incident{},
+}
+
+// New returns an empty model with modelName. Returns nil if the model is not defined in this package.
+func New(modelName string) Model {
+	return models[modelName]
+}
+
+// ModelNames returns the names of all models defined in this package, sorted.
+func ModelNames() (res []string) {
+	for k := range models {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return
+}
+
+// Hash returns the hashcode of object from id and all fields that can change.
+func Hash(id interface{}, fields ...interface{}) string {
+	return hash.Values(append([]interface{}{id}, fields...)...)
+}
+
+// Date is a date in the same format as used in integration-sdk models. Used by all models in this package.
+type Date struct {
+	Epoch   int64
+	Offset  int64
+	Rfc3339 string
+}
+
+// NewDate converts t to Date. Returns empty date for zero time.
+func NewDate(t time.Time) (res Date) {
+	date.ConvertToModel(t, &res)
+	return
+}
+
+// Time returns the date as time in utc. Returns zero time for empty date.
+func (s Date) Time() time.Time {
+	if s.Epoch == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, s.Epoch*int64(time.Millisecond)).UTC()
+}
+
+// ToMap returns date in the format used in exported objects.
+func (s Date) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"epoch":   s.Epoch,
+		"offset":  s.Offset,
+		"rfc3339": s.Rfc3339,
+	}
+}
@@ -0,0 +1,57 @@
+package extmodels
+
+import (
+	"github.com/pinpt/go-common/hash"
+)
+
+// IncidentModelName is the model name used for exported incidents.
+const IncidentModelName = "ops.Incident"
+
+// Incident is a production incident, used together with deployments for change failure rate and time to restore.
+type Incident struct {
+	CustomerID string
+	RefType    string
+	RefID      string
+	Title      string
+	// Status is OPEN, ACKNOWLEDGED or RESOLVED
+	Status      string
+	Severity    string
+	Description string
+	URL         string
+	Service     string
+	Environment string
+	// CreatorRefID is the username or email of the reporter, as passed by the tool
+	CreatorRefID string
+	// DeploymentID is the id of sourcecode.Deployment that caused the incident, empty if not known
+	DeploymentID     string
+	CreatedDate      Date
+	AcknowledgedDate Date
+	ResolvedDate     Date
+}
+
+// ID returns the id of the incident.
+func (s Incident) ID() string {
+	return hash.Values("Incident", s.CustomerID, s.RefType, s.RefID)
+}
+
+func (s Incident) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["title"] = s.Title
+	res["status"] = s.Status
+	res["severity"] = s.Severity
+	res["description"] = s.Description
+	res["url"] = s.URL
+	res["service"] = s.Service
+	res["environment"] = s.Environment
+	res["creator_ref_id"] = s.CreatorRefID
+	res["deployment_id"] = s.DeploymentID
+	res["created_date"] = s.CreatedDate.ToMap()
+	res["acknowledged_date"] = s.AcknowledgedDate.ToMap()
+	res["resolved_date"] = s.ResolvedDate.ToMap()
+	res["hashcode"] = Hash(s.ID(), s.Title, s.Status, s.Severity, s.Description, s.URL, s.Service, s.Environment, s.CreatorRefID, s.DeploymentID, s.CreatedDate.Epoch, s.AcknowledgedDate.Epoch, s.ResolvedDate.Epoch)
+	return res
+}
@@ -0,0 +1,222 @@
+package extmodels
+
+import (
+	"strings"
+
+	"github.com/pinpt/go-common/hash"
+)
+
+// Model names used for exported deployment objects.
+const (
+	EnvironmentModelName      = "sourcecode.Environment"
+	DeploymentModelName       = "sourcecode.Deployment"
+	DeploymentStatusModelName = "sourcecode.DeploymentStatus"
+)
+
+// Deployment states, normalized from states used in github and gitlab.
+const (
+	DeploymentStatePending  = "PENDING"
+	DeploymentStateRunning  = "RUNNING"
+	DeploymentStateSuccess  = "SUCCESS"
+	DeploymentStateFailure  = "FAILURE"
+	DeploymentStateCanceled = "CANCELED"
+	// DeploymentStateInactive is used when deployment was replaced by a newer deployment to the same environment or the environment was removed
+	DeploymentStateInactive = "INACTIVE"
+)
+
+// IsDeploymentFinished returns true if deployment in state is completed, successfully or not.
+func IsDeploymentFinished(state string) bool {
+	switch state {
+	case DeploymentStateSuccess, DeploymentStateFailure, DeploymentStateCanceled:
+		return true
+	}
+	return false
+}
+
+// EnvironmentID returns the id of environment in repo. Environments are identified by name, since that is the only environment field available in deployments.
+func EnvironmentID(customerID, refType, repoID, name string) string {
+	return hash.Values("Environment", customerID, refType, repoID, name)
+}
+
+// Environment is a deployment target of the repo, such as staging or production.
+type Environment struct {
+	CustomerID string
+	RefType    string
+	// RefID is the environment id in source system, or the name if source system does not have ids for environments
+	RefID       string
+	RepoID      string
+	Name        string
+	URL         string
+	CreatedDate Date
+	UpdatedDate Date
+}
+
+// ID returns the id of the environment.
+func (s Environment) ID() string {
+	return EnvironmentID(s.CustomerID, s.RefType, s.RepoID, s.Name)
+}
+
+func (s Environment) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["repo_id"] = s.RepoID
+	res["name"] = s.Name
+	res["url"] = s.URL
+	res["created_date"] = s.CreatedDate.ToMap()
+	res["updated_date"] = s.UpdatedDate.ToMap()
+	res["hashcode"] = Hash(s.ID(), s.RefID, s.URL, s.CreatedDate.Epoch, s.UpdatedDate.Epoch)
+	return res
+}
+
+// Deployment is a deployment of commit to environment.
+type Deployment struct {
+	CustomerID string
+	RefType    string
+	RefID      string
+	// RepoID is the id of sourcecode.Repo, empty for deployments pushed by tools when repo is not known
+	RepoID string
+	// Service is the name of deployed service, only set for deployments pushed by tools
+	Service       string
+	EnvironmentID string
+	Environment   string
+	// Ref is the branch or tag that was deployed
+	Ref       string
+	CommitSHA string
+	// CommitID is the id of sourcecode.Commit of the deployed sha
+	CommitID string
+	// PullRequestIDs are ids of merged sourcecode.PullRequest associated with the deployed commit
+	PullRequestIDs []string
+	CreatorRefID   string
+	Description    string
+	// State is the latest state of deployment, one of DeploymentState constants
+	State       string
+	URL         string
+	CreatedDate Date
+	UpdatedDate Date
+	// FinishedDate is the time when deployment reached SUCCESS, FAILURE or CANCELED state, empty if not finished
+	FinishedDate Date
+}
+
+// ID returns the id of the deployment.
+func (s Deployment) ID() string {
+	return DeploymentID(s.CustomerID, s.RefType, s.RefID)
+}
+
+// DeploymentID returns the id of the deployment with refID.
+func DeploymentID(customerID, refType, refID string) string {
+	return hash.Values("Deployment", customerID, refType, refID)
+}
+
+func (s Deployment) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["repo_id"] = s.RepoID
+	res["service"] = s.Service
+	res["environment_id"] = s.EnvironmentID
+	res["environment"] = s.Environment
+	res["ref"] = s.Ref
+	res["commit_sha"] = s.CommitSHA
+	res["commit_id"] = s.CommitID
+	res["pull_request_ids"] = s.PullRequestIDs
+	res["creator_ref_id"] = s.CreatorRefID
+	res["description"] = s.Description
+	res["state"] = s.State
+	res["url"] = s.URL
+	res["created_date"] = s.CreatedDate.ToMap()
+	res["updated_date"] = s.UpdatedDate.ToMap()
+	res["finished_date"] = s.FinishedDate.ToMap()
+	res["hashcode"] = Hash(s.ID(), s.RepoID, s.Service, s.EnvironmentID, s.Ref, s.CommitSHA, strings.Join(s.PullRequestIDs, ","), s.CreatorRefID, s.Description, s.State, s.URL, s.UpdatedDate.Epoch, s.FinishedDate.Epoch)
+	return res
+}
+
+// DeploymentStatus is a state change of deployment.
+type DeploymentStatus struct {
+	CustomerID   string
+	RefType      string
+	RefID        string
+	RepoID       string
+	DeploymentID string
+	// State is one of DeploymentState constants
+	State string
+	// SourceState is the state as returned by source system
+	SourceState    string
+	Description    string
+	EnvironmentURL string
+	LogURL         string
+	CreatorRefID   string
+	CreatedDate    Date
+}
+
+// ID returns the id of the deployment status.
+func (s DeploymentStatus) ID() string {
+	return hash.Values("DeploymentStatus", s.CustomerID, s.RefType, s.RefID)
+}
+
+func (s DeploymentStatus) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["repo_id"] = s.RepoID
+	res["deployment_id"] = s.DeploymentID
+	res["state"] = s.State
+	res["source_state"] = s.SourceState
+	res["description"] = s.Description
+	res["environment_url"] = s.EnvironmentURL
+	res["log_url"] = s.LogURL
+	res["creator_ref_id"] = s.CreatorRefID
+	res["created_date"] = s.CreatedDate.ToMap()
+	res["hashcode"] = Hash(s.ID(), s.RepoID, s.DeploymentID, s.State, s.SourceState, s.Description, s.EnvironmentURL, s.LogURL, s.CreatorRefID, s.CreatedDate.Epoch)
+	return res
+}
+
+// CommitFileModelName is the model name used for files changed in commits, exported by integrations for sources that are not processed using git.
+const CommitFileModelName = "sourcecode.CommitFile"
+
+// Change types of commit files
+const (
+	CommitFileAdded    = "ADDED"
+	CommitFileModified = "MODIFIED"
+	CommitFileDeleted  = "DELETED"
+	CommitFileRenamed  = "RENAMED"
+)
+
+// CommitFile is a file changed in a commit.
+type CommitFile struct {
+	CustomerID string
+	RefType    string
+	RepoID     string
+	// CommitID is the id of sourcecode.Commit
+	CommitID string
+	Path     string
+	// ChangeType is one of CommitFile change type constants
+	ChangeType string
+	// SourceChangeType is the change type as returned by source system
+	SourceChangeType string
+}
+
+// ID returns the id of the commit file.
+func (s CommitFile) ID() string {
+	return hash.Values("CommitFile", s.CustomerID, s.RefType, s.CommitID, s.Path)
+}
+
+func (s CommitFile) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["repo_id"] = s.RepoID
+	res["commit_id"] = s.CommitID
+	res["path"] = s.Path
+	res["change_type"] = s.ChangeType
+	res["source_change_type"] = s.SourceChangeType
+	res["hashcode"] = Hash(s.ID(), s.RepoID, s.ChangeType, s.SourceChangeType)
+	return res
+}
@@ -0,0 +1,367 @@
+package extmodels
+
+import (
+	"strings"
+
+	"github.com/pinpt/go-common/hash"
+	pjson "github.com/pinpt/go-common/json"
+	"github.com/pinpt/integration-sdk/work"
+)
+
+// Model names used for exported work objects.
+const (
+	ClassificationNodeModelName = "work.ClassificationNode"
+	TeamModelName               = "work.Team"
+	TeamCapacityModelName       = "work.TeamCapacity"
+	VersionModelName            = "work.Version"
+	ComponentModelName          = "work.Component"
+)
+
+// Kinds of classification nodes
+const (
+	ClassificationNodeKindArea      = "AREA"
+	ClassificationNodeKindIteration = "ITERATION"
+)
+
+// ClassificationNode is an area or iteration. Nodes form a tree per project and kind, linked using ParentID.
+type ClassificationNode struct {
+	CustomerID string
+	RefType    string
+	RefID      string
+	ProjectID  string
+	// Kind is one of ClassificationNodeKind constants
+	Kind string
+	Name string
+	// Path is the full path of the node, in the same format as used in issue fields, for example Project\Team\Backend
+	Path string
+	// ParentID is the id of the parent node, empty for root nodes
+	ParentID string
+	// SprintID is the id of work.Sprint, only set for iterations
+	SprintID   string
+	StartDate  Date
+	FinishDate Date
+}
+
+// ID returns the id of the node.
+func (s ClassificationNode) ID() string {
+	return ClassificationNodeID(s.CustomerID, s.RefType, s.RefID)
+}
+
+// ClassificationNodeID returns the id of the node with refID.
+func ClassificationNodeID(customerID, refType, refID string) string {
+	if refID == "" {
+		return ""
+	}
+	return hash.Values("ClassificationNode", customerID, refType, refID)
+}
+
+func (s ClassificationNode) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["project_id"] = s.ProjectID
+	res["kind"] = s.Kind
+	res["name"] = s.Name
+	res["path"] = s.Path
+	res["parent_id"] = s.ParentID
+	res["sprint_id"] = s.SprintID
+	res["start_date"] = s.StartDate.ToMap()
+	res["finish_date"] = s.FinishDate.ToMap()
+	res["hashcode"] = Hash(s.ID(), s.ProjectID, s.Kind, s.Name, s.Path, s.ParentID, s.SprintID, s.StartDate.Epoch, s.FinishDate.Epoch)
+	return res
+}
+
+// Team is a team with its area and iteration settings.
+type Team struct {
+	CustomerID string
+	RefType    string
+	RefID      string
+	ProjectID  string
+	Name       string
+	// DefaultAreaPath is the area assigned to new issues created by the team
+	DefaultAreaPath string
+	// AreaPaths are the areas owned by the team
+	AreaPaths            []TeamAreaPath
+	BacklogIterationPath string
+	DefaultIterationPath string
+	// WorkingDays are lowercase days of week, for example monday
+	WorkingDays []string
+}
+
+// TeamAreaPath is an area owned by a team.
+type TeamAreaPath struct {
+	Path string
+	// IncludeChildren is true if the team also owns all areas below Path
+	IncludeChildren bool
+}
+
+// ID returns the id of the team.
+func (s Team) ID() string {
+	return TeamID(s.CustomerID, s.RefType, s.RefID)
+}
+
+// TeamID returns the id of the team with refID.
+func TeamID(customerID, refType, refID string) string {
+	return hash.Values("Team", customerID, refType, refID)
+}
+
+func (s Team) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["project_id"] = s.ProjectID
+	res["name"] = s.Name
+	res["default_area_path"] = s.DefaultAreaPath
+	var areas []map[string]interface{}
+	for _, a := range s.AreaPaths {
+		areas = append(areas, map[string]interface{}{
+			"path":             a.Path,
+			"include_children": a.IncludeChildren,
+		})
+	}
+	res["area_paths"] = areas
+	res["backlog_iteration_path"] = s.BacklogIterationPath
+	res["default_iteration_path"] = s.DefaultIterationPath
+	res["working_days"] = s.WorkingDays
+	res["hashcode"] = Hash(s.ID(), s.ProjectID, s.Name, s.DefaultAreaPath, pjson.Stringify(s.AreaPaths), s.BacklogIterationPath, s.DefaultIterationPath, strings.Join(s.WorkingDays, ","))
+	return res
+}
+
+// TeamCapacity is the planned capacity of a team in one iteration.
+type TeamCapacity struct {
+	CustomerID string
+	RefType    string
+	// RefID is the team ref id and iteration ref id separated by /
+	RefID     string
+	ProjectID string
+	// TeamID is the id of Team
+	TeamID string
+	// SprintID is the id of work.Sprint for the iteration
+	SprintID string
+	// TeamDaysOff are days off for the whole team
+	TeamDaysOff []DateRange
+	Members     []MemberCapacity
+}
+
+// MemberCapacity is the capacity of one team member in an iteration.
+type MemberCapacity struct {
+	UserRefID  string
+	Activities []Activity
+	DaysOff    []DateRange
+}
+
+// Activity is capacity planned for one type of work, for example Development or Testing.
+type Activity struct {
+	Name string
+	// CapacityPerDay is in hours
+	CapacityPerDay float64
+}
+
+// DateRange is a range of days, both start and end are included.
+type DateRange struct {
+	Start Date
+	End   Date
+}
+
+func (s DateRange) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"start": s.Start.ToMap(),
+		"end":   s.End.ToMap(),
+	}
+}
+
+func dateRangesToMap(ranges []DateRange) (res []map[string]interface{}) {
+	for _, r := range ranges {
+		res = append(res, r.toMap())
+	}
+	return
+}
+
+// ID returns the id of the team capacity.
+func (s TeamCapacity) ID() string {
+	return hash.Values("TeamCapacity", s.CustomerID, s.RefType, s.RefID)
+}
+
+func (s TeamCapacity) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["project_id"] = s.ProjectID
+	res["team_id"] = s.TeamID
+	res["sprint_id"] = s.SprintID
+	res["team_days_off"] = dateRangesToMap(s.TeamDaysOff)
+	var members []map[string]interface{}
+	for _, m := range s.Members {
+		var activities []map[string]interface{}
+		for _, a := range m.Activities {
+			activities = append(activities, map[string]interface{}{
+				"name":             a.Name,
+				"capacity_per_day": a.CapacityPerDay,
+			})
+		}
+		members = append(members, map[string]interface{}{
+			"user_ref_id": m.UserRefID,
+			"activities":  activities,
+			"days_off":    dateRangesToMap(m.DaysOff),
+		})
+	}
+	res["members"] = members
+	res["hashcode"] = Hash(s.ID(), s.ProjectID, s.TeamID, s.SprintID, pjson.Stringify(s.TeamDaysOff), pjson.Stringify(s.Members))
+	return res
+}
+
+// Version is a release of a project, in jira also called fix version.
+type Version struct {
+	CustomerID  string
+	RefType     string
+	RefID       string
+	ProjectID   string
+	Name        string
+	Description string
+	Released    bool
+	Archived    bool
+	StartDate   Date
+	ReleaseDate Date
+}
+
+// ID returns the id of the version.
+func (s Version) ID() string {
+	return VersionID(s.CustomerID, s.RefType, s.RefID)
+}
+
+// VersionID returns the id of the version with refID.
+func VersionID(customerID, refType, refID string) string {
+	return hash.Values("Version", customerID, refType, refID)
+}
+
+func (s Version) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["project_id"] = s.ProjectID
+	res["name"] = s.Name
+	res["description"] = s.Description
+	res["released"] = s.Released
+	res["archived"] = s.Archived
+	res["start_date"] = s.StartDate.ToMap()
+	res["release_date"] = s.ReleaseDate.ToMap()
+	res["hashcode"] = Hash(s.ID(), s.ProjectID, s.Name, s.Description, s.Released, s.Archived, s.StartDate.Epoch, s.ReleaseDate.Epoch)
+	return res
+}
+
+// Component is a part of a project that issues can be grouped by.
+type Component struct {
+	CustomerID  string
+	RefType     string
+	RefID       string
+	ProjectID   string
+	Name        string
+	Description string
+	// LeadRefID is the ref id of the user leading the component, empty if not set
+	LeadRefID string
+}
+
+// ID returns the id of the component.
+func (s Component) ID() string {
+	return ComponentID(s.CustomerID, s.RefType, s.RefID)
+}
+
+// ComponentID returns the id of the component with refID.
+func ComponentID(customerID, refType, refID string) string {
+	return hash.Values("Component", customerID, refType, refID)
+}
+
+func (s Component) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["ref_id"] = s.RefID
+	res["project_id"] = s.ProjectID
+	res["name"] = s.Name
+	res["description"] = s.Description
+	res["lead_ref_id"] = s.LeadRefID
+	res["hashcode"] = Hash(s.ID(), s.ProjectID, s.Name, s.Description, s.LeadRefID)
+	return res
+}
+
+// Fields of issue changelog that are not available in work.IssueChangeLogField
+const (
+	// ChangeLogFieldAreaPath is a change of area, from and to are area paths
+	ChangeLogFieldAreaPath = "AREA_PATH"
+	// ChangeLogFieldFixVersionIDs is a fix version added or removed, from or to is the id of Version
+	ChangeLogFieldFixVersionIDs = "FIX_VERSION_IDS"
+	// ChangeLogFieldAffectsVersionIDs is an affects version added or removed, from or to is the id of Version
+	ChangeLogFieldAffectsVersionIDs = "AFFECTS_VERSION_IDS"
+	// ChangeLogFieldComponentIDs is a component added or removed, from or to is the id of Component
+	ChangeLogFieldComponentIDs = "COMPONENT_IDS"
+)
+
+// IssueChangeLog is a change of issue field that is not available in work.IssueChangeLogField.
+type IssueChangeLog struct {
+	RefID string
+	// Field is one of ChangeLogField constants
+	Field       string
+	From        string
+	FromString  string
+	To          string
+	ToString    string
+	UserID      string
+	Ordinal     int64
+	CreatedDate Date
+}
+
+func (s IssueChangeLog) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"ref_id":       s.RefID,
+		"field":        s.Field,
+		"from":         s.From,
+		"from_string":  s.FromString,
+		"to":           s.To,
+		"to_string":    s.ToString,
+		"user_id":      s.UserID,
+		"ordinal":      s.Ordinal,
+		"created_date": s.CreatedDate.ToMap(),
+	}
+}
+
+// WorkIssue is work.Issue with additional fields.
+type WorkIssue struct {
+	*work.Issue
+	// AreaID is the id of ClassificationNode of the issue area
+	AreaID   string
+	AreaPath string
+	// FixVersionIDs are ids of Version the issue is planned to be fixed in
+	FixVersionIDs []string
+	// AffectsVersionIDs are ids of Version the issue was found in
+	AffectsVersionIDs []string
+	// ComponentIDs are ids of Component
+	ComponentIDs []string
+	// ExtraChangeLog contains changes of fields that are not available in work.IssueChangeLogField, exported in extra_change_log
+	ExtraChangeLog []IssueChangeLog
+}
+
+func (s WorkIssue) ToMap() map[string]interface{} {
+	res := s.Issue.ToMap()
+	res["area_id"] = s.AreaID
+	res["area_path"] = s.AreaPath
+	res["fix_version_ids"] = s.FixVersionIDs
+	res["affects_version_ids"] = s.AffectsVersionIDs
+	res["component_ids"] = s.ComponentIDs
+	var changelog []map[string]interface{}
+	for _, c := range s.ExtraChangeLog {
+		changelog = append(changelog, c.toMap())
+	}
+	res["extra_change_log"] = changelog
+	// include additional fields in hashcode, otherwise changes only to them would be skipped by dedup store
+	res["hashcode"] = Hash(res["hashcode"], s.AreaID, s.AreaPath, strings.Join(s.FixVersionIDs, ","), strings.Join(s.AffectsVersionIDs, ","), strings.Join(s.ComponentIDs, ","), pjson.Stringify(s.ExtraChangeLog))
+	return res
+}
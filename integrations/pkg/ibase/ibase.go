@@ -20,8 +20,8 @@ func MainFunc(construct func(logger hclog.Logger) rpcdef.Integration) {
 		"integration": &rpcdef.IntegrationPlugin{Impl: impl},
 	}
 	plugin.Serve(&plugin.ServeConfig{
-		HandshakeConfig: rpcdef.Handshake,
-		Plugins:         pluginMap,
-		GRPCServer:      plugin.DefaultGRPCServer,
+		HandshakeConfig:  rpcdef.Handshake,
+		VersionedPlugins: rpcdef.VersionedPlugins(pluginMap),
+		GRPCServer:       plugin.DefaultGRPCServer,
 	})
 }
@@ -183,6 +183,16 @@ func (s *JiraCommon) issuesAndChangelogsForProject(
 
 	logger.Info("processing issues and changelogs for project", "project", project.Key)
 
+	// versions and components are always exported in full, there is no way to get only updated ones
+	err := s.exportVersions(ctx, project)
+	if err != nil {
+		return err
+	}
+	err = s.exportComponents(ctx, project)
+	if err != nil {
+		return err
+	}
+
 	qc := s.CommonQC()
 	issueResolver := newIssueResolver(qc)
 
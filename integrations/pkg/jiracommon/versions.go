@@ -0,0 +1,56 @@
+package jiracommon
+
+import (
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/agent/integrations/pkg/jiracommonapi"
+	"github.com/pinpt/agent/integrations/pkg/repoprojects"
+	"github.com/pinpt/go-common/datamodel"
+)
+
+func (s *JiraCommon) exportVersions(ctx *repoprojects.ProjectCtx, project Project) error {
+	s.opts.Logger.Debug("exporting versions", "project", project.Key)
+
+	sender, err := ctx.Session(datamodel.ModelNameType(extmodels.VersionModelName))
+	if err != nil {
+		return err
+	}
+	res, err := jiracommonapi.Versions(s.CommonQC(), project.Project)
+	if err != nil {
+		return err
+	}
+	err = sender.SetTotal(len(res))
+	if err != nil {
+		return err
+	}
+	for _, item := range res {
+		err := sender.Send(item)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *JiraCommon) exportComponents(ctx *repoprojects.ProjectCtx, project Project) error {
+	s.opts.Logger.Debug("exporting components", "project", project.Key)
+
+	sender, err := ctx.Session(datamodel.ModelNameType(extmodels.ComponentModelName))
+	if err != nil {
+		return err
+	}
+	res, err := jiracommonapi.Components(s.CommonQC(), project.Project)
+	if err != nil {
+		return err
+	}
+	err = sender.SetTotal(len(res))
+	if err != nil {
+		return err
+	}
+	for _, item := range res {
+		err := sender.Send(item)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/hashicorp/go-hclog"
 
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
 	"github.com/pinpt/agent/pkg/ids"
 	pstrings "github.com/pinpt/go-common/strings"
 )
@@ -51,6 +52,14 @@ func (s QueryContext) UserID(refID string) string {
 	return ids.WorkUser(s.CustomerID, "jira", refID)
 }
 
+func (s QueryContext) VersionID(refID string) string {
+	return extmodels.VersionID(s.CustomerID, "jira", refID)
+}
+
+func (s QueryContext) ComponentID(refID string) string {
+	return extmodels.ComponentID(s.CustomerID, "jira", refID)
+}
+
 type PageInfo struct {
 	Total      int
 	MaxResults int
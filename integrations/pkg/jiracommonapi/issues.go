@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
 	"github.com/pinpt/agent/pkg/date"
 	"github.com/pinpt/agent/pkg/structmarshal"
 	"github.com/pinpt/go-common/datetime"
@@ -22,7 +23,7 @@ type CustomFieldValue struct {
 }
 
 type IssueWithCustomFields struct {
-	*work.Issue
+	*extmodels.WorkIssue
 	CustomFields []CustomFieldValue
 }
 
@@ -75,6 +76,11 @@ type linkedIssue struct {
 	Key string `json:"key"`
 }
 
+type issueFieldRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
 type issueFields struct {
 	Project struct {
 		ID  string `json:"id"`
@@ -98,12 +104,15 @@ type issueFields struct {
 	Resolution struct {
 		Name string `json:"name"`
 	} `json:"resolution"`
-	Creator    User
-	Reporter   User
-	Assignee   User
-	Labels     []string `json:"labels"`
-	SprintIDs  []string
-	IssueLinks []struct {
+	Creator     User
+	Reporter    User
+	Assignee    User
+	Labels      []string        `json:"labels"`
+	FixVersions []issueFieldRef `json:"fixVersions"`
+	Versions    []issueFieldRef `json:"versions"`
+	Components  []issueFieldRef `json:"components"`
+	SprintIDs   []string
+	IssueLinks  []struct {
 		ID   string `json:"id"`
 		Type struct {
 			//ID   string `json:"id"`
@@ -268,7 +277,7 @@ func convertIssue(qc QueryContext, data issueSource, fieldByID map[string]Custom
 	}
 
 	item := IssueWithCustomFields{}
-	item.Issue = &work.Issue{}
+	item.Issue = &extmodels.WorkIssue{Issue: &work.Issue{}}
 	item.CustomerID = qc.CustomerID
 	item.RefID = data.ID
 	item.RefType = "jira"
@@ -345,6 +354,15 @@ func convertIssue(qc QueryContext, data issueSource, fieldByID map[string]Custom
 
 	item.URL = qc.IssueURL(data.Key)
 	item.Tags = fields.Labels
+	for _, v := range fields.FixVersions {
+		item.FixVersionIDs = append(item.FixVersionIDs, qc.VersionID(v.ID))
+	}
+	for _, v := range fields.Versions {
+		item.AffectsVersionIDs = append(item.AffectsVersionIDs, qc.VersionID(v.ID))
+	}
+	for _, c := range fields.Components {
+		item.ComponentIDs = append(item.ComponentIDs, qc.ComponentID(c.ID))
+	}
 
 	for _, link := range fields.IssueLinks {
 		var linkType work.IssueLinkedIssuesLinkType
@@ -572,6 +590,33 @@ func convertIssue(qc QueryContext, data issueSource, fieldByID map[string]Custom
 				if data.To != "" {
 					item.To = work.NewIssueID(qc.CustomerID, data.To, "jira")
 				}
+			case "fix version", "version", "component":
+				// each change adds or removes one value, from or to is empty
+				extra := extmodels.IssueChangeLog{}
+				extra.RefID = item.RefID
+				extra.Ordinal = item.Ordinal
+				extra.CreatedDate = extmodels.NewDate(createdAt)
+				extra.UserID = item.UserID
+				extra.FromString = item.FromString
+				extra.ToString = item.ToString
+				refID := qc.VersionID
+				switch strings.ToLower(data.Field) {
+				case "fix version":
+					extra.Field = extmodels.ChangeLogFieldFixVersionIDs
+				case "version":
+					extra.Field = extmodels.ChangeLogFieldAffectsVersionIDs
+				case "component":
+					extra.Field = extmodels.ChangeLogFieldComponentIDs
+					refID = qc.ComponentID
+				}
+				if data.From != "" {
+					extra.From = refID(data.From)
+				}
+				if data.To != "" {
+					extra.To = refID(data.To)
+				}
+				issue.ExtraChangeLog = append(issue.ExtraChangeLog, extra)
+				continue
 			default:
 				// Ignore other change types
 				continue
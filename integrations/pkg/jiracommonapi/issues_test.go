@@ -1,8 +1,11 @@
 package jiracommonapi
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -65,3 +68,45 @@ func TestAdjustRenderedHTML(t *testing.T) {
 		}
 	}
 }
+
+func TestConvertIssueVersionsAndComponents(t *testing.T) {
+	qc := QueryContext{CustomerID: "c1", Logger: hclog.NewNullLogger()}
+	var data issueSource
+	err := json.Unmarshal([]byte(`{
+		"id": "1",
+		"key": "P-1",
+		"fields": {
+			"project": {"id": "p1", "key": "P"},
+			"fixVersions": [{"id": "10", "name": "1.0"}],
+			"versions": [{"id": "9", "name": "0.9"}],
+			"components": [{"id": "20", "name": "Backend"}]
+		},
+		"changelog": {"histories": [
+			{"id": "100", "created": "2020-01-02T10:00:00.000+0000", "author": {"key": "u1"}, "items": [
+				{"field": "Fix Version", "to": "10", "toString": "1.0"},
+				{"field": "Component", "from": "21", "fromString": "Frontend"},
+				{"field": "summary", "fromString": "a", "toString": "b"}
+			]}
+		]}
+	}`), &data)
+	assert.NoError(t, err)
+
+	got, err := convertIssue(qc, data, map[string]CustomField{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{qc.VersionID("10")}, got.FixVersionIDs)
+	assert.Equal(t, []string{qc.VersionID("9")}, got.AffectsVersionIDs)
+	assert.Equal(t, []string{qc.ComponentID("20")}, got.ComponentIDs)
+
+	assert.Len(t, got.ChangeLog, 1)
+	assert.Len(t, got.ExtraChangeLog, 2)
+	fix := got.ExtraChangeLog[0]
+	assert.Equal(t, extmodels.ChangeLogFieldFixVersionIDs, fix.Field)
+	assert.Equal(t, "", fix.From)
+	assert.Equal(t, qc.VersionID("10"), fix.To)
+	assert.Equal(t, "1.0 @ 10", fix.ToString)
+	assert.Equal(t, "u1", fix.UserID)
+	component := got.ExtraChangeLog[1]
+	assert.Equal(t, extmodels.ChangeLogFieldComponentIDs, component.Field)
+	assert.Equal(t, qc.ComponentID("21"), component.From)
+	assert.Equal(t, "", component.To)
+}
@@ -0,0 +1,47 @@
+package jiracommonapi
+
+import (
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+)
+
+// Components returns components of the project. Calls qc.ExportUser for component leads.
+func Components(qc QueryContext, project Project) (res []extmodels.Component, rerr error) {
+
+	objectPath := "project/" + project.JiraID + "/components"
+
+	var components []struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Lead        User   `json:"lead"`
+	}
+
+	err := qc.Req.Get(objectPath, nil, &components)
+	if err != nil {
+		rerr = err
+		return
+	}
+
+	for _, data := range components {
+		item := extmodels.Component{}
+		item.CustomerID = qc.CustomerID
+		item.RefType = "jira"
+		item.RefID = data.ID
+		item.ProjectID = qc.ProjectID(project.JiraID)
+		item.Name = data.Name
+		item.Description = data.Description
+		if !data.Lead.IsZero() {
+			item.LeadRefID = data.Lead.RefID()
+			if qc.ExportUser != nil {
+				err := qc.ExportUser(data.Lead)
+				if err != nil {
+					rerr = err
+					return
+				}
+			}
+		}
+		res = append(res, item)
+	}
+
+	return
+}
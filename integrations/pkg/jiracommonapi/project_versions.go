@@ -0,0 +1,72 @@
+package jiracommonapi
+
+import (
+	"fmt"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+)
+
+type versionSource struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Archived    bool   `json:"archived"`
+	Released    bool   `json:"released"`
+	StartDate   string `json:"startDate"`
+	ReleaseDate string `json:"releaseDate"`
+}
+
+// Versions returns versions (releases) of the project. These are used in issue fix versions and affects versions fields.
+func Versions(qc QueryContext, project Project) (res []extmodels.Version, rerr error) {
+
+	objectPath := "project/" + project.JiraID + "/versions"
+
+	var versions []versionSource
+
+	err := qc.Req.Get(objectPath, nil, &versions)
+	if err != nil {
+		rerr = err
+		return
+	}
+
+	for _, data := range versions {
+		item, err := convertVersion(qc, project, data)
+		if err != nil {
+			rerr = err
+			return
+		}
+		res = append(res, item)
+	}
+
+	return
+}
+
+func convertVersion(qc QueryContext, project Project, data versionSource) (res extmodels.Version, rerr error) {
+	res.CustomerID = qc.CustomerID
+	res.RefType = "jira"
+	res.RefID = data.ID
+	res.ProjectID = qc.ProjectID(project.JiraID)
+	res.Name = data.Name
+	res.Description = data.Description
+	res.Archived = data.Archived
+	res.Released = data.Released
+
+	if data.StartDate != "" {
+		d, err := ParsePlannedDate(data.StartDate)
+		if err != nil {
+			rerr = fmt.Errorf("could not parse start date of version: %v err: %v", data.StartDate, err)
+			return
+		}
+		res.StartDate = extmodels.NewDate(d)
+	}
+	if data.ReleaseDate != "" {
+		d, err := ParsePlannedDate(data.ReleaseDate)
+		if err != nil {
+			rerr = fmt.Errorf("could not parse release date of version: %v err: %v", data.ReleaseDate, err)
+			return
+		}
+		res.ReleaseDate = extmodels.NewDate(d)
+	}
+
+	return
+}
@@ -0,0 +1,26 @@
+package jiracommonapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertVersion(t *testing.T) {
+	qc := QueryContext{CustomerID: "c1"}
+	project := Project{JiraID: "p1", Key: "P"}
+	data := versionSource{ID: "10", Name: "1.0", Released: true, ReleaseDate: "2020-02-03"}
+
+	got, err := convertVersion(qc, project, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "10", got.RefID)
+	assert.Equal(t, qc.ProjectID("p1"), got.ProjectID)
+	assert.True(t, got.Released)
+	assert.True(t, got.StartDate.Time().IsZero(), "start date is optional")
+	assert.Equal(t, time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC), got.ReleaseDate.Time().UTC())
+
+	data.StartDate = "invalid"
+	_, err = convertVersion(qc, project, data)
+	assert.Error(t, err)
+}
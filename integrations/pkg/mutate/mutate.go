@@ -29,6 +29,9 @@ type AllowedValue struct {
 
 const ErrNotFound = "not_found"
 
+// ErrNotSupported is the error code returned when integration does not support the mutation action
+const ErrNotSupported = "not_supported"
+
 func UnmarshalAction(fn string) (v agent.IntegrationMutationRequestAction) {
 	/*
 		Below example doesn't work due to bug in schemagen
@@ -58,6 +61,13 @@ func UnmarshalAction(fn string) (v agent.IntegrationMutationRequestAction) {
 	return
 }
 
+// ResultNotSupported returns the result for mutation actions not supported by integration
+func ResultNotSupported(fn string) (res rpcdef.MutateResult) {
+	res.ErrorCode = ErrNotSupported
+	res.Error = "mutate fn not supported: " + fn
+	return res
+}
+
 func ResultFromError(err error) (res rpcdef.MutateResult) {
 	var e requests2.StatusCodeError
 	if errors.As(err, &e) && e.Got == http.StatusNotFound {
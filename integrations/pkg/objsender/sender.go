@@ -22,6 +22,8 @@ type Session struct {
 
 	sessionID     int
 	lastProcessed interface{}
+	// lastProcessedNew is stored on Done instead of start time when set
+	lastProcessedNew string
 
 	batch *batch
 
@@ -180,12 +182,33 @@ func (s *Session) LastProcessedTime() time.Time {
 	return res
 }
 
+// LastProcessedString returns the last processed value stored using SetLastProcessed. Returns empty string if not set.
+func (s *Session) LastProcessedString() string {
+	if s.lastProcessed == nil {
+		return ""
+	}
+	str, ok := s.lastProcessed.(string)
+	if !ok {
+		panic(fmt.Errorf("attempted to get last processed as string, but have different type stored %v %T", s.lastProcessed, s.lastProcessed))
+	}
+	return str
+}
+
+// SetLastProcessed sets the value stored as last processed on Done, instead of the session start time. Used for sources that are incremental by id instead of time. Use LastProcessedString to read it in the next export.
+func (s *Session) SetLastProcessed(v string) {
+	s.lastProcessedNew = v
+}
+
 func (s *Session) Done() error {
 	err := s.batch.Flush()
 	if err != nil {
 		return err
 	}
-	s.agent.ExportDone(strconv.Itoa(s.sessionID), s.startTime.Format(time.RFC3339))
+	lastProcessed := s.startTime.Format(time.RFC3339)
+	if s.lastProcessedNew != "" {
+		lastProcessed = s.lastProcessedNew
+	}
+	s.agent.ExportDone(strconv.Itoa(s.sessionID), lastProcessed)
 	return nil
 }
 
@@ -0,0 +1,299 @@
+// Package reportfiles finds report files, such as sarif or coverage reports, in local directories, drop folder and cloned repos. Shared by sarif and coverage integrations.
+package reportfiles
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/pkg/gitclone"
+)
+
+// Document is a report file to export.
+type Document struct {
+	// Path is the file path, or cache dir and path in repo for files in repos. Used in logs.
+	Path string
+	// RelPath is the path relative to configured dir, drop folder or repo root.
+	RelPath string
+	// Dir is the configured dir or drop folder document was found in, empty for repos.
+	Dir string
+	// Repo is the cloned repo document was read from, nil for other sources.
+	Repo *gitclone.CachedRepo
+	// RepoURL is the origin url of the cloned repo, empty for other sources.
+	RepoURL string
+	// Commit is the sha of HEAD commit for files in repos, empty for other sources.
+	Commit string
+	// Modified is the modification time of file, or commit time for files in repos.
+	Modified time.Time
+	Data     []byte
+	// Done marks document as processed. Moves documents in drop folder into processed subdir, does nothing for other sources. Use MarkProcessed to call it after export.
+	Done func() error
+}
+
+// MarkProcessed marks documents as processed. Call after all sessions with data from documents are done, so that documents are exported again if export fails. Errors are logged, since data was already exported.
+func MarkProcessed(logger hclog.Logger, docs []Document) {
+	for _, doc := range docs {
+		if err := doc.Done(); err != nil {
+			logger.Warn("could not mark report file as processed", "path", doc.Path, "err", err)
+		}
+	}
+}
+
+func noop() error {
+	return nil
+}
+
+// MatchFunc returns true for report files, p is the path relative to dir or repo root, using / as separator.
+type MatchFunc func(p string) bool
+
+// MatchPatterns returns MatchFunc for patterns. Patterns containing / are matched against the full path, other patterns against the file name.
+func MatchPatterns(patterns []string) MatchFunc {
+	return func(p string) bool {
+		for _, pattern := range patterns {
+			name := p
+			if !strings.Contains(pattern, "/") {
+				name = path.Base(p)
+			}
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FromDirs returns matching documents in dirs and their subdirs modified after since. Returns all documents if since is zero.
+func FromDirs(dirs []string, match MatchFunc, since time.Time) (res []Document, _ error) {
+	for _, dir := range dirs {
+		docs, err := walk(dir, match, func(info os.FileInfo) bool {
+			return info.ModTime().After(since)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not read report dir %v: %v", dir, err)
+		}
+		res = append(res, docs...)
+	}
+	return
+}
+
+// ProcessedDir is the subdir of drop folder, where exported documents are moved to.
+const ProcessedDir = "processed"
+
+// ProcessedMaxAge is the time documents are kept in ProcessedDir after export.
+const ProcessedMaxAge = 30 * 24 * time.Hour
+
+// FromDropDir returns matching documents uploaded to drop folder and its subdirs. Documents are moved to ProcessedDir, keeping the relative path, when Done is called, so that every uploaded document is exported once. Documents processed more than ProcessedMaxAge ago are deleted.
+func FromDropDir(dir string, match MatchFunc) (res []Document, _ error) {
+	err := cleanProcessed(dir, ProcessedMaxAge)
+	if err != nil {
+		return nil, fmt.Errorf("could not clean processed documents in drop dir %v: %v", dir, err)
+	}
+	docs, err := walk(dir, match, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not read drop dir %v: %v", dir, err)
+	}
+	for i := range docs {
+		doc := &docs[i]
+		p := doc.Path
+		target := filepath.Join(dir, ProcessedDir, filepath.FromSlash(doc.RelPath))
+		doc.Done = func() error {
+			err := os.MkdirAll(filepath.Dir(target), 0777)
+			if err != nil {
+				return err
+			}
+			// prefix with time to not overwrite documents uploaded with the same name before
+			now := time.Now()
+			loc := filepath.Join(filepath.Dir(target), now.UTC().Format("20060102T150405.000")+"-"+filepath.Base(target))
+			err = os.Rename(p, loc)
+			if err != nil {
+				return err
+			}
+			// modification time is used for retention
+			return os.Chtimes(loc, now, now)
+		}
+	}
+	return docs, nil
+}
+
+// cleanProcessed deletes documents in ProcessedDir of drop folder modified more than maxAge ago and removes empty subdirs.
+func cleanProcessed(dir string, maxAge time.Duration) error {
+	processed := filepath.Join(dir, ProcessedDir)
+	var dirs []string
+	err := filepath.Walk(processed, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if p == processed && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			if p != processed {
+				dirs = append(dirs, p)
+			}
+			return nil
+		}
+		if time.Since(info.ModTime()) <= maxAge {
+			return nil
+		}
+		return os.Remove(p)
+	})
+	if err != nil {
+		return err
+	}
+	// subdirs before parents, fails for dirs that are not empty
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i])
+	}
+	return nil
+}
+
+func walk(dir string, match MatchFunc, include func(os.FileInfo) bool) (res []Document, _ error) {
+	processed := filepath.Join(dir, ProcessedDir)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if include == nil && p == processed {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !match(rel) || (include != nil && !include(info)) {
+			return nil
+		}
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		res = append(res, Document{
+			Path:     p,
+			RelPath:  rel,
+			Dir:      dir,
+			Modified: info.ModTime(),
+			Data:     b,
+			Done:     noop,
+		})
+		return nil
+	})
+	return res, err
+}
+
+// FromRepos returns matching files at HEAD of repos mirrored in cacheRoot, for repos with HEAD commit after since. Repos that can not be read are skipped with a warning.
+func FromRepos(ctx context.Context, logger hclog.Logger, cacheRoot string, match MatchFunc, since time.Time) (res []Document, _ error) {
+	repos, err := gitclone.CachedRepos(cacheRoot)
+	if err != nil {
+		return nil, err
+	}
+	if len(repos) == 0 {
+		logger.Warn("no repos in repo cache, repos are cloned by sourcecode integrations", "dir", cacheRoot)
+	}
+	for _, repo := range repos {
+		docs, err := fromRepo(ctx, repo, match, since)
+		if err != nil {
+			logger.Warn("could not read report files from repo", "dir", repo.Dir, "err", err)
+			continue
+		}
+		res = append(res, docs...)
+	}
+	return
+}
+
+func fromRepo(ctx context.Context, repo gitclone.CachedRepo, match MatchFunc, since time.Time) (res []Document, _ error) {
+	dir := repo.Dir
+	out, err := git(ctx, dir, "log", "-1", "--format=%H %ct", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Fields(string(out))
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid git log output: %s", out)
+	}
+	commit := parts[0]
+	ts, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit time: %v", err)
+	}
+	modified := time.Unix(ts, 0).UTC()
+	if !modified.After(since) {
+		return nil, nil
+	}
+	files, err := ListFiles(ctx, dir, match)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	// remote is not set for repos that failed to clone, skip in that case
+	out, err = git(ctx, dir, "config", "--get", "remote.origin.url")
+	if err != nil {
+		return nil, err
+	}
+	repoURL := strings.TrimSpace(string(out))
+	for _, p := range files {
+		b, err := ReadFile(ctx, dir, p)
+		if err != nil {
+			return nil, err
+		}
+		r := repo
+		res = append(res, Document{
+			Path:     filepath.Base(dir) + ":" + p,
+			RelPath:  p,
+			Repo:     &r,
+			RepoURL:  repoURL,
+			Commit:   commit,
+			Modified: modified,
+			Data:     b,
+			Done:     noop,
+		})
+	}
+	return
+}
+
+// ListFiles returns sorted paths of matching files at HEAD of repo in dir.
+func ListFiles(ctx context.Context, dir string, match MatchFunc) (res []string, _ error) {
+	out, err := git(ctx, dir, "ls-tree", "-r", "-z", "--name-only", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p != "" && match(p) {
+			res = append(res, p)
+		}
+	}
+	sort.Strings(res)
+	return
+}
+
+// ReadFile returns the content of file at HEAD of repo in dir.
+func ReadFile(ctx context.Context, dir string, p string) ([]byte, error) {
+	return git(ctx, dir, "show", "HEAD:"+p)
+}
+
+func git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %v failed: %v %v", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
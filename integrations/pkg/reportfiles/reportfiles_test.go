@@ -0,0 +1,145 @@
+package reportfiles
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/pkg/gitclone"
+	"github.com/stretchr/testify/assert"
+)
+
+var matchSarif = MatchPatterns([]string{"*.sarif", "*.sarif.json"})
+
+func writeFile(t *testing.T, p string, data string, modified time.Time) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0777))
+	assert.NoError(t, ioutil.WriteFile(p, []byte(data), 0666))
+	assert.NoError(t, os.Chtimes(p, modified, modified))
+}
+
+func TestFromDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeFile(t, filepath.Join(dir, "old.sarif"), "{}", old)
+	writeFile(t, filepath.Join(dir, "sub", "new.sarif.json"), "{}", old.Add(time.Hour))
+	writeFile(t, filepath.Join(dir, "other.json"), "{}", old.Add(time.Hour))
+
+	docs, err := FromDirs([]string{dir}, matchSarif, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, docs, 2)
+
+	docs, err = FromDirs([]string{dir}, matchSarif, old)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 1)
+	assert.Equal(t, filepath.Join(dir, "sub", "new.sarif.json"), docs[0].Path)
+	assert.Equal(t, "sub/new.sarif.json", docs[0].RelPath)
+	assert.Equal(t, dir, docs[0].Dir)
+}
+
+func TestFromDropDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFile(t, filepath.Join(dir, "a.sarif"), "{}", time.Now())
+	writeFile(t, filepath.Join(dir, "org", "repo", "b.sarif"), "{}", time.Now())
+
+	docs, err := FromDropDir(dir, matchSarif)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 2)
+	for _, doc := range docs {
+		assert.NoError(t, doc.Done())
+	}
+
+	docs, err = FromDropDir(dir, matchSarif)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 0, "processed documents are not returned again")
+	processed, err := ioutil.ReadDir(filepath.Join(dir, ProcessedDir, "org", "repo"))
+	assert.NoError(t, err)
+	assert.Len(t, processed, 1)
+}
+
+func TestFromDropDirRetention(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFile(t, filepath.Join(dir, "a.sarif"), "{}", time.Now().Add(-60*24*time.Hour))
+	docs, err := FromDropDir(dir, matchSarif)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 1)
+	MarkProcessed(hclog.NewNullLogger(), docs)
+
+	// old upload is kept after processing
+	docs, err = FromDropDir(dir, matchSarif)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 0)
+	processed, err := ioutil.ReadDir(filepath.Join(dir, ProcessedDir))
+	assert.NoError(t, err)
+	assert.Len(t, processed, 1)
+
+	old := time.Now().Add(-ProcessedMaxAge - time.Hour)
+	writeFile(t, filepath.Join(dir, ProcessedDir, "org", "repo", "b.sarif"), "{}", old)
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, ProcessedDir, processed[0].Name()), old, old))
+	writeFile(t, filepath.Join(dir, ProcessedDir, "c.sarif"), "{}", time.Now())
+
+	_, err = FromDropDir(dir, matchSarif)
+	assert.NoError(t, err)
+	processed, err = ioutil.ReadDir(filepath.Join(dir, ProcessedDir))
+	assert.NoError(t, err)
+	if assert.Len(t, processed, 1, "old documents and empty dirs are removed") {
+		assert.Equal(t, "c.sarif", processed[0].Name())
+	}
+}
+
+func TestFromRepos(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	writeFile(t, filepath.Join(src, "reports", "codeql.sarif"), `{"version":"2.1.0"}`, time.Now())
+	writeFile(t, filepath.Join(src, "lint.sarif"), "{}", time.Now())
+	run := func(dir string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v %s", args, err, out)
+		}
+	}
+	run(src, "init")
+	run(src, "add", ".")
+	run(src, "commit", "-m", "c1")
+
+	cache := filepath.Join(dir, "cache")
+	assert.NoError(t, os.MkdirAll(cache, 0777))
+	name := gitclone.RepoNameUsedInCacheDir("github-org/repo", "r1")
+	run(cache, "clone", "--mirror", src, name)
+
+	docs, err := FromRepos(context.Background(), hclog.NewNullLogger(), cache, MatchPatterns([]string{"reports/*.sarif"}), time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, docs, 1)
+	assert.Equal(t, name+":reports/codeql.sarif", docs[0].Path)
+	assert.Equal(t, src, docs[0].RepoURL)
+	assert.Equal(t, "r1", docs[0].Repo.RepoID)
+	assert.Len(t, docs[0].Commit, 40)
+	assert.Equal(t, `{"version":"2.1.0"}`, string(docs[0].Data))
+
+	docs, err = FromRepos(context.Background(), hclog.NewNullLogger(), cache, matchSarif, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, docs, 2)
+
+	docs, err = FromRepos(context.Background(), hclog.NewNullLogger(), cache, matchSarif, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, docs, 0, "repos without new commits are skipped")
+}
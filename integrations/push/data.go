@@ -0,0 +1,209 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/agent/integrations/pkg/objsender"
+	"github.com/pinpt/agent/integrations/pkg/reportfiles"
+	"github.com/pinpt/agent/pkg/gitclone"
+	"github.com/pinpt/agent/pkg/ids"
+	"github.com/pinpt/agent/pkg/pushevents"
+)
+
+// events returns events from spool dir. When the same event was received multiple times, only the latest version is returned. Invalid files are skipped with a warning, but returned in docs, so that they are moved to processed dir together with valid ones.
+func (s *Integration) events() (res []pushevents.Event, docs []reportfiles.Document, _ error) {
+	if _, err := os.Stat(s.config.SpoolDir); os.IsNotExist(err) {
+		// push endpoint not enabled and no events written by tools
+		return nil, nil, nil
+	}
+	docs, err := reportfiles.FromDropDir(s.config.SpoolDir, pushevents.IsEventFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	// files written by push endpoint are named by sequence, use modification time for files written by tools
+	sort.SliceStable(docs, func(i, j int) bool {
+		a, b := docs[i], docs[j]
+		if !a.Modified.Equal(b.Modified) {
+			return a.Modified.Before(b.Modified)
+		}
+		return a.RelPath < b.RelPath
+	})
+	byKey := map[string]int{}
+	for _, doc := range docs {
+		events, err := pushevents.Parse(doc.Data, doc.Modified)
+		if err != nil {
+			s.logger.Warn("skipping invalid event file", "path", doc.Path, "err", err)
+			continue
+		}
+		for _, ev := range events {
+			if i, ok := byKey[ev.Key()]; ok {
+				res[i] = ev
+				continue
+			}
+			byKey[ev.Key()] = len(res)
+			res = append(res, ev)
+		}
+	}
+	return res, docs, nil
+}
+
+func (s *Integration) exportAll() error {
+	events, docs, err := s.events()
+	if err != nil {
+		return err
+	}
+	s.logger.Info("found pushed events", "count", len(events), "files", len(docs))
+
+	var cached []gitclone.CachedRepo
+	if s.repoCache != "" {
+		cached, err = gitclone.CachedRepos(s.repoCache)
+		if err != nil {
+			return err
+		}
+	}
+
+	deploymentSession, err := objsender.Root(s.agent, extmodels.DeploymentModelName)
+	if err != nil {
+		return err
+	}
+	statusSession, err := objsender.Root(s.agent, extmodels.DeploymentStatusModelName)
+	if err != nil {
+		return err
+	}
+	incidentSession, err := objsender.Root(s.agent, extmodels.IncidentModelName)
+	if err != nil {
+		return err
+	}
+
+	deployments := 0
+	for _, ev := range events {
+		if ev.Type == pushevents.TypeDeploy {
+			deployments++
+		}
+	}
+	if err := deploymentSession.SetTotal(deployments); err != nil {
+		return err
+	}
+	if err := incidentSession.SetTotal(len(events) - deployments); err != nil {
+		return err
+	}
+
+	for _, ev := range events {
+		switch ev.Type {
+		case pushevents.TypeDeploy:
+			deployment, status := s.deployment(ev, s.repo(ev, cached))
+			if err := deploymentSession.Send(deployment); err != nil {
+				return err
+			}
+			if err := statusSession.Send(status); err != nil {
+				return err
+			}
+		case pushevents.TypeIncident:
+			if err := incidentSession.Send(s.incident(ev)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, session := range []*objsender.Session{deploymentSession, statusSession, incidentSession} {
+		if err := session.Done(); err != nil {
+			return err
+		}
+	}
+	reportfiles.MarkProcessed(s.logger, docs)
+	return nil
+}
+
+// repo returns the cloned repo of deploy event, nil if repo is not set or was not cloned by sourcecode integrations
+func (s *Integration) repo(ev pushevents.Event, cached []gitclone.CachedRepo) *gitclone.CachedRepo {
+	if ev.Repo == "" {
+		return nil
+	}
+	name := ev.Repo
+	if strings.Contains(name, ":") {
+		// clone url instead of name
+		_, name = gitclone.RepoNameFromURL(name)
+	}
+	for i, repo := range cached {
+		if repo.MatchesName(name) {
+			return &cached[i]
+		}
+	}
+	s.logger.Debug("repo of deploy event was not cloned by sourcecode integration, deploy will not be linked to commit", "repo", ev.Repo)
+	return nil
+}
+
+func (s *Integration) deployment(ev pushevents.Event, repo *gitclone.CachedRepo) (extmodels.Deployment, extmodels.DeploymentStatus) {
+	item := extmodels.Deployment{}
+	item.CustomerID = s.customerID
+	item.RefType = refType
+	item.RefID = ev.RefID()
+	item.Service = ev.Service
+	item.Environment = ev.Environment
+	item.EnvironmentID = extmodels.EnvironmentID(s.customerID, refType, "", ev.Environment)
+	if repo != nil {
+		item.RepoID = repo.RepoID
+		// use the same environment id as deployments exported by sourcecode integration for the repo
+		item.EnvironmentID = extmodels.EnvironmentID(s.customerID, repo.RefType, repo.RepoID, ev.Environment)
+		if ev.CommitSHA != "" {
+			item.CommitID = ids.CodeCommit(s.customerID, repo.RefType, repo.RepoID, ev.CommitSHA)
+		}
+	}
+	item.Ref = ev.Ref
+	if item.Ref == "" {
+		item.Ref = ev.Version
+	}
+	item.CommitSHA = ev.CommitSHA
+	item.CreatorRefID = ev.Creator
+	item.Description = ev.Description
+	item.State = ev.Status
+	item.URL = ev.URL
+	item.CreatedDate = extmodels.NewDate(ev.StartedAt.Time)
+	changedAt := ev.StartedAt.Time
+	if !ev.FinishedAt.IsZero() {
+		changedAt = ev.FinishedAt.Time
+	}
+	item.UpdatedDate = extmodels.NewDate(changedAt)
+	if extmodels.IsDeploymentFinished(ev.Status) {
+		item.FinishedDate = extmodels.NewDate(ev.FinishedAt.Time)
+	}
+
+	status := extmodels.DeploymentStatus{}
+	status.CustomerID = s.customerID
+	status.RefType = refType
+	status.RefID = item.RefID + "-" + ev.Status
+	status.RepoID = item.RepoID
+	status.DeploymentID = item.ID()
+	status.State = ev.Status
+	status.SourceState = ev.Status
+	status.LogURL = ev.URL
+	status.CreatorRefID = ev.Creator
+	status.CreatedDate = extmodels.NewDate(changedAt)
+	return item, status
+}
+
+func (s *Integration) incident(ev pushevents.Event) extmodels.Incident {
+	item := extmodels.Incident{}
+	item.CustomerID = s.customerID
+	item.RefType = refType
+	item.RefID = ev.RefID()
+	item.Title = ev.Title
+	item.Status = ev.Status
+	item.Severity = ev.Severity
+	item.Description = ev.Description
+	item.URL = ev.URL
+	item.Service = ev.Service
+	item.Environment = ev.Environment
+	item.CreatorRefID = ev.Creator
+	if ev.DeployID != "" {
+		deploy := pushevents.Event{Source: ev.Source, ID: ev.DeployID}
+		item.DeploymentID = extmodels.DeploymentID(s.customerID, refType, deploy.RefID())
+	}
+	item.CreatedDate = extmodels.NewDate(ev.CreatedAt.Time)
+	item.AcknowledgedDate = extmodels.NewDate(ev.AcknowledgedAt.Time)
+	item.ResolvedDate = extmodels.NewDate(ev.ResolvedAt.Time)
+	return item
+}
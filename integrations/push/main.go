@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/pinpt/agent/integrations/pkg/ibase"
+	"github.com/pinpt/agent/pkg/gitclone"
+	"github.com/pinpt/agent/pkg/pushevents"
+	"github.com/pinpt/agent/pkg/structmarshal"
+	"github.com/pinpt/agent/rpcdef"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// refType is the ref_type of all exported objects
+const refType = "push"
+
+type Integration struct {
+	logger     hclog.Logger
+	agent      rpcdef.Agent
+	customerID string
+	config     Config
+	// repoCache is the location of repos cloned by sourcecode integrations, passed by agent
+	repoCache string
+}
+
+// Config is the integration config, passed in options, since integration is configured manually in extra_integrations.
+type Config struct {
+	// SpoolDir is the folder with event files. Defaults to the spool of push endpoint of the agent service, passed by agent. Tools can also write event files to this folder directly. Files are moved to processed subdir after export.
+	SpoolDir string `json:"spool_dir"`
+}
+
+func (s *Integration) Init(agent rpcdef.Agent) (rpcdef.Capabilities, error) {
+	s.agent = agent
+	return rpcdef.Capabilities{
+		IntegrationTypes: []string{"SOURCECODE"},
+		Streaming:        true,
+	}, nil
+}
+
+func (s *Integration) Export(ctx context.Context, config rpcdef.ExportConfig) (res rpcdef.ExportResult, _ error) {
+	if err := s.initConfig(config); err != nil {
+		return res, err
+	}
+	if err := s.exportAll(); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func (s *Integration) ValidateConfig(ctx context.Context, config rpcdef.ExportConfig) (res rpcdef.ValidationResult, _ error) {
+	if err := s.initConfig(config); err != nil {
+		res.Errors = append(res.Errors, err.Error())
+		return res, nil
+	}
+	if _, err := os.Stat(s.config.SpoolDir); err != nil && !os.IsNotExist(err) {
+		res.Errors = append(res.Errors, "Push spool dir is not accessible: "+err.Error())
+	}
+	return res, nil
+}
+
+func (s *Integration) OnboardExport(ctx context.Context, objectType rpcdef.OnboardExportType, config rpcdef.ExportConfig) (res rpcdef.OnboardExportResult, _ error) {
+	res.Error = rpcdef.ErrOnboardExportNotSupported
+	return
+}
+
+func (s *Integration) initConfig(config rpcdef.ExportConfig) error {
+	var conf Config
+	opts, _ := config.Integration.Config["options"].(map[string]interface{})
+	err := structmarshal.MapToStruct(opts, &conf)
+	if err != nil {
+		return err
+	}
+	if conf.SpoolDir == "" {
+		conf.SpoolDir = os.Getenv(pushevents.EnvSpoolDir)
+	}
+	if conf.SpoolDir == "" {
+		return errors.New("spool_dir is required in options when not running in agent")
+	}
+	s.repoCache = os.Getenv(gitclone.EnvCacheRoot)
+	s.config = conf
+	s.customerID = config.Pinpoint.CustomerID
+	return nil
+}
+
+func NewIntegration(logger hclog.Logger) *Integration {
+	s := &Integration{}
+	s.logger = logger
+	return s
+}
+
+func main() {
+	ibase.MainFunc(func(logger hclog.Logger) rpcdef.Integration {
+		return NewIntegration(logger)
+	})
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/agent/integrations/pkg/objsender"
+	"github.com/pinpt/agent/integrations/pkg/reportfiles"
+	"github.com/pinpt/agent/integrations/sarif/issues"
+	"github.com/pinpt/agent/integrations/sarif/sarif"
+	"github.com/pinpt/agent/pkg/date"
+	"github.com/pinpt/agent/pkg/gitclone"
+	"github.com/pinpt/agent/rpcdef"
+	"github.com/pinpt/go-common/hash"
+	"github.com/pinpt/integration-sdk/codequality"
+)
+
+type project struct {
+	*codequality.Project
+	Analyses []issues.Analysis
+}
+
+// isSarifFile returns true for files with .sarif or .sarif.json extension
+func isSarifFile(p string) bool {
+	p = strings.ToLower(p)
+	return strings.HasSuffix(p, ".sarif") || strings.HasSuffix(p, ".sarif.json")
+}
+
+func (s *Integration) documents(ctx context.Context, since time.Time) (res []reportfiles.Document, _ error) {
+	if len(s.config.Dirs) != 0 {
+		docs, err := reportfiles.FromDirs(s.config.Dirs, isSarifFile, since)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, docs...)
+	}
+	if len(s.config.RepoPaths) != 0 {
+		docs, err := reportfiles.FromRepos(ctx, s.logger, s.repoCache, reportfiles.MatchPatterns(s.config.RepoPaths), since)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, docs...)
+	}
+	if s.config.DropDir != "" {
+		docs, err := reportfiles.FromDropDir(s.config.DropDir, isSarifFile)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, docs...)
+	}
+	return
+}
+
+func (s *Integration) exportAll(ctx context.Context) (exported []rpcdef.ExportProject, _ error) {
+	session, err := objsender.Root(s.agent, codequality.ProjectModelName.String())
+	if err != nil {
+		s.logger.Error("error creating project session", "err", err)
+		return nil, err
+	}
+	docs, err := s.documents(ctx, session.LastProcessedTime())
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("found sarif documents", "count", len(docs))
+
+	projects := map[string]*project{}
+	var processed []reportfiles.Document
+	for _, doc := range docs {
+		log, err := sarif.Parse(doc.Data)
+		if err != nil {
+			s.logger.Warn("skipping invalid sarif document", "path", doc.Path, "err", err)
+			continue
+		}
+		processed = append(processed, doc)
+		for _, run := range log.Runs {
+			identifier, name := projectName(run, doc)
+			p := projects[identifier]
+			if p == nil {
+				p = &project{Project: &codequality.Project{
+					CustomerID: s.customerID,
+					Identifier: identifier,
+					Name:       name,
+					RefID:      identifier,
+					RefType:    refType,
+				}}
+				projects[identifier] = p
+			}
+			a := issues.Analysis{
+				Tool:     strings.ToLower(run.ToolName()),
+				Category: run.Category(),
+				Date:     run.Date(),
+				Findings: run.Findings(),
+			}
+			if a.Date.IsZero() {
+				a.Date = doc.Modified.UTC()
+			}
+			p.Analyses = append(p.Analyses, a)
+		}
+	}
+
+	var identifiers []string
+	for k := range projects {
+		identifiers = append(identifiers, k)
+	}
+	sort.Strings(identifiers)
+	if err := session.SetTotal(len(identifiers)); err != nil {
+		return nil, err
+	}
+	for _, identifier := range identifiers {
+		p := projects[identifier]
+		if err := session.Send(p.Project); err != nil {
+			s.logger.Error("error sending project to agent", "err", err, "id", p.RefID)
+			return nil, err
+		}
+		if err := s.exportProject(session, p); err != nil {
+			return nil, err
+		}
+		exported = append(exported, rpcdef.ExportProject{
+			ID:         p.ID,
+			RefID:      p.RefID,
+			ReadableID: p.Identifier,
+		})
+	}
+	if err := session.Done(); err != nil {
+		return nil, err
+	}
+	reportfiles.MarkProcessed(s.logger, processed)
+	return exported, nil
+}
+
+func (s *Integration) exportProject(projectSession *objsender.Session, p *project) error {
+	sort.SliceStable(p.Analyses, func(i, j int) bool {
+		return p.Analyses[i].Date.Before(p.Analyses[j].Date)
+	})
+	metricSession, err := projectSession.Session(codequality.MetricModelName.String(), p.RefID, p.Name)
+	if err != nil {
+		s.logger.Error("error creating metric session", "err", err)
+		return err
+	}
+	for _, metric := range metrics(p) {
+		metric.CustomerID = s.customerID
+		if err := metricSession.Send(metric); err != nil {
+			s.logger.Error("error sending metric to agent", "err", err, "id", metric.RefID)
+			return err
+		}
+	}
+	if err := metricSession.Done(); err != nil {
+		return err
+	}
+	issueSession, err := projectSession.Session(extmodels.QualityIssueModelName, p.RefID, p.Name)
+	if err != nil {
+		s.logger.Error("error creating issue session", "err", err)
+		return err
+	}
+	// state of issues from previous exports is stored in integration state dir, so that issues are closed when not found in the next analysis and keep created date
+	stateLoc := filepath.Join(s.stateDir, "issues", hash.Values(p.RefID)+".json")
+	prev, err := issues.ReadState(stateLoc)
+	if err != nil {
+		s.logger.Warn("could not parse previous issue state, issues from previous exports will not be closed", "project", p.RefID, "err", err)
+		prev = issues.State{}
+	}
+	res, next := issues.Project(p.RefID, p.ID, refType, p.Analyses, prev)
+	for _, issue := range res {
+		issue.CustomerID = s.customerID
+		if err := issueSession.Send(issue); err != nil {
+			s.logger.Error("error sending issue to agent", "err", err, "id", issue.RefID)
+			return err
+		}
+	}
+	if err := issueSession.Done(); err != nil {
+		return err
+	}
+	return next.Save(stateLoc)
+}
+
+var nonAlphaNumericRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// metrics returns counts of open results by level for each analysis, named <tool>_results, <tool>_errors, <tool>_warnings and <tool>_notes
+func metrics(p *project) (res []*codequality.Metric) {
+	for _, a := range p.Analyses {
+		prefix := strings.Trim(nonAlphaNumericRe.ReplaceAllString(a.Tool, "_"), "_")
+		counts := map[string]int{"results": 0, "errors": 0, "warnings": 0, "notes": 0}
+		for _, f := range a.Findings {
+			if f.Suppressed || f.Absent {
+				continue
+			}
+			counts["results"]++
+			switch f.Level {
+			case "error":
+				counts["errors"]++
+			case "warning":
+				counts["warnings"]++
+			case "note":
+				counts["notes"]++
+			}
+		}
+		for _, k := range []string{"results", "errors", "warnings", "notes"} {
+			name := prefix + "_" + k
+			metric := &codequality.Metric{
+				Name:      name,
+				Value:     strconv.Itoa(counts[k]),
+				RefID:     hash.Values(p.ID, a.Date.Unix(), name),
+				RefType:   refType,
+				ProjectID: p.ID,
+			}
+			date.ConvertToModel(a.Date, &metric.CreatedDate)
+			res = append(res, metric)
+		}
+	}
+	return
+}
+
+// projectName returns project identifier and name of run. Uses repository uri of the run if available, then the repo document was read from and then the directory.
+func projectName(run *sarif.Run, doc reportfiles.Document) (identifier string, name string) {
+	for _, u := range []string{run.RepositoryURI(), doc.RepoURL} {
+		if u == "" {
+			continue
+		}
+		if identifier, name = gitclone.RepoNameFromURL(u); identifier != "" {
+			return
+		}
+	}
+	name = filepath.Base(doc.Dir)
+	return name, name
+}
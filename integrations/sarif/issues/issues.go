@@ -0,0 +1,173 @@
+// Package issues tracks issues found by sarif analyses across exports, using result fingerprints.
+package issues
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/agent/integrations/sarif/sarif"
+	"github.com/pinpt/agent/pkg/fs"
+	"github.com/pinpt/go-common/hash"
+)
+
+// Analysis is one run of analysis tool from sarif document.
+type Analysis struct {
+	Tool string
+	// Category separates runs of the same tool, issues are closed when not found in the next analysis of the same category
+	Category string
+	Date     time.Time
+	Findings []sarif.Finding
+}
+
+func (s Analysis) key() string {
+	return s.Tool + "/" + s.Category
+}
+
+// State is the state of issues after the latest analysis of each tool and category, by tool/category and fingerprint. Only issues that are not closed are kept, these are closed when not found in the next analysis. Stored between exports in a file per project, since it grows with the number of open issues.
+type State map[string]map[string]*extmodels.QualityIssue
+
+// ReadState reads state saved by Save. Returns empty state if file does not exist.
+func ReadState(loc string) (State, error) {
+	res := State{}
+	b, err := ioutil.ReadFile(loc)
+	if os.IsNotExist(err) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Save writes state to file at loc, creating the dir if needed.
+func (s State) Save(loc string) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(loc), 0777)
+	if err != nil {
+		return err
+	}
+	return fs.WriteToTempAndRename(bytes.NewReader(b), loc)
+}
+
+// Project returns issues found in project analyses, which must be sorted by date. Results with the same fingerprint are the same issue, the latest analysis of the same tool and category defines the current state. Issues not found in the latest analysis, including issues from prev state, are closed. Returns the new state, prev is not modified.
+func Project(projectRefID string, projectID string, refType string, analyses []Analysis, prev State) (res []*extmodels.QualityIssue, next State) {
+	next = State{}
+	groups := map[string][]Analysis{}
+	var keys []string
+	for _, a := range analyses {
+		k := a.key()
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], a)
+	}
+	for k, v := range prev {
+		if _, ok := groups[k]; !ok {
+			next[k] = v
+		}
+	}
+	for _, k := range keys {
+		analyses := groups[k]
+		byFingerprint := map[string]*extmodels.QualityIssue{}
+		// lastSeen is the index of the last analysis containing the issue, -1 for issues only in previous export
+		lastSeen := map[string]int{}
+		var order []string
+		var prevFingerprints []string
+		for fp := range prev[k] {
+			prevFingerprints = append(prevFingerprints, fp)
+		}
+		sort.Strings(prevFingerprints)
+		for _, fp := range prevFingerprints {
+			issue := *prev[k][fp]
+			byFingerprint[fp] = &issue
+			lastSeen[fp] = -1
+			order = append(order, fp)
+		}
+		for i, a := range analyses {
+			for _, f := range a.Findings {
+				issue := byFingerprint[f.Fingerprint]
+				if issue == nil {
+					issue = &extmodels.QualityIssue{
+						RefType:     refType,
+						RefID:       hash.Values(projectRefID, f.Fingerprint),
+						ProjectID:   projectID,
+						Fingerprint: f.Fingerprint,
+						CreatedDate: extmodels.NewDate(a.Date),
+					}
+					byFingerprint[f.Fingerprint] = issue
+					order = append(order, f.Fingerprint)
+				}
+				update(issue, f, a)
+				lastSeen[f.Fingerprint] = i
+			}
+		}
+		last := analyses[len(analyses)-1]
+		next[k] = map[string]*extmodels.QualityIssue{}
+		for _, fp := range order {
+			issue := byFingerprint[fp]
+			if lastSeen[fp] != len(analyses)-1 && issue.Status != "CLOSED" {
+				issue.Status = "CLOSED"
+				issue.Resolution = "FIXED"
+				issue.UpdatedDate = extmodels.NewDate(last.Date)
+				issue.ClosedDate = issue.UpdatedDate
+			}
+			if issue.Status != "CLOSED" {
+				v := *issue
+				next[k][fp] = &v
+			}
+			res = append(res, issue)
+		}
+	}
+	return
+}
+
+// update sets issue fields from finding. UpdatedDate is only changed when issue changes, so that unchanged issues are not sent again.
+func update(issue *extmodels.QualityIssue, f sarif.Finding, a Analysis) {
+	before := fields(*issue)
+	issue.Tool = a.Tool
+	issue.Type = f.Type()
+	issue.Severity = f.Severity()
+	issue.Rule = f.Rule
+	issue.Message = f.Message
+	issue.FilePath = f.FilePath
+	issue.Line = f.Line
+	issue.Tags = f.Tags
+	switch {
+	case f.Absent:
+		issue.Status = "CLOSED"
+		issue.Resolution = "FIXED"
+	case f.Suppressed:
+		issue.Status = "RESOLVED"
+		issue.Resolution = "WONTFIX"
+	default:
+		issue.Status = "OPEN"
+		issue.Resolution = ""
+	}
+	if fields(*issue) == before && issue.UpdatedDate.Epoch != 0 {
+		return
+	}
+	issue.UpdatedDate = extmodels.NewDate(a.Date)
+	issue.ClosedDate = extmodels.Date{}
+	if issue.Status == "CLOSED" {
+		issue.ClosedDate = issue.UpdatedDate
+	}
+}
+
+// fields returns hash of issue fields set from findings
+func fields(issue extmodels.QualityIssue) string {
+	return hash.Values(issue.Tool, issue.Type, issue.Severity, issue.Rule, issue.Message, issue.FilePath, issue.Line, issue.Status, issue.Resolution, strings.Join(issue.Tags, ","))
+}
@@ -0,0 +1,122 @@
+package issues
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pinpt/agent/integrations/sarif/sarif"
+	"github.com/stretchr/testify/assert"
+)
+
+func testFinding(fingerprint string) sarif.Finding {
+	return sarif.Finding{Rule: "go/unused", Level: "warning", Message: "x is unused", FilePath: "main.go", Line: 3, Fingerprint: fingerprint}
+}
+
+func TestProjectClosedInLaterAnalysis(t *testing.T) {
+	d1 := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	d2 := d1.Add(time.Hour)
+	analyses := []Analysis{
+		{Tool: "codeql", Date: d1, Findings: []sarif.Finding{testFinding("a"), testFinding("b")}},
+		{Tool: "codeql", Date: d2, Findings: []sarif.Finding{testFinding("a")}},
+		// other category does not close issues
+		{Tool: "codeql", Category: "js", Date: d2, Findings: []sarif.Finding{testFinding("c")}},
+	}
+	res, next := Project("p1", "pid", "sarif", analyses, State{})
+	assert.Len(t, res, 3)
+	assert.Equal(t, "OPEN", res[0].Status)
+	assert.Equal(t, d1, res[0].UpdatedDate.Time(), "unchanged issue keeps updated date")
+	assert.Equal(t, "CLOSED", res[1].Status)
+	assert.Equal(t, d2, res[1].ClosedDate.Time())
+	assert.Equal(t, "OPEN", res[2].Status)
+	assert.Len(t, next["codeql/"], 1)
+	assert.Len(t, next["codeql/js"], 1)
+}
+
+func TestProjectTwoExports(t *testing.T) {
+	d1 := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	d2 := d1.Add(24 * time.Hour)
+	d3 := d2.Add(24 * time.Hour)
+
+	// first export
+	res, state := Project("p1", "pid", "sarif", []Analysis{
+		{Tool: "codeql", Date: d1, Findings: []sarif.Finding{testFinding("a"), testFinding("b")}},
+		{Tool: "eslint", Date: d1, Findings: []sarif.Finding{testFinding("e")}},
+	}, State{})
+	assert.Len(t, res, 3)
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	loc := filepath.Join(dir, "issues", "p1.json")
+	err = state.Save(loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// second export, only has new analysis of codeql
+	prev, err := ReadState(loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := testFinding("a")
+	changed.Line = 5
+	res, state = Project("p1", "pid", "sarif", []Analysis{
+		{Tool: "codeql", Date: d2, Findings: []sarif.Finding{changed, testFinding("c")}},
+	}, prev)
+	byFingerprint := map[string]int{}
+	for i, issue := range res {
+		byFingerprint[issue.Fingerprint] = i
+	}
+	assert.Len(t, res, 3)
+
+	a := res[byFingerprint["a"]]
+	assert.Equal(t, "OPEN", a.Status)
+	assert.Equal(t, int64(5), a.Line)
+	assert.Equal(t, d1, a.CreatedDate.Time(), "keeps created date from previous export")
+	assert.Equal(t, d2, a.UpdatedDate.Time())
+
+	b := res[byFingerprint["b"]]
+	assert.Equal(t, "CLOSED", b.Status, "issue missing in second export is closed")
+	assert.Equal(t, "FIXED", b.Resolution)
+	assert.Equal(t, d1, b.CreatedDate.Time())
+	assert.Equal(t, d2, b.ClosedDate.Time())
+	assert.Equal(t, "go/unused", b.Rule, "closed issue keeps fields")
+
+	c := res[byFingerprint["c"]]
+	assert.Equal(t, "OPEN", c.Status)
+	assert.Equal(t, d2, c.CreatedDate.Time())
+
+	assert.Len(t, state["codeql/"], 2)
+	assert.Len(t, state["eslint/"], 1, "state of tools without new analysis is kept")
+
+	// third export, issue fixed earlier is not sent again
+	res, _ = Project("p1", "pid", "sarif", []Analysis{
+		{Tool: "codeql", Date: d3, Findings: []sarif.Finding{changed, testFinding("c")}},
+	}, state)
+	assert.Len(t, res, 2)
+	for _, issue := range res {
+		assert.Equal(t, d2, issue.UpdatedDate.Time(), "unchanged issue keeps updated date")
+	}
+}
+
+func TestReadStateInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	loc := filepath.Join(dir, "p1.json")
+	res, err := ReadState(loc)
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+	err = ioutil.WriteFile(loc, []byte("2020-05-01T10:00:00Z"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ReadState(loc)
+	assert.Error(t, err)
+}
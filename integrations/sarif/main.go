@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/pinpt/agent/integrations/pkg/ibase"
+	"github.com/pinpt/agent/pkg/gitclone"
+	"github.com/pinpt/agent/pkg/istate"
+	"github.com/pinpt/agent/pkg/structmarshal"
+	"github.com/pinpt/agent/rpcdef"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// refType is the ref_type of all exported objects
+const refType = "sarif"
+
+type Integration struct {
+	logger     hclog.Logger
+	agent      rpcdef.Agent
+	customerID string
+	config     Config
+	// repoCache is the location of repos cloned by sourcecode integrations, passed by agent
+	repoCache string
+	// stateDir stores issues of previous exports
+	stateDir string
+}
+
+// Config is the integration config, passed in options, since integration is configured manually in extra_integrations.
+type Config struct {
+	// Dirs are local directories with sarif documents, searched recursively. Documents modified since last export are exported.
+	Dirs []string `json:"dirs"`
+	// RepoPaths are patterns of sarif files committed to repos, for example reports/*.sarif. Read from HEAD of repos cloned by sourcecode integrations.
+	RepoPaths []string `json:"repo_paths"`
+	// DropDir is the folder where sarif documents are uploaded to, for example by ci. Documents are moved to processed subdir after export.
+	DropDir string `json:"drop_dir"`
+}
+
+func (s *Integration) Init(agent rpcdef.Agent) (rpcdef.Capabilities, error) {
+	s.agent = agent
+	return rpcdef.Capabilities{
+		IntegrationTypes: []string{"CODEQUALITY"},
+		Streaming:        true,
+	}, nil
+}
+
+func (s *Integration) Export(ctx context.Context, config rpcdef.ExportConfig) (res rpcdef.ExportResult, _ error) {
+	if err := s.initConfig(config); err != nil {
+		return res, err
+	}
+	projects, err := s.exportAll(ctx)
+	if err != nil {
+		return res, err
+	}
+	res.Projects = projects
+	return res, nil
+}
+
+func (s *Integration) ValidateConfig(ctx context.Context, config rpcdef.ExportConfig) (res rpcdef.ValidationResult, _ error) {
+	if err := s.initConfig(config); err != nil {
+		res.Errors = append(res.Errors, err.Error())
+		return res, nil
+	}
+	dirs := append([]string{}, s.config.Dirs...)
+	if s.config.DropDir != "" {
+		dirs = append(dirs, s.config.DropDir)
+	}
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			res.Errors = append(res.Errors, "Sarif dir is not accessible: "+err.Error())
+		}
+	}
+	return res, nil
+}
+
+func (s *Integration) OnboardExport(ctx context.Context, objectType rpcdef.OnboardExportType, config rpcdef.ExportConfig) (res rpcdef.OnboardExportResult, _ error) {
+	res.Error = rpcdef.ErrOnboardExportNotSupported
+	return
+}
+
+func (s *Integration) initConfig(config rpcdef.ExportConfig) error {
+	var conf Config
+	opts, _ := config.Integration.Config["options"].(map[string]interface{})
+	err := structmarshal.MapToStruct(opts, &conf)
+	if err != nil {
+		return err
+	}
+	if len(conf.Dirs) == 0 && len(conf.RepoPaths) == 0 && conf.DropDir == "" {
+		return errors.New("at least one of dirs, repo_paths or drop_dir is required in options")
+	}
+	s.repoCache = os.Getenv(gitclone.EnvCacheRoot)
+	if len(conf.RepoPaths) != 0 && s.repoCache == "" {
+		return errors.New("repo_paths require repo cache location, which is passed by agent")
+	}
+	s.stateDir, err = istate.Dir(refType)
+	if err != nil {
+		return err
+	}
+	s.config = conf
+	s.customerID = config.Pinpoint.CustomerID
+	return nil
+}
+
+func NewIntegration(logger hclog.Logger) *Integration {
+	s := &Integration{}
+	s.logger = logger
+	return s
+}
+
+func main() {
+	ibase.MainFunc(func(logger hclog.Logger) rpcdef.Integration {
+		return NewIntegration(logger)
+	})
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"context"
+
+	"github.com/pinpt/agent/integrations/pkg/mutate"
+	"github.com/pinpt/agent/rpcdef"
+)
+
+func (s *Integration) Mutate(ctx context.Context, fn, data string, config rpcdef.ExportConfig) (res rpcdef.MutateResult, rerr error) {
+	return mutate.ResultNotSupported(fn), nil
+}
@@ -0,0 +1,191 @@
+package sarif
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pinpt/go-common/hash"
+)
+
+// Finding is a result of analysis with defaults from the rule applied.
+type Finding struct {
+	Tool string
+	Rule string
+	// Level is error, warning, note or none
+	Level    string
+	Message  string
+	FilePath string
+	Line     int64
+	// Fingerprint identifies the same result across runs. Uses fingerprints provided by the tool if available, otherwise is based on rule, file and message.
+	Fingerprint string
+	// SecuritySeverity is the score from 0.1 to 10 set for security rules, 0 if not set
+	SecuritySeverity float64
+	Tags             []string
+	// Suppressed is true if the result was suppressed in source or externally, for example dismissed in code scanning
+	Suppressed bool
+	// Absent is true if the result was in the baseline but not in this run, meaning that it was fixed
+	Absent bool
+}
+
+// Findings returns failed results of the run. Results of pass, informational and notApplicable kinds are skipped.
+func (s *Run) Findings() (res []Finding) {
+	tool := s.ToolName()
+	for _, r := range s.Results {
+		switch r.Kind {
+		case "pass", "informational", "notApplicable":
+			continue
+		}
+		rule, _ := s.rule(r)
+		f := Finding{}
+		f.Tool = tool
+		f.Rule = r.RuleID
+		if f.Rule == "" && r.Rule != nil {
+			f.Rule = r.Rule.ID
+		}
+		if f.Rule == "" {
+			f.Rule = rule.ID
+		}
+		f.Level = r.Level
+		if f.Level == "" && r.Kind != "" && r.Kind != "fail" {
+			// level must be none for other kinds
+			f.Level = "none"
+		}
+		if f.Level == "" {
+			f.Level = rule.DefaultConfiguration.Level
+		}
+		if f.Level == "" {
+			f.Level = "warning"
+		}
+		f.Message = message(r.Message, rule)
+		for _, loc := range r.Locations {
+			if loc.PhysicalLocation == nil {
+				continue
+			}
+			f.FilePath = s.filePath(loc.PhysicalLocation.ArtifactLocation)
+			if loc.PhysicalLocation.Region != nil {
+				f.Line = loc.PhysicalLocation.Region.StartLine
+			}
+			break
+		}
+		f.SecuritySeverity = r.Properties.securitySeverity()
+		if f.SecuritySeverity == 0 {
+			f.SecuritySeverity = rule.Properties.securitySeverity()
+		}
+		f.Tags = mergeTags(rule.Properties.Tags, r.Properties.Tags)
+		for _, sup := range r.Suppressions {
+			if sup.Status == "" || sup.Status == "accepted" {
+				f.Suppressed = true
+			}
+		}
+		f.Absent = r.BaselineState == "absent"
+		f.Fingerprint = fingerprint(r, f)
+		res = append(res, f)
+	}
+	return
+}
+
+// Security returns true for results of security rules.
+func (s Finding) Security() bool {
+	if s.SecuritySeverity > 0 {
+		return true
+	}
+	for _, tag := range s.Tags {
+		if tag == "security" {
+			return true
+		}
+	}
+	return false
+}
+
+// Type returns the type of the finding, same as used in sonarqube issues: VULNERABILITY, BUG or CODE_SMELL.
+func (s Finding) Type() string {
+	if s.Security() {
+		return "VULNERABILITY"
+	}
+	if s.Level == "error" {
+		return "BUG"
+	}
+	return "CODE_SMELL"
+}
+
+// Severity returns severity on the scale used by sonarqube issues: BLOCKER, CRITICAL, MAJOR, MINOR or INFO. Security severity is mapped using the same ranges as github code scanning uses for critical, high, medium and low.
+func (s Finding) Severity() string {
+	switch {
+	case s.SecuritySeverity >= 9:
+		return "BLOCKER"
+	case s.SecuritySeverity >= 7:
+		return "CRITICAL"
+	case s.SecuritySeverity >= 4:
+		return "MAJOR"
+	case s.SecuritySeverity > 0:
+		return "MINOR"
+	}
+	switch s.Level {
+	case "error":
+		return "CRITICAL"
+	case "warning":
+		return "MAJOR"
+	case "note":
+		return "MINOR"
+	default:
+		return "INFO"
+	}
+}
+
+func (s PropertyBag) securitySeverity() float64 {
+	switch v := s.SecuritySeverity.(type) {
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
+
+// message returns the text of message, using rule message strings for messages referenced by id
+func message(msg Message, rule ReportingDescriptor) string {
+	text := msg.Text
+	if text == "" && msg.ID != "" {
+		text = rule.MessageStrings[msg.ID].Text
+	}
+	if text == "" && rule.ShortDescription != nil {
+		text = rule.ShortDescription.Text
+	}
+	for i, arg := range msg.Arguments {
+		text = strings.Replace(text, "{"+strconv.Itoa(i)+"}", arg, -1)
+	}
+	return text
+}
+
+func mergeTags(lists ...[]string) (res []string) {
+	seen := map[string]bool{}
+	for _, tags := range lists {
+		for _, tag := range tags {
+			if seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			res = append(res, tag)
+		}
+	}
+	return
+}
+
+// fingerprint returns the id of the result that stays the same when lines are added above the result. Partial fingerprints, such as primaryLocationLineHash, are only unique per rule and file.
+func fingerprint(r Result, f Finding) string {
+	fps := r.Fingerprints
+	if len(fps) == 0 {
+		fps = r.PartialFingerprints
+	}
+	if len(fps) == 0 {
+		return hash.Values(f.Tool, f.Rule, f.FilePath, f.Message)
+	}
+	var parts []string
+	for k, v := range fps {
+		parts = append(parts, k+"="+v)
+	}
+	sort.Strings(parts)
+	return hash.Values(f.Tool, f.Rule, f.FilePath, strings.Join(parts, ","))
+}
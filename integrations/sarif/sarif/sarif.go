@@ -0,0 +1,231 @@
+// Package sarif parses SARIF 2.1 static analysis results and converts them to findings.
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+package sarif
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Log is the root object of sarif document.
+type Log struct {
+	Version string `json:"version"`
+	Runs    []*Run `json:"runs"`
+}
+
+// Run contains results of one invocation of analysis tool.
+type Run struct {
+	Tool                     Tool                           `json:"tool"`
+	AutomationDetails        *RunAutomationDetails          `json:"automationDetails"`
+	Invocations              []Invocation                   `json:"invocations"`
+	OriginalURIBaseIDs       map[string]ArtifactLocation    `json:"originalUriBaseIds"`
+	VersionControlProvenance []VersionControlDetails        `json:"versionControlProvenance"`
+	Results                  []Result                       `json:"results"`
+	rules                    map[string]ReportingDescriptor `json:"-"`
+}
+
+type Tool struct {
+	Driver ToolComponent `json:"driver"`
+}
+
+type ToolComponent struct {
+	Name    string                `json:"name"`
+	Version string                `json:"version"`
+	Rules   []ReportingDescriptor `json:"rules"`
+}
+
+type ReportingDescriptor struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	DefaultConfiguration ReportingConfiguration `json:"defaultConfiguration"`
+	MessageStrings       map[string]Message     `json:"messageStrings"`
+	Properties           PropertyBag            `json:"properties"`
+	ShortDescription     *Message               `json:"shortDescription"`
+}
+
+type ReportingConfiguration struct {
+	Level string `json:"level"`
+}
+
+type RunAutomationDetails struct {
+	// ID is category/instance, for example codeql category used for each language
+	ID string `json:"id"`
+}
+
+type Invocation struct {
+	StartTimeUTC string `json:"startTimeUtc"`
+	EndTimeUTC   string `json:"endTimeUtc"`
+}
+
+type VersionControlDetails struct {
+	RepositoryURI string `json:"repositoryUri"`
+	RevisionID    string `json:"revisionId"`
+	Branch        string `json:"branch"`
+}
+
+type ArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type Message struct {
+	Text      string   `json:"text"`
+	ID        string   `json:"id"`
+	Arguments []string `json:"arguments"`
+}
+
+type Result struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           *int              `json:"ruleIndex"`
+	Rule                *RuleReference    `json:"rule"`
+	Kind                string            `json:"kind"`
+	Level               string            `json:"level"`
+	Message             Message           `json:"message"`
+	Locations           []Location        `json:"locations"`
+	Fingerprints        map[string]string `json:"fingerprints"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	BaselineState       string            `json:"baselineState"`
+	Suppressions        []Suppression     `json:"suppressions"`
+	Properties          PropertyBag       `json:"properties"`
+}
+
+type RuleReference struct {
+	ID    string `json:"id"`
+	Index *int   `json:"index"`
+}
+
+type Location struct {
+	PhysicalLocation *PhysicalLocation `json:"physicalLocation"`
+}
+
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region"`
+}
+
+type Region struct {
+	StartLine int64 `json:"startLine"`
+}
+
+type Suppression struct {
+	Kind string `json:"kind"`
+	// Status is accepted, underReview or rejected. Empty is the same as accepted.
+	Status string `json:"status"`
+}
+
+// PropertyBag contains custom properties. Tags and security-severity are used by codeql and github code scanning.
+type PropertyBag struct {
+	Tags             []string    `json:"tags"`
+	SecuritySeverity interface{} `json:"security-severity"`
+}
+
+// Parse parses sarif document. Returns an error for versions other than 2.1.
+func Parse(data []byte) (*Log, error) {
+	var res Log
+	err := json.Unmarshal(data, &res)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sarif json: %v", err)
+	}
+	if !strings.HasPrefix(res.Version, "2.1") {
+		return nil, fmt.Errorf("unsupported sarif version: %q, only 2.1 is supported", res.Version)
+	}
+	var runs []*Run
+	for _, run := range res.Runs {
+		if run == nil {
+			continue
+		}
+		runs = append(runs, run)
+		run.rules = map[string]ReportingDescriptor{}
+		for _, rule := range run.Tool.Driver.Rules {
+			run.rules[rule.ID] = rule
+		}
+	}
+	res.Runs = runs
+	return &res, nil
+}
+
+// ToolName returns the name of analysis tool.
+func (s *Run) ToolName() string {
+	return s.Tool.Driver.Name
+}
+
+// RepositoryURI returns the url of the analyzed repo. Returns empty string if run does not contain version control details.
+func (s *Run) RepositoryURI() string {
+	for _, v := range s.VersionControlProvenance {
+		if v.RepositoryURI != "" {
+			return v.RepositoryURI
+		}
+	}
+	return ""
+}
+
+// Category returns the category from automation details, which separates runs of the same tool on different parts of the repo, for example different languages. Returns empty string if run has no category.
+func (s *Run) Category() string {
+	if s.AutomationDetails == nil {
+		return ""
+	}
+	id := s.AutomationDetails.ID
+	i := strings.LastIndex(id, "/")
+	if i < 0 {
+		return ""
+	}
+	return id[:i]
+}
+
+// Date returns the time when analysis finished. Returns zero time if run does not contain invocation times.
+func (s *Run) Date() time.Time {
+	for _, inv := range s.Invocations {
+		for _, v := range []string{inv.EndTimeUTC, inv.StartTimeUTC} {
+			if v == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, v)
+			if err == nil {
+				return t.UTC()
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Run) rule(res Result) (ReportingDescriptor, bool) {
+	index := res.RuleIndex
+	if index == nil && res.Rule != nil {
+		index = res.Rule.Index
+	}
+	rules := s.Tool.Driver.Rules
+	if index != nil && *index >= 0 && *index < len(rules) {
+		return rules[*index], true
+	}
+	id := res.RuleID
+	if id == "" && res.Rule != nil {
+		id = res.Rule.ID
+	}
+	rule, ok := s.rules[id]
+	if !ok {
+		// ids of the form rule/subrule use the parent rule
+		if i := strings.Index(id, "/"); i > 0 {
+			rule, ok = s.rules[id[:i]]
+		}
+	}
+	return rule, ok
+}
+
+// filePath returns the path of artifact relative to source root if it's known, otherwise returns the uri
+func (s *Run) filePath(loc ArtifactLocation) string {
+	uri := loc.URI
+	// relative uris are already relative to their base, absolute uris are made relative to the matching base
+	for id, base := range s.OriginalURIBaseIDs {
+		if loc.URIBaseID != "" && id != loc.URIBaseID {
+			continue
+		}
+		if base.URI != "" && strings.HasPrefix(uri, base.URI) {
+			uri = strings.TrimPrefix(uri, base.URI)
+			break
+		}
+	}
+	uri = strings.TrimPrefix(uri, "file://")
+	return strings.TrimPrefix(uri, "./")
+}
@@ -0,0 +1,84 @@
+package sarif
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testLog = `{
+	"version": "2.1.0",
+	"runs": [{
+		"tool": {"driver": {"name": "CodeQL", "rules": [
+			{"id": "go/sql-injection", "defaultConfiguration": {"level": "error"}, "properties": {"tags": ["security"], "security-severity": "8.8"}},
+			{"id": "go/unused", "defaultConfiguration": {"level": "note"}, "messageStrings": {"default": {"text": "{0} is unused"}}}
+		]}},
+		"invocations": [{"startTimeUtc": "2020-05-01T10:00:00Z", "endTimeUtc": "2020-05-01T10:05:00+02:00"}],
+		"originalUriBaseIds": {"SRCROOT": {"uri": "file:///build/src/"}},
+		"versionControlProvenance": [{"repositoryUri": "https://github.com/pinpt/agent"}],
+		"results": [
+			{"ruleId": "go/sql-injection", "ruleIndex": 0, "message": {"text": "query built from user input"},
+				"locations": [{"physicalLocation": {"artifactLocation": {"uri": "db/query.go", "uriBaseId": "SRCROOT"}, "region": {"startLine": 12}}}],
+				"partialFingerprints": {"primaryLocationLineHash": "abc:1"}},
+			{"ruleId": "go/unused", "message": {"id": "default", "arguments": ["x"]},
+				"locations": [{"physicalLocation": {"artifactLocation": {"uri": "file:///build/src/main.go"}, "region": {"startLine": 3}}}],
+				"suppressions": [{"kind": "inSource"}]},
+			{"ruleId": "go/unused", "kind": "pass", "message": {"text": "ok"}},
+			{"ruleId": "lint/other", "message": {"text": "fixed"}, "baselineState": "absent"}
+		]
+	}]
+}`
+
+func TestParseFindings(t *testing.T) {
+	log, err := Parse([]byte(testLog))
+	assert.NoError(t, err)
+	assert.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, "CodeQL", run.ToolName())
+	assert.Equal(t, "https://github.com/pinpt/agent", run.RepositoryURI())
+	assert.Equal(t, time.Date(2020, 5, 1, 8, 5, 0, 0, time.UTC), run.Date())
+
+	findings := run.Findings()
+	assert.Len(t, findings, 3, "pass results are skipped")
+
+	f := findings[0]
+	assert.Equal(t, "go/sql-injection", f.Rule)
+	assert.Equal(t, "error", f.Level)
+	assert.Equal(t, "db/query.go", f.FilePath)
+	assert.Equal(t, int64(12), f.Line)
+	assert.Equal(t, 8.8, f.SecuritySeverity)
+	assert.Equal(t, "VULNERABILITY", f.Type())
+	assert.Equal(t, "CRITICAL", f.Severity())
+	assert.False(t, f.Suppressed)
+
+	f = findings[1]
+	assert.Equal(t, "x is unused", f.Message)
+	assert.Equal(t, "note", f.Level)
+	assert.Equal(t, "main.go", f.FilePath)
+	assert.Equal(t, "CODE_SMELL", f.Type())
+	assert.Equal(t, "MINOR", f.Severity())
+	assert.True(t, f.Suppressed)
+
+	f = findings[2]
+	assert.Equal(t, "warning", f.Level, "default level is warning")
+	assert.Equal(t, "MAJOR", f.Severity())
+	assert.True(t, f.Absent)
+}
+
+func TestFingerprintStableWhenLineChanges(t *testing.T) {
+	run := &Run{Tool: Tool{Driver: ToolComponent{Name: "eslint"}}}
+	run.Results = []Result{
+		{RuleID: "no-eval", Message: Message{Text: "eval"}, Locations: []Location{{PhysicalLocation: &PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: "a.js"}, Region: &Region{StartLine: 1}}}}},
+		{RuleID: "no-eval", Message: Message{Text: "eval"}, Locations: []Location{{PhysicalLocation: &PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: "a.js"}, Region: &Region{StartLine: 5}}}}},
+		{RuleID: "no-eval", Message: Message{Text: "eval"}, Locations: []Location{{PhysicalLocation: &PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: "b.js"}}}}},
+	}
+	findings := run.Findings()
+	assert.Equal(t, findings[0].Fingerprint, findings[1].Fingerprint)
+	assert.NotEqual(t, findings[0].Fingerprint, findings[2].Fingerprint)
+}
+
+func TestParseUnsupportedVersion(t *testing.T) {
+	_, err := Parse([]byte(`{"version":"1.0.0","runs":[]}`))
+	assert.Error(t, err)
+}
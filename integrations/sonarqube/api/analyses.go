@@ -0,0 +1,165 @@
+package api
+
+import (
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/go-common/hash"
+	"github.com/pinpt/integration-sdk/codequality"
+)
+
+// AnalysisModelName is the model name used for exported branch and pull request analyses.
+const AnalysisModelName = extmodels.AnalysisModelName
+
+const (
+	AnalysisKindBranch      = extmodels.AnalysisKindBranch
+	AnalysisKindPullRequest = extmodels.AnalysisKindPullRequest
+)
+
+// Analysis contains measures and quality gate status of the last analysis of branch or pull request.
+type Analysis = extmodels.Analysis
+
+type analysisStatus struct {
+	QualityGateStatus string `json:"qualityGateStatus"`
+}
+
+type branchesResponse struct {
+	Branches []struct {
+		Name         string         `json:"name"`
+		IsMain       bool           `json:"isMain"`
+		Status       analysisStatus `json:"status"`
+		AnalysisDate string         `json:"analysisDate"`
+	} `json:"branches"`
+}
+
+type pullRequestsResponse struct {
+	PullRequests []struct {
+		Key          string         `json:"key"`
+		Title        string         `json:"title"`
+		Branch       string         `json:"branch"`
+		Base         string         `json:"base"`
+		Status       analysisStatus `json:"status"`
+		AnalysisDate string         `json:"analysisDate"`
+		URL          string         `json:"url"`
+	} `json:"pullRequests"`
+}
+
+type measuresResponse struct {
+	Component struct {
+		Measures []struct {
+			Metric string `json:"metric"`
+			Value  string `json:"value"`
+			// Period contains value of metrics on new code, used since 8.1
+			Period *struct {
+				Value string `json:"value"`
+			} `json:"period"`
+			Periods []struct {
+				Value string `json:"value"`
+			} `json:"periods"`
+		} `json:"measures"`
+	} `json:"component"`
+}
+
+// FetchAnalyses returns last analyses of project branches and pull requests analyzed after fromDate. Returns all analyses if fromDate is zero. repo is the repo bound to project, can be nil. Branch and pull request analysis requires developer edition, for other editions only the main branch is returned.
+func (a *SonarqubeAPI) FetchAnalyses(project *codequality.Project, fromDate time.Time, repo *RepoBinding) (res []*Analysis, _ error) {
+	project.ToMap() // need to call setDefaults so that ID is set
+
+	projectParam := neturl.QueryEscape(project.Identifier)
+
+	newAnalysis := func(kind string, name string, status analysisStatus, date string) (*Analysis, error) {
+		analyzed, err := parseDate(date)
+		if err != nil {
+			return nil, err
+		}
+		if analyzed.IsZero() || analyzed.Before(fromDate) {
+			return nil, nil
+		}
+		return &Analysis{
+			RefType:           refType,
+			RefID:             hash.Values(project.ID, kind, name),
+			ProjectID:         project.ID,
+			Kind:              kind,
+			QualityGateStatus: status.QualityGateStatus,
+			AnalysisDate:      newDate(analyzed),
+			Repo:              repo,
+		}, nil
+	}
+
+	var branches branchesResponse
+	err := a.doRequest("GET", "/project_branches/list?project="+projectParam, time.Time{}, &branches)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	for _, data := range branches.Branches {
+		analysis, err := newAnalysis(AnalysisKindBranch, data.Name, data.Status, data.AnalysisDate)
+		if err != nil {
+			return nil, err
+		}
+		if analysis == nil {
+			continue
+		}
+		analysis.Branch = data.Name
+		analysis.Main = data.IsMain
+		analysis.Measures, err = a.fetchMeasures(project, "branch", data.Name)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, analysis)
+	}
+
+	var prs pullRequestsResponse
+	err = a.doRequest("GET", "/project_pull_requests/list?project="+projectParam, time.Time{}, &prs)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	for _, data := range prs.PullRequests {
+		analysis, err := newAnalysis(AnalysisKindPullRequest, data.Key, data.Status, data.AnalysisDate)
+		if err != nil {
+			return nil, err
+		}
+		if analysis == nil {
+			continue
+		}
+		analysis.Branch = data.Branch
+		analysis.BaseBranch = data.Base
+		analysis.PullRequest = data.Key
+		analysis.PullRequestTitle = data.Title
+		analysis.PullRequestURL = data.URL
+		analysis.Measures, err = a.fetchMeasures(project, "pullRequest", data.Key)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, analysis)
+	}
+	return res, nil
+}
+
+// fetchMeasures returns current values of configured metrics for branch or pull request. param is branch or pullRequest.
+func (a *SonarqubeAPI) fetchMeasures(project *codequality.Project, param string, value string) (map[string]string, error) {
+	params := neturl.Values{}
+	params.Set("component", project.Identifier)
+	params.Set(param, value)
+	params.Set("metricKeys", strings.Join(a.metrics, ","))
+	var val measuresResponse
+	err := a.doRequest("GET", "/measures/component?"+params.Encode(), time.Time{}, &val)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]string{}
+	for _, m := range val.Component.Measures {
+		v := m.Value
+		if v == "" && m.Period != nil {
+			v = m.Period.Value
+		}
+		if v == "" && len(m.Periods) != 0 {
+			v = m.Periods[0].Value
+		}
+		if v == "" {
+			continue
+		}
+		res[m.Metric] = v
+	}
+	return res, nil
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/pinpt/integration-sdk/codequality"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchAnalyses(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/alm_settings/get_binding":
+			w.Write([]byte(`{"alm":"azure","repository":"repo1","slug":"proj1","url":"https://dev.azure.com/org"}`))
+		case "/project_branches/list":
+			w.Write([]byte(`{"branches":[
+				{"name":"master","isMain":true,"type":"LONG","status":{"qualityGateStatus":"OK"},"analysisDate":"2020-02-01T10:00:00+0000"},
+				{"name":"old","isMain":false,"type":"LONG","status":{"qualityGateStatus":"ERROR"},"analysisDate":"2019-01-01T10:00:00+0000"}
+			]}`))
+		case "/project_pull_requests/list":
+			w.Write([]byte(`{"pullRequests":[
+				{"key":"12","title":"Fix","branch":"feature/a","base":"master","status":{"qualityGateStatus":"ERROR"},"analysisDate":"2020-02-02T10:00:00+0000","url":"https://dev.azure.com/org/proj1/_git/repo1/pullrequest/12"}
+			]}`))
+		case "/measures/component":
+			assert.Equal(t, "p1", q.Get("component"))
+			if q.Get("pullRequest") == "12" {
+				w.Write([]byte(`{"component":{"measures":[{"metric":"new_coverage","period":{"value":"50.0"}},{"metric":"code_smells","value":"1"}]}}`))
+				return
+			}
+			assert.Equal(t, "master", q.Get("branch"))
+			w.Write([]byte(`{"component":{"measures":[{"metric":"coverage","value":"80.0"},{"metric":"new_coverage","periods":[{"index":1,"value":"70.0"}]}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"msg":"Unknown url"}]}`))
+		}
+	}))
+	defer ts.Close()
+
+	sonarapi := NewSonarqubeAPI(context.Background(), hclog.NewNullLogger(), testClients(), ts.URL, "token", metricsArray)
+	project := &codequality.Project{Identifier: "p1", RefID: "1"}
+
+	repo, err := sonarapi.FetchRepoBinding(project)
+	assert.NoError(t, err)
+	assert.Equal(t, &RepoBinding{RefType: "azure", Name: "proj1/repo1", URL: "https://dev.azure.com/org"}, repo)
+
+	analyses, err := sonarapi.FetchAnalyses(project, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), repo)
+	assert.NoError(t, err)
+	if !assert.Len(t, analyses, 2) {
+		return
+	}
+
+	branch := analyses[0]
+	assert.Equal(t, AnalysisKindBranch, branch.Kind)
+	assert.Equal(t, "master", branch.Branch)
+	assert.True(t, branch.Main)
+	assert.Equal(t, "OK", branch.QualityGateStatus)
+	assert.Equal(t, map[string]string{"coverage": "80.0", "new_coverage": "70.0"}, branch.Measures)
+
+	pr := analyses[1]
+	assert.Equal(t, AnalysisKindPullRequest, pr.Kind)
+	assert.Equal(t, "12", pr.PullRequest)
+	assert.Equal(t, "feature/a", pr.Branch)
+	assert.Equal(t, "master", pr.BaseBranch)
+	assert.Equal(t, map[string]string{"new_coverage": "50.0", "code_smells": "1"}, pr.Measures)
+
+	m := pr.ToMap()
+	assert.Equal(t, "azure", m["repo_ref_type"])
+	assert.Equal(t, "proj1/repo1", m["repo_name"])
+	assert.NotEqual(t, branch.ToMap()["id"], m["id"])
+}
+
+func TestFetchRepoBindingNotBound(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[{"msg":"Project 'p1' is not bound to any ALM"}]}`))
+	}))
+	defer ts.Close()
+
+	sonarapi := NewSonarqubeAPI(context.Background(), hclog.NewNullLogger(), testClients(), ts.URL, "token", metricsArray)
+	repo, err := sonarapi.FetchRepoBinding(&codequality.Project{Identifier: "p1"})
+	assert.NoError(t, err)
+	assert.Nil(t, repo)
+}
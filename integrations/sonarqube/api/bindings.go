@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	neturl "net/url"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/integration-sdk/codequality"
+)
+
+// RepoBinding is the source repo of project, configured in sonarqube ALM integration settings.
+type RepoBinding = extmodels.AnalysisRepo
+
+type bindingResponse struct {
+	ALM        string `json:"alm"`
+	Repository string `json:"repository"`
+	Slug       string `json:"slug"`
+	URL        string `json:"url"`
+}
+
+// FetchRepoBinding returns the repo bound to project. Returns nil if the project is not bound, the server does not support ALM bindings (before 8.1) or token does not have project admin permission required to read it.
+func (a *SonarqubeAPI) FetchRepoBinding(project *codequality.Project) (*RepoBinding, error) {
+	var val bindingResponse
+	err := a.doRequest("GET", "/alm_settings/get_binding?project="+neturl.QueryEscape(project.Identifier), time.Time{}, &val)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		if serr, ok := err.(*statusError); ok && serr.StatusCode == http.StatusForbidden {
+			a.logger.Warn("no permission to read ALM binding of project, project admin permission is required to link project to repo", "project", project.Identifier)
+			return nil, nil
+		}
+		return nil, err
+	}
+	res := &RepoBinding{}
+	res.URL = val.URL
+	switch val.ALM {
+	case "github":
+		res.RefType = "github"
+		res.Name = val.Repository
+	case "gitlab":
+		res.RefType = "gitlab"
+		// gitlab binding contains project id instead of name
+		res.RefID = val.Repository
+	case "azure":
+		res.RefType = "azure"
+		// slug is the azure project name
+		res.Name = val.Repository
+		if val.Slug != "" {
+			res.Name = val.Slug + "/" + val.Repository
+		}
+	case "bitbucketcloud", "bitbucket":
+		res.RefType = "bitbucket"
+		res.Name = val.Repository
+		if val.Slug != "" {
+			res.Name += "/" + val.Slug
+		}
+	default:
+		a.logger.Warn("unsupported ALM in project binding", "project", project.Identifier, "alm", val.ALM)
+		return nil, nil
+	}
+	return res, nil
+}
@@ -3,7 +3,6 @@ package api
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -13,7 +12,7 @@ import (
 	"time"
 
 	hclog "github.com/hashicorp/go-hclog"
-	"github.com/pinpt/go-common/httpdefaults"
+	"github.com/pinpt/agent/pkg/reqstats"
 	pstring "github.com/pinpt/go-common/strings"
 	"github.com/pinpt/httpclient"
 )
@@ -23,41 +22,47 @@ type SonarqubeAPI struct {
 	url       string
 	authToken string
 	metrics   []string
-	client    *httpclient.HTTPClient
-	logger    hclog.Logger
-	context   context.Context
+	// httpClient is the reqstats client used by all requests
+	httpClient *http.Client
+	client     *httpclient.HTTPClient
+	// pageClient does not follow pagination, used for requests where pages are requested manually
+	pageClient *httpclient.HTTPClient
+	logger     hclog.Logger
+	context    context.Context
 }
 
-func newClient(ctx context.Context, url string, retryable bool) *httpclient.HTTPClient {
-	transport := httpdefaults.DefaultTransport()
-	if !strings.Contains(url, "sonarcloud.io") {
-		// if a self-service installation allow self-signed certificates
-		// TODO: make this configurable
-		transport.TLSClientConfig = &tls.Config{}
-		transport.TLSClientConfig.InsecureSkipVerify = true
-	}
-	hcConfig := &httpclient.Config{
-		Paginator: httpclient.InBodyPaginator(),
+func newClient(ctx context.Context, httpClient *http.Client, retryable bool, paginate bool) *httpclient.HTTPClient {
+	hcConfig := &httpclient.Config{}
+	if paginate {
+		hcConfig.Paginator = httpclient.InBodyPaginator()
 	}
 	if retryable {
 		hcConfig.Retryable = httpclient.NewBackoffRetry(10*time.Millisecond, 100*time.Millisecond, 60*time.Second, 2.0)
 	}
-	client := &http.Client{
+	return httpclient.NewHTTPClient(ctx, hcConfig, httpClient)
+}
+
+// NewSonarqubeAPI creates api using clients from reqstats. Clients.TLSInsecure is used for self-hosted servers, so it should be created with TLSInsecureSkipVerify.
+func NewSonarqubeAPI(ctx context.Context, logger hclog.Logger, clients reqstats.Clients, url string, authToken string, metrics []string) *SonarqubeAPI {
+	// if a self-service installation allow self-signed certificates
+	// TODO: make this configurable
+	transport := clients.TLSInsecure.Transport
+	if strings.Contains(url, "sonarcloud.io") {
+		transport = clients.Default.Transport
+	}
+	httpClient := &http.Client{
 		Transport: transport,
 		Timeout:   1 * time.Minute,
 	}
-	return httpclient.NewHTTPClient(ctx, hcConfig, client)
-}
-
-func NewSonarqubeAPI(ctx context.Context, logger hclog.Logger, url string, authToken string, metrics []string) *SonarqubeAPI {
-
 	a := &SonarqubeAPI{
-		url:       url,
-		authToken: authToken,
-		metrics:   metrics,
-		logger:    logger,
-		context:   ctx,
-		client:    newClient(ctx, url, true),
+		url:        url,
+		authToken:  authToken,
+		metrics:    metrics,
+		logger:     logger,
+		context:    ctx,
+		httpClient: httpClient,
+		client:     newClient(ctx, httpClient, true, true),
+		pageClient: newClient(ctx, httpClient, true, false),
 	}
 	return a
 }
@@ -69,11 +74,12 @@ func (a *SonarqubeAPI) Validate() (bool, error) {
 		Valid bool `json:"valid"`
 	}
 	a = &SonarqubeAPI{
-		url:       a.url,
-		authToken: a.authToken,
-		metrics:   a.metrics,
-		client:    newClient(a.context, a.url, false),
-		logger:    a.logger,
+		url:        a.url,
+		authToken:  a.authToken,
+		metrics:    a.metrics,
+		httpClient: a.httpClient,
+		client:     newClient(a.context, a.httpClient, false, true),
+		logger:     a.logger,
 	}
 	err := a.doRequest("GET", "/authentication/validate", time.Time{}, &val)
 	if err != nil {
@@ -84,6 +90,50 @@ func (a *SonarqubeAPI) Validate() (bool, error) {
 }
 
 func (a *SonarqubeAPI) doRequest(method string, endPoint string, fromDate time.Time, obj interface{}) error {
+	return a.request(a.client, method, endPoint, fromDate, obj)
+}
+
+// doPageRequest requests a single page, endPoint must include p and ps params
+func (a *SonarqubeAPI) doPageRequest(endPoint string, obj interface{}) error {
+	return a.request(a.pageClient, "GET", endPoint, time.Time{}, obj)
+}
+
+// dateFormat is the format of dates in sonarqube api
+const dateFormat = "2006-01-02T15:04:05-0700"
+
+func formatDate(t time.Time) string {
+	// use utc, so that only the zero offset is changed below
+	str := t.UTC().Format(dateFormat)
+	// There seems to be a bug in Sonarqube api where it fails if the from date's
+	// time zone is -0 instead of +0
+	return strings.Replace(str, "+0", "-0", 1)
+}
+
+// parseDate parses date from sonarqube api, returns zero time for empty string
+func parseDate(str string) (time.Time, error) {
+	if str == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(dateFormat, str)
+}
+
+// statusError is returned when server responds with status other than 200
+type statusError struct {
+	StatusCode int
+	Errors     []string
+}
+
+func (s *statusError) Error() string {
+	return fmt.Sprintf("request failed, errors: %s", strings.Join(s.Errors, ", "))
+}
+
+// isNotFound returns true for 404 errors, returned by older servers for api endpoints that do not exist
+func isNotFound(err error) bool {
+	serr, ok := err.(*statusError)
+	return ok && serr.StatusCode == http.StatusNotFound
+}
+
+func (a *SonarqubeAPI) request(client *httpclient.HTTPClient, method string, endPoint string, fromDate time.Time, obj interface{}) error {
 	if a.url == "" {
 		return fmt.Errorf("Sonarqube API missing `url` property")
 	}
@@ -96,10 +146,7 @@ func (a *SonarqubeAPI) doRequest(method string, endPoint string, fromDate time.T
 	url := pstring.JoinURL(a.url, endPoint)
 
 	addFrom := func(url string, from time.Time) string {
-		str := from.Format("2006-01-02T15:04:05-0700")
-		// There seems to be a bug in Sonarqube api where it fails if the from date's
-		// time zone is -0 instead of +0
-		str = strings.Replace(str, "+0", "-0", 1)
+		str := formatDate(from)
 		if strings.Contains(url, "?") {
 			return url + "&from=" + str
 		}
@@ -116,7 +163,7 @@ func (a *SonarqubeAPI) doRequest(method string, endPoint string, fromDate time.T
 	req.Header.Add("Content-Type", "application/json")
 	req.SetBasicAuth(a.authToken, "")
 
-	res, err := a.client.Do(req)
+	res, err := client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -143,7 +190,7 @@ func (a *SonarqubeAPI) doRequest(method string, endPoint string, fromDate time.T
 
 		a.logger.Error("request failed", "status", res.Status, "url", req.URL.String())
 
-		return fmt.Errorf("request failed, errors: %s", strings.Join(errorsArr, ", "))
+		return &statusError{StatusCode: res.StatusCode, Errors: errorsArr}
 
 	}
 
@@ -0,0 +1,95 @@
+package api
+
+import (
+	neturl "net/url"
+	"strconv"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/integration-sdk/codequality"
+)
+
+// HotspotModelName is the model name used for exported security hotspots.
+const HotspotModelName = extmodels.SecurityHotspotModelName
+
+// Hotspot is a security sensitive piece of code that needs to be reviewed.
+type Hotspot = extmodels.SecurityHotspot
+
+type hotspotsResponse struct {
+	Paging   paging `json:"paging"`
+	Hotspots []struct {
+		Key                      string `json:"key"`
+		Component                string `json:"component"`
+		SecurityCategory         string `json:"securityCategory"`
+		VulnerabilityProbability string `json:"vulnerabilityProbability"`
+		Status                   string `json:"status"`
+		Resolution               string `json:"resolution"`
+		Line                     int64  `json:"line"`
+		Message                  string `json:"message"`
+		Author                   string `json:"author"`
+		CreationDate             string `json:"creationDate"`
+		UpdateDate               string `json:"updateDate"`
+		RuleKey                  string `json:"ruleKey"`
+	} `json:"hotspots"`
+	Components []component `json:"components"`
+}
+
+// FetchHotspots returns security hotspots created or updated after fromDate. Returns all hotspots if fromDate is zero. Hotspots api does not support date filters, so all hotspots are requested and filtered by update date. Returns no hotspots for sonarqube versions before 8.1, which do not have hotspots api.
+func (a *SonarqubeAPI) FetchHotspots(project *codequality.Project, fromDate time.Time) ([]*Hotspot, error) {
+	project.ToMap() // need to call setDefaults so that ID is set
+
+	var res []*Hotspot
+	params := neturl.Values{}
+	params.Set("projectKey", project.Identifier)
+	params.Set("ps", strconv.Itoa(pageSize))
+	for p := 1; ; p++ {
+		if p*pageSize > maxResults {
+			a.logger.Warn("too many security hotspots, skipping the rest", "project", project.Identifier, "limit", maxResults)
+			break
+		}
+		params.Set("p", strconv.Itoa(p))
+		var val hotspotsResponse
+		err := a.doPageRequest("/hotspots/search?"+params.Encode(), &val)
+		if err != nil {
+			if isNotFound(err) {
+				a.logger.Info("security hotspots api not available, requires sonarqube 8.1 or later")
+				return nil, nil
+			}
+			return nil, err
+		}
+		paths := componentPaths(val.Components)
+		for _, data := range val.Hotspots {
+			created, err := parseDate(data.CreationDate)
+			if err != nil {
+				return nil, err
+			}
+			updated, err := parseDate(data.UpdateDate)
+			if err != nil {
+				return nil, err
+			}
+			if updated.Before(fromDate) {
+				continue
+			}
+			res = append(res, &Hotspot{
+				RefType:                  refType,
+				RefID:                    data.Key,
+				ProjectID:                project.ID,
+				Rule:                     data.RuleKey,
+				SecurityCategory:         data.SecurityCategory,
+				VulnerabilityProbability: data.VulnerabilityProbability,
+				Message:                  data.Message,
+				FilePath:                 filePath(paths, project.Identifier, data.Component),
+				Line:                     data.Line,
+				Status:                   data.Status,
+				Resolution:               data.Resolution,
+				Author:                   data.Author,
+				CreatedDate:              newDate(created),
+				UpdatedDate:              newDate(updated),
+			})
+		}
+		if p*pageSize >= val.Paging.Total {
+			break
+		}
+	}
+	return res, nil
+}
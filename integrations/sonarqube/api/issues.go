@@ -0,0 +1,181 @@
+package api
+
+import (
+	neturl "net/url"
+	"strconv"
+	"time"
+
+	"github.com/pinpt/integration-sdk/codequality"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+)
+
+// IssueModelName is the model name used for exported issues.
+const IssueModelName = extmodels.QualityIssueModelName
+
+// Issue is a bug, vulnerability or code smell found by analysis.
+type Issue = extmodels.QualityIssue
+
+type issuesResponse struct {
+	// Total is returned by older versions instead of paging
+	Total  int    `json:"total"`
+	Paging paging `json:"paging"`
+	Issues []struct {
+		Key          string   `json:"key"`
+		Rule         string   `json:"rule"`
+		Severity     string   `json:"severity"`
+		Component    string   `json:"component"`
+		Line         int64    `json:"line"`
+		Status       string   `json:"status"`
+		Resolution   string   `json:"resolution"`
+		Message      string   `json:"message"`
+		Effort       string   `json:"effort"`
+		Author       string   `json:"author"`
+		Tags         []string `json:"tags"`
+		Type         string   `json:"type"`
+		CreationDate string   `json:"creationDate"`
+		UpdateDate   string   `json:"updateDate"`
+		CloseDate    string   `json:"closeDate"`
+	} `json:"issues"`
+	Components []component `json:"components"`
+}
+
+func (s issuesResponse) total() int {
+	if s.Paging.Total > s.Total {
+		return s.Paging.Total
+	}
+	return s.Total
+}
+
+func (s issuesResponse) convert(project *codequality.Project) (res []*Issue, _ error) {
+	paths := componentPaths(s.Components)
+	for _, data := range s.Issues {
+		issue := &Issue{
+			RefType:    refType,
+			RefID:      data.Key,
+			ProjectID:  project.ID,
+			Tool:       refType,
+			Type:       data.Type,
+			Severity:   data.Severity,
+			Rule:       data.Rule,
+			Message:    data.Message,
+			FilePath:   filePath(paths, project.Identifier, data.Component),
+			Line:       data.Line,
+			Status:     data.Status,
+			Resolution: data.Resolution,
+			Effort:     data.Effort,
+			Author:     data.Author,
+			Tags:       data.Tags,
+		}
+		created, err := parseDate(data.CreationDate)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := parseDate(data.UpdateDate)
+		if err != nil {
+			return nil, err
+		}
+		closed, err := parseDate(data.CloseDate)
+		if err != nil {
+			return nil, err
+		}
+		issue.CreatedDate = newDate(created)
+		issue.UpdatedDate = newDate(updated)
+		issue.ClosedDate = newDate(closed)
+		res = append(res, issue)
+	}
+	return
+}
+
+// FetchIssues returns issues created or updated after fromDate. Returns all issues if fromDate is zero.
+//
+// New issues are fetched using createdAfter filter. Since search api returns at most 10000 results for one query, createdAfter is moved to the creation date of the last returned issue until all issues are fetched. Changes to existing issues, such as status changes, are fetched by sorting by update date and stopping at fromDate.
+func (a *SonarqubeAPI) FetchIssues(project *codequality.Project, fromDate time.Time) ([]*Issue, error) {
+	project.ToMap() // need to call setDefaults so that ID is set
+
+	var res []*Issue
+	seen := map[string]bool{}
+	add := func(issue *Issue) {
+		if seen[issue.RefID] {
+			return
+		}
+		seen[issue.RefID] = true
+		res = append(res, issue)
+	}
+
+	createdAfter := fromDate
+	for {
+		params := neturl.Values{}
+		params.Set("componentKeys", project.Identifier)
+		params.Set("s", "CREATION_DATE")
+		params.Set("asc", "true")
+		if !createdAfter.IsZero() {
+			params.Set("createdAfter", formatDate(createdAfter))
+		}
+		var last time.Time
+		complete, err := a.issuesPages(project, params, func(issues []*Issue) bool {
+			for _, issue := range issues {
+				add(issue)
+				last = issue.CreatedDate.Time()
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		if complete {
+			break
+		}
+		if !last.After(createdAfter) {
+			a.logger.Warn("too many issues with the same creation date, skipping the rest", "project", project.Identifier, "created", last.String())
+			break
+		}
+		createdAfter = last
+	}
+
+	if fromDate.IsZero() {
+		return res, nil
+	}
+
+	params := neturl.Values{}
+	params.Set("componentKeys", project.Identifier)
+	params.Set("s", "UPDATE_DATE")
+	params.Set("asc", "false")
+	complete, err := a.issuesPages(project, params, func(issues []*Issue) bool {
+		for _, issue := range issues {
+			if issue.UpdatedDate.Time().Before(fromDate) {
+				return false
+			}
+			add(issue)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !complete {
+		a.logger.Warn("too many issues updated since last export, skipping older updates", "project", project.Identifier, "limit", maxResults)
+	}
+	return res, nil
+}
+
+// issuesPages requests pages of issues matching params and passes them to fn, until fn returns false or there are no more pages. Returns false if result limit of search api was reached before all issues were returned.
+func (a *SonarqubeAPI) issuesPages(project *codequality.Project, params neturl.Values, fn func([]*Issue) bool) (complete bool, _ error) {
+	params.Set("ps", strconv.Itoa(pageSize))
+	for p := 1; p*pageSize <= maxResults; p++ {
+		params.Set("p", strconv.Itoa(p))
+		var val issuesResponse
+		err := a.doPageRequest("/issues/search?"+params.Encode(), &val)
+		if err != nil {
+			return false, err
+		}
+		issues, err := val.convert(project)
+		if err != nil {
+			return false, err
+		}
+		if !fn(issues) || p*pageSize >= val.total() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/pinpt/integration-sdk/codequality"
+	"github.com/stretchr/testify/assert"
+)
+
+type testIssue struct {
+	Key          string `json:"key"`
+	Component    string `json:"component"`
+	Status       string `json:"status"`
+	CreationDate string `json:"creationDate"`
+	UpdateDate   string `json:"updateDate"`
+}
+
+func testIssuesServer(t *testing.T, issues []testIssue) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/issues/search" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"msg":"Unknown url"}]}`))
+			return
+		}
+		q := r.URL.Query()
+		assert.Equal(t, "p1", q.Get("componentKeys"))
+		var res []testIssue
+		for _, issue := range issues {
+			if v := q.Get("createdAfter"); v != "" {
+				after, err := time.Parse(dateFormat, v)
+				assert.NoError(t, err)
+				created, _ := time.Parse(dateFormat, issue.CreationDate)
+				if created.Before(after) {
+					continue
+				}
+			}
+			res = append(res, issue)
+		}
+		sort.Slice(res, func(i, j int) bool {
+			if q.Get("s") == "UPDATE_DATE" {
+				return res[i].UpdateDate > res[j].UpdateDate
+			}
+			return res[i].CreationDate < res[j].CreationDate
+		})
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"paging":     paging{PageIndex: 1, PageSize: pageSize, Total: len(res)},
+			"issues":     res,
+			"components": []component{{Key: "p1:src/a.go", Path: "src/a.go"}},
+		})
+	}))
+}
+
+func issueKeys(issues []*Issue) (res []string) {
+	for _, issue := range issues {
+		res = append(res, issue.RefID)
+	}
+	sort.Strings(res)
+	return
+}
+
+func TestFetchIssuesIncremental(t *testing.T) {
+	ts := testIssuesServer(t, []testIssue{
+		{Key: "old", Component: "p1:src/a.go", Status: "OPEN", CreationDate: "2020-01-01T10:00:00+0000", UpdateDate: "2020-01-01T10:00:00+0000"},
+		{Key: "closed", Component: "p1:src/b.go", Status: "CLOSED", CreationDate: "2020-01-02T10:00:00+0000", UpdateDate: "2020-02-02T10:00:00+0000"},
+		{Key: "new", Component: "p1", Status: "OPEN", CreationDate: "2020-02-01T10:00:00+0200", UpdateDate: "2020-02-03T10:00:00+0000"},
+	})
+	defer ts.Close()
+
+	sonarapi := NewSonarqubeAPI(context.Background(), hclog.NewNullLogger(), testClients(), ts.URL, "token", metricsArray)
+	project := &codequality.Project{Identifier: "p1", RefID: "1"}
+
+	issues, err := sonarapi.FetchIssues(project, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"closed", "new", "old"}, issueKeys(issues))
+
+	issues, err = sonarapi.FetchIssues(project, time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"closed", "new"}, issueKeys(issues))
+	for _, issue := range issues {
+		assert.Equal(t, project.ID, issue.ProjectID)
+		switch issue.RefID {
+		case "closed":
+			assert.Equal(t, "src/b.go", issue.FilePath)
+			assert.Equal(t, "CLOSED", issue.Status)
+		case "new":
+			assert.Equal(t, "", issue.FilePath)
+			assert.Equal(t, time.Date(2020, 2, 1, 8, 0, 0, 0, time.UTC).UnixNano()/1e6, issue.CreatedDate.Epoch)
+		}
+	}
+
+	hotspots, err := sonarapi.FetchHotspots(project, time.Time{})
+	assert.NoError(t, err, "hotspots are skipped on servers without hotspots api")
+	assert.Empty(t, hotspots)
+}
+
+func TestFilePath(t *testing.T) {
+	paths := map[string]string{"p1:a": "src/a.go"}
+	assert.Equal(t, "src/a.go", filePath(paths, "p1", "p1:a"))
+	assert.Equal(t, "src/b.go", filePath(paths, "p1", "p1:src/b.go"))
+	assert.Equal(t, "", filePath(paths, "p1", "p1"))
+}
+
+func TestIssueHashcodeIncludesDates(t *testing.T) {
+	created := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	issue := Issue{RefType: refType, RefID: "i1", Tool: refType, Status: "OPEN", CreatedDate: newDate(created), UpdatedDate: newDate(created)}
+	h1 := issue.ToMap()["hashcode"]
+
+	// comment or assignee changes only update the date
+	issue.UpdatedDate = newDate(created.Add(time.Hour))
+	h2 := issue.ToMap()["hashcode"]
+	assert.NotEqual(t, h1, h2)
+
+	issue.ClosedDate = issue.UpdatedDate
+	assert.NotEqual(t, h2, issue.ToMap()["hashcode"])
+}
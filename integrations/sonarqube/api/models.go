@@ -0,0 +1,57 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+)
+
+// refType is the ref_type of all exported objects
+const refType = "sonarqube"
+
+// Date is a date in the same format as used in integration-sdk models.
+type Date = extmodels.Date
+
+func newDate(t time.Time) Date {
+	return extmodels.NewDate(t)
+}
+
+// component is a file or directory in project, returned together with issues and hotspots
+type component struct {
+	Key  string `json:"key"`
+	Path string `json:"path"`
+}
+
+// componentPaths returns file paths by component key
+func componentPaths(components []component) map[string]string {
+	res := map[string]string{}
+	for _, c := range components {
+		res[c.Key] = c.Path
+	}
+	return res
+}
+
+// filePath returns path of file in project for component key. Component keys have the project:path format.
+func filePath(paths map[string]string, projectKey string, componentKey string) string {
+	if v := paths[componentKey]; v != "" {
+		return v
+	}
+	if componentKey == projectKey {
+		return ""
+	}
+	return strings.TrimPrefix(componentKey, projectKey+":")
+}
+
+type paging struct {
+	PageIndex int `json:"pageIndex"`
+	PageSize  int `json:"pageSize"`
+	Total     int `json:"total"`
+}
+
+const (
+	// pageSize is the max page size supported by search apis
+	pageSize = 500
+	// maxResults is the max number of results returned by search apis for one query, pages past this limit return an error
+	maxResults = 10000
+)
@@ -27,10 +27,15 @@ func (a *SonarqubeAPI) FetchProjects() ([]*codequality.Project, error) {
 	}
 	var projects []*codequality.Project
 	for _, proj := range val.Components {
+		refID := proj.ID
+		if refID == "" {
+			// id is not returned since sonarqube 8.0, key is also unique
+			refID = proj.Key
+		}
 		projects = append(projects, &codequality.Project{
 			Identifier: proj.Key,
 			Name:       proj.Name,
-			RefID:      proj.ID,
+			RefID:      refID,
 			RefType:    "sonarqube",
 		})
 	}
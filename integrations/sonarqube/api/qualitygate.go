@@ -0,0 +1,66 @@
+package api
+
+import (
+	neturl "net/url"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/go-common/hash"
+	"github.com/pinpt/integration-sdk/codequality"
+)
+
+// QualityGateStatusModelName is the model name used for exported quality gate statuses.
+const QualityGateStatusModelName = extmodels.QualityGateStatusModelName
+
+// QualityGateStatus is the quality gate result of one project analysis.
+type QualityGateStatus = extmodels.QualityGateStatus
+
+type qualityGateResponse struct {
+	QualityGate struct {
+		Name string `json:"name"`
+	} `json:"qualityGate"`
+}
+
+// FetchQualityGateStatuses returns quality gate status of project analyses after fromDate. Returns all analyses if fromDate is zero.
+func (a *SonarqubeAPI) FetchQualityGateStatuses(project *codequality.Project, fromDate time.Time) ([]*QualityGateStatus, error) {
+	project.ToMap() // need to call setDefaults so that ID is set
+
+	gate := qualityGateResponse{}
+	err := a.doRequest("GET", "/qualitygates/get_by_project?project="+neturl.QueryEscape(project.Identifier), time.Time{}, &gate)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+
+	// alert_status metric contains the quality gate status of each analysis
+	ur := "/measures/search_history?p=1&ps=500&component=" + neturl.QueryEscape(project.Identifier) + "&metrics=alert_status"
+	val := []metricsResponse{}
+	err = a.doRequest("GET", ur, fromDate, &val)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*QualityGateStatus
+	for _, each := range val {
+		for _, measure := range each.Measures {
+			for _, h := range measure.History {
+				if h.Value == "" {
+					continue
+				}
+				created, err := parseDate(h.Date)
+				if err != nil {
+					return nil, err
+				}
+				res = append(res, &QualityGateStatus{
+					RefType:     refType,
+					RefID:       hash.Values(project.ID, h.Date),
+					ProjectID:   project.ID,
+					QualityGate: gate.QualityGate.Name,
+					Status:      h.Value,
+					Passed:      h.Value != "ERROR",
+					CreatedDate: newDate(created),
+				})
+			}
+		}
+	}
+	return res, nil
+}
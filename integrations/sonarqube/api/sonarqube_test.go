@@ -7,6 +7,7 @@ import (
 	"time"
 
 	hclog "github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/pkg/reqstats"
 	"github.com/pinpt/integration-sdk/codequality"
 	"github.com/stretchr/testify/assert"
 )
@@ -26,6 +27,10 @@ func init() {
 		authToken = os.Getenv("PP_TEST_SONARQUBE_APIKEY")
 	}
 }
+func testClients() reqstats.Clients {
+	return reqstats.New(reqstats.Opts{Logger: hclog.NewNullLogger(), TLSInsecureSkipVerify: true}).Clients
+}
+
 func skipTests(t *testing.T) bool {
 	if os.Getenv("PP_TEST_SONARQUBE") == "" {
 		t.Skip("skipping sonarqube tests")
@@ -37,7 +42,7 @@ func TestFetchProjects(t *testing.T) {
 	if skipTests(t) {
 		return
 	}
-	sonarapi := NewSonarqubeAPI(context.Background(), hclog.NewNullLogger(), url, authToken, metricsArray)
+	sonarapi := NewSonarqubeAPI(context.Background(), hclog.NewNullLogger(), testClients(), url, authToken, metricsArray)
 	projects, err := sonarapi.FetchProjects()
 	assert.NoError(t, err)
 	assert.NotEmpty(t, projects)
@@ -46,7 +51,7 @@ func TestValidate(t *testing.T) {
 	if skipTests(t) {
 		return
 	}
-	sonarapi := NewSonarqubeAPI(context.Background(), hclog.NewNullLogger(), url, authToken, metricsArray)
+	sonarapi := NewSonarqubeAPI(context.Background(), hclog.NewNullLogger(), testClients(), url, authToken, metricsArray)
 	valid, err := sonarapi.Validate()
 	assert.NoError(t, err)
 	assert.True(t, valid)
@@ -56,7 +61,7 @@ func TestFetchMetrics(t *testing.T) {
 	if skipTests(t) {
 		return
 	}
-	sonarapi := NewSonarqubeAPI(context.Background(), hclog.NewNullLogger(), url, authToken, metricsArray)
+	sonarapi := NewSonarqubeAPI(context.Background(), hclog.NewNullLogger(), testClients(), url, authToken, metricsArray)
 	proj := &codequality.Project{
 		Identifier: "key-2",
 	}
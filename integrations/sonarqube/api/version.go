@@ -1,21 +1,14 @@
 package api
 
 import (
-	"crypto/tls"
 	"io/ioutil"
 	"net/http"
 
-	"github.com/pinpt/go-common/httpdefaults"
 	pstring "github.com/pinpt/go-common/strings"
 )
 
 func (a *SonarqubeAPI) ServerVersion() (serverVersion string, err error) {
 
-	c := &http.Client{}
-	transport := httpdefaults.DefaultTransport()
-	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: false}
-	c.Transport = transport
-
 	url := pstring.JoinURL(a.url, "server", "version")
 
 	var req *http.Request
@@ -27,7 +20,7 @@ func (a *SonarqubeAPI) ServerVersion() (serverVersion string, err error) {
 	req.SetBasicAuth(a.authToken, "")
 
 	var resp *http.Response
-	resp, err = c.Do(req)
+	resp, err = a.httpClient.Do(req)
 	if err != nil {
 		return
 	}
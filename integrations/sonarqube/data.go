@@ -2,48 +2,195 @@ package main
 
 import (
 	"github.com/pinpt/agent/integrations/pkg/objsender"
+	"github.com/pinpt/agent/integrations/pkg/repoprojects"
+	"github.com/pinpt/agent/integrations/sonarqube/api"
+	"github.com/pinpt/agent/rpcdef"
 	"github.com/pinpt/integration-sdk/codequality"
 )
 
-func (s *Integration) exportAll() error {
+// repoProject implements repoprojects.RepoProject for filtering
+type repoProject struct {
+	*codequality.Project
+}
+
+func (s repoProject) GetID() string {
+	return s.RefID
+}
+
+func (s repoProject) GetReadableID() string {
+	return s.Identifier
+}
+
+// filterProjects returns projects selected in onboarding
+func (s *Integration) filterProjects(projects []*codequality.Project) (res []*codequality.Project) {
+	var all []repoprojects.RepoProject
+	for _, project := range projects {
+		all = append(all, repoProject{project})
+	}
+	filtered := repoprojects.Filter(s.logger, all, repoprojects.FilterConfig{
+		OnlyIncludeReadableIDs: s.config.Projects,
+		ExcludedIDs:            s.config.Exclusions,
+		IncludedIDs:            s.config.Inclusions,
+	})
+	for _, project := range filtered {
+		res = append(res, project.(repoProject).Project)
+	}
+	return
+}
+
+func (s *Integration) exportAll() (exported []rpcdef.ExportProject, _ error) {
 	projects, err := s.api.FetchProjects()
 	if err != nil {
 		s.logger.Error("error fetching projects", "err", err)
-		return err
+		return nil, err
 	}
+	projects = s.filterProjects(projects)
 	session, err := objsender.Root(s.agent, codequality.ProjectModelName.String())
 	if err != nil {
 		s.logger.Error("error creating project session", "err", err)
-		return err
+		return nil, err
 	}
 	if err := session.SetTotal(len(projects)); err != nil {
 		s.logger.Error("error setting total projects on exportAll", "err", err)
-		return err
+		return nil, err
 	}
 	for _, project := range projects {
 		project.CustomerID = s.customerID
 		if err := session.Send(project); err != nil {
 			s.logger.Error("error sending project to agent", "err", err, "id", project.RefID)
+			return nil, err
+		}
+		if err := s.exportProject(session, project); err != nil {
+			return nil, err
+		}
+		exported = append(exported, rpcdef.ExportProject{
+			ID:         project.ID,
+			RefID:      project.RefID,
+			ReadableID: project.Identifier,
+		})
+	}
+	return exported, session.Done()
+}
+
+func (s *Integration) exportProject(session *objsender.Session, project *codequality.Project) error {
+	metricsession, err := session.Session(codequality.MetricModelName.String(), project.RefID, project.Name)
+	if err != nil {
+		s.logger.Error("error creating metric session", "err", err)
+		return err
+	}
+	metrics, err := s.api.FetchMetrics(project, session.LastProcessedTime())
+	if err != nil {
+		s.logger.Error("error fetching metrics", "err", err)
+		return err
+	}
+	for _, metric := range metrics {
+		metric.CustomerID = s.customerID
+		if err := metricsession.Send(metric); err != nil {
+			s.logger.Error("error sending metric to agent", "err", err, "id", metric.RefID)
 			return err
 		}
-		metricsession, err := session.Session(codequality.MetricModelName.String(), project.RefID, project.Name)
-		if err != nil {
-			s.logger.Error("error creating metric session", "err", err)
+	}
+	if err := metricsession.Done(); err != nil {
+		return err
+	}
+	if err := s.exportIssues(session, project); err != nil {
+		return err
+	}
+	if err := s.exportHotspots(session, project); err != nil {
+		return err
+	}
+	if err := s.exportQualityGateStatuses(session, project); err != nil {
+		return err
+	}
+	if err := s.exportAnalyses(session, project); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Integration) exportIssues(projectSession *objsender.Session, project *codequality.Project) error {
+	session, err := projectSession.Session(api.IssueModelName, project.RefID, project.Name)
+	if err != nil {
+		s.logger.Error("error creating issue session", "err", err)
+		return err
+	}
+	issues, err := s.api.FetchIssues(project, session.LastProcessedTime())
+	if err != nil {
+		s.logger.Error("error fetching issues", "err", err)
+		return err
+	}
+	for _, issue := range issues {
+		issue.CustomerID = s.customerID
+		if err := session.Send(issue); err != nil {
+			s.logger.Error("error sending issue to agent", "err", err, "id", issue.RefID)
 			return err
 		}
-		metrics, err := s.api.FetchMetrics(project, session.LastProcessedTime())
-		if err != nil {
-			s.logger.Error("error fetching metrics", "err", err)
+	}
+	return session.Done()
+}
+
+func (s *Integration) exportHotspots(projectSession *objsender.Session, project *codequality.Project) error {
+	session, err := projectSession.Session(api.HotspotModelName, project.RefID, project.Name)
+	if err != nil {
+		s.logger.Error("error creating hotspot session", "err", err)
+		return err
+	}
+	hotspots, err := s.api.FetchHotspots(project, session.LastProcessedTime())
+	if err != nil {
+		s.logger.Error("error fetching hotspots", "err", err)
+		return err
+	}
+	for _, hotspot := range hotspots {
+		hotspot.CustomerID = s.customerID
+		if err := session.Send(hotspot); err != nil {
+			s.logger.Error("error sending hotspot to agent", "err", err, "id", hotspot.RefID)
 			return err
 		}
-		for _, metric := range metrics {
-			metric.CustomerID = s.customerID
-			if err := metricsession.Send(metric); err != nil {
-				s.logger.Error("error sending metric to agent", "err", err, "id", metric.RefID)
-				return err
-			}
+	}
+	return session.Done()
+}
+
+func (s *Integration) exportQualityGateStatuses(projectSession *objsender.Session, project *codequality.Project) error {
+	session, err := projectSession.Session(api.QualityGateStatusModelName, project.RefID, project.Name)
+	if err != nil {
+		s.logger.Error("error creating quality gate session", "err", err)
+		return err
+	}
+	statuses, err := s.api.FetchQualityGateStatuses(project, session.LastProcessedTime())
+	if err != nil {
+		s.logger.Error("error fetching quality gate statuses", "err", err)
+		return err
+	}
+	for _, status := range statuses {
+		status.CustomerID = s.customerID
+		if err := session.Send(status); err != nil {
+			s.logger.Error("error sending quality gate status to agent", "err", err, "id", status.RefID)
+			return err
 		}
-		if err := metricsession.Done(); err != nil {
+	}
+	return session.Done()
+}
+
+func (s *Integration) exportAnalyses(projectSession *objsender.Session, project *codequality.Project) error {
+	session, err := projectSession.Session(api.AnalysisModelName, project.RefID, project.Name)
+	if err != nil {
+		s.logger.Error("error creating analysis session", "err", err)
+		return err
+	}
+	repo, err := s.api.FetchRepoBinding(project)
+	if err != nil {
+		s.logger.Error("error fetching repo binding", "err", err)
+		return err
+	}
+	analyses, err := s.api.FetchAnalyses(project, session.LastProcessedTime(), repo)
+	if err != nil {
+		s.logger.Error("error fetching branch and pull request analyses", "err", err)
+		return err
+	}
+	for _, analysis := range analyses {
+		analysis.CustomerID = s.customerID
+		if err := session.Send(analysis); err != nil {
+			s.logger.Error("error sending analysis to agent", "err", err, "id", analysis.RefID)
 			return err
 		}
 	}
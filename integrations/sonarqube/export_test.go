@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/cmd/cmdrunnorestarts/inconfig"
+	"github.com/pinpt/agent/integrations/sonarqube/api"
+	"github.com/pinpt/agent/pkg/expin"
+	"github.com/pinpt/agent/pkg/replay"
+	"github.com/pinpt/agent/pkg/reqstats"
+	"github.com/pinpt/agent/rpcdef"
+	"github.com/pinpt/integration-sdk/codequality"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// hashFields are created using go-common hash or are ids of integration-sdk models. These depend on dependency versions and are not compared.
+var hashFields = map[string][]string{
+	api.IssueModelName:             {"id", "hashcode", "project_id"},
+	api.HotspotModelName:           {"id", "hashcode", "project_id"},
+	api.QualityGateStatusModelName: {"id", "hashcode", "project_id", "ref_id"},
+	api.AnalysisModelName:          {"id", "hashcode", "project_id", "ref_id"},
+}
+
+// TestExportReplay runs full export using responses recorded in testdata/fixtures and compares exported objects with testdata/golden. Run with -update to create golden files after changing fixtures.
+func TestExportReplay(t *testing.T) {
+	os.Setenv(reqstats.EnvReplayDir, filepath.Join("testdata", "fixtures"))
+	defer os.Unsetenv(reqstats.EnvReplayDir)
+
+	// golden file differences are logged as errors
+	logger := hclog.New(&hclog.LoggerOptions{Level: hclog.Error})
+	output := replay.NewOutput(logger)
+	s := NewIntegration(logger)
+	exp := expin.Export{IntegrationID: "1", IntegrationDef: inconfig.IntegrationDef{Type: inconfig.IntegrationTypeCodequality, Name: "sonarqube"}}
+	_, err := s.Init(output.Agent(exp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := rpcdef.ExportConfig{}
+	config.Pinpoint.CustomerID = "c1"
+	config.Integration.Config = map[string]interface{}{
+		"url":     "https://sonarqube.example.com/api",
+		"api_key": "replay",
+	}
+	res, err := s.Export(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, res.Projects, 1) {
+		assert.Equal(t, "pinpt_agent", res.Projects[0].ReadableID)
+	}
+
+	got := output.Data(nil)
+	// integration-sdk models are only counted, their format depends on sdk version
+	assert.Len(t, got[codequality.ProjectModelName.String()], 1)
+	assert.Len(t, got[codequality.MetricModelName.String()], 4)
+	delete(got, codequality.ProjectModelName.String())
+	delete(got, codequality.MetricModelName.String())
+	for model, objs := range got {
+		for _, obj := range objs {
+			for _, f := range hashFields[model] {
+				delete(obj, f)
+			}
+		}
+		// objects are sorted by id, which is not compared
+		sort.Slice(objs, func(i, j int) bool {
+			return jsonString(t, objs[i]) < jsonString(t, objs[j])
+		})
+	}
+
+	dir := filepath.Join("testdata", "golden")
+	if *update {
+		err = replay.WriteGolden(logger, dir, got)
+	} else {
+		err = replay.CompareGolden(logger, dir, got)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func jsonString(t *testing.T, obj map[string]interface{}) string {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/pinpt/agent/integrations/pkg/ibase"
 	"github.com/pinpt/agent/integrations/sonarqube/api"
+	"github.com/pinpt/agent/pkg/reqstats"
 	"github.com/pinpt/agent/pkg/structmarshal"
 	"github.com/pinpt/agent/rpcdef"
 
@@ -26,11 +27,29 @@ type Integration struct {
 	agent      rpcdef.Agent
 	customerID string
 	api        *api.SonarqubeAPI
+	config     Config
 }
 
-func (s *Integration) Init(agent rpcdef.Agent) error {
+// Config is the integration config.
+type Config struct {
+	URL     string   `json:"url"`
+	APIKey  string   `json:"api_key"`
+	Metrics []string `json:"metrics"`
+	// Exclusions are project ref ids to skip, as returned by onboarding.
+	Exclusions []string `json:"exclusions"`
+	// Inclusions are project ref ids to export, as returned by onboarding. All projects are exported if empty.
+	Inclusions []string `json:"inclusions"`
+	// Projects specifies projects to export using project key. Ignores exclusions and inclusions in this case.
+	Projects []string `json:"projects"`
+}
+
+func (s *Integration) Init(agent rpcdef.Agent) (rpcdef.Capabilities, error) {
 	s.agent = agent
-	return nil
+	return rpcdef.Capabilities{
+		IntegrationTypes: []string{"CODEQUALITY"},
+		OnboardKinds:     []rpcdef.OnboardExportType{rpcdef.OnboardExportTypeProjects},
+		Streaming:        true,
+	}, nil
 }
 
 func (s *Integration) Export(ctx context.Context, config rpcdef.ExportConfig) (res rpcdef.ExportResult, _ error) {
@@ -38,9 +57,11 @@ func (s *Integration) Export(ctx context.Context, config rpcdef.ExportConfig) (r
 	if err := s.initConfig(ctx, config); err != nil {
 		return res, err
 	}
-	if err := s.exportAll(); err != nil {
+	projects, err := s.exportAll()
+	if err != nil {
 		return res, err
 	}
+	res.Projects = projects
 	return res, nil
 }
 
@@ -69,18 +90,9 @@ func (s *Integration) ValidateConfig(ctx context.Context, config rpcdef.ExportCo
 	return res, nil
 }
 
-func (s *Integration) OnboardExport(ctx context.Context, objectType rpcdef.OnboardExportType, config rpcdef.ExportConfig) (res rpcdef.OnboardExportResult, _ error) {
-	res.Error = rpcdef.ErrOnboardExportNotSupported
-	return
-}
-
 func (s *Integration) initConfig(ctx context.Context, config rpcdef.ExportConfig) error {
 
-	var defConfig struct {
-		URL     string   `json:"url"`
-		APIKey  string   `json:"api_key"`
-		Metrics []string `json:"metrics"`
-	}
+	var defConfig Config
 
 	err := structmarshal.MapToStruct(config.Integration.Config, &defConfig)
 	if err != nil {
@@ -104,7 +116,13 @@ func (s *Integration) initConfig(ctx context.Context, config rpcdef.ExportConfig
 	if len(metrics) == 0 {
 		metrics = defaultMetrics
 	}
-	s.api = api.NewSonarqubeAPI(ctx, s.logger, purl, apikey, metrics)
+	clients := reqstats.New(reqstats.Opts{
+		Logger:                s.logger,
+		TLSInsecureSkipVerify: true,
+		Agent:                 s.agent,
+	}).Clients
+	s.api = api.NewSonarqubeAPI(ctx, s.logger, clients, purl, apikey, metrics)
+	s.config = defConfig
 	s.customerID = config.Pinpoint.CustomerID
 	return nil
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	pstrings "github.com/pinpt/go-common/strings"
+	"github.com/pinpt/integration-sdk/agent"
+
+	"github.com/pinpt/agent/rpcdef"
+)
+
+func (s *Integration) OnboardExport(ctx context.Context, objectType rpcdef.OnboardExportType, config rpcdef.ExportConfig) (res rpcdef.OnboardExportResult, _ error) {
+	switch objectType {
+	case rpcdef.OnboardExportTypeProjects:
+		return s.onboardExportProjects(ctx, config)
+	default:
+		res.Error = rpcdef.ErrOnboardExportNotSupported
+		return
+	}
+}
+
+func (s *Integration) onboardExportProjects(ctx context.Context, config rpcdef.ExportConfig) (res rpcdef.OnboardExportResult, rerr error) {
+	err := s.initConfig(ctx, config)
+	if err != nil {
+		rerr = err
+		return
+	}
+	projects, err := s.api.FetchProjects()
+	if err != nil {
+		rerr = err
+		return
+	}
+
+	// config url points to web api, dashboard is on the server root
+	serverURL := strings.TrimSuffix(strings.TrimSuffix(s.config.URL, "/"), "/api")
+
+	var records []map[string]interface{}
+	for _, project := range projects {
+		item := &agent.ProjectResponseProjects{}
+		item.RefID = project.RefID
+		item.RefType = project.RefType
+		item.Name = project.Name
+		item.Identifier = project.Identifier
+		item.Active = true
+		item.URL = pstrings.JoinURL(serverURL, "dashboard") + "?id=" + url.QueryEscape(project.Identifier)
+		records = append(records, item.ToMap())
+	}
+	res.Data = records
+	return res, nil
+}
@@ -0,0 +1,72 @@
+package aevent
+
+import (
+	"time"
+)
+
+// OfflineAfter is the time without successfully published events after which outbox health is HealthOffline.
+const OfflineAfter = 10 * time.Minute
+
+// HealthState is the state of event delivery to backend.
+type HealthState string
+
+const (
+	// HealthOK means the last publish succeeded.
+	HealthOK HealthState = "ok"
+	// HealthDegraded means recent publishes failed, but there was a successful one in OfflineAfter.
+	HealthDegraded HealthState = "degraded"
+	// HealthOffline means no event was published for OfflineAfter.
+	HealthOffline HealthState = "offline"
+)
+
+// Health contains stats about event delivery to backend.
+type Health struct {
+	ConsecutiveFailures int
+	LastSuccess         time.Time
+	LastError           error
+	Pending             int
+}
+
+// State returns the health state based on failures and the time of last successful publish. since is the time outbox was created, used when nothing was published yet.
+func (s Health) State(since time.Time) HealthState {
+	if s.ConsecutiveFailures == 0 {
+		return HealthOK
+	}
+	last := s.LastSuccess
+	if last.IsZero() {
+		last = since
+	}
+	if time.Since(last) > OfflineAfter {
+		return HealthOffline
+	}
+	return HealthDegraded
+}
+
+func (s *Outbox) failed(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.ConsecutiveFailures++
+	s.health.LastError = err
+}
+
+func (s *Outbox) succeeded() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.ConsecutiveFailures = 0
+	s.health.LastError = nil
+	s.health.LastSuccess = time.Now()
+}
+
+// Health returns stats about event delivery.
+func (s *Outbox) Health() Health {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := s.health
+	res.Pending = len(s.pending)
+	return res
+}
+
+// HealthState returns the current state of event delivery.
+func (s *Outbox) HealthState() HealthState {
+	return s.Health().State(s.started)
+}
@@ -0,0 +1,437 @@
+package aevent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/pkg/fs"
+	"github.com/pinpt/go-common/datamodel"
+	"github.com/pinpt/go-common/event"
+	isdk "github.com/pinpt/integration-sdk"
+)
+
+const (
+	// outboxMinBackoff is the delay before retrying after the first failed publish. Doubled on each consecutive failure.
+	outboxMinBackoff = time.Second
+	// outboxMaxBackoff is the max delay between retries.
+	outboxMaxBackoff = 5 * time.Minute
+	// outboxMaxAge is the max time event is retried. Older events are dropped, so that a broken event does not block the queue forever.
+	outboxMaxAge = 24 * time.Hour
+	// outboxMaxAttempts is the number of consecutive failed attempts after which event is moved to the end of the queue, so that it does not block the events after it. Moved event is dropped if it fails again after other events were published, since it means that backend rejects it.
+	outboxMaxAttempts = 10
+	// outboxSentKeys is the number of dedup keys of sent events to remember.
+	outboxSentKeys = 1000
+)
+
+// PublishFunc publishes event to backend. Publish is used by default.
+type PublishFunc func(ctx context.Context, ev event.PublishEvent, channel string, apiKey string, options ...event.Option) error
+
+// OutboxOpts are options for NewOutbox.
+type OutboxOpts struct {
+	Logger hclog.Logger
+	// Dir stores pending events. Use a separate dir for each process.
+	Dir     string
+	Channel string
+	APIKey  string
+	// Publish is used to send events, defaults to Publish.
+	Publish PublishFunc
+}
+
+// Outbox is a durable, ordered queue of events sent to backend. Events are saved to disk before sending and retried with backoff until published, so that they are not lost because of network errors or agent restarts.
+type Outbox struct {
+	opts    OutboxOpts
+	logger  hclog.Logger
+	publish PublishFunc
+	started time.Time
+
+	minBackoff  time.Duration
+	maxAttempts int
+
+	mu      sync.Mutex
+	pending []*outboxEvent
+	lastSeq int64
+	// keys of sent events, sentOrder is used to drop oldest keys
+	sent      map[string]bool
+	sentOrder []string
+	notify    chan bool
+
+	health Health
+}
+
+type outboxEvent struct {
+	Seq int64  `json:"seq"`
+	Key string `json:"key"`
+	// Latest is true for events sent using SendLatest, these are not deduplicated after sending
+	Latest  bool                    `json:"latest"`
+	Model   datamodel.ModelNameType `json:"model"`
+	Data    map[string]interface{}  `json:"data"`
+	Headers map[string]string       `json:"headers"`
+	Created time.Time               `json:"created"`
+	// Attempts is the number of failed attempts to publish the event
+	Attempts int `json:"attempts"`
+	// Moved is the time event was moved to the end of the queue after outboxMaxAttempts failed attempts
+	Moved time.Time `json:"moved"`
+
+	// version is incremented when pending event with the same key is replaced
+	version int
+}
+
+// NewOutbox creates outbox and loads events that were not sent by previous run. Call Run to start sending.
+func NewOutbox(opts OutboxOpts) (*Outbox, error) {
+	s := &Outbox{}
+	s.opts = opts
+	s.logger = opts.Logger.Named("outbox")
+	s.publish = opts.Publish
+	if s.publish == nil {
+		s.publish = Publish
+	}
+	s.started = time.Now()
+	s.minBackoff = outboxMinBackoff
+	s.maxAttempts = outboxMaxAttempts
+	s.sent = map[string]bool{}
+	s.notify = make(chan bool, 1)
+
+	err := os.MkdirAll(opts.Dir, 0777)
+	if err != nil {
+		return nil, err
+	}
+	err = s.load()
+	if err != nil {
+		return nil, fmt.Errorf("could not load pending events: %v", err)
+	}
+	if len(s.pending) != 0 {
+		s.logger.Info("loaded events not sent by previous run", "count", len(s.pending))
+	}
+	return s, nil
+}
+
+func (s *Outbox) load() error {
+	events, err := s.readDir(s.opts.Dir)
+	if err != nil {
+		return err
+	}
+	s.pending = events
+	for _, ev := range events {
+		if ev.Seq > s.lastSeq {
+			s.lastSeq = ev.Seq
+		}
+	}
+	return nil
+}
+
+// readDir returns events saved in dir ordered by seq. Removes invalid and temp files.
+func (s *Outbox) readDir(dir string) (res []*outboxEvent, _ error) {
+	items, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		loc := filepath.Join(dir, item.Name())
+		if !strings.HasSuffix(item.Name(), ".json") {
+			// leftover temp file from interrupted write
+			err := os.Remove(loc)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		b, err := ioutil.ReadFile(loc)
+		if err != nil {
+			return nil, err
+		}
+		ev := &outboxEvent{}
+		err = json.Unmarshal(b, ev)
+		if err != nil {
+			s.logger.Error("skipping invalid event file", "file", loc, "err", err)
+			err := os.Remove(loc)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		res = append(res, ev)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Seq < res[j].Seq
+	})
+	return res, nil
+}
+
+// Adopt moves events left in outbox dir of another process to this outbox and deletes that dir. Events keep their order and are placed after pending events of this outbox. Use for dirs of processes that exited without sending all events. Returns the number of adopted events.
+func (s *Outbox) Adopt(dir string) (int, error) {
+	events, err := s.readDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	s.mu.Lock()
+	for _, ev := range events {
+		ev.Attempts = 0
+		ev.Moved = time.Time{}
+		err := s.add(ev)
+		if err != nil {
+			s.mu.Unlock()
+			return 0, err
+		}
+	}
+	s.mu.Unlock()
+	return len(events), os.RemoveAll(dir)
+}
+
+func (s *Outbox) file(ev *outboxEvent) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%020d.json", ev.Seq))
+}
+
+func (s *Outbox) save(ev *outboxEvent) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return fs.WriteToTempAndRename(bytes.NewReader(b), s.file(ev))
+}
+
+// Send saves the event to be sent to backend. Events are sent in the same order as passed to Send and SendLatest.
+//
+// Key is used for deduplication, pass empty string to always send the event. If an event with the same key is pending or was already sent, the passed event is ignored.
+func (s *Outbox) Send(ev event.PublishEvent, key string) error {
+	return s.send(ev, key, false)
+}
+
+// SendLatest saves the event to be sent to backend, replacing pending event with the same key and keeping its position in queue. Use for state updates, such as pings and progress, where only the latest event is useful.
+func (s *Outbox) SendLatest(ev event.PublishEvent, key string) error {
+	if key == "" {
+		return errors.New("key is required for SendLatest")
+	}
+	return s.send(ev, key, true)
+}
+
+func (s *Outbox) send(ev event.PublishEvent, key string, latest bool) error {
+	if isdk.New(ev.Object.GetModelName()) == nil {
+		// events are restored from disk using integration-sdk models
+		return fmt.Errorf("outbox does not support model: %v", ev.Object.GetModelName())
+	}
+
+	p := &outboxEvent{}
+	p.Key = key
+	p.Latest = latest
+	p.Model = ev.Object.GetModelName()
+	p.Data = ev.Object.ToMap()
+	p.Headers = ev.Headers
+	p.Created = time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.add(p)
+}
+
+// add saves the event and adds it to the queue. If event with the same key was already sent or is pending it is skipped, or replaced for latest events. Call with mu locked.
+func (s *Outbox) add(p *outboxEvent) error {
+	if p.Key != "" {
+		if s.sent[p.Key] {
+			s.logger.Debug("event with the same key was already sent, skipping", "key", p.Key)
+			return nil
+		}
+		for _, e := range s.pending {
+			if e.Key != p.Key {
+				continue
+			}
+			if !p.Latest {
+				s.logger.Debug("event with the same key is pending, skipping", "key", p.Key)
+				return nil
+			}
+			e.Data = p.Data
+			e.Headers = p.Headers
+			e.Created = p.Created
+			e.Attempts = 0
+			e.Moved = time.Time{}
+			e.version++
+			return s.save(e)
+		}
+	}
+
+	s.lastSeq++
+	p.Seq = s.lastSeq
+	err := s.save(p)
+	if err != nil {
+		return err
+	}
+	s.pending = append(s.pending, p)
+
+	select {
+	case s.notify <- true:
+	default:
+	}
+	return nil
+}
+
+func (s *Outbox) first() (_ *outboxEvent, version int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) == 0 {
+		return nil, 0
+	}
+	return s.pending[0], s.pending[0].version
+}
+
+// done removes the event from queue. Returns false if event was replaced since it was sent.
+func (s *Outbox) done(ev *outboxEvent, version int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ev.version != version {
+		return false, nil
+	}
+	s.pending = s.pending[1:]
+	if ev.Key != "" && !ev.Latest {
+		s.sent[ev.Key] = true
+		s.sentOrder = append(s.sentOrder, ev.Key)
+		if len(s.sentOrder) > outboxSentKeys {
+			delete(s.sent, s.sentOrder[0])
+			s.sentOrder = s.sentOrder[1:]
+		}
+	}
+	err := os.Remove(s.file(ev))
+	if err != nil && !os.IsNotExist(err) {
+		return true, err
+	}
+	return true, nil
+}
+
+func (s *Outbox) publishEvent(ctx context.Context, ev *outboxEvent) error {
+	s.mu.Lock()
+	obj := isdk.New(ev.Model)
+	obj.FromMap(ev.Data)
+	headers := map[string]string{}
+	for k, v := range ev.Headers {
+		headers[k] = v
+	}
+	s.mu.Unlock()
+
+	if ev.Key != "" && !ev.Latest {
+		// allows backend to ignore events published more than once when the response is lost
+		headers["dedup_key"] = ev.Key
+	}
+	return s.publish(ctx, event.PublishEvent{Object: obj, Headers: headers}, s.opts.Channel, s.opts.APIKey)
+}
+
+// Run sends pending events until ctx is cancelled. This is a blocking call.
+func (s *Outbox) Run(ctx context.Context) {
+	backoff := s.minBackoff
+	for {
+		ev, version := s.first()
+		if ev == nil {
+			select {
+			case <-s.notify:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		if time.Since(ev.Created) > outboxMaxAge || isdk.New(ev.Model) == nil {
+			s.logger.Error("dropping event that could not be sent", "model", ev.Model, "key", ev.Key, "created", ev.Created)
+			s.remove(ev, version)
+			continue
+		}
+		err := s.publishEvent(ctx, ev)
+		if err != nil {
+			if s.rejected(ev) {
+				s.logger.Error("dropping event rejected by backend, other events were published after it was moved to the end of the queue", "model", ev.Model, "key", ev.Key, "attempts", ev.Attempts+1, "err", err)
+				s.remove(ev, version)
+				continue
+			}
+			s.failed(err)
+			s.attemptFailed(ev, version)
+			s.logger.Warn("could not publish event, will retry", "model", ev.Model, "retry_in", backoff.String(), "err", err)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			backoff *= 2
+			if backoff > outboxMaxBackoff {
+				backoff = outboxMaxBackoff
+			}
+			continue
+		}
+		backoff = s.minBackoff
+		s.succeeded()
+		s.remove(ev, version)
+	}
+}
+
+// rejected returns true if event failed before and was moved to the end of the queue, and other events were published successfully since then.
+func (s *Outbox) rejected(ev *outboxEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !ev.Moved.IsZero() && s.health.LastSuccess.After(ev.Moved)
+}
+
+// attemptFailed increments failed attempts of the first event in queue and moves it to the end of the queue after maxAttempts, so that the events after it are not blocked.
+func (s *Outbox) attemptFailed(ev *outboxEvent, version int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ev.version != version || len(s.pending) == 0 || s.pending[0] != ev {
+		return
+	}
+	ev.Attempts++
+	if ev.Attempts%s.maxAttempts != 0 || len(s.pending) == 1 {
+		return
+	}
+	err := os.Remove(s.file(ev))
+	if err != nil && !os.IsNotExist(err) {
+		s.logger.Error("could not delete event file", "err", err)
+	}
+	s.lastSeq++
+	ev.Seq = s.lastSeq
+	ev.Moved = time.Now()
+	s.pending = append(s.pending[1:], ev)
+	err = s.save(ev)
+	if err != nil {
+		s.logger.Error("could not save event", "err", err)
+	}
+	s.logger.Warn("event could not be sent, moved to the end of the queue", "model", ev.Model, "key", ev.Key, "attempts", ev.Attempts)
+}
+
+func (s *Outbox) remove(ev *outboxEvent, version int) {
+	removed, err := s.done(ev, version)
+	if err != nil {
+		s.logger.Error("could not delete sent event file", "err", err)
+	}
+	if !removed {
+		s.logger.Debug("event was replaced while sending, sending again", "key", ev.Key)
+	}
+}
+
+// Flush waits until all pending events are sent or ctx is done.
+func (s *Outbox) Flush(ctx context.Context) error {
+	for {
+		n := s.Pending()
+		if n == 0 {
+			return nil
+		}
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return fmt.Errorf("could not send all events, pending: %v", n)
+		}
+	}
+}
+
+// Pending returns the number of events not sent yet.
+func (s *Outbox) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
@@ -0,0 +1,245 @@
+package aevent
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/go-common/event"
+	"github.com/pinpt/integration-sdk/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPublisher struct {
+	mu    sync.Mutex
+	fail  int
+	calls int
+	sent  []string
+	// reject is the event that always fails
+	reject string
+}
+
+func (s *testPublisher) publish(ctx context.Context, ev event.PublishEvent, channel string, apiKey string, options ...event.Option) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.fail > 0 {
+		s.fail--
+		return errors.New("publish failed")
+	}
+	if s.reject != "" && ev.Headers["n"] == s.reject {
+		return errors.New("rejected")
+	}
+	s.sent = append(s.sent, ev.Headers["n"])
+	return nil
+}
+
+func (s *testPublisher) Sent() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.sent...)
+}
+
+func testEvent(n string) event.PublishEvent {
+	return event.PublishEvent{
+		Object:  &agent.Ping{Success: true},
+		Headers: map[string]string{"n": n},
+	}
+}
+
+func newTestOutbox(t *testing.T, dir string, pub *testPublisher) *Outbox {
+	s, err := NewOutbox(OutboxOpts{
+		Logger:  hclog.NewNullLogger(),
+		Dir:     dir,
+		Publish: pub.publish,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.minBackoff = time.Millisecond
+	return s
+}
+
+func TestOutboxOrderAndRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pub := &testPublisher{fail: 2}
+	s := newTestOutbox(t, dir, pub)
+	assert.NoError(t, s.Send(testEvent("1"), ""))
+	assert.NoError(t, s.Send(testEvent("2"), ""))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	flushCtx, flushCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer flushCancel()
+	assert.NoError(t, s.Flush(flushCtx))
+	assert.Equal(t, []string{"1", "2"}, pub.Sent())
+	assert.Equal(t, 4, pub.calls)
+	assert.Equal(t, HealthOK, s.HealthState())
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
+func TestOutboxPersistsPending(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newTestOutbox(t, dir, &testPublisher{})
+	assert.NoError(t, s.Send(testEvent("1"), ""))
+	assert.NoError(t, s.Send(testEvent("2"), ""))
+
+	// simulate restart without sending
+	pub := &testPublisher{}
+	s = newTestOutbox(t, dir, pub)
+	assert.Equal(t, 2, s.Pending())
+	assert.NoError(t, s.Send(testEvent("3"), ""))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go s.Run(ctx)
+	assert.NoError(t, s.Flush(ctx))
+	assert.Equal(t, []string{"1", "2", "3"}, pub.Sent())
+}
+
+func TestOutboxDedupKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pub := &testPublisher{}
+	s := newTestOutbox(t, dir, pub)
+	assert.NoError(t, s.SendLatest(testEvent("1"), "ping"))
+	assert.NoError(t, s.Send(testEvent("2"), "resp"))
+	// replaces pending event keeping position
+	assert.NoError(t, s.SendLatest(testEvent("3"), "ping"))
+	// ignored, since the same key is pending
+	assert.NoError(t, s.Send(testEvent("4"), "resp"))
+	assert.Equal(t, 2, s.Pending())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go s.Run(ctx)
+	assert.NoError(t, s.Flush(ctx))
+	assert.Equal(t, []string{"3", "2"}, pub.Sent())
+
+	// already sent
+	assert.NoError(t, s.Send(testEvent("5"), "resp"))
+	assert.Equal(t, 0, s.Pending())
+
+	// latest events are sent again
+	assert.NoError(t, s.SendLatest(testEvent("6"), "ping"))
+	assert.NoError(t, s.Flush(ctx))
+	assert.Equal(t, []string{"3", "2", "6"}, pub.Sent())
+}
+
+func TestOutboxRejectedEventDoesNotBlock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pub := &testPublisher{reject: "bad"}
+	s := newTestOutbox(t, dir, pub)
+	s.maxAttempts = 3
+	assert.NoError(t, s.Send(testEvent("bad"), ""))
+	assert.NoError(t, s.Send(testEvent("1"), ""))
+	assert.NoError(t, s.Send(testEvent("2"), ""))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go s.Run(ctx)
+	assert.NoError(t, s.Flush(ctx))
+	assert.Equal(t, []string{"1", "2"}, pub.Sent())
+	// 3 attempts before moving to the end and one after other events were sent
+	assert.Equal(t, 6, pub.calls)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
+func TestOutboxOfflineKeepsEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// all events fail while backend is not reachable, none of them is dropped
+	pub := &testPublisher{fail: 10}
+	s := newTestOutbox(t, dir, pub)
+	s.maxAttempts = 3
+	assert.NoError(t, s.Send(testEvent("1"), ""))
+	assert.NoError(t, s.Send(testEvent("2"), ""))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go s.Run(ctx)
+	assert.NoError(t, s.Flush(ctx))
+	assert.ElementsMatch(t, []string{"1", "2"}, pub.Sent())
+}
+
+func TestOutboxAdopt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// events left by other process
+	other := newTestOutbox(t, filepath.Join(dir, "other"), &testPublisher{})
+	assert.NoError(t, other.Send(testEvent("1"), ""))
+	assert.NoError(t, other.Send(testEvent("2"), "k2"))
+
+	pub := &testPublisher{}
+	s := newTestOutbox(t, filepath.Join(dir, "run"), pub)
+	assert.NoError(t, s.Send(testEvent("0"), ""))
+	n, err := s.Adopt(filepath.Join(dir, "other"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	_, err = os.Stat(filepath.Join(dir, "other"))
+	assert.True(t, os.IsNotExist(err))
+
+	// missing dir is ignored
+	n, err = s.Adopt(filepath.Join(dir, "missing"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go s.Run(ctx)
+	assert.NoError(t, s.Flush(ctx))
+	assert.Equal(t, []string{"0", "1", "2"}, pub.Sent())
+
+	// dedup key of adopted event is kept
+	assert.NoError(t, s.Send(testEvent("3"), "k2"))
+	assert.Equal(t, 0, s.Pending())
+}
+
+func TestHealthState(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, HealthOK, Health{}.State(now))
+	assert.Equal(t, HealthDegraded, Health{ConsecutiveFailures: 3, LastSuccess: now.Add(-time.Minute)}.State(now))
+	assert.Equal(t, HealthOffline, Health{ConsecutiveFailures: 3, LastSuccess: now.Add(-time.Hour)}.State(now))
+	assert.Equal(t, HealthOffline, Health{ConsecutiveFailures: 1}.State(now.Add(-time.Hour)))
+}
@@ -9,6 +9,14 @@ import (
 
 	"github.com/pinpt/agent/cmd/cmdrunnorestarts/inconfig"
 	"github.com/pinpt/agent/pkg/fs"
+	"github.com/pinpt/agent/pkg/hangdetect"
+	"github.com/pinpt/agent/pkg/issuelinks"
+	"github.com/pinpt/agent/pkg/netconf"
+	"github.com/pinpt/agent/pkg/objvalidate"
+	"github.com/pinpt/agent/pkg/plugins"
+	"github.com/pinpt/agent/pkg/pushevents"
+	"github.com/pinpt/agent/pkg/ratelimit"
+	"github.com/pinpt/agent/pkg/sandbox"
 )
 
 type Config struct {
@@ -25,6 +33,33 @@ type Config struct {
 
 	// ExtraIntegrations defines additional integrations that will run on every export trigger in run command. This is needed to run a custom integration for one of our customers. You need to add these custom integrations to config manually after enroll.
 	ExtraIntegrations []inconfig.IntegrationAgent `json:"extra_integrations"`
+
+	// CommitUserAliases maps alternative commit emails to the canonical email of the same person. Needs to be added to config manually, similar to ExtraIntegrations.
+	CommitUserAliases map[string]string `json:"commit_user_aliases"`
+
+	// IssueLinks configures additional issue keys and patterns for linking commits, branches and pull requests to issues. Optional, needs to be added to config manually.
+	IssueLinks issuelinks.Config `json:"issue_links"`
+
+	// Validation configures thresholds for invalid exported objects. Optional, needs to be added to config manually.
+	Validation objvalidate.Config `json:"validation"`
+
+	// Plugins configures trusted keys and allowlist for third-party integration plugins installed in plugins subdir of integrations dir. Optional, needs to be added to config manually.
+	Plugins plugins.Config `json:"plugins"`
+
+	// Sandbox configures cgroup limits, nice/ionice and restricted filesystem for integration processes on linux. Optional, needs to be added to config manually.
+	Sandbox sandbox.Config `json:"sandbox"`
+
+	// HangDetection configures export timeout and heartbeat timeout for integrations. Optional, needs to be added to config manually.
+	HangDetection hangdetect.Config `json:"hang_detection"`
+
+	// MaxIntegrationRestarts is the number of times crashed integration is restarted during export. Defaults to 2, set to -1 to disable. Optional, needs to be added to config manually.
+	MaxIntegrationRestarts int `json:"max_integration_restarts"`
+	// Network configures proxy, additional CAs and client certificates for all outbound connections, including integrations and git. Optional, needs to be added to config manually.
+	Network netconf.Config `json:"network"`
+	// RateLimits configures max concurrent requests per host for integrations. Optional, needs to be added to config manually.
+	RateLimits ratelimit.Config `json:"rate_limits"`
+	// Push configures local http endpoint where tools without integration send deploy and incident events, exported by push integration. Optional, needs to be added to config manually.
+	Push pushevents.Config `json:"push"`
 }
 
 func Save(c Config, loc string) error {
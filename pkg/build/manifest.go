@@ -0,0 +1,134 @@
+package build
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ManifestFile is the name of the release manifest, stored in bin-gz/<os-arch> next to the binaries.
+const ManifestFile = "manifest.json"
+
+// ManifestSigFile is the name of the file containing base64 encoded ed25519 signature of ManifestFile.
+const ManifestSigFile = "manifest.json.sig"
+
+// Manifest lists checksums of all binaries released for one platform. It is signed at build time and verified by the updater before replacing any binaries.
+type Manifest struct {
+	Version string `json:"version"`
+	// Platform is os-arch, for example linux-amd64
+	Platform string `json:"platform"`
+	// Files maps binary path relative to the platform dir, using forward slashes, to hex encoded sha256 of uncompressed binary. For example pinpoint-agent or integrations/github.exe
+	Files map[string]string `json:"files"`
+}
+
+// Checksum returns expected checksum for file. Returns an error if file is not in manifest.
+func (s Manifest) Checksum(file string) (string, error) {
+	v, ok := s.Files[file]
+	if !ok || v == "" {
+		return "", fmt.Errorf("file is not in signed manifest: %v", file)
+	}
+	return v, nil
+}
+
+// VerifyFile checks that sha256 of file at loc matches the manifest.
+func (s Manifest) VerifyFile(file string, loc string) error {
+	want, err := s.Checksum(file)
+	if err != nil {
+		return err
+	}
+	got, err := FileSHA256(loc)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("checksum mismatch for %v, manifest: %v downloaded: %v", file, want, got)
+	}
+	return nil
+}
+
+// FileSHA256 returns hex encoded sha256 of file.
+func FileSHA256(loc string) (string, error) {
+	f, err := os.Open(loc)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SignManifest serializes manifest and signs it. Returns manifest and signature file contents.
+func SignManifest(m Manifest, key ed25519.PrivateKey) (data []byte, sig []byte, _ error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	sig = []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)))
+	return data, sig, nil
+}
+
+// VerifyManifest checks the signature and returns parsed manifest.
+func VerifyManifest(data []byte, sig []byte, key ed25519.PublicKey) (res Manifest, _ error) {
+	if len(key) != ed25519.PublicKeySize {
+		return res, errors.New("invalid public key for update verification")
+	}
+	sigb, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return res, fmt.Errorf("invalid manifest signature encoding: %v", err)
+	}
+	if !ed25519.Verify(key, data, sigb) {
+		return res, errors.New("invalid manifest signature")
+	}
+	err = json.Unmarshal(data, &res)
+	if err != nil {
+		return res, fmt.Errorf("could not parse manifest: %v", err)
+	}
+	return res, nil
+}
+
+// ErrNoUpdatePublicKey is returned by UpdatePublicKey when the agent was built without the key, for example dev builds. Updates are not possible in that case.
+var ErrNoUpdatePublicKey = errors.New("agent was built without update public key, can't verify updates; dev builds do not support updates, build with agent-dev build --signing-key-file to enable them")
+
+// UpdatePublicKey returns the key used to verify release manifests. The key is read from PP_AGENT_UPDATE_PUBLIC_KEY, which is set on startup in cmd/setup.go from cmd.UpdatePublicKey passed in ldflags at build time.
+func UpdatePublicKey() (ed25519.PublicKey, error) {
+	v := os.Getenv("PP_AGENT_UPDATE_PUBLIC_KEY")
+	if v == "" {
+		return nil, ErrNoUpdatePublicKey
+	}
+	return ParsePublicKey(v)
+}
+
+// ParsePublicKey parses base64 encoded ed25519 public key.
+func ParsePublicKey(v string) (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(v))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key encoding: %v", err)
+	}
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key size: %v", len(b))
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// ParsePrivateKey parses base64 encoded ed25519 private key.
+func ParsePrivateKey(v string) (ed25519.PrivateKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(v))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key encoding: %v", err)
+	}
+	if len(b) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key size: %v", len(b))
+	}
+	return ed25519.PrivateKey(b), nil
+}
@@ -0,0 +1,23 @@
+package commitusers
+
+import "strings"
+
+// Aliases maps alternative commit emails to the canonical email of the same person. Configured at agent level and applied to commit users from all integrations.
+type Aliases map[string]string
+
+// NewAliases creates aliases from config map of alternative email to canonical email. Lookups are case-insensitive.
+func NewAliases(data map[string]string) Aliases {
+	res := Aliases{}
+	for k, v := range data {
+		res[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+	}
+	return res
+}
+
+// Resolve returns the canonical email or passed email if no alias is defined.
+func (s Aliases) Resolve(email string) string {
+	if v, ok := s[strings.ToLower(email)]; ok && v != "" {
+		return v
+	}
+	return email
+}
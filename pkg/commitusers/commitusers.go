@@ -3,6 +3,8 @@ package commitusers
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/pinpt/go-common/hash"
 )
 
 const TableName = "sourcecode.CommitUser"
@@ -34,3 +36,54 @@ func (s CommitUser) ToMap() map[string]interface{} {
 	res["source_id"] = s.SourceID
 	return res
 }
+
+const LinkTableName = "sourcecode.CommitUserLink"
+
+// LinkRole defines how the user is related to the commit.
+type LinkRole string
+
+const (
+	LinkRoleCoAuthor    LinkRole = "co_author"
+	LinkRoleSignedOffBy LinkRole = "signed_off_by"
+)
+
+// CommitUserLink connects the commit to additional users referenced in commit message trailers. Author and committer are already linked in sourcecode.Commit.
+// Like CommitUser, it is defined here and not in integration-sdk, since it is only created by the agent when processing git repos and integration-sdk is a separately released dependency.
+type CommitUserLink struct {
+	CustomerID string
+	RefType    string
+	RepoID     string
+	CommitID   string
+	// UserRefID is the same as sourcecode.Commit.AuthorRefID, a hash of customer id and email
+	UserRefID string
+	Email     string
+	Name      string
+	Role      LinkRole
+}
+
+func (s CommitUserLink) ID() string {
+	return hash.Values("CommitUserLink", s.CustomerID, s.CommitID, s.UserRefID, string(s.Role))
+}
+
+func (s CommitUserLink) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["repo_id"] = s.RepoID
+	res["commit_id"] = s.CommitID
+	res["user_ref_id"] = s.UserRefID
+	res["email"] = s.Email
+	res["name"] = s.Name
+	res["role"] = string(s.Role)
+	res["hashcode"] = hash.Values(s.ID(), s.RefType, s.RepoID, s.Email, s.Name)
+	return res
+}
+
+// TrailerLinkRole returns the link role for trailer kind.
+func TrailerLinkRole(kind TrailerKind) LinkRole {
+	if kind == TrailerSignedOffBy {
+		return LinkRoleSignedOffBy
+	}
+	return LinkRoleCoAuthor
+}
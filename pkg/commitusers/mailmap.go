@@ -0,0 +1,135 @@
+package commitusers
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// Mailmap canonicalizes commit identities using the git .mailmap format.
+// See https://git-scm.com/docs/gitmailmap for details.
+// Emails and names are matched case-insensitively, same as git.
+type Mailmap struct {
+	// byEmail contains entries that match on email only
+	byEmail map[string]mailmapEntry
+	// byNameEmail contains entries that match on both name and email
+	byNameEmail map[string]mailmapEntry
+}
+
+type mailmapEntry struct {
+	Name  string
+	Email string
+}
+
+// NewMailmap creates an empty mailmap, which does not change any identities.
+func NewMailmap() *Mailmap {
+	s := &Mailmap{}
+	s.byEmail = map[string]mailmapEntry{}
+	s.byNameEmail = map[string]mailmapEntry{}
+	return s
+}
+
+// LoadMailmap reads the mailmap from the file. Returns empty mailmap if file does not exist.
+func LoadMailmap(loc string) (*Mailmap, error) {
+	f, err := os.Open(loc)
+	if os.IsNotExist(err) {
+		return NewMailmap(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseMailmap(f)
+}
+
+// ParseMailmap parses the mailmap in the git format. Invalid lines are ignored, same as git.
+func ParseMailmap(r io.Reader) (*Mailmap, error) {
+	s := NewMailmap()
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+		s.parseLine(line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// parseLine handles the following formats
+// Proper Name <commit@email.xx>
+// <proper@email.xx> <commit@email.xx>
+// Proper Name <proper@email.xx> <commit@email.xx>
+// Proper Name <proper@email.xx> Commit Name <commit@email.xx>
+func (s *Mailmap) parseLine(line string) {
+	name1, email1, rest, ok := parseMailmapPart(line)
+	if !ok {
+		return
+	}
+	name2, email2, _, ok := parseMailmapPart(rest)
+	if !ok {
+		// only one email, it is both the proper and commit email
+		s.byEmail[mailmapKey(email1)] = mailmapEntry{Name: name1}
+		return
+	}
+	entry := mailmapEntry{Name: name1, Email: email1}
+	if name2 == "" {
+		s.byEmail[mailmapKey(email2)] = entry
+		return
+	}
+	s.byNameEmail[mailmapKey(name2, email2)] = entry
+}
+
+func parseMailmapPart(str string) (name string, email string, rest string, ok bool) {
+	start := strings.Index(str, "<")
+	if start == -1 {
+		return
+	}
+	end := strings.Index(str[start:], ">")
+	if end == -1 {
+		return
+	}
+	end += start
+	name = strings.TrimSpace(str[:start])
+	email = strings.TrimSpace(str[start+1 : end])
+	rest = str[end+1:]
+	ok = true
+	return
+}
+
+func mailmapKey(parts ...string) string {
+	return strings.ToLower(strings.Join(parts, "@@@"))
+}
+
+// Resolve returns the canonical name and email for the identity. Returns passed values if there is no matching entry.
+func (s *Mailmap) Resolve(name, email string) (string, string) {
+	if s == nil {
+		return name, email
+	}
+	entry, ok := s.byNameEmail[mailmapKey(name, email)]
+	if !ok {
+		entry, ok = s.byEmail[mailmapKey(email)]
+	}
+	if !ok {
+		return name, email
+	}
+	if entry.Name != "" {
+		name = entry.Name
+	}
+	if entry.Email != "" {
+		email = entry.Email
+	}
+	return name, email
+}
+
+// Len returns the number of entries in mailmap.
+func (s *Mailmap) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.byEmail) + len(s.byNameEmail)
+}
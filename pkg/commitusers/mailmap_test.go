@@ -0,0 +1,49 @@
+package commitusers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMailmapResolve(t *testing.T) {
+	data := `# comment
+Proper One <one@example.com>
+<two@example.com> <two@old.example.com>
+Proper Three <three@example.com> <three@old.example.com>
+Proper Four <four@example.com> Commit Four <four@old.example.com>
+invalid line
+`
+	mm, err := ParseMailmap(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert := assert.New(t)
+	assert.Equal(4, mm.Len())
+
+	cases := []struct {
+		Name      string
+		Email     string
+		WantName  string
+		WantEmail string
+	}{
+		{"one", "ONE@example.com", "Proper One", "ONE@example.com"},
+		{"Two", "two@old.example.com", "Two", "two@example.com"},
+		{"three", "three@old.example.com", "Proper Three", "three@example.com"},
+		{"Commit Four", "four@old.example.com", "Proper Four", "four@example.com"},
+		{"Other Four", "four@old.example.com", "Other Four", "four@old.example.com"},
+		{"Unknown", "unknown@example.com", "Unknown", "unknown@example.com"},
+	}
+	for _, c := range cases {
+		name, email := mm.Resolve(c.Name, c.Email)
+		assert.Equal(c.WantName, name, c.Email)
+		assert.Equal(c.WantEmail, email, c.Email)
+	}
+}
+
+func TestAliasesResolve(t *testing.T) {
+	a := NewAliases(map[string]string{"Old@Example.com": "new@example.com"})
+	assert.Equal(t, "new@example.com", a.Resolve("old@example.com"))
+	assert.Equal(t, "other@example.com", a.Resolve("other@example.com"))
+}
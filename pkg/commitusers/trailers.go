@@ -0,0 +1,125 @@
+package commitusers
+
+import (
+	"bufio"
+	"net/mail"
+	"strings"
+)
+
+// TrailerKind is the kind of git trailer that references an additional commit user.
+type TrailerKind string
+
+const (
+	// TrailerCoAuthoredBy is for pair-programmed commits, the format used by GitHub and GitLab.
+	TrailerCoAuthoredBy TrailerKind = "Co-authored-by"
+	// TrailerSignedOffBy is added by git commit --signoff.
+	TrailerSignedOffBy TrailerKind = "Signed-off-by"
+)
+
+var trailerKinds = []TrailerKind{TrailerCoAuthoredBy, TrailerSignedOffBy}
+
+// Trailer is a user referenced in commit message trailers.
+type Trailer struct {
+	Kind  TrailerKind
+	Name  string
+	Email string
+}
+
+// ParseTrailers returns users referenced in Co-authored-by and Signed-off-by trailers of the commit message.
+// Only the trailer block is parsed, which is the last paragraph of the message after the subject, same as in git interpret-trailers. Trailer keys are matched case-insensitively. Lines without a valid email are skipped. Duplicates of the same kind and email are only returned once.
+func ParseTrailers(message string) (res []Trailer) {
+	seen := map[string]bool{}
+	for _, line := range trailerBlock(message) {
+		key, value, ok := splitTrailer(line)
+		if !ok {
+			continue
+		}
+		kind := trailerKind(key)
+		if kind == "" {
+			continue
+		}
+		addr, err := mail.ParseAddress(value)
+		if err != nil || addr.Address == "" {
+			continue
+		}
+		email := strings.ToLower(addr.Address)
+		key = string(kind) + "@@@" + email
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		res = append(res, Trailer{
+			Kind:  kind,
+			Name:  addr.Name,
+			Email: addr.Address,
+		})
+	}
+	return
+}
+
+// trailerBlock returns the lines of the last paragraph of message if it is a trailer block. Same rules as in git: the first paragraph is the subject and is never a trailer block, all lines must be trailers, or at least 25% of lines if one of them is a known trailer. Lines starting with whitespace continue the previous line.
+func trailerBlock(message string) []string {
+	var paragraphs [][]string
+	var cur []string
+	sc := bufio.NewScanner(strings.NewReader(message))
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" {
+			if len(cur) != 0 {
+				paragraphs = append(paragraphs, cur)
+				cur = nil
+			}
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(cur) != 0 {
+			cur[len(cur)-1] += " " + strings.TrimSpace(line)
+			continue
+		}
+		cur = append(cur, line)
+	}
+	if len(cur) != 0 {
+		paragraphs = append(paragraphs, cur)
+	}
+	if len(paragraphs) < 2 {
+		return nil
+	}
+	block := paragraphs[len(paragraphs)-1]
+	trailers := 0
+	known := false
+	for _, line := range block {
+		key, _, ok := splitTrailer(line)
+		if !ok {
+			continue
+		}
+		trailers++
+		if trailerKind(key) != "" {
+			known = true
+		}
+	}
+	if trailers == len(block) || (known && trailers*4 >= len(block)) {
+		return block
+	}
+	return nil
+}
+
+// splitTrailer splits "Key: value" line. Key must not contain whitespace.
+func splitTrailer(line string) (key string, value string, ok bool) {
+	i := strings.Index(line, ":")
+	if i <= 0 {
+		return
+	}
+	key = line[:i]
+	if strings.ContainsAny(key, " \t") {
+		return
+	}
+	return key, strings.TrimSpace(line[i+1:]), true
+}
+
+func trailerKind(key string) TrailerKind {
+	for _, k := range trailerKinds {
+		if strings.EqualFold(key, string(k)) {
+			return k
+		}
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package commitusers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTrailers(t *testing.T) {
+	msg := `Fix login
+
+Some description mentioning Co-authored-by in text.
+
+Co-authored-by: User One <user1@example.com>
+co-authored-by: User Two <USER2@example.com>
+Co-authored-by: User One <user1@example.com>
+Co-authored-by: invalid
+Signed-off-by: User Three <user3@example.com>
+Reviewed-by: User Four <user4@example.com>`
+
+	got := ParseTrailers(msg)
+	want := []Trailer{
+		{Kind: TrailerCoAuthoredBy, Name: "User One", Email: "user1@example.com"},
+		{Kind: TrailerCoAuthoredBy, Name: "User Two", Email: "USER2@example.com"},
+		{Kind: TrailerSignedOffBy, Name: "User Three", Email: "user3@example.com"},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestParseTrailersNone(t *testing.T) {
+	got := ParseTrailers("c1")
+	assert.Empty(t, got)
+}
+
+func TestParseTrailersOnlyLastParagraph(t *testing.T) {
+	msg := `Merge pairing work
+
+Co-authored-by: User One <user1@example.com>
+
+More description after the line above.`
+	assert.Empty(t, ParseTrailers(msg))
+
+	assert.Empty(t, ParseTrailers("Co-authored-by: User One <user1@example.com>"), "subject is not a trailer block")
+
+	msg = `Fix login
+
+Description.
+
+Reviewed by: someone
+text line
+text line
+Signed-off-by: User Three
+  <user3@example.com>`
+	want := []Trailer{
+		{Kind: TrailerSignedOffBy, Name: "User Three", Email: "user3@example.com"},
+	}
+	assert.Equal(t, want, ParseTrailers(msg), "25% of lines are trailers, with continuation line")
+}
@@ -9,6 +9,7 @@ import (
 )
 
 type session struct {
+	export       expin.Export
 	isTracking   bool
 	name         string
 	id           ID
@@ -29,6 +30,7 @@ type session struct {
 
 func newSession(
 	export expin.Export,
+	prefix string,
 	isTracking bool,
 	name string,
 	id ID,
@@ -38,6 +40,7 @@ func newSession(
 	parentObjectID string,
 	parentObjectName string) *session {
 	s := &session{}
+	s.export = export
 	s.isTracking = isTracking
 	s.name = name
 	s.id = id
@@ -49,6 +52,8 @@ func newSession(
 		if parentObjectID == "" {
 			panic("parentObjectID must be set if using parent session")
 		}
+		// child sessions are created without export, use the one from root session
+		s.export = s.parent.export
 		s.ProgressPath = s.parent.ProgressPath.Copy()
 		s.ProgressPath = append(s.ProgressPath, ProgressPathComponent{
 			ObjectID:   parentObjectID,
@@ -61,8 +66,6 @@ func newSession(
 		//
 		// not particularly important for progress path either
 		// use integration with id/index
-		prefix := export.IntegrationDef.String()
-
 		s.ProgressPath = append(s.ProgressPath, ProgressPathComponent{
 			TrackingName: prefix})
 	}
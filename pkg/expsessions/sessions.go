@@ -60,6 +60,11 @@ func (s *Manager) SessionRootTracking(export expin.Export, modelType string) (_
 	return s.SessionFlex(export, true, modelType, 0, "", "")
 }
 
+// SessionRootAgent creates a root session for objects produced by agent itself, for example from data of all integrations. Name is used instead of integration name in progress path and last processed key.
+func (s *Manager) SessionRootAgent(name string, modelType string) (_ ID, lastProcessed interface{}, _ error) {
+	return s.sessionFlex(expin.Export{}, name, false, modelType, 0, "", "")
+}
+
 func (s *Manager) Session(modelType string, parentSessionID ID, parentObjectID, parentObjectName string) (_ ID, lastProcessed interface{}, _ error) {
 	return s.SessionFlex(expin.Export{}, false, modelType, parentSessionID, parentObjectID, parentObjectName)
 }
@@ -69,6 +74,10 @@ func (s *Manager) SessionTracking(modelType string, parentSessionID ID, parentOb
 }
 
 func (s *Manager) SessionFlex(export expin.Export, isTracking bool, name string, parentSessionID ID, parentObjectID, parentObjectName string) (_ ID, lastProcessed interface{}, _ error) {
+	return s.sessionFlex(export, export.IntegrationDef.String(), isTracking, name, parentSessionID, parentObjectID, parentObjectName)
+}
+
+func (s *Manager) sessionFlex(export expin.Export, prefix string, isTracking bool, name string, parentSessionID ID, parentObjectID, parentObjectName string) (_ ID, lastProcessed interface{}, _ error) {
 	s.sessionsMu.Lock()
 	defer s.sessionsMu.Unlock()
 
@@ -82,7 +91,7 @@ func (s *Manager) SessionFlex(export expin.Export, isTracking bool, name string,
 	}
 
 	id := s.newID()
-	sess := newSession(export, isTracking, name, id, s.opts.NewWriter, s.opts.SendProgress, parent, parentObjectID, parentObjectName)
+	sess := newSession(export, prefix, isTracking, name, id, s.opts.NewWriter, s.opts.SendProgress, parent, parentObjectID, parentObjectName)
 	s.sessions[id] = sess
 
 	if s.opts.LastProcessed != nil {
@@ -141,6 +150,16 @@ func (s *Manager) GetModelType(id ID) string {
 	return ""
 }
 
+// GetExport returns the integration export for the session. For child sessions it is the export of the root session.
+func (s *Manager) GetExport(id ID) expin.Export {
+	sess, err := s.getLocked(id)
+	if err != nil {
+		s.logger.Error("could not get session to get GetExport", "err", err)
+		return expin.Export{}
+	}
+	return sess.export
+}
+
 // Done closes the session
 func (s *Manager) Done(id ID, lastProcessed interface{}) error {
 	s.sessionsMu.Lock()
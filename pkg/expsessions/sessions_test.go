@@ -123,6 +123,30 @@ func TestExpSessionsLastProcessed(t *testing.T) {
 	}
 }
 
+func TestExpSessionsRootAgent(t *testing.T) {
+	opts := Opts{}
+	opts.Logger = hclog.Default()
+
+	opts.NewWriter = func(modelType string, id ID) Writer {
+		return NewMockWriter()
+	}
+
+	lpm := lastProcessedMock{}
+	opts.LastProcessed = lpm
+	m := New(opts)
+
+	id, _, err := m.SessionRootAgent("agent1", "m1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, m.GetExport(id).Empty(), "agent sessions do not belong to integration")
+	err = m.Done(id, "id1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, lastProcessedMock{"agent1/m1": "id1"}, lpm)
+}
+
 func TestExpSessionsLastProcessedNested(t *testing.T) {
 	opts := Opts{}
 	opts.Logger = hclog.Default()
@@ -12,10 +12,19 @@ import (
 	"github.com/pinpt/agent/pkg/fs"
 )
 
+// WriterDedup skips objects that were already sent in previous exports or written by other open sessions of the same model. Objects are marked as sent only when writer is closed, so that objects from rolled back sessions are sent again and checkpoints of dedup store do not include objects of unfinished sessions.
 type WriterDedup struct {
 	wr        Writer
 	ds        DedupStore
 	modelName string
+
+	// hashcodes of objects written but not yet marked as sent, full objects are not kept in memory
+	pending map[dedupKey]string
+}
+
+type dedupKey struct {
+	RefType string
+	ID      string
 }
 
 func NewWriterDedup(wr Writer, ds DedupStore, modelName string) *WriterDedup {
@@ -23,19 +32,30 @@ func NewWriterDedup(wr Writer, ds DedupStore, modelName string) *WriterDedup {
 	s.wr = wr
 	s.ds = ds
 	s.modelName = modelName
+	s.pending = map[dedupKey]string{}
 	return s
 }
 
 func (s *WriterDedup) Write(logger hclog.Logger, objs []map[string]interface{}) error {
 	var filtered []map[string]interface{}
 	for _, obj := range objs {
-		wasAlreadySent, err := s.ds.MarkAsSent(obj, s.modelName)
+		refType, id, hashcode, err := objKeys(obj)
 		if err != nil {
 			return err
 		}
-		if !wasAlreadySent {
-			filtered = append(filtered, obj)
+		k := dedupKey{RefType: refType, ID: id}
+		prev, ok := s.pending[k]
+		if ok && prev == hashcode {
+			continue
+		}
+		if s.ds.addPending(s.modelName, k, hashcode) {
+			continue
 		}
+		if ok {
+			s.ds.removePending(s.modelName, k, prev)
+		}
+		s.pending[k] = hashcode
+		filtered = append(filtered, obj)
 	}
 	if len(filtered) == 0 {
 		return nil
@@ -44,22 +64,56 @@ func (s *WriterDedup) Write(logger hclog.Logger, objs []map[string]interface{})
 }
 
 func (s *WriterDedup) Close() error {
-	return s.wr.Close()
+	err := s.wr.Close()
+	if err != nil {
+		return err
+	}
+	for k, hashcode := range s.pending {
+		obj := map[string]interface{}{
+			"ref_type": k.RefType,
+			"id":       k.ID,
+			"hashcode": hashcode,
+		}
+		_, err := s.ds.MarkAsSent(obj, s.modelName)
+		if err != nil {
+			return err
+		}
+		s.ds.removePending(s.modelName, k, hashcode)
+	}
+	s.pending = map[dedupKey]string{}
+	return nil
 }
 
 func (s *WriterDedup) Rollback() error {
+	for k, hashcode := range s.pending {
+		s.ds.removePending(s.modelName, k, hashcode)
+	}
+	s.pending = map[dedupKey]string{}
 	return s.wr.Rollback()
 }
 
 type DedupStore interface {
+	// WasSent returns true if the object with the same hashcode was already sent.
+	// Safe for concurrent use.
+	WasSent(obj map[string]interface{}, modelName string) (bool, error)
+
 	// MarkAsSent marks the object as sent, if it wasn't already.
 	// And returns the bool if it was already sent before.
 	// Safe for concurrent use.
 	MarkAsSent(obj map[string]interface{}, modelName string) (wasAlreadySent bool, _ error)
 
+	// Save writes objects marked as sent to file. Pending objects are not saved.
 	Save() error
 
 	Stats() (new int, dups int)
+
+	// addPending records object written by open session. Returns true without recording it if the object was already sent or is pending in other session.
+	// Safe for concurrent use.
+	addPending(modelName string, k dedupKey, hashcode string) (dup bool)
+
+	// removePending removes object recorded by addPending, called when session is closed or rolled back.
+	// Safe for concurrent use.
+	removePending(modelName string, k dedupKey, hashcode string)
 }
 
 type dedupStore struct {
@@ -69,6 +123,9 @@ type dedupStore struct {
 	// map[ref_type][model_name][id][data_hashcode]
 	data map[string]map[string]map[string]string
 
+	// pending are objects written by open sessions, only one session can write the same object
+	pending map[pendingKey]bool
+
 	dups int
 	new  int
 }
@@ -78,6 +135,7 @@ func NewDedupStore(loc string) (DedupStore, error) {
 	s := &dedupStore{}
 	s.loc = loc
 	s.data = map[string]map[string]map[string]string{}
+	s.pending = map[pendingKey]bool{}
 
 	b, err := ioutil.ReadFile(loc)
 	if err != nil {
@@ -90,23 +148,45 @@ func NewDedupStore(loc string) (DedupStore, error) {
 	return s, json.Unmarshal(b, &s.data)
 }
 
-func (s *dedupStore) MarkAsSent(obj map[string]interface{}, modelName string) (wasAlreadySent bool, rerr error) {
-
+func objKeys(obj map[string]interface{}) (refType, id, hashcode string, rerr error) {
 	refType, ok := obj["ref_type"].(string)
 	if !ok || refType == "" {
 		rerr = errors.New("dedupStore: passed object does not have ref_type")
 		return
 	}
-	id, ok := obj["id"].(string)
+	id, ok = obj["id"].(string)
 	if !ok {
 		rerr = errors.New("dedupStore: passed object does not have id")
 		return
 	}
-	hashcode, ok := obj["hashcode"].(string)
+	hashcode, ok = obj["hashcode"].(string)
 	if !ok {
 		rerr = errors.New("dedupStore: passed object does not have hashcode")
 		return
 	}
+	return
+}
+
+func (s *dedupStore) WasSent(obj map[string]interface{}, modelName string) (bool, error) {
+	refType, id, hashcode, err := objKeys(obj)
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dup := s.data[refType][modelName][id] == hashcode
+	if dup {
+		s.dups++
+	}
+	return dup, nil
+}
+
+func (s *dedupStore) MarkAsSent(obj map[string]interface{}, modelName string) (wasAlreadySent bool, rerr error) {
+	refType, id, hashcode, err := objKeys(obj)
+	if err != nil {
+		rerr = err
+		return
+	}
 	s.mu.Lock()
 	if _, ok := s.data[refType]; !ok {
 		s.data[refType] = map[string]map[string]string{}
@@ -118,21 +198,47 @@ func (s *dedupStore) MarkAsSent(obj map[string]interface{}, modelName string) (w
 	s.data[refType][modelName][id] = hashcode
 	dup := prev == hashcode
 
-	if dup {
-		s.dups++
-	} else {
+	if !dup {
 		s.new++
 	}
 	s.mu.Unlock()
 	return dup, nil
 }
 
+type pendingKey struct {
+	ModelName string
+	dedupKey
+	Hashcode string
+}
+
+func (s *dedupStore) addPending(modelName string, k dedupKey, hashcode string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pk := pendingKey{ModelName: modelName, dedupKey: k, Hashcode: hashcode}
+	if s.data[k.RefType][modelName][k.ID] == hashcode || s.pending[pk] {
+		s.dups++
+		return true
+	}
+	s.pending[pk] = true
+	return false
+}
+
+func (s *dedupStore) removePending(modelName string, k dedupKey, hashcode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, pendingKey{ModelName: modelName, dedupKey: k, Hashcode: hashcode})
+}
+
 func (s *dedupStore) Stats() (new int, dups int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.new, s.dups
 }
 
 func (s *dedupStore) Save() error {
+	s.mu.Lock()
 	b, err := json.Marshal(s.data)
+	s.mu.Unlock()
 	if err != nil {
 		return err
 	}
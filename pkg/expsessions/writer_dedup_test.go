@@ -0,0 +1,135 @@
+package expsessions
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterDedupMarksOnClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	ds, err := NewDedupStore(filepath.Join(dir, "dedup.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := hclog.NewNullLogger()
+	obj := map[string]interface{}{"ref_type": "jira", "id": "1", "hashcode": "h1"}
+
+	// rolled back objects are not marked as sent
+	wr1 := NewMockWriter()
+	d1 := NewWriterDedup(wr1, ds, "model")
+	assert.NoError(t, d1.Write(logger, []map[string]interface{}{obj, obj}))
+	assert.Len(t, wr1.Data, 1)
+	assert.NoError(t, d1.Rollback())
+
+	wr2 := NewMockWriter()
+	d2 := NewWriterDedup(wr2, ds, "model")
+	assert.NoError(t, d2.Write(logger, []map[string]interface{}{obj}))
+	assert.Len(t, wr2.Data, 1)
+	assert.NoError(t, d2.Close())
+
+	wr3 := NewMockWriter()
+	d3 := NewWriterDedup(wr3, ds, "model")
+	assert.NoError(t, d3.Write(logger, []map[string]interface{}{obj}))
+	assert.Len(t, wr3.Data, 0)
+}
+
+func TestWriterDedupKeepsOnlyKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	ds, err := NewDedupStore(filepath.Join(dir, "dedup.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := hclog.NewNullLogger()
+
+	wr := NewMockWriter()
+	d := NewWriterDedup(wr, ds, "model")
+	objs := []map[string]interface{}{
+		{"ref_type": "jira", "id": "1", "hashcode": "h1", "name": "n1"},
+		{"ref_type": "jira", "id": "1", "hashcode": "h2", "name": "n2"},
+		{"ref_type": "github", "id": "1", "hashcode": "h1", "name": "n1"},
+	}
+	assert.NoError(t, d.Write(logger, objs))
+	assert.Len(t, wr.Data, 3)
+	assert.Equal(t, map[dedupKey]string{
+		{RefType: "jira", ID: "1"}:   "h2",
+		{RefType: "github", ID: "1"}: "h1",
+	}, d.pending)
+	assert.NoError(t, d.Close())
+
+	sent, err := ds.WasSent(objs[1], "model")
+	assert.NoError(t, err)
+	assert.True(t, sent)
+	sent, err = ds.WasSent(objs[0], "model")
+	assert.NoError(t, err)
+	assert.False(t, sent)
+	sent, err = ds.WasSent(objs[2], "model")
+	assert.NoError(t, err)
+	assert.True(t, sent)
+}
+
+func TestWriterDedupOpenSessions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	loc := filepath.Join(dir, "dedup.json")
+	ds, err := NewDedupStore(loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := hclog.NewNullLogger()
+	obj := map[string]interface{}{"ref_type": "jira", "id": "1", "hashcode": "h1"}
+
+	// two open sessions of the same model
+	wr1 := NewMockWriter()
+	d1 := NewWriterDedup(wr1, ds, "model")
+	wr2 := NewMockWriter()
+	d2 := NewWriterDedup(wr2, ds, "model")
+	assert.NoError(t, d1.Write(logger, []map[string]interface{}{obj}))
+	assert.NoError(t, d2.Write(logger, []map[string]interface{}{obj}))
+	assert.Len(t, wr1.Data, 1)
+	assert.Len(t, wr2.Data, 0, "object pending in other open session is skipped")
+
+	// other model is not affected
+	wr3 := NewMockWriter()
+	d3 := NewWriterDedup(wr3, ds, "model2")
+	assert.NoError(t, d3.Write(logger, []map[string]interface{}{obj}))
+	assert.Len(t, wr3.Data, 1)
+
+	// pending objects are not saved
+	assert.NoError(t, ds.Save())
+	ds2, err := NewDedupStore(loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent, err := ds2.WasSent(obj, "model")
+	assert.NoError(t, err)
+	assert.False(t, sent)
+
+	// rolled back session does not block later sessions
+	assert.NoError(t, d1.Rollback())
+	wr4 := NewMockWriter()
+	d4 := NewWriterDedup(wr4, ds, "model")
+	assert.NoError(t, d4.Write(logger, []map[string]interface{}{obj}))
+	assert.Len(t, wr4.Data, 1)
+	assert.NoError(t, d4.Close())
+
+	wr5 := NewMockWriter()
+	d5 := NewWriterDedup(wr5, ds, "model")
+	assert.NoError(t, d5.Write(logger, []map[string]interface{}{obj}))
+	assert.Len(t, wr5.Data, 0)
+}
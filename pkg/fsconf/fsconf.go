@@ -23,12 +23,21 @@ type Locs struct {
 	Uploads           string
 	UploadZips        string
 	RipsrcCheckpoints string
+	// IntegrationsState stores state of integrations that does not fit in last processed, in a separate dir for each integration
+	IntegrationsState string
 
 	Backup                  string
 	RipsrcCheckpointsBackup string
+	IntegrationsStateBackup string
 
 	ServiceRunCrashes string
 
+	// LogSpool stores log batches that were not uploaded to backend yet. Not in state dir, so that logs are kept when state version changes.
+	LogSpool string
+
+	// PushSpool stores deploy and incident events pushed to the agent until they are exported by push integration. Not in state dir, so that events are kept when state version changes.
+	PushSpool string
+
 	IntegrationsDefaultDir string
 
 	// Special files
@@ -42,7 +51,23 @@ type Locs struct {
 	ExportQueueFile string
 
 	// DedupFile contains hashes of all objects sent in incrementals to avoid sending the same objects multiple times
-	DedupFile string
+	DedupFile       string
+	DedupFileBackup string
+
+	// Outbox stores events that were not sent to backend yet, in a separate dir for each process
+	Outbox string
+
+	// ExportCheckpointFile stores the export request in progress, used to resume export after agent restart
+	ExportCheckpointFile string
+
+	// CABundle contains system and additional CAs from network config, used by git and libraries creating their own http clients
+	CABundle string
+
+	// UpdatePendingFile stores agent update that was not yet confirmed by the new version. Not in state dir, since new version may use a different state version.
+	UpdatePendingFile string
+
+	// CapabilitiesFile stores capabilities manifests of integrations loaded for export, reported in enabled event
+	CapabilitiesFile string
 
 	// CleanupDirs are directories that will be removed on every run
 	CleanupDirs []string
@@ -89,7 +114,12 @@ func New(pinpointRoot string) Locs {
 	s.RipsrcCheckpoints = j(s.State, "ripsrc_checkpoints/v3")
 	s.RipsrcCheckpointsBackup = j(s.Backup, "ripsrc_checkpoints/v3")
 
+	s.IntegrationsState = j(s.State, "integrations")
+	s.IntegrationsStateBackup = j(s.Backup, "integrations")
+
 	s.ServiceRunCrashes = j(s.Logs, "service-run-crashes")
+	s.LogSpool = j(s.Logs, "spool")
+	s.PushSpool = j(s.Root, "push-spool")
 
 	s.IntegrationsDefaultDir = j(s.Root, "integrations")
 
@@ -97,6 +127,12 @@ func New(pinpointRoot string) Locs {
 	s.LastProcessedFile = j(s.State, "last_processed.json")
 	s.LastProcessedFileBackup = j(s.Backup, "last_processed.json")
 	s.ExportQueueFile = j(s.State, "export_queue.json")
+	s.CapabilitiesFile = j(s.Cache, "capabilities.json")
 	s.DedupFile = j(s.State, "dedup_v2.json")
+	s.DedupFileBackup = j(s.Backup, "dedup_v2.json")
+	s.ExportCheckpointFile = j(s.State, "export_checkpoint.json")
+	s.Outbox = j(s.State, "outbox")
+	s.CABundle = j(s.Cache, "ca-bundle.pem")
+	s.UpdatePendingFile = j(s.Root, "update_pending.json")
 	return s
 }
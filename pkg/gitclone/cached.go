@@ -0,0 +1,81 @@
+package gitclone
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// CachedRepo is a repo mirrored into cache by repo export.
+type CachedRepo struct {
+	// Dir is the location of the mirror
+	Dir string
+	// RefType is the ref type of sourcecode integration that exported the repo
+	RefType string
+	// RepoID is the pinpoint id of the repo
+	RepoID string
+	// escapedName is the repo name as used in dir name
+	escapedName string
+}
+
+// MatchesName returns true if the name of the repo is name. Name is compared after escaping, the same way as in cache dir name.
+func (s CachedRepo) MatchesName(name string) bool {
+	return s.escapedName != "" && s.escapedName == escapeForFS(name)
+}
+
+// CachedRepos returns repos mirrored into cacheRoot. Repo export uses refType-uniqueName as the name of repo, so the dir name is refType-escapedName-repoID. Dirs not following this format are skipped.
+func CachedRepos(cacheRoot string) (res []CachedRepo, _ error) {
+	dirs, err := ioutil.ReadDir(cacheRoot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for _, info := range dirs {
+		if !info.IsDir() {
+			continue
+		}
+		name := info.Name()
+		first := strings.Index(name, "-")
+		last := strings.LastIndex(name, "-")
+		if first <= 0 || last <= first+1 || last == len(name)-1 {
+			continue
+		}
+		res = append(res, CachedRepo{
+			Dir:         filepath.Join(cacheRoot, name),
+			RefType:     name[:first],
+			RepoID:      name[last+1:],
+			escapedName: name[first+1 : last],
+		})
+	}
+	return
+}
+
+var scpLikeURLRe = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// RepoNameFromURL returns host/path and path of repo url, without credentials and .git suffix. For example github.com/org/repo and org/repo. Supports scp like urls, such as git@github.com:org/repo.git. Returns empty strings if url is not valid.
+func RepoNameFromURL(repoURL string) (hostPath string, name string) {
+	var host, p string
+	if m := scpLikeURLRe.FindStringSubmatch(repoURL); m != nil && !strings.Contains(repoURL, "://") {
+		host, p = m[1], m[2]
+	} else {
+		u, err := url.Parse(repoURL)
+		if err != nil {
+			return "", ""
+		}
+		host, p = u.Host, u.Path
+	}
+	p = strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+	if p == "" {
+		return "", ""
+	}
+	if host == "" {
+		// local path used as remote
+		return p, filepath.Base(p)
+	}
+	return host + "/" + p, p
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/pkg/netconf"
 	"github.com/pinpt/go-common/fileutil"
 )
 
@@ -25,6 +26,9 @@ type Dirs struct {
 	CacheRoot string
 }
 
+// EnvCacheRoot is the env variable used to pass the location of repo cache to integrations.
+const EnvCacheRoot = "PP_AGENT_REPO_CACHE"
+
 type CloneResults struct {
 	CacheDir string
 	Checkout string
@@ -204,13 +208,21 @@ func gitRunGCForLongClone(ctx context.Context, logger hclog.Logger, dir string)
 }
 
 func runGitCommand(ctx context.Context, logger hclog.Logger, cmd *exec.Cmd) error {
+	c := ""
+	if len(cmd.Args) > 2 {
+		c = cmd.Args[1]
+	}
+	// proxy and ca are passed in env, client certificates need to be set in config
+	netConf, err := netconf.Current()
+	if err != nil {
+		return err
+	}
+	if args := netConf.GitArgs(); len(args) != 0 {
+		cmd.Args = append(append([]string{cmd.Args[0]}, args...), cmd.Args[1:]...)
+	}
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 	if err := cmd.Run(); err != nil {
-		c := ""
-		if len(cmd.Args) > 2 {
-			c = cmd.Args[1]
-		}
 		//logger.Info("git command failed", "command", c, "output", stderr.String())
 		return fmt.Errorf("git command failed, command %v, output %v", c, stderr.String())
 	}
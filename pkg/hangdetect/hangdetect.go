@@ -0,0 +1,148 @@
+// Package hangdetect detects integrations that stopped responding during export.
+//
+// Integrations using rpcdef.ProtocolVersionHeartbeat send heartbeats while export is making progress, that is when integration sends data, updates sessions or waits for rate limits. Export is considered hanging when no heartbeat was received for HeartbeatTimeout or when the whole export takes longer than ExportTimeout.
+package hangdetect
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultHeartbeatTimeout is used when heartbeat timeout is not set in config. Heartbeats are only sent on progress, so this is the max time integration can work without sending anything to agent.
+const DefaultHeartbeatTimeout = 10 * time.Minute
+
+// DefaultExportTimeout is used when export timeout is not set in config. Exports resume from completed sessions, so a killed export continues on next run.
+const DefaultExportTimeout = 24 * time.Hour
+
+// Config is the user configuration for hang detection, passed from agent config.
+type Config struct {
+	// ExportTimeoutMinutes is the max duration of export for one integration. Defaults to 24 hours, use -1 for no limit.
+	ExportTimeoutMinutes int `json:"export_timeout_minutes"`
+	// HeartbeatTimeoutSeconds is the max time between heartbeats from integration. Defaults to 10 minutes.
+	HeartbeatTimeoutSeconds int `json:"heartbeat_timeout_seconds"`
+}
+
+// Opts are options for New.
+type Opts struct {
+	Logger hclog.Logger
+	Config Config
+	// Heartbeats is true if integration sends heartbeats. Only export timeout is checked otherwise.
+	Heartbeats bool
+}
+
+// Detector detects a hang in one integration export.
+type Detector struct {
+	opts   Opts
+	logger hclog.Logger
+
+	heartbeatTimeout time.Duration
+	exportTimeout    time.Duration
+	checkInterval    time.Duration
+
+	mu            sync.Mutex
+	lastHeartbeat time.Time
+	hang          error
+	cancel        func()
+	done          chan bool
+}
+
+// New creates a detector.
+func New(opts Opts) *Detector {
+	s := &Detector{}
+	s.opts = opts
+	s.logger = opts.Logger
+	s.heartbeatTimeout = DefaultHeartbeatTimeout
+	if opts.Config.HeartbeatTimeoutSeconds > 0 {
+		s.heartbeatTimeout = time.Duration(opts.Config.HeartbeatTimeoutSeconds) * time.Second
+	}
+	s.exportTimeout = DefaultExportTimeout
+	if opts.Config.ExportTimeoutMinutes > 0 {
+		s.exportTimeout = time.Duration(opts.Config.ExportTimeoutMinutes) * time.Minute
+	} else if opts.Config.ExportTimeoutMinutes < 0 {
+		s.exportTimeout = 0
+	}
+	s.checkInterval = time.Second
+	return s
+}
+
+// Start starts checking for hang. Returned context is cancelled when hang is detected, use it for the export rpc call. Call Stop after export returns.
+func (s *Detector) Start(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan bool)
+	s.mu.Lock()
+	s.lastHeartbeat = time.Now()
+	s.cancel = cancel
+	s.done = done
+	s.mu.Unlock()
+
+	start := time.Now()
+	go func() {
+		ticker := time.NewTicker(s.checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := s.check(start)
+				if err != nil {
+					s.logger.Error("integration hang detected", "err", err)
+					s.mu.Lock()
+					s.hang = err
+					s.mu.Unlock()
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return ctx
+}
+
+func (s *Detector) check(start time.Time) error {
+	if s.exportTimeout != 0 && time.Since(start) > s.exportTimeout {
+		return fmt.Errorf("export did not finish in %v (export_timeout_minutes)", s.exportTimeout)
+	}
+	if !s.opts.Heartbeats {
+		return nil
+	}
+	s.mu.Lock()
+	last := s.lastHeartbeat
+	s.mu.Unlock()
+	if since := time.Since(last); since > s.heartbeatTimeout {
+		return fmt.Errorf("no heartbeat received from integration for %v", since.Round(time.Second))
+	}
+	return nil
+}
+
+// Beat records a heartbeat from integration.
+func (s *Detector) Beat() {
+	s.mu.Lock()
+	s.lastHeartbeat = time.Now()
+	s.mu.Unlock()
+}
+
+// Stop stops checking and cancels the context returned from Start.
+func (s *Detector) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done == nil {
+		return
+	}
+	close(s.done)
+	s.done = nil
+	s.cancel()
+}
+
+// Hang returns the reason if hang was detected, nil otherwise.
+func (s *Detector) Hang() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hang
+}
@@ -0,0 +1,77 @@
+package hangdetect
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDetector(heartbeats bool) *Detector {
+	s := New(Opts{Logger: hclog.NewNullLogger(), Heartbeats: heartbeats})
+	s.heartbeatTimeout = 50 * time.Millisecond
+	s.checkInterval = 5 * time.Millisecond
+	return s
+}
+
+func TestHeartbeatMissed(t *testing.T) {
+	s := newTestDetector(true)
+	ctx := s.Start(context.Background())
+	defer s.Stop()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("hang not detected")
+	}
+	assert.Contains(t, s.Hang().Error(), "no heartbeat received")
+}
+
+func TestHeartbeatReceived(t *testing.T) {
+	s := newTestDetector(true)
+	ctx := s.Start(context.Background())
+	for i := 0; i < 20; i++ {
+		s.Beat()
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NoError(t, ctx.Err())
+	assert.NoError(t, s.Hang())
+	s.Stop()
+	assert.Error(t, ctx.Err())
+	assert.NoError(t, s.Hang())
+}
+
+func TestExportTimeout(t *testing.T) {
+	s := newTestDetector(false)
+	s.exportTimeout = 30 * time.Millisecond
+	ctx := s.Start(context.Background())
+	defer s.Stop()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("timeout not detected")
+	}
+	assert.Contains(t, s.Hang().Error(), "export did not finish")
+}
+
+func TestNoHeartbeatsWithoutSupport(t *testing.T) {
+	s := newTestDetector(false)
+	ctx := s.Start(context.Background())
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, ctx.Err())
+	s.Stop()
+}
+
+func TestTimeoutsFromConfig(t *testing.T) {
+	s := New(Opts{Logger: hclog.NewNullLogger()})
+	assert.Equal(t, DefaultHeartbeatTimeout, s.heartbeatTimeout)
+	assert.Equal(t, DefaultExportTimeout, s.exportTimeout)
+
+	s = New(Opts{Logger: hclog.NewNullLogger(), Config: Config{ExportTimeoutMinutes: 30, HeartbeatTimeoutSeconds: 60}})
+	assert.Equal(t, time.Minute, s.heartbeatTimeout)
+	assert.Equal(t, 30*time.Minute, s.exportTimeout)
+
+	s = New(Opts{Logger: hclog.NewNullLogger(), Config: Config{ExportTimeoutMinutes: -1}})
+	assert.Equal(t, time.Duration(0), s.exportTimeout)
+}
@@ -1,6 +1,8 @@
 package iloader
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -18,6 +20,8 @@ import (
 	"github.com/pinpt/agent/pkg/expin"
 
 	"github.com/pinpt/agent/pkg/fsconf"
+	"github.com/pinpt/agent/pkg/plugins"
+	"github.com/pinpt/agent/pkg/sandbox"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
@@ -31,6 +35,8 @@ type IntegrationOpts struct {
 	Locs                       fsconf.Locs
 	IntegrationsDir            string
 	DevUseCompiledIntegrations bool
+	Plugins                    plugins.Config
+	Sandbox                    sandbox.Config
 }
 
 type Integration struct {
@@ -44,6 +50,8 @@ type Integration struct {
 	pluginClient     *plugin.Client
 	rpcClientGeneric plugin.ClientProtocol
 	rpcClient        rpcdef.Integration
+	capabilities     rpcdef.Capabilities
+	sandbox          *sandbox.Sandbox
 
 	closed bool
 }
@@ -76,6 +84,19 @@ func (s *Integration) RPCClient() rpcdef.Integration {
 	return s.rpcClient
 }
 
+// Capabilities returns the capabilities manifest returned by integration on init.
+func (s *Integration) Capabilities() rpcdef.Capabilities {
+	return s.capabilities
+}
+
+// LimitViolation returns an error if integration process exceeded sandbox limits. Use to explain rpc errors after integration was killed.
+func (s *Integration) LimitViolation() error {
+	if s.sandbox == nil {
+		return nil
+	}
+	return s.sandbox.Violation()
+}
+
 func prodIntegrationCommand(integrationsDir string, integrationName string) (*exec.Cmd, error) {
 	binName := integrationName
 	if runtime.GOOS == "windows" {
@@ -96,6 +117,20 @@ func prodIntegrationCommand(integrationsDir string, integrationName string) (*ex
 	return exec.Command(bin), nil
 }
 
+// pluginCommand returns command for third-party plugin, after verifying the binary.
+func (s *Integration) pluginCommand() (*exec.Cmd, error) {
+	def := s.Export.IntegrationDef
+	p, err := plugins.Get(s.opts.IntegrationsDir, s.opts.Plugins, def.Name)
+	if err != nil {
+		return nil, err
+	}
+	if t := def.Type.String(); t != "" && t != "unset" && !p.Manifest.SupportsType(t) {
+		return nil, fmt.Errorf("plugin %v does not support integration type %v, supported: %v", def.Name, t, p.Manifest.Types)
+	}
+	s.logger.Info("using plugin", "version", p.Manifest.Version, "trusted_by", p.TrustedBy)
+	return exec.Command(p.Binary), nil
+}
+
 func devIntegrationCommand(binaryName string) (*exec.Cmd, error) {
 	gop := os.Getenv("GOPATH")
 	if gop == "" {
@@ -138,6 +173,12 @@ func (s *Integration) Close() error {
 	//	return err
 	//}
 	s.pluginClient.Kill()
+	if s.sandbox != nil {
+		err := s.sandbox.Close()
+		if err != nil {
+			s.logger.Error("could not close sandbox", "err", err)
+		}
+	}
 	err := s.logFile.Close()
 	if err != nil {
 		return err
@@ -145,6 +186,25 @@ func (s *Integration) Close() error {
 	return nil
 }
 
+// Exited returns true if integration process exited, for example after a panic.
+func (s *Integration) Exited() bool {
+	return s.pluginClient.Exited()
+}
+
+// Kill force kills integration process and closes integration. Use for hanging integrations, graceful shutdown in Close blocks if process does not respond.
+func (s *Integration) Kill() error {
+	if rc := s.pluginClient.ReattachConfig(); rc != nil && rc.Pid != 0 {
+		p, err := os.FindProcess(rc.Pid)
+		if err == nil {
+			err = p.Kill()
+		}
+		if err != nil {
+			s.logger.Error("could not kill integration process", "pid", rc.Pid, "err", err)
+		}
+	}
+	return s.Close()
+}
+
 func (s *Integration) setupLogFile() error {
 	dir := s.opts.Locs.LogsIntegrations
 	err := os.MkdirAll(dir, 0777)
@@ -162,7 +222,13 @@ func (s *Integration) setupLogFile() error {
 
 func (s *Integration) setupRPC() error {
 	var cmd *exec.Cmd
-	if build.IsProduction() || s.opts.DevUseCompiledIntegrations {
+	if plugins.Exists(s.opts.IntegrationsDir, s.Export.IntegrationDef.Name) {
+		var err error
+		cmd, err = s.pluginCommand()
+		if err != nil {
+			return err
+		}
+	} else if build.IsProduction() || s.opts.DevUseCompiledIntegrations {
 		var err error
 		cmd, err = prodIntegrationCommand(s.opts.IntegrationsDir, s.Export.IntegrationDef.Name)
 		if err != nil {
@@ -176,12 +242,20 @@ func (s *Integration) setupRPC() error {
 		}
 	}
 
+	if s.opts.Sandbox.Enabled() {
+		var err error
+		cmd, err = s.setupSandbox(cmd)
+		if err != nil {
+			return err
+		}
+	}
+
 	client := plugin.NewClient(&plugin.ClientConfig{
-		Stderr:          s.logFile,
-		Logger:          s.logger,
-		HandshakeConfig: rpcdef.Handshake,
-		Plugins:         rpcdef.PluginMap,
-		Cmd:             cmd,
+		Stderr:           s.logFile,
+		Logger:           s.logger,
+		HandshakeConfig:  rpcdef.Handshake,
+		VersionedPlugins: rpcdef.VersionedPlugins(rpcdef.PluginMap),
+		Cmd:              cmd,
 		AllowedProtocols: []plugin.Protocol{
 			plugin.ProtocolGRPC},
 		Managed: true,
@@ -201,10 +275,48 @@ func (s *Integration) setupRPC() error {
 
 	s.rpcClient = rpcClientIface.(rpcdef.Integration)
 
-	s.rpcClient.Init(s.opts.Agent)
+	caps, err := s.rpcClient.Init(s.opts.Agent)
+	if err != nil {
+		return fmt.Errorf("could not init integration: %v", err)
+	}
+	caps = rpcdef.NegotiatedCapabilities(client.NegotiatedVersion(), caps)
+	s.capabilities = caps
+	s.logger.Debug("integration initialized", "protocol_version", caps.ProtocolVersion, "legacy", caps.Legacy)
 	return nil
 }
 
+// GoroutineDump returns stack traces of all goroutines in integration process. Only supported when integration supports heartbeats.
+func (s *Integration) GoroutineDump(ctx context.Context) ([]byte, error) {
+	if !s.capabilities.SupportsHeartbeat() {
+		return nil, fmt.Errorf("integration does not support goroutine dump, protocol version: %v", s.capabilities.ProtocolVersion)
+	}
+	client, ok := s.rpcClient.(*rpcdef.IntegrationClient)
+	if !ok {
+		return nil, errors.New("goroutine dump is not supported by rpc client")
+	}
+	return client.GoroutineDump(ctx)
+}
+
+// setupSandbox returns the command wrapped to run integration with configured resource limits
+func (s *Integration) setupSandbox(cmd *exec.Cmd) (*exec.Cmd, error) {
+	opts := sandbox.Opts{}
+	opts.Logger = s.logger
+	opts.Config = s.opts.Sandbox
+	opts.Name = s.Export.IntegrationDef.Name
+	opts.WritablePaths = []string{s.opts.Locs.Root}
+	opts.HiddenPaths = []string{s.opts.Locs.Config2}
+	sb, err := sandbox.New(opts)
+	if err != nil {
+		return nil, err
+	}
+	s.sandbox = sb
+	res, err := sb.Wrap(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("could not start integration in sandbox: %v", err)
+	}
+	return res, nil
+}
+
 func (s *Integration) CloseAndDetectPanic() (panicOut string, rerr error) {
 	rerr = s.Close()
 	b, err := ioutil.ReadFile(s.logFileLoc)
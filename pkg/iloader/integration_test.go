@@ -0,0 +1,143 @@
+package iloader
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/cmd/cmdrunnorestarts/inconfig"
+	"github.com/pinpt/agent/pkg/expin"
+	"github.com/pinpt/agent/pkg/fsconf"
+	"github.com/pinpt/agent/pkg/hangdetect"
+	"github.com/pinpt/agent/rpcdef"
+	"github.com/stretchr/testify/assert"
+)
+
+type testAgent struct {
+	heartbeat func()
+}
+
+func (s testAgent) ExportStarted(modelType string) (sessionID string, lastProcessed interface{}) {
+	return "1", nil
+}
+
+func (s testAgent) ExportDone(sessionID string, lastProcessed interface{}) {}
+
+func (s testAgent) SendExported(sessionID string, objs []rpcdef.ExportObj) {}
+
+func (s testAgent) SessionStart(isTracking bool, name string, parentSessionID int, parentObjectID, parentObjectName string) (sessionID int, lastProcessed interface{}, _ error) {
+	return 1, nil, nil
+}
+
+func (s testAgent) SessionProgress(id int, current, total int) error {
+	return nil
+}
+
+func (s testAgent) SessionRollback(id int) error {
+	return nil
+}
+
+func (s testAgent) ExportGitRepo(fetch rpcdef.GitRepoFetch) error {
+	return nil
+}
+
+func (s testAgent) OAuthNewAccessToken() (token string, _ error) {
+	return "", nil
+}
+
+func (s testAgent) SendPauseEvent(msg string, resumeDate time.Time) error {
+	return nil
+}
+
+func (s testAgent) SendResumeEvent(msg string) error {
+	return nil
+}
+
+func (s testAgent) Heartbeat() error {
+	s.heartbeat()
+	return nil
+}
+
+// TestKillHangingIntegration builds support/pluginhang integration, which sleeps in export and still responds to rpc calls, and checks that it is detected and killed.
+func TestKillHangingIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds integration binary")
+	}
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bin := "aaihang"
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+	integrationsDir := filepath.Join(dir, "integrations")
+	cmd := exec.Command("go", "build", "-o", filepath.Join(integrationsDir, bin), ".")
+	cmd.Dir = filepath.Join("..", "..", "support", "pluginhang", "integration")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("could not build pluginhang: %v\n%s", err, out)
+	}
+
+	opts := IntegrationOpts{}
+	opts.Logger = hclog.NewNullLogger()
+	agent := &testAgent{}
+	opts.Agent = agent
+	opts.Export = expin.Export{IntegrationID: "1", IntegrationDef: inconfig.IntegrationDef{Name: "aaihang"}}
+	opts.Locs = fsconf.New(filepath.Join(dir, "root"))
+	opts.IntegrationsDir = integrationsDir
+	opts.DevUseCompiledIntegrations = true
+	integration, err := NewIntegration(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer integration.Close()
+	assert.True(t, integration.Capabilities().SupportsHeartbeat())
+
+	detector := hangdetect.New(hangdetect.Opts{
+		Logger: hclog.NewNullLogger(),
+		// longer than rpcdef.HeartbeatInterval, so that hang is only detected if no heartbeats are sent
+		Config:     hangdetect.Config{HeartbeatTimeoutSeconds: 15},
+		Heartbeats: true,
+	})
+	agent.heartbeat = detector.Beat
+	// fail instead of waiting for pluginhang to finish if hang is not detected
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ctx = detector.Start(ctx)
+	_, err = integration.RPCClient().Export(ctx, rpcdef.ExportConfig{})
+	detector.Stop()
+	assert.Error(t, err)
+	if assert.Error(t, detector.Hang()) {
+		assert.Contains(t, detector.Hang().Error(), "no heartbeat received")
+	}
+
+	dump, err := integration.GoroutineDump(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(dump), "time.Sleep")
+
+	pid := integration.pluginClient.ReattachConfig().Pid
+	err = integration.Kill()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, integration.Exited())
+	if runtime.GOOS != "windows" {
+		p, err := os.FindProcess(pid)
+		if err == nil {
+			err = p.Signal(syscall.Signal(0))
+		}
+		assert.Error(t, err, "integration process is still running")
+	}
+}
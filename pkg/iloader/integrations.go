@@ -7,6 +7,8 @@ import (
 	"github.com/hashicorp/go-hclog"
 	"github.com/pinpt/agent/pkg/expin"
 	"github.com/pinpt/agent/pkg/fsconf"
+	"github.com/pinpt/agent/pkg/plugins"
+	"github.com/pinpt/agent/pkg/sandbox"
 	"github.com/pinpt/agent/rpcdef"
 )
 
@@ -19,6 +21,10 @@ type Opts struct {
 	IntegrationsDir string
 	// DevUseCompiledIntegrations set to true to use compiled integrations in dev build. They are used by default in prod builds.
 	DevUseCompiledIntegrations bool
+	// Plugins configures verification of third-party plugins
+	Plugins plugins.Config
+	// Sandbox configures resource limits for integration processes
+	Sandbox sandbox.Config
 }
 
 type Loader struct {
@@ -79,5 +85,7 @@ func (s *Loader) load(export expin.Export) (*Integration, error) {
 	opts.Locs = s.locs
 	opts.IntegrationsDir = s.opts.IntegrationsDir
 	opts.DevUseCompiledIntegrations = s.opts.DevUseCompiledIntegrations
+	opts.Plugins = s.opts.Plugins
+	opts.Sandbox = s.opts.Sandbox
 	return NewIntegration(opts)
 }
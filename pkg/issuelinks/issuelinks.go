@@ -0,0 +1,293 @@
+// Package issuelinks finds issue keys in commit messages, branch names and pull requests and links them to work issues.
+//
+// Objects are scanned when written to export sessions, so both git processing and integrations are covered. Found keys are filtered at the end of export, using project keys learned from work projects exported in the same run or configured explicitly.
+package issuelinks
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/pkg/ids"
+	"github.com/pinpt/go-common/hash"
+)
+
+// TableName is the model name used for exported links.
+const TableName = "sourcecode.IssueLink"
+
+// SourceType is the type of object containing the issue key.
+type SourceType string
+
+const (
+	SourceCommit      SourceType = "commit"
+	SourceBranch      SourceType = "branch"
+	SourcePullRequest SourceType = "pull_request"
+)
+
+// Link connects sourcecode object to work issue.
+type Link struct {
+	CustomerID string
+	// RefType is the ref type of the source object
+	RefType    string
+	SourceType SourceType
+	SourceID   string
+	// IssueKey is the key as found in text, for example ABC-123 for jira or AB#123 for azure
+	IssueKey string
+	// IssueRefType is set when issue key matched the project exported in the same run
+	IssueRefType string
+	// IssueID is set when the issue id could be resolved from key. Requires issue to be exported in the same run for jira, always set for azure.
+	IssueID string
+}
+
+// ID returns stable id for the link, based on customer, source and issue key.
+func (s Link) ID() string {
+	return hash.Values("IssueLink", s.CustomerID, string(s.SourceType), s.SourceID, s.IssueKey)
+}
+
+func (s Link) ToMap() map[string]interface{} {
+	res := map[string]interface{}{}
+	res["id"] = s.ID()
+	res["customer_id"] = s.CustomerID
+	res["ref_type"] = s.RefType
+	res["source_type"] = string(s.SourceType)
+	res["source_id"] = s.SourceID
+	res["issue_key"] = s.IssueKey
+	res["issue_ref_type"] = s.IssueRefType
+	res["issue_id"] = s.IssueID
+	res["hashcode"] = hash.Values(s.ID(), s.RefType, s.IssueRefType, s.IssueID)
+	return res
+}
+
+// Config is the user configuration for issue linking, passed from agent config.
+type Config struct {
+	// ProjectKeys are jira style project keys to always link, in addition to projects exported in the same run.
+	ProjectKeys []string `json:"project_keys"`
+	// Patterns are additional regular expressions for issue keys. If pattern contains a capture group, the first group is used as the key, otherwise full match.
+	Patterns []string `json:"patterns"`
+}
+
+// Opts are options for New.
+type Opts struct {
+	Logger     hclog.Logger
+	CustomerID string
+	Config     Config
+}
+
+// Linker collects issue key candidates from written objects and creates links at the end of export.
+// Safe for concurrent use.
+type Linker struct {
+	opts   Opts
+	logger hclog.Logger
+
+	patterns []*regexp.Regexp
+
+	mu sync.Mutex
+	// candidates are keys found in objects, filtered in Links once all projects are known. Deduplicated by object and key.
+	candidates map[candidate]bool
+	// jiraProjects is map[project_key]ref_type
+	jiraProjects map[string]string
+	// azureRefType is set when azure projects were exported in this run
+	azureRefType string
+	// issues is map[issue_key]issue_ref_id for issues exported in this run
+	issues map[string]string
+}
+
+type candidate struct {
+	Kind       keyKind
+	RefType    string
+	SourceType SourceType
+	SourceID   string
+	Key        string
+}
+
+type keyKind int
+
+const (
+	keyJira keyKind = iota
+	keyAzure
+	keyCustom
+)
+
+// jiraKeyRe matches uppercase keys only, lowercase matches are mostly noise such as utf-8 or sha-256
+var jiraKeyRe = regexp.MustCompile(`\b([A-Z][A-Z0-9_]+)-([1-9][0-9]*)\b`)
+var azureKeyRe = regexp.MustCompile(`(?i)\bAB#([1-9][0-9]*)\b`)
+
+// New creates a linker.
+func New(opts Opts) (*Linker, error) {
+	if opts.Logger == nil || opts.CustomerID == "" {
+		return nil, fmt.Errorf("issuelinks: provide all required params")
+	}
+	s := &Linker{}
+	s.opts = opts
+	s.logger = opts.Logger.Named("issuelinks")
+	s.jiraProjects = map[string]string{}
+	s.issues = map[string]string{}
+	s.candidates = map[candidate]bool{}
+	for _, k := range opts.Config.ProjectKeys {
+		s.jiraProjects[strings.ToUpper(k)] = ""
+	}
+	for _, p := range opts.Config.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("issuelinks: invalid issue key pattern %v: %v", p, err)
+		}
+		s.patterns = append(s.patterns, re)
+	}
+	return s, nil
+}
+
+// extract returns all possible issue keys in text. These are filtered later based on known projects.
+func (s *Linker) extract(text string) (res []candidate) {
+	if text == "" {
+		return
+	}
+	for _, m := range jiraKeyRe.FindAllStringSubmatch(text, -1) {
+		res = append(res, candidate{Kind: keyJira, Key: m[1] + "-" + m[2]})
+	}
+	for _, m := range azureKeyRe.FindAllStringSubmatch(text, -1) {
+		res = append(res, candidate{Kind: keyAzure, Key: "AB#" + m[1]})
+	}
+	for _, re := range s.patterns {
+		for _, m := range re.FindAllStringSubmatch(text, -1) {
+			key := m[0]
+			if len(m) > 1 {
+				key = m[1]
+			}
+			res = append(res, candidate{Kind: keyCustom, Key: key})
+		}
+	}
+	return
+}
+
+// scan returns candidates for the object based on model name. Other objects are ignored.
+func (s *Linker) scan(modelName string, obj map[string]interface{}) (res []candidate) {
+	str := func(k string) string {
+		v, _ := obj[k].(string)
+		return v
+	}
+	var sourceType SourceType
+	var texts []string
+	switch modelName {
+	case "sourcecode.Commit":
+		sourceType = SourceCommit
+		texts = []string{str("message")}
+	case "sourcecode.Branch", "sourcecode.PullRequestBranch":
+		sourceType = SourceBranch
+		texts = []string{str("name")}
+	case "sourcecode.PullRequest":
+		sourceType = SourcePullRequest
+		texts = []string{str("title"), str("description"), str("branch_name")}
+	default:
+		return
+	}
+	sourceID := str("id")
+	refType := str("ref_type")
+	if sourceID == "" {
+		return
+	}
+	seen := map[string]bool{}
+	for _, text := range texts {
+		for _, c := range s.extract(text) {
+			if seen[c.Key] {
+				continue
+			}
+			seen[c.Key] = true
+			c.SourceType = sourceType
+			c.SourceID = sourceID
+			c.RefType = refType
+			res = append(res, c)
+		}
+	}
+	return
+}
+
+// observe records projects and issues used to filter and resolve keys.
+func (s *Linker) observe(modelName string, obj map[string]interface{}) {
+	str := func(k string) string {
+		v, _ := obj[k].(string)
+		return v
+	}
+	switch modelName {
+	case "work.Project":
+		refType := str("ref_type")
+		if refType == "jira" {
+			key := strings.ToUpper(str("identifier"))
+			if key != "" {
+				s.jiraProjects[key] = refType
+			}
+		} else if refType != "" {
+			// azure and tfs projects use global work item ids
+			s.azureRefType = refType
+		}
+	case "work.Issue":
+		if str("ref_type") == "jira" {
+			key := strings.ToUpper(str("identifier"))
+			if key != "" {
+				s.issues[key] = str("ref_id")
+			}
+		}
+	}
+}
+
+func (s *Linker) add(modelName string, objs []map[string]interface{}, cands []candidate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, obj := range objs {
+		s.observe(modelName, obj)
+	}
+	for _, c := range cands {
+		s.candidates[c] = true
+	}
+}
+
+// Links returns links for all candidates matching known projects or patterns. Call after all objects were written.
+func (s *Linker) Links() (res []Link) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := map[string]bool{}
+	skipped := 0
+	for c := range s.candidates {
+		link := Link{
+			CustomerID: s.opts.CustomerID,
+			RefType:    c.RefType,
+			SourceType: c.SourceType,
+			SourceID:   c.SourceID,
+			IssueKey:   c.Key,
+		}
+		switch c.Kind {
+		case keyJira:
+			project := c.Key[:strings.LastIndex(c.Key, "-")]
+			refType, ok := s.jiraProjects[project]
+			if !ok {
+				skipped++
+				continue
+			}
+			link.IssueRefType = refType
+			if refID := s.issues[c.Key]; refID != "" && refType != "" {
+				link.IssueID = ids.WorkIssue(s.opts.CustomerID, refType, refID)
+			}
+		case keyAzure:
+			if s.azureRefType == "" {
+				skipped++
+				continue
+			}
+			link.IssueRefType = s.azureRefType
+			link.IssueID = ids.WorkIssue(s.opts.CustomerID, s.azureRefType, strings.TrimPrefix(c.Key, "AB#"))
+		}
+		id := link.ID()
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		res = append(res, link)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID() < res[j].ID()
+	})
+	s.logger.Info("issue links", "links", len(res), "candidates", len(s.candidates), "no_matching_project", skipped, "jira_projects", len(s.jiraProjects))
+	return
+}
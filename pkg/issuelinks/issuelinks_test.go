@@ -0,0 +1,103 @@
+package issuelinks
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/pkg/expsessions"
+	"github.com/pinpt/agent/pkg/ids"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLinker(t *testing.T, opts Opts) *Linker {
+	opts.Logger = hclog.NewNullLogger()
+	opts.CustomerID = "c1"
+	s, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func write(t *testing.T, s *Linker, modelName string, objs ...map[string]interface{}) {
+	wr := s.NewWriter(expsessions.NewMockWriter(), modelName)
+	err := wr.Write(nil, objs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = wr.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLinksLearnedFromProjects(t *testing.T) {
+	s := testLinker(t, Opts{})
+	write(t, s, "sourcecode.Commit", map[string]interface{}{
+		"id": "commit1", "ref_type": "github", "message": "ABC-1 fix, also UTF-8 and abc-1 again",
+	})
+	write(t, s, "sourcecode.PullRequest", map[string]interface{}{
+		"id": "pr1", "ref_type": "github", "title": "Feature", "description": "Fixes AB#12", "branch_name": "feature/ABC-2-login",
+	})
+	write(t, s, "work.Project", map[string]interface{}{
+		"ref_type": "jira", "ref_id": "1", "identifier": "ABC",
+	}, map[string]interface{}{
+		"ref_type": "azure", "ref_id": "2", "identifier": "Project2",
+	})
+	write(t, s, "work.Issue", map[string]interface{}{
+		"ref_type": "jira", "ref_id": "1001", "identifier": "ABC-1",
+	})
+
+	got := map[string]Link{}
+	for _, l := range s.Links() {
+		got[l.SourceID+"/"+l.IssueKey] = l
+	}
+	assert := assert.New(t)
+	assert.Len(got, 3)
+	assert.Equal(ids.WorkIssue("c1", "jira", "1001"), got["commit1/ABC-1"].IssueID)
+	assert.Equal(SourceCommit, got["commit1/ABC-1"].SourceType)
+	assert.Equal("jira", got["pr1/ABC-2"].IssueRefType)
+	assert.Equal("", got["pr1/ABC-2"].IssueID)
+	assert.Equal(ids.WorkIssue("c1", "azure", "12"), got["pr1/AB#12"].IssueID)
+}
+
+func TestLinksConfigured(t *testing.T) {
+	s := testLinker(t, Opts{Config: Config{
+		ProjectKeys: []string{"xyz"},
+		Patterns:    []string{`TICKET\((\d+)\)`},
+	}})
+	write(t, s, "sourcecode.Branch", map[string]interface{}{
+		"id": "b1", "ref_type": "gitlab", "name": "XYZ-5-TICKET(77)",
+	})
+	links := s.Links()
+	var keys []string
+	for _, l := range links {
+		keys = append(keys, l.IssueKey)
+	}
+	assert.ElementsMatch(t, []string{"XYZ-5", "77"}, keys)
+}
+
+func TestLinksRollback(t *testing.T) {
+	s := testLinker(t, Opts{Config: Config{ProjectKeys: []string{"ABC"}}})
+	wr := s.NewWriter(expsessions.NewMockWriter(), "sourcecode.Commit")
+	err := wr.Write(nil, []map[string]interface{}{{"id": "c1", "ref_type": "github", "message": "ABC-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = wr.Rollback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, s.Links())
+}
+
+func TestLinksCandidates(t *testing.T) {
+	s := testLinker(t, Opts{})
+	commit := map[string]interface{}{
+		"id": "c1", "ref_type": "github", "message": "abc-1 utf-8 sha-256 ABC-2",
+	}
+	write(t, s, "sourcecode.Commit", commit)
+	// same commit exported again, for example by git processing and integration
+	write(t, s, "sourcecode.Commit", commit)
+	assert.Len(t, s.candidates, 1, "lowercase keys are ignored and candidates deduplicated")
+}
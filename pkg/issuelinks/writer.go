@@ -0,0 +1,59 @@
+package issuelinks
+
+import (
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/pkg/expsessions"
+)
+
+// Writer wraps session writer and scans written objects for issue keys.
+// Objects are passed to linker only when session is closed, so rolled back sessions do not create links.
+type Writer struct {
+	wr        expsessions.Writer
+	linker    *Linker
+	modelName string
+
+	objs  []map[string]interface{}
+	cands []candidate
+}
+
+// NewWriter creates a writer that scans objects for issue keys before passing them to wr.
+func (s *Linker) NewWriter(wr expsessions.Writer, modelName string) expsessions.Writer {
+	return &Writer{
+		wr:        wr,
+		linker:    s,
+		modelName: modelName,
+	}
+}
+
+func (s *Writer) Write(logger hclog.Logger, objs []map[string]interface{}) error {
+	for _, obj := range objs {
+		if s.modelName == "work.Project" || s.modelName == "work.Issue" {
+			s.objs = append(s.objs, projectOrIssueFields(obj))
+			continue
+		}
+		s.cands = append(s.cands, s.linker.scan(s.modelName, obj)...)
+	}
+	return s.wr.Write(logger, objs)
+}
+
+// projectOrIssueFields keeps only the fields needed to avoid holding full issues in memory
+func projectOrIssueFields(obj map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"ref_type":   obj["ref_type"],
+		"ref_id":     obj["ref_id"],
+		"identifier": obj["identifier"],
+	}
+}
+
+func (s *Writer) Close() error {
+	s.linker.add(s.modelName, s.objs, s.cands)
+	s.objs = nil
+	s.cands = nil
+	return s.wr.Close()
+}
+
+func (s *Writer) Rollback() error {
+	s.objs = nil
+	s.cands = nil
+	return s.wr.Rollback()
+}
@@ -0,0 +1,20 @@
+// Package istate provides the state dir of integrations, used for state that does not fit in last processed values. The dir is in the agent state dir, so it is backed up and restored together with last processed when export fails.
+package istate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// EnvDir is the env variable used by agent to pass the location of integrations state dir.
+const EnvDir = "PP_AGENT_INTEGRATIONS_STATE"
+
+// Dir returns the state dir of integration. The dir is not created, integrations create it when saving state.
+func Dir(integrationName string) (string, error) {
+	root := os.Getenv(EnvDir)
+	if root == "" {
+		return "", errors.New("integrations state dir is not passed by agent")
+	}
+	return filepath.Join(root, integrationName), nil
+}
@@ -52,7 +52,9 @@ func (s *Store) Set(val interface{}, key ...string) error {
 }
 
 func (s *Store) Save() error {
+	s.mu.RLock()
 	b, err := json.Marshal(s.data)
+	s.mu.RUnlock()
 	if err != nil {
 		return err
 	}
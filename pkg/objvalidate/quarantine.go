@@ -0,0 +1,292 @@
+// Package objvalidate validates exported objects against the expected datamodel and moves invalid objects to quarantine.
+//
+// Quarantined objects are written to a separate directory in uploads, so they are uploaded together with valid data for inspection, but are not processed as regular objects.
+package objvalidate
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/pkg/expsessions"
+)
+
+// DirName is the name of the directory in uploads containing quarantined objects.
+const DirName = "quarantine"
+
+// SummaryFile is the name of the summary file in quarantine directory.
+const SummaryFile = "summary.json.gz"
+
+// Config is the user configuration for validation, passed from agent config.
+type Config struct {
+	// Disabled turns off validation, all objects are written as is.
+	Disabled bool `json:"disabled"`
+	// MaxInvalid fails the integration when more than this number of objects is invalid. 0 means no limit.
+	MaxInvalid int `json:"max_invalid"`
+	// MaxInvalidPercent fails the integration when more than this percent of objects is invalid. 0 means no limit.
+	MaxInvalidPercent float64 `json:"max_invalid_percent"`
+}
+
+// Opts are options for New.
+type Opts struct {
+	Logger hclog.Logger
+	// UploadsDir is the directory containing export output
+	UploadsDir string
+	Config     Config
+}
+
+// Quarantine validates objects written via its writers and keeps stats per integration.
+type Quarantine struct {
+	opts   Opts
+	logger hclog.Logger
+
+	mu    sync.Mutex
+	stats map[string]*IntegrationStats
+}
+
+// New creates Quarantine.
+func New(opts Opts) *Quarantine {
+	s := &Quarantine{}
+	s.opts = opts
+	s.logger = opts.Logger.Named("objvalidate")
+	s.stats = map[string]*IntegrationStats{}
+	return s
+}
+
+// IntegrationStats contains validation counts for one integration.
+type IntegrationStats struct {
+	Valid   int                    `json:"valid"`
+	Invalid int                    `json:"invalid"`
+	Models  map[string]*ModelStats `json:"models"`
+}
+
+// ModelStats contains validation counts for one model.
+type ModelStats struct {
+	Valid   int `json:"valid"`
+	Invalid int `json:"invalid"`
+	// Problems counts objects by problem key, see Problem.Key
+	Problems map[string]int `json:"problems"`
+}
+
+// InvalidPercent returns the percent of invalid objects.
+func (s IntegrationStats) InvalidPercent() float64 {
+	total := s.Valid + s.Invalid
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Invalid) * 100 / float64(total)
+}
+
+// Stats returns validation stats for integration.
+func (s *Quarantine) Stats(integration string) IntegrationStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.stats[integration]
+	if st == nil {
+		return IntegrationStats{}
+	}
+	return *st
+}
+
+// CheckThresholds returns an error if integration exceeded configured thresholds for invalid objects.
+func (s *Quarantine) CheckThresholds(integration string) error {
+	st := s.Stats(integration)
+	if st.Invalid == 0 {
+		return nil
+	}
+	c := s.opts.Config
+	if c.MaxInvalid != 0 && st.Invalid > c.MaxInvalid {
+		return fmt.Errorf("integration exported %v invalid objects, more than allowed %v, see %v in uploads", st.Invalid, c.MaxInvalid, DirName)
+	}
+	if c.MaxInvalidPercent != 0 && st.InvalidPercent() > c.MaxInvalidPercent {
+		return fmt.Errorf("integration exported %.2f%% invalid objects, more than allowed %v%%, see %v in uploads", st.InvalidPercent(), c.MaxInvalidPercent, DirName)
+	}
+	return nil
+}
+
+// WriteSummary writes stats for all integrations into quarantine directory. Does nothing if there were no invalid objects.
+func (s *Quarantine) WriteSummary() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invalid := 0
+	for _, st := range s.stats {
+		invalid += st.Invalid
+	}
+	if invalid == 0 {
+		return nil
+	}
+
+	keys := []string{}
+	for k := range s.stats {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		st := s.stats[k]
+		if st.Invalid == 0 {
+			continue
+		}
+		s.logger.Warn("Invalid objects moved to quarantine", "integration", k, "invalid", st.Invalid, "valid", st.Valid)
+	}
+
+	loc := filepath.Join(s.opts.UploadsDir, DirName, SummaryFile)
+	err := os.MkdirAll(filepath.Dir(loc), 0777)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(loc)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	err = json.NewEncoder(gw).Encode(s.stats)
+	if err != nil {
+		return err
+	}
+	err = gw.Close()
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (s *Quarantine) record(integration string, modelName string, valid int, invalid map[string]int, invalidObjs int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.stats[integration]
+	if st == nil {
+		st = &IntegrationStats{Models: map[string]*ModelStats{}}
+		s.stats[integration] = st
+	}
+	ms := st.Models[modelName]
+	if ms == nil {
+		ms = &ModelStats{Problems: map[string]int{}}
+		st.Models[modelName] = ms
+	}
+	st.Valid += valid
+	st.Invalid += invalidObjs
+	ms.Valid += valid
+	ms.Invalid += invalidObjs
+	for k, v := range invalid {
+		ms.Problems[k] += v
+	}
+}
+
+// Writer validates objects and passes valid objects to wrapped writer. Invalid objects are written to quarantine. Stats are recorded when session is closed, so rolled back sessions are not counted.
+type Writer struct {
+	wr          expsessions.Writer
+	q           *Quarantine
+	modelName   string
+	integration string
+	id          expsessions.ID
+
+	quarantined *expsessions.FileWriter
+
+	valid       int
+	invalid     int
+	problems    map[string]int
+	loggedFirst bool
+}
+
+// QuarantinedObj is the format of objects written to quarantine files.
+type QuarantinedObj struct {
+	Integration string                 `json:"integration"`
+	Model       string                 `json:"model"`
+	Problems    []string               `json:"problems"`
+	Object      map[string]interface{} `json:"object"`
+}
+
+// NewWriter creates a writer that validates objects before passing them to wr. Returns wr if validation is disabled.
+func (s *Quarantine) NewWriter(wr expsessions.Writer, modelName string, integration string, id expsessions.ID) expsessions.Writer {
+	if s.opts.Config.Disabled {
+		return wr
+	}
+	return &Writer{
+		wr:          wr,
+		q:           s,
+		modelName:   modelName,
+		integration: integration,
+		id:          id,
+		problems:    map[string]int{},
+	}
+}
+
+func (s *Writer) Write(logger hclog.Logger, objs []map[string]interface{}) error {
+	var valid []map[string]interface{}
+	var invalid []map[string]interface{}
+	for _, obj := range objs {
+		problems := Validate(s.modelName, obj)
+		if len(problems) == 0 {
+			valid = append(valid, obj)
+			continue
+		}
+		if !s.loggedFirst {
+			s.loggedFirst = true
+			logger.Warn("invalid object moved to quarantine, further objects in this session are not logged", "model", s.modelName, "integration", s.integration, "id", obj["id"], "problems", fmt.Sprint(problems))
+		}
+		var details []string
+		for _, p := range problems {
+			s.problems[p.Key()]++
+			details = append(details, p.String())
+		}
+		qo := QuarantinedObj{
+			Integration: s.integration,
+			Model:       s.modelName,
+			Problems:    details,
+			Object:      obj,
+		}
+		invalid = append(invalid, qo.toMap())
+	}
+	s.valid += len(valid)
+	s.invalid += len(invalid)
+	if len(invalid) != 0 {
+		if s.quarantined == nil {
+			s.quarantined = expsessions.NewFileWriter(s.modelName, filepath.Join(s.q.opts.UploadsDir, DirName), s.id)
+		}
+		err := s.quarantined.Write(logger, invalid)
+		if err != nil {
+			return err
+		}
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+	return s.wr.Write(logger, valid)
+}
+
+func (s QuarantinedObj) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"integration": s.Integration,
+		"model":       s.Model,
+		"problems":    s.Problems,
+		"object":      s.Object,
+	}
+}
+
+func (s *Writer) Close() error {
+	s.q.record(s.integration, s.modelName, s.valid, s.problems, s.invalid)
+	if s.quarantined != nil {
+		err := s.quarantined.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return s.wr.Close()
+}
+
+func (s *Writer) Rollback() error {
+	if s.quarantined != nil {
+		err := s.quarantined.Rollback()
+		if err != nil {
+			return err
+		}
+	}
+	return s.wr.Rollback()
+}
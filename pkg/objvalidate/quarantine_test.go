@@ -0,0 +1,99 @@
+package objvalidate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/pkg/expsessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func validCommit() map[string]interface{} {
+	return map[string]interface{}{
+		"id":          "a1b2c3",
+		"customer_id": "c1",
+		"ref_type":    "github",
+		"ref_id":      "sha1",
+		"repo_id":     "r1",
+		"sha":         "sha1",
+		"created_date": map[string]interface{}{
+			"epoch":   float64(1577836800000),
+			"offset":  float64(0),
+			"rfc3339": "2020-01-01T00:00:00Z",
+		},
+	}
+}
+
+func TestValidateValid(t *testing.T) {
+	assert.Empty(t, Validate("sourcecode.Commit", validCommit()))
+	// unknown models only get common checks
+	assert.Empty(t, Validate("custom.Model", map[string]interface{}{"id": "a1", "customer_id": "c1", "ref_type": "x"}))
+}
+
+func TestValidateProblems(t *testing.T) {
+	obj := validCommit()
+	obj["id"] = "Not-A-Hash"
+	delete(obj, "sha")
+	obj["created_date"] = map[string]interface{}{"epoch": float64(0), "rfc3339": ""}
+	var keys []string
+	for _, p := range Validate("sourcecode.Commit", obj) {
+		keys = append(keys, p.Key())
+	}
+	assert.Equal(t, []string{"id_format:id", "required:sha", "date:created_date"}, keys)
+
+	pr := map[string]interface{}{
+		"id": "a1", "customer_id": "c1", "ref_type": "github", "ref_id": "1", "repo_id": "r1", "status": "unset",
+		"created_date": map[string]interface{}{"epoch": float64(1577836800000), "rfc3339": "bad"},
+	}
+	keys = nil
+	for _, p := range Validate("sourcecode.PullRequest", pr) {
+		keys = append(keys, p.Key())
+	}
+	assert.Equal(t, []string{"enum:status", "date:created_date"}, keys)
+}
+
+func TestWriterQuarantine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "objvalidate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	q := New(Opts{Logger: hclog.NewNullLogger(), UploadsDir: dir, Config: Config{MaxInvalidPercent: 40}})
+	mock := expsessions.NewMockWriter()
+	wr := q.NewWriter(mock, "sourcecode.Commit", "github@1", 1)
+	invalid := validCommit()
+	delete(invalid, "repo_id")
+	err = wr.Write(hclog.NewNullLogger(), []map[string]interface{}{validCommit(), invalid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = wr.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, mock.Data, 1)
+	st := q.Stats("github@1")
+	assert.Equal(t, 1, st.Valid)
+	assert.Equal(t, 1, st.Invalid)
+	assert.Equal(t, 1, st.Models["sourcecode.Commit"].Problems["required:repo_id"])
+	assert.Error(t, q.CheckThresholds("github@1"))
+	assert.NoError(t, q.CheckThresholds("jira@2"))
+
+	files, err := filepath.Glob(filepath.Join(dir, DirName, "sourcecode.Commit", "*.json.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, files, 1)
+
+	err = q.WriteSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(filepath.Join(dir, DirName, SummaryFile))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,316 @@
+package objvalidate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/go-common/datamodel"
+	isdk "github.com/pinpt/integration-sdk"
+)
+
+// Rule describes the fields checked for one model.
+type Rule struct {
+	// Required are fields that must be present and not empty
+	Required []string
+	// Enums are fields containing enum values. Enums are serialized by integration-sdk using String(), which returns "unset" for values not defined in the model.
+	Enums []string
+	// Dates are date fields in integration-sdk format, map with epoch, offset and rfc3339 keys.
+	Dates []string
+	// RequiredDates are dates that must be set.
+	RequiredDates []string
+}
+
+// common are the fields checked for all models
+var common = Rule{
+	Required: []string{"id", "customer_id", "ref_type"},
+}
+
+// required are the fields that must be set for models exported by integrations and git processing, in addition to common fields and ref_id. Dates listed here must have epoch set. Other checks are created from model definitions, see ruleFor. Field names are checked against model definitions in tests.
+var required = map[string][]string{
+	"sourcecode.Repo":               {"name"},
+	"sourcecode.User":               {},
+	"sourcecode.Branch":             {"name", "repo_id"},
+	"sourcecode.Commit":             {"repo_id", "sha", "created_date"},
+	"sourcecode.PullRequest":        {"repo_id", "created_date"},
+	"sourcecode.PullRequestBranch":  {"repo_id"},
+	"sourcecode.PullRequestReview":  {"repo_id", "pull_request_id"},
+	"sourcecode.PullRequestComment": {"repo_id", "pull_request_id"},
+	"sourcecode.PullRequestCommit":  {"repo_id", "pull_request_id", "sha"},
+	"sourcecode.CommitFile":         {"repo_id", "commit_id", "path", "change_type"},
+	"sourcecode.Environment":        {"repo_id", "name"},
+	"sourcecode.Deployment":         {"environment_id", "state", "created_date"},
+	"sourcecode.DeploymentStatus":   {"deployment_id", "state", "created_date"},
+	"cicd.Pipeline":                 {"name"},
+	"cicd.Build":                    {"pipeline_id", "status", "queued_date"},
+	"cicd.BuildStep":                {"build_id", "kind", "status"},
+	"ops.Incident":                  {"title", "status", "created_date"},
+	"work.Project":                  {"name"},
+	"work.User":                     {},
+	"work.IssueType":                {"name"},
+	"work.IssuePriority":            {"name"},
+	"work.Issue":                    {"identifier", "project_id", "created_date"},
+	"work.IssueComment":             {"issue_id", "project_id"},
+	"work.Sprint":                   {"name"},
+	"work.ClassificationNode":       {"project_id", "kind", "name", "path"},
+	"work.Team":                     {"project_id", "name"},
+	"work.TeamCapacity":             {"team_id"},
+	"work.Version":                  {"project_id", "name"},
+	"work.Component":                {"project_id", "name"},
+	"codequality.Project":           {"name"},
+	"codequality.Metric":            {"project_id"},
+	"codequality.Issue":             {"project_id", "created_date"},
+	"codequality.Coverage":          {"project_id", "created_date"},
+	"codequality.SecurityHotspot":   {"project_id", "created_date"},
+	"codequality.Analysis":          {"project_id", "kind", "analysis_date"},
+	"codequality.QualityGateStatus": {"project_id", "status", "created_date"},
+}
+
+type model interface {
+	ToMap() map[string]interface{}
+}
+
+// newModel returns an empty model from extmodels or integration-sdk, nil if the model is not defined. extmodels are checked first, since these are exported instead of integration-sdk models with the same name.
+func newModel(modelName string) model {
+	if m := extmodels.New(modelName); m != nil {
+		return m
+	}
+	if m := isdk.New(datamodel.ModelNameType(modelName)); m != nil {
+		return m
+	}
+	return nil
+}
+
+var (
+	rulesMu sync.Mutex
+	// rules are created on first use, nil for models without definition
+	rules = map[string]*Rule{}
+)
+
+// ruleFor returns the rule for model, created from the model definition. Dates are fields serialized as dates by ToMap, enums are fields of integer types with String method, ref_id is required when model has it. Returns false for models without definition, these only get common checks.
+func ruleFor(modelName string) (Rule, bool) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	rule, ok := rules[modelName]
+	if !ok {
+		rule = newRule(modelName)
+		rules[modelName] = rule
+	}
+	if rule == nil {
+		return Rule{}, false
+	}
+	return *rule, true
+}
+
+func newRule(modelName string) *Rule {
+	m := newModel(modelName)
+	if m == nil {
+		return nil
+	}
+	rule := &Rule{}
+	fields := m.ToMap()
+	dates := map[string]bool{}
+	for k, v := range fields {
+		if isDate(v) {
+			dates[k] = true
+			rule.Dates = append(rule.Dates, k)
+		}
+	}
+	sort.Strings(rule.Dates)
+	if _, ok := fields["ref_id"]; ok {
+		rule.Required = append(rule.Required, "ref_id")
+	}
+	for _, f := range required[modelName] {
+		if dates[f] {
+			rule.RequiredDates = append(rule.RequiredDates, f)
+		} else {
+			rule.Required = append(rule.Required, f)
+		}
+	}
+	rule.Enums = enumFields(m)
+	return rule
+}
+
+func isDate(v interface{}) bool {
+	if d, ok := v.(model); ok {
+		v = d.ToMap()
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = m["epoch"]
+	return ok
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// enumFields returns json names of struct fields with enum types. integration-sdk enums are integer types serialized using String().
+func enumFields(m model) (res []string) {
+	t := reflect.TypeOf(m)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		switch f.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		default:
+			continue
+		}
+		if !f.Type.Implements(stringerType) {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		res = append(res, name)
+	}
+	return
+}
+
+// idRe matches ids created using hash.Values
+var idRe = regexp.MustCompile(`^[0-9a-f]{1,64}$`)
+
+// maxFuture is how far in the future dates are accepted, to allow for clock differences and due dates
+const maxFuture = 5 * 365 * 24 * time.Hour
+
+// Problem is a single validation error for an object.
+type Problem struct {
+	Field string `json:"field"`
+	// Kind is the type of problem, one of: required, id_format, enum, date
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+// Key returns field and kind of the problem, without details specific to the object. Used for counting problems.
+func (s Problem) Key() string {
+	return s.Kind + ":" + s.Field
+}
+
+func (s Problem) String() string {
+	return s.Key() + " " + s.Detail
+}
+
+// Validate checks obj against common rules and rules for modelName. Returns the list of problems found, empty if object is valid.
+func Validate(modelName string, obj map[string]interface{}) (problems []Problem) {
+	add := func(field, kind string, format string, args ...interface{}) {
+		problems = append(problems, Problem{Field: field, Kind: kind, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	check := func(rule Rule) {
+		for _, f := range rule.Required {
+			if isEmpty(obj[f]) {
+				add(f, "required", "missing or empty")
+			}
+		}
+		for _, f := range rule.Enums {
+			v, ok := obj[f]
+			if !ok {
+				continue
+			}
+			str, ok := v.(string)
+			if !ok {
+				add(f, "enum", "not a string: %v", v)
+				continue
+			}
+			if str == "" || str == "unset" {
+				add(f, "enum", "invalid value: %q", str)
+			}
+		}
+		required := map[string]bool{}
+		for _, f := range rule.RequiredDates {
+			required[f] = true
+		}
+		for _, f := range rule.Dates {
+			if err := checkDate(obj[f], required[f]); err != nil {
+				add(f, "date", "%v", err)
+			}
+		}
+	}
+
+	check(common)
+
+	if id, ok := obj["id"].(string); ok && id != "" && !idRe.MatchString(id) {
+		add("id", "id_format", "not a hash: %q", id)
+	}
+
+	if rule, ok := ruleFor(modelName); ok {
+		check(rule)
+	}
+
+	return
+}
+
+func isEmpty(v interface{}) bool {
+	switch vv := v.(type) {
+	case nil:
+		return true
+	case string:
+		return vv == ""
+	}
+	return false
+}
+
+func checkDate(v interface{}, required bool) error {
+	if v == nil {
+		if required {
+			return fmt.Errorf("missing")
+		}
+		return nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected object with epoch and rfc3339, got %T", v)
+	}
+	epoch, err := toInt64(m["epoch"])
+	if err != nil {
+		return fmt.Errorf("epoch: %v", err)
+	}
+	rfc, _ := m["rfc3339"].(string)
+	if epoch == 0 && rfc == "" {
+		if required {
+			return fmt.Errorf("not set")
+		}
+		return nil
+	}
+	if epoch < 0 {
+		return fmt.Errorf("negative epoch: %v", epoch)
+	}
+	if rfc != "" {
+		if _, err := time.Parse(time.RFC3339, rfc); err != nil {
+			return fmt.Errorf("rfc3339: %v", err)
+		}
+	}
+	// epoch is in milliseconds
+	if time.Unix(0, epoch*int64(time.Millisecond)).After(time.Now().Add(maxFuture)) {
+		return fmt.Errorf("too far in the future: %v", epoch)
+	}
+	return nil
+}
+
+func toInt64(v interface{}) (int64, error) {
+	switch vv := v.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return int64(vv), nil
+	case int64:
+		return vv, nil
+	case float64:
+		return int64(vv), nil
+	case string:
+		return strconv.ParseInt(vv, 10, 64)
+	}
+	return 0, fmt.Errorf("unexpected type %T", v)
+}
@@ -0,0 +1,98 @@
+package objvalidate
+
+import (
+	"testing"
+
+	"github.com/pinpt/agent/integrations/pkg/extmodels"
+	"github.com/pinpt/go-common/datamodel"
+	"github.com/pinpt/integration-sdk/codequality"
+	"github.com/pinpt/integration-sdk/sourcecode"
+	"github.com/pinpt/integration-sdk/work"
+	"github.com/stretchr/testify/assert"
+)
+
+// exportedSDKModels are integration-sdk models exported by integrations and git processing
+var exportedSDKModels = []datamodel.ModelNameType{
+	sourcecode.RepoModelName,
+	sourcecode.UserModelName,
+	sourcecode.BranchModelName,
+	sourcecode.CommitModelName,
+	sourcecode.PullRequestModelName,
+	sourcecode.PullRequestBranchModelName,
+	sourcecode.PullRequestReviewModelName,
+	sourcecode.PullRequestCommentModelName,
+	sourcecode.PullRequestCommitModelName,
+	work.ProjectModelName,
+	work.UserModelName,
+	work.IssueTypeModelName,
+	work.IssuePriorityModelName,
+	work.IssueModelName,
+	work.IssueCommentModelName,
+	work.SprintModelName,
+	codequality.ProjectModelName,
+	codequality.MetricModelName,
+}
+
+func TestModelsHaveRules(t *testing.T) {
+	var names []string
+	for _, name := range exportedSDKModels {
+		names = append(names, name.String())
+	}
+	names = append(names, extmodels.ModelNames()...)
+	for _, name := range names {
+		_, ok := ruleFor(name)
+		assert.True(t, ok, "no model definition for %v", name)
+		_, ok = required[name]
+		assert.True(t, ok, "no required fields for %v", name)
+	}
+}
+
+func TestRequiredFieldsInDefinitions(t *testing.T) {
+	for name, fields := range required {
+		m := newModel(name)
+		if !assert.NotNil(t, m, "no model definition for %v", name) {
+			continue
+		}
+		obj := m.ToMap()
+		for _, f := range fields {
+			_, ok := obj[f]
+			assert.True(t, ok, "required field %v is not defined in %v", f, name)
+		}
+	}
+}
+
+func TestRuleFromDefinition(t *testing.T) {
+	rule, ok := ruleFor(extmodels.BuildModelName)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"ref_id", "pipeline_id", "status"}, rule.Required)
+	assert.Equal(t, []string{"queued_date"}, rule.RequiredDates)
+	assert.Equal(t, []string{"finished_date", "queued_date", "started_date"}, rule.Dates)
+	assert.Empty(t, rule.Enums)
+
+	_, ok = ruleFor("custom.Model")
+	assert.False(t, ok)
+}
+
+type testStatus int32
+
+func (s testStatus) String() string {
+	if s == 1 {
+		return "OPEN"
+	}
+	return "unset"
+}
+
+type testModel struct {
+	Status testStatus `json:"status"`
+	Count  int64      `json:"count"`
+	Name   string     `json:"name"`
+}
+
+func (s testModel) ToMap() map[string]interface{} {
+	return map[string]interface{}{"status": s.Status.String(), "count": s.Count, "name": s.Name}
+}
+
+func TestEnumFields(t *testing.T) {
+	assert.Equal(t, []string{"status"}, enumFields(testModel{}))
+	assert.Equal(t, []string{"status"}, enumFields(&testModel{}))
+}
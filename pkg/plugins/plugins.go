@@ -0,0 +1,250 @@
+// Package plugins loads and verifies third-party integration plugins.
+//
+// Plugins are installed in plugins subdirectory of integrations dir, one directory per plugin:
+//
+//	<integrations-dir>/plugins/<name>/plugin.json
+//	<integrations-dir>/plugins/<name>/<binary>
+//	<integrations-dir>/plugins/<name>/plugin.json.sig (optional)
+//
+// The plugins directory is separate from bin directory managed by the updater, so plugins are not replaced on update.
+//
+// Before exec the sha256 of the binary must match the manifest and the plugin must be trusted, either the binary checksum is in the allowlist or the manifest is signed by one of the trusted keys.
+package plugins
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/pinpt/agent/pkg/build"
+)
+
+// DirName is the name of plugins directory in integrations dir.
+const DirName = "plugins"
+
+// ManifestFile is the name of plugin manifest in plugin dir.
+const ManifestFile = "plugin.json"
+
+// SigFile contains base64 encoded ed25519 signature of ManifestFile.
+const SigFile = "plugin.json.sig"
+
+// Config is the user configuration for plugins, passed from agent config.
+type Config struct {
+	// TrustedKeys are base64 encoded ed25519 public keys. Plugins with manifest signed by one of these keys are allowed.
+	TrustedKeys []string `json:"trusted_keys"`
+	// Allowlist contains hex encoded sha256 of allowed plugin binaries. Use for unsigned plugins.
+	Allowlist []string `json:"allowlist"`
+}
+
+// Manifest describes a plugin.
+type Manifest struct {
+	// Name is the integration name used in integration config
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Types are integration types supported by plugin, WORK, SOURCECODE or CODEQUALITY
+	Types []string `json:"types"`
+	// Binary is the file name of the binary in plugin dir. Defaults to name, with .exe suffix on windows.
+	Binary string `json:"binary"`
+	// SHA256 is hex encoded sha256 of the binary
+	SHA256 string `json:"sha256"`
+}
+
+var nameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+func (s Manifest) validate() error {
+	if !nameRe.MatchString(s.Name) {
+		return fmt.Errorf("invalid plugin name: %q", s.Name)
+	}
+	if s.Version == "" {
+		return errors.New("version is required")
+	}
+	if len(s.Types) == 0 {
+		return errors.New("types are required")
+	}
+	if s.SHA256 == "" {
+		return errors.New("sha256 is required")
+	}
+	if s.Binary != "" && filepath.Base(s.Binary) != s.Binary {
+		return fmt.Errorf("binary must be a file name in plugin dir: %q", s.Binary)
+	}
+	return nil
+}
+
+// SupportsType returns true if plugin supports integration type.
+func (s Manifest) SupportsType(t string) bool {
+	for _, v := range s.Types {
+		if strings.EqualFold(v, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Plugin is an installed plugin.
+type Plugin struct {
+	Manifest Manifest `json:"manifest"`
+	// Dir is the plugin directory
+	Dir string `json:"dir"`
+	// Binary is the location of plugin binary
+	Binary string `json:"binary"`
+	// TrustedBy is either allowlist or key:<key> for verified plugins
+	TrustedBy string `json:"trusted_by"`
+	// Error is set if plugin could not be loaded or verified
+	Error string `json:"error"`
+}
+
+// Verified returns true if plugin can be executed.
+func (s Plugin) Verified() bool {
+	return s.Error == "" && s.TrustedBy != ""
+}
+
+// Dir returns plugins dir in integrations dir.
+func Dir(integrationsDir string) string {
+	return filepath.Join(integrationsDir, DirName)
+}
+
+// Exists returns true if plugin with name is installed. Does not verify it. Returns false for names of built-in integrations, these always use built-in binaries.
+func Exists(integrationsDir string, name string) bool {
+	if !nameRe.MatchString(name) || isBuiltin(name) {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(Dir(integrationsDir), name, ManifestFile))
+	return err == nil
+}
+
+// List returns all installed plugins sorted by name, including the ones that failed verification.
+func List(integrationsDir string, conf Config) (res []Plugin, _ error) {
+	dir := Dir(integrationsDir)
+	items, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if !item.IsDir() {
+			continue
+		}
+		res = append(res, load(filepath.Join(dir, item.Name()), conf))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Dir < res[j].Dir
+	})
+	return
+}
+
+// Get returns verified plugin by name. Returns an error if plugin is not installed or could not be verified.
+func Get(integrationsDir string, conf Config, name string) (Plugin, error) {
+	if !nameRe.MatchString(name) {
+		return Plugin{}, fmt.Errorf("invalid plugin name: %q", name)
+	}
+	res := load(filepath.Join(Dir(integrationsDir), name), conf)
+	if res.Error != "" {
+		return res, fmt.Errorf("plugin %v: %v", name, res.Error)
+	}
+	if !res.Verified() {
+		return res, fmt.Errorf("plugin %v: not verified", name)
+	}
+	return res, nil
+}
+
+func load(dir string, conf Config) (res Plugin) {
+	res.Dir = dir
+	err := res.load(conf)
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return
+}
+
+func (s *Plugin) load(conf Config) error {
+	data, err := ioutil.ReadFile(filepath.Join(s.Dir, ManifestFile))
+	if err != nil {
+		return fmt.Errorf("could not read manifest: %v", err)
+	}
+	err = json.Unmarshal(data, &s.Manifest)
+	if err != nil {
+		return fmt.Errorf("could not parse manifest: %v", err)
+	}
+	err = s.Manifest.validate()
+	if err != nil {
+		return fmt.Errorf("invalid manifest: %v", err)
+	}
+	if filepath.Base(s.Dir) != s.Manifest.Name {
+		return fmt.Errorf("plugin dir name %v does not match manifest name %v", filepath.Base(s.Dir), s.Manifest.Name)
+	}
+	if isBuiltin(s.Manifest.Name) {
+		return fmt.Errorf("name conflicts with built-in integration: %v", s.Manifest.Name)
+	}
+
+	bin := s.Manifest.Binary
+	if bin == "" {
+		bin = s.Manifest.Name
+		if runtime.GOOS == "windows" {
+			bin += ".exe"
+		}
+	}
+	s.Binary = filepath.Join(s.Dir, bin)
+
+	sum, err := build.FileSHA256(s.Binary)
+	if err != nil {
+		return fmt.Errorf("could not read binary: %v", err)
+	}
+	if !strings.EqualFold(sum, s.Manifest.SHA256) {
+		return fmt.Errorf("binary sha256 %v does not match manifest %v", sum, s.Manifest.SHA256)
+	}
+
+	for _, v := range conf.Allowlist {
+		if strings.EqualFold(strings.TrimSpace(v), sum) {
+			s.TrustedBy = "allowlist"
+			return nil
+		}
+	}
+
+	sig, err := ioutil.ReadFile(filepath.Join(s.Dir, SigFile))
+	if os.IsNotExist(err) {
+		return errors.New("binary is not in allowlist and manifest is not signed")
+	}
+	if err != nil {
+		return err
+	}
+	sigb, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %v", err)
+	}
+	for _, k := range conf.TrustedKeys {
+		key, err := build.ParsePublicKey(k)
+		if err != nil {
+			return fmt.Errorf("invalid trusted key in config: %v", err)
+		}
+		if ed25519.Verify(key, data, sigb) {
+			s.TrustedBy = "key:" + strings.TrimSpace(k)
+			return nil
+		}
+	}
+	return errors.New("manifest signature does not match any trusted key")
+}
+
+func isBuiltin(name string) bool {
+	for _, bin := range build.BuiltinIntegrationBinaries() {
+		if bin == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns signature file contents for plugin manifest. Used by plugin authors.
+func Sign(manifest []byte, key ed25519.PrivateKey) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifest)))
+}
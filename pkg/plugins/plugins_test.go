@@ -0,0 +1,133 @@
+package plugins
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writePlugin(t *testing.T, integrationsDir string, m Manifest, bin []byte, key ed25519.PrivateKey) {
+	dir := filepath.Join(Dir(integrationsDir), m.Name)
+	err := os.MkdirAll(dir, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Binary = "bin"
+	if m.SHA256 == "" {
+		h := sha256.Sum256(bin)
+		m.SHA256 = hex.EncodeToString(h[:])
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, ManifestFile), data, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "bin"), bin, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != nil {
+		err = ioutil.WriteFile(filepath.Join(dir, SigFile), Sign(data, key), 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func sum(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func TestPluginsVerification(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writePlugin(t, dir, Manifest{Name: "allowed", Version: "1.0", Types: []string{"WORK"}}, []byte("a"), nil)
+	writePlugin(t, dir, Manifest{Name: "signed", Version: "1.1", Types: []string{"SOURCECODE"}}, []byte("s"), key)
+	writePlugin(t, dir, Manifest{Name: "unsigned", Version: "1.0", Types: []string{"WORK"}}, []byte("u"), nil)
+	writePlugin(t, dir, Manifest{Name: "otherkey", Version: "1.0", Types: []string{"WORK"}}, []byte("o"), otherKey)
+	writePlugin(t, dir, Manifest{Name: "tampered", Version: "1.0", Types: []string{"WORK"}, SHA256: sum([]byte("x"))}, []byte("t"), key)
+
+	conf := Config{
+		TrustedKeys: []string{base64.StdEncoding.EncodeToString(pub)},
+		Allowlist:   []string{sum([]byte("a"))},
+	}
+
+	list, err := List(dir, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := map[string]string{}
+	for _, p := range list {
+		if p.Verified() {
+			status[p.Manifest.Name] = p.TrustedBy
+		} else {
+			status[p.Manifest.Name] = p.Error
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"allowed":  "allowlist",
+		"signed":   "key:" + conf.TrustedKeys[0],
+		"unsigned": "binary is not in allowlist and manifest is not signed",
+		"otherkey": "manifest signature does not match any trusted key",
+		"tampered": "binary sha256 " + sum([]byte("t")) + " does not match manifest " + sum([]byte("x")),
+	}, status)
+
+	p, err := Get(dir, conf, "signed")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(Dir(dir), "signed", "bin"), p.Binary)
+	assert.True(t, p.Manifest.SupportsType("sourcecode"))
+	assert.False(t, p.Manifest.SupportsType("WORK"))
+
+	_, err = Get(dir, conf, "unsigned")
+	assert.Error(t, err)
+
+	_, err = Get(dir, conf, "../signed")
+	assert.Error(t, err)
+
+	assert.True(t, Exists(dir, "unsigned"))
+	assert.False(t, Exists(dir, "missing"))
+}
+
+func TestPluginsBuiltinConflict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv("PP_INTEGRATION_BINARIES_ALL", "github,jira-cloud")
+	defer os.Setenv("PP_INTEGRATION_BINARIES_ALL", "")
+
+	writePlugin(t, dir, Manifest{Name: "github", Version: "1.0", Types: []string{"SOURCECODE"}}, []byte("g"), nil)
+	conf := Config{Allowlist: []string{sum([]byte("g"))}}
+
+	assert.False(t, Exists(dir, "github"))
+	_, err = Get(dir, conf, "github")
+	assert.EqualError(t, err, "plugin github: name conflicts with built-in integration: github")
+}
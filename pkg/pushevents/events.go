@@ -0,0 +1,252 @@
+// Package pushevents defines deploy and incident events pushed to the agent by tools without integration, such as ci jobs and deploy scripts. Events are received by local http endpoint or dropped to spool dir directly and exported by push integration.
+package pushevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pinpt/go-common/hash"
+)
+
+// Event types
+const (
+	TypeDeploy   = "deploy"
+	TypeIncident = "incident"
+)
+
+// Normalized deploy statuses, the same as used for deployments exported by sourcecode integrations.
+const (
+	DeployStatusPending  = "PENDING"
+	DeployStatusRunning  = "RUNNING"
+	DeployStatusSuccess  = "SUCCESS"
+	DeployStatusFailure  = "FAILURE"
+	DeployStatusCanceled = "CANCELED"
+)
+
+// Normalized incident statuses
+const (
+	IncidentStatusOpen         = "OPEN"
+	IncidentStatusAcknowledged = "ACKNOWLEDGED"
+	IncidentStatusResolved     = "RESOLVED"
+)
+
+var deployStatuses = map[string]string{
+	"pending":     DeployStatusPending,
+	"queued":      DeployStatusPending,
+	"started":     DeployStatusRunning,
+	"running":     DeployStatusRunning,
+	"in_progress": DeployStatusRunning,
+	"success":     DeployStatusSuccess,
+	"succeeded":   DeployStatusSuccess,
+	"successful":  DeployStatusSuccess,
+	"failure":     DeployStatusFailure,
+	"failed":      DeployStatusFailure,
+	"error":       DeployStatusFailure,
+	"canceled":    DeployStatusCanceled,
+	"cancelled":   DeployStatusCanceled,
+	"aborted":     DeployStatusCanceled,
+}
+
+var incidentStatuses = map[string]string{
+	"open":         IncidentStatusOpen,
+	"triggered":    IncidentStatusOpen,
+	"acknowledged": IncidentStatusAcknowledged,
+	"resolved":     IncidentStatusResolved,
+	"closed":       IncidentStatusResolved,
+}
+
+// Time is time in event. Accepts rfc3339 string or unix timestamp in seconds or milliseconds, so that it is easy to send from shell scripts.
+type Time struct {
+	time.Time
+}
+
+func (s *Time) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" || string(b) == `""` {
+		s.Time = time.Time{}
+		return nil
+	}
+	if len(b) != 0 && b[0] == '"' {
+		var str string
+		if err := json.Unmarshal(b, &str); err != nil {
+			return err
+		}
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return err
+		}
+		s.Time = t
+		return nil
+	}
+	v, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid time, use rfc3339 string or unix timestamp: %s", b)
+	}
+	// assume milliseconds for values after year 33658 in seconds
+	if v > 1e12 {
+		s.Time = time.Unix(0, v*int64(time.Millisecond))
+	} else {
+		s.Time = time.Unix(v, 0)
+	}
+	return nil
+}
+
+func (s Time) MarshalJSON() ([]byte, error) {
+	if s.IsZero() {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(s.UTC().Format(time.RFC3339Nano))
+}
+
+// Event is a deploy or incident event. Fields not used by event type are ignored.
+type Event struct {
+	// Type is deploy or incident
+	Type string `json:"type"`
+	// ID is the idempotency key of the event, unique per source. Events with the same key replace each other, so tools can send the updated status of the same deployment or incident. Generated from event content if not set.
+	ID string `json:"id"`
+	// Source is the name of the tool sending the event, for example jenkins
+	Source string `json:"source"`
+	// Status is pending, running, success, failure or canceled for deploys and open, acknowledged or resolved for incidents
+	Status      string `json:"status"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+	// Creator is the username or email of the person who started the deploy or reported the incident
+	Creator string `json:"creator"`
+
+	// Service is the name of deployed service. Service or Repo is required for deploys.
+	Service     string `json:"service"`
+	Environment string `json:"environment"`
+	// Repo is the name or url of deployed repo, used to link deploy to commit
+	Repo      string `json:"repo"`
+	CommitSHA string `json:"commit_sha"`
+	// Ref is the deployed branch or tag
+	Ref        string `json:"ref"`
+	Version    string `json:"version"`
+	StartedAt  Time   `json:"started_at"`
+	FinishedAt Time   `json:"finished_at"`
+
+	Title    string `json:"title"`
+	Severity string `json:"severity"`
+	// DeployID is the id of deploy event that caused the incident
+	DeployID       string `json:"deploy_id"`
+	CreatedAt      Time   `json:"created_at"`
+	AcknowledgedAt Time   `json:"acknowledged_at"`
+	ResolvedAt     Time   `json:"resolved_at"`
+}
+
+// Normalize validates event and converts it to normalized form. Missing start or creation time is set to received.
+func (s *Event) Normalize(received time.Time) error {
+	s.Type = strings.ToLower(strings.TrimSpace(s.Type))
+	s.ID = strings.TrimSpace(s.ID)
+	s.Source = strings.TrimSpace(s.Source)
+	s.CommitSHA = strings.ToLower(strings.TrimSpace(s.CommitSHA))
+	status := strings.ToLower(strings.TrimSpace(s.Status))
+	switch s.Type {
+	case TypeDeploy:
+		if s.Environment == "" {
+			return errors.New("environment is required for deploy events")
+		}
+		if s.Service == "" && s.Repo == "" {
+			return errors.New("service or repo is required for deploy events")
+		}
+		if status == "" {
+			if s.FinishedAt.IsZero() {
+				status = "running"
+			} else {
+				status = "success"
+			}
+		}
+		s.Status = deployStatuses[status]
+		if s.Status == "" {
+			return fmt.Errorf("invalid deploy status: %q", status)
+		}
+		if s.StartedAt.IsZero() {
+			s.StartedAt.Time = received
+		}
+		if s.FinishedAt.IsZero() && isDeployFinished(s.Status) {
+			s.FinishedAt.Time = received
+		}
+	case TypeIncident:
+		if s.Title == "" {
+			return errors.New("title is required for incident events")
+		}
+		if status == "" {
+			if s.ResolvedAt.IsZero() {
+				status = "open"
+			} else {
+				status = "resolved"
+			}
+		}
+		s.Status = incidentStatuses[status]
+		if s.Status == "" {
+			return fmt.Errorf("invalid incident status: %q", status)
+		}
+		s.Severity = strings.ToUpper(strings.TrimSpace(s.Severity))
+		if s.CreatedAt.IsZero() {
+			s.CreatedAt.Time = received
+		}
+		if s.ResolvedAt.IsZero() && s.Status == IncidentStatusResolved {
+			s.ResolvedAt.Time = received
+		}
+	default:
+		return fmt.Errorf("invalid event type: %q, wanted deploy or incident", s.Type)
+	}
+	if s.ID == "" {
+		s.ID = s.contentID()
+	}
+	return nil
+}
+
+func isDeployFinished(status string) bool {
+	return status == DeployStatusSuccess || status == DeployStatusFailure || status == DeployStatusCanceled
+}
+
+// contentID returns id for events without idempotency key, based on fields that do not change when status is updated
+func (s Event) contentID() string {
+	if s.Type == TypeDeploy {
+		return hash.Values(s.Type, s.Source, s.Service, s.Repo, s.Environment, s.CommitSHA, s.Version, s.StartedAt.Unix())
+	}
+	return hash.Values(s.Type, s.Source, s.Title, s.CreatedAt.Unix())
+}
+
+// Key returns the idempotency key of the event, unique across sources and event types.
+func (s Event) Key() string {
+	return hash.Values(s.Type, s.Source, s.ID)
+}
+
+// RefID returns the id of the event to be used as ref_id in exported objects.
+func (s Event) RefID() string {
+	if s.Source == "" {
+		return s.ID
+	}
+	return s.Source + "/" + s.ID
+}
+
+// Parse parses a single event or json array of events and normalizes them. Returns error if any of the events is not valid.
+func Parse(data []byte, received time.Time) (res []Event, _ error) {
+	data = bytes.TrimSpace(data)
+	if len(data) != 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &res); err != nil {
+			return nil, err
+		}
+	} else {
+		var ev Event
+		if err := json.Unmarshal(data, &ev); err != nil {
+			return nil, err
+		}
+		res = append(res, ev)
+	}
+	if len(res) == 0 {
+		return nil, errors.New("no events")
+	}
+	for i := range res {
+		if err := res[i].Normalize(received); err != nil {
+			return nil, fmt.Errorf("event %v: %v", i, err)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,126 @@
+package pushevents
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDeploy(t *testing.T) {
+	received := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	events, err := Parse([]byte(`{"type":"Deploy","source":"jenkins","id":"42","service":"api","environment":"production","commit_sha":"ABC","status":"succeeded","started_at":1577934000}`), received)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	ev := events[0]
+	assert.Equal(t, TypeDeploy, ev.Type)
+	assert.Equal(t, DeployStatusSuccess, ev.Status)
+	assert.Equal(t, "abc", ev.CommitSHA)
+	assert.Equal(t, int64(1577934000), ev.StartedAt.Unix())
+	assert.Equal(t, received, ev.FinishedAt.Time, "finished deploy without finish time uses received time")
+	assert.Equal(t, "jenkins/42", ev.RefID())
+}
+
+func TestParseIncidentArray(t *testing.T) {
+	events, err := Parse([]byte(`[{"type":"incident","title":"down","created_at":"2020-01-02T03:04:05Z"},{"type":"incident","title":"slow","status":"closed","severity":"sev1"}]`), time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, IncidentStatusOpen, events[0].Status)
+	assert.NotEmpty(t, events[0].ID, "id is generated when not passed")
+	assert.Equal(t, IncidentStatusResolved, events[1].Status)
+	assert.False(t, events[1].ResolvedAt.IsZero())
+	assert.Equal(t, "SEV1", events[1].Severity)
+}
+
+func TestParseContentIDStable(t *testing.T) {
+	data := []byte(`{"type":"deploy","repo":"org/repo","environment":"prod","started_at":"2020-01-02T03:04:05Z","status":"running"}`)
+	a, err := Parse(data, time.Now())
+	assert.NoError(t, err)
+	data = []byte(`{"type":"deploy","repo":"org/repo","environment":"prod","started_at":"2020-01-02T03:04:05Z","status":"failed"}`)
+	b, err := Parse(data, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, a[0].Key(), b[0].Key(), "status update of the same deploy has the same key")
+}
+
+func TestParseInvalid(t *testing.T) {
+	cases := []string{
+		`{"type":"build"}`,
+		`{"type":"deploy","service":"api"}`,
+		`{"type":"deploy","environment":"prod"}`,
+		`{"type":"deploy","service":"api","environment":"prod","status":"unknown"}`,
+		`{"type":"incident"}`,
+		`{"type":"incident","title":"x","created_at":"yesterday"}`,
+		`[]`,
+	}
+	for _, c := range cases {
+		_, err := Parse([]byte(c), time.Now())
+		assert.Error(t, err, c)
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	spool, err := NewSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewServer(ServerOpts{
+		Logger: hclog.NewNullLogger(),
+		Config: Config{Listen: "127.0.0.1:0", Token: "secret"},
+		Spool:  spool,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	post := func(token, body string) int {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/v1/events", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	deploy := `{"type":"deploy","id":"1","service":"api","environment":"prod"}`
+	assert.Equal(t, http.StatusUnauthorized, post("", deploy))
+	assert.Equal(t, http.StatusUnauthorized, post("wrong", deploy))
+	assert.Equal(t, http.StatusBadRequest, post("secret", `{"type":"deploy"}`))
+	assert.Equal(t, http.StatusAccepted, post("secret", deploy))
+	assert.Equal(t, http.StatusAccepted, post("secret", deploy))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, files, 2, "every request is written to a new file")
+	for _, f := range files {
+		assert.True(t, IsEventFile(f))
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, Config{}.Validate())
+	assert.Error(t, Config{Listen: "127.0.0.1:8765"}.Validate())
+}
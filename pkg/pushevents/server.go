@@ -0,0 +1,156 @@
+package pushevents
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// Config configures local http endpoint for pushing events to the agent.
+type Config struct {
+	// Listen is the address of the endpoint, for example 127.0.0.1:8765. Endpoint is disabled if empty.
+	Listen string `json:"listen"`
+	// Token is required in Authorization: Bearer header of every request.
+	Token string `json:"token"`
+}
+
+// Enabled returns true if endpoint is configured.
+func (s Config) Enabled() bool {
+	return s.Listen != ""
+}
+
+// Validate checks that token is set when endpoint is enabled.
+func (s Config) Validate() error {
+	if s.Enabled() && s.Token == "" {
+		return errors.New("push endpoint requires token")
+	}
+	return nil
+}
+
+// maxBodyBytes is the max size of request body
+const maxBodyBytes = 1024 * 1024
+
+// ServerOpts are options for NewServer.
+type ServerOpts struct {
+	Logger hclog.Logger
+	Config Config
+	Spool  *Spool
+}
+
+// Server receives events over http and writes them to spool.
+//
+// POST /v1/events with a single event or json array of events. Returns 202 with ids of accepted events, 400 for invalid events and 401 for missing or invalid token.
+type Server struct {
+	opts   ServerOpts
+	logger hclog.Logger
+}
+
+// NewServer creates server.
+func NewServer(opts ServerOpts) (*Server, error) {
+	if err := opts.Config.Validate(); err != nil {
+		return nil, err
+	}
+	s := &Server{}
+	s.opts = opts
+	s.logger = opts.Logger.Named("push")
+	return s, nil
+}
+
+// Handler returns http handler for the endpoint.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/events", s.handleEvents)
+	return mux
+}
+
+// Run listens on configured address until ctx is cancelled.
+func (s *Server) Run(ctx context.Context) error {
+	l, err := net.Listen("tcp", s.opts.Config.Listen)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:      s.Handler(),
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			s.logger.Warn("could not shutdown push endpoint", "err", err)
+		}
+	}()
+	s.logger.Info("listening for pushed events", "addr", l.Addr().String())
+	err = srv.Serve(l)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	h := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(h, prefix) {
+		return false
+	}
+	token := strings.TrimPrefix(h, prefix)
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Config.Token)) == 1
+}
+
+type response struct {
+	IDs   []string `json:"ids,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+func (s *Server) respond(w http.ResponseWriter, code int, res response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	b, _ := json.Marshal(res)
+	w.Write(b)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respond(w, http.StatusMethodNotAllowed, response{Error: "use POST"})
+		return
+	}
+	if !s.authorized(r) {
+		s.logger.Warn("rejected event with invalid token", "remote", r.RemoteAddr)
+		s.respond(w, http.StatusUnauthorized, response{Error: "invalid token"})
+		return
+	}
+	b, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		s.respond(w, http.StatusRequestEntityTooLarge, response{Error: err.Error()})
+		return
+	}
+	events, err := Parse(b, time.Now())
+	if err != nil {
+		s.logger.Warn("rejected invalid event", "err", err, "remote", r.RemoteAddr)
+		s.respond(w, http.StatusBadRequest, response{Error: err.Error()})
+		return
+	}
+	err = s.opts.Spool.Write(events)
+	if err != nil {
+		s.logger.Error("could not save event", "err", err)
+		s.respond(w, http.StatusInternalServerError, response{Error: "could not save event"})
+		return
+	}
+	res := response{}
+	for _, ev := range events {
+		res.IDs = append(res.IDs, ev.ID)
+	}
+	s.logger.Debug("received events", "count", len(events))
+	s.respond(w, http.StatusAccepted, res)
+}
@@ -0,0 +1,62 @@
+package pushevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pinpt/agent/pkg/fs"
+)
+
+// EnvSpoolDir is the env variable used by agent to pass spool dir location to push integration.
+const EnvSpoolDir = "PP_AGENT_PUSH_SPOOL"
+
+// Spool writes received events to dir, where they are kept until the next export. Every write creates a new file, so that files read by export in progress are never modified. Events with the same key are merged by export, using the latest file.
+type Spool struct {
+	dir string
+
+	mu   sync.Mutex
+	last int64
+}
+
+// NewSpool creates spool in dir.
+func NewSpool(dir string) (*Spool, error) {
+	err := os.MkdirAll(dir, 0777)
+	if err != nil {
+		return nil, err
+	}
+	return &Spool{dir: dir}, nil
+}
+
+// Write saves normalized events to a new file in spool dir.
+func (s *Spool) Write(events []Event) error {
+	b, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%019d-%s.json", s.next(), events[0].Key())
+	return fs.WriteToTempAndRename(bytes.NewReader(b), filepath.Join(s.dir, name))
+}
+
+// next returns unique increasing file sequence, based on time so that order is kept across restarts
+func (s *Spool) next() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := time.Now().UnixNano()
+	if v <= s.last {
+		v = s.last + 1
+	}
+	s.last = v
+	return v
+}
+
+// IsEventFile returns true for files in spool dir containing events. Temp files of writes in progress and hidden files are skipped.
+func IsEventFile(p string) bool {
+	name := filepath.Base(p)
+	return strings.HasSuffix(name, ".json") && !strings.HasPrefix(name, ".")
+}
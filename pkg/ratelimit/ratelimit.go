@@ -0,0 +1,400 @@
+// Package ratelimit limits requests from integrations per host to avoid overloading servers and to handle throttling.
+//
+// Concurrency is adapted using AIMD. When server responds with 429 or 503 the concurrency limit is halved, after that it is increased by one after each limit successful responses, up to configured ceiling. When server sets Retry-After or rate limit headers with no remaining requests, all requests to the host wait until the reset time.
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// EnvConfig is the env variable used to pass config to integrations.
+const EnvConfig = "PP_AGENT_RATE_LIMITS"
+
+const (
+	// PauseEventAfter is the min wait time for which pause and resume events are sent.
+	PauseEventAfter = time.Minute
+	// maxWait limits wait time from headers, in case server returns invalid reset time
+	maxWait = time.Hour
+	// minBackoff is the wait time after throttled response without retry headers. Doubled on each consecutive throttled response.
+	minBackoff = time.Second
+	maxBackoff = 5 * time.Minute
+	// decreaseInterval is the min time between decreasing the limit, so that concurrent throttled responses only decrease it once
+	decreaseInterval = time.Second
+)
+
+// Config is the user configuration for rate limits, passed from agent config.
+type Config struct {
+	// Hosts configures limits by host name.
+	Hosts map[string]HostConfig `json:"hosts"`
+}
+
+// HostConfig configures limits for one host.
+type HostConfig struct {
+	// MaxConcurrency is the max number of concurrent requests to host. 0 means no limit, concurrency is only reduced when server throttles requests.
+	MaxConcurrency int `json:"max_concurrency"`
+}
+
+// SetEnv passes config to child processes, such as integrations.
+func SetEnv(conf Config) error {
+	if len(conf.Hosts) == 0 {
+		return os.Unsetenv(EnvConfig)
+	}
+	b, err := json.Marshal(conf)
+	if err != nil {
+		return err
+	}
+	return os.Setenv(EnvConfig, string(b))
+}
+
+// FromEnv returns config passed by agent in EnvConfig.
+func FromEnv() (res Config, _ error) {
+	v := os.Getenv(EnvConfig)
+	if v == "" {
+		return res, nil
+	}
+	err := json.Unmarshal([]byte(v), &res)
+	if err != nil {
+		return res, fmt.Errorf("invalid %v env variable: %v", EnvConfig, err)
+	}
+	return res, nil
+}
+
+// Agent sends pause and resume events to backend. Implemented by rpcdef.Agent.
+type Agent interface {
+	SendPauseEvent(msg string, resumeDate time.Time) error
+	SendResumeEvent(msg string) error
+}
+
+// Opts are options for New.
+type Opts struct {
+	Logger hclog.Logger
+	Config Config
+	// Agent is used to send events when requests are paused for longer than PauseEventAfter. Optional.
+	Agent Agent
+}
+
+// Limiter limits requests per host. Use Wrap to apply it to http transports, state is shared by all wrapped transports.
+type Limiter struct {
+	opts   Opts
+	logger hclog.Logger
+
+	mu    sync.Mutex
+	hosts map[string]*host
+	agent Agent
+}
+
+// New creates limiter.
+func New(opts Opts) *Limiter {
+	s := &Limiter{}
+	s.opts = opts
+	s.logger = opts.Logger.Named("ratelimit")
+	s.hosts = map[string]*host{}
+	s.agent = opts.Agent
+	return s
+}
+
+// SetAgent sets agent used to send pause and resume events. Use when limiter is shared and created before agent is available.
+func (s *Limiter) SetAgent(agent Agent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.agent = agent
+}
+
+func (s *Limiter) getAgent() Agent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.agent
+}
+
+// Wrap returns round tripper limiting requests sent using rt.
+func (s *Limiter) Wrap(rt http.RoundTripper) http.RoundTripper {
+	return roundTripper{limiter: s, rt: rt}
+}
+
+type roundTripper struct {
+	limiter *Limiter
+	rt      http.RoundTripper
+}
+
+func (s roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	h := s.limiter.host(req.URL.Hostname())
+	err := h.acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.rt.RoundTrip(req)
+	h.release(resp, err)
+	return resp, err
+}
+
+func (s *Limiter) host(name string) *host {
+	name = strings.ToLower(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := s.hosts[name]
+	if res != nil {
+		return res
+	}
+	res = &host{}
+	res.name = name
+	res.logger = s.logger.With("host", name)
+	res.limiter = s
+	for k, v := range s.opts.Config.Hosts {
+		if strings.ToLower(k) == name {
+			res.ceiling = v.MaxConcurrency
+		}
+	}
+	res.limit = float64(res.ceiling)
+	res.changed = make(chan bool)
+	s.hosts[name] = res
+	return res
+}
+
+// Pause makes all requests to host wait for the given time, for integrations that check their quota using separate api calls. Sends pause event if wait is longer than PauseEventAfter and requests to host are not already paused, resume event is sent when next request starts.
+func (s *Limiter) Pause(host string, wait time.Duration) {
+	s.host(host).pause(wait)
+}
+
+// Limit returns the current concurrency limit for host, 0 means no limit.
+func (s *Limiter) Limit(host string) int {
+	h := s.host(host)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return int(h.limit)
+}
+
+type host struct {
+	name    string
+	logger  hclog.Logger
+	limiter *Limiter
+	ceiling int
+
+	mu sync.Mutex
+	// limit is the current concurrency limit, 0 means no limit
+	limit        float64
+	inflight     int
+	waitUntil    time.Time
+	backoff      time.Duration
+	lastDecrease time.Time
+	paused       time.Time
+	// changed is closed when limit, inflight or waitUntil changes
+	changed chan bool
+}
+
+func (s *host) acquire(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		wait := time.Until(s.waitUntil)
+		if wait <= 0 && (s.limit == 0 || float64(s.inflight) < s.limit) {
+			s.inflight++
+			paused := s.paused
+			s.paused = time.Time{}
+			s.mu.Unlock()
+			if !paused.IsZero() {
+				s.sendResume(paused)
+			}
+			return nil
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *host) release(resp *http.Response, err error) {
+	now := time.Now()
+	var pause time.Duration
+
+	s.mu.Lock()
+	inflight := s.inflight
+	s.inflight--
+	if err == nil {
+		throttled := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+		if throttled {
+			s.decrease(now, inflight)
+		} else if resp.StatusCode < 500 {
+			s.increase()
+		}
+		wait := waitFromHeaders(resp.Header, now)
+		if throttled && wait <= 0 {
+			if s.backoff == 0 {
+				s.backoff = minBackoff
+			} else if s.backoff < maxBackoff {
+				s.backoff *= 2
+			}
+			wait = s.backoff
+		}
+		if wait > maxWait {
+			wait = maxWait
+		}
+		if s.setWait(now, wait) {
+			s.logger.Info("rate limited, pausing requests", "status", resp.StatusCode, "wait", wait.String(), "limit", int(s.limit))
+			pause = s.startPause(now, wait)
+		}
+	}
+	close(s.changed)
+	s.changed = make(chan bool)
+	s.mu.Unlock()
+
+	if pause != 0 {
+		s.sendPause(pause)
+	}
+}
+
+// pause makes requests wait for the given time. Returns after pause event is sent, but does not wait itself.
+func (s *host) pause(wait time.Duration) {
+	now := time.Now()
+	var pause time.Duration
+	if wait > maxWait {
+		wait = maxWait
+	}
+	s.mu.Lock()
+	if s.setWait(now, wait) {
+		pause = s.startPause(now, wait)
+		close(s.changed)
+		s.changed = make(chan bool)
+	}
+	s.mu.Unlock()
+
+	if pause != 0 {
+		s.sendPause(pause)
+	}
+}
+
+// setWait sets waitUntil if it is later than current, returns true if it was changed. Must be called with mu held.
+func (s *host) setWait(now time.Time, wait time.Duration) bool {
+	if wait <= 0 || !now.Add(wait).After(s.waitUntil) {
+		return false
+	}
+	s.waitUntil = now.Add(wait)
+	return true
+}
+
+// startPause marks host as paused if wait is long enough for pause event and not already paused. Returns wait to send in pause event or 0. Must be called with mu held.
+func (s *host) startPause(now time.Time, wait time.Duration) time.Duration {
+	if wait < PauseEventAfter || !s.paused.IsZero() {
+		return 0
+	}
+	s.paused = now
+	return wait
+}
+
+// decrease halves the limit. Must be called with mu held.
+func (s *host) decrease(now time.Time, inflight int) {
+	if now.Sub(s.lastDecrease) < decreaseInterval {
+		return
+	}
+	s.lastDecrease = now
+	limit := s.limit
+	if limit == 0 {
+		limit = float64(inflight)
+	}
+	limit = limit / 2
+	if limit < 1 {
+		limit = 1
+	}
+	s.limit = limit
+}
+
+// increase adds one to limit after limit successful requests. Must be called with mu held.
+func (s *host) increase() {
+	s.backoff = 0
+	if s.limit == 0 {
+		return
+	}
+	s.limit += 1 / s.limit
+	if s.ceiling != 0 && s.limit > float64(s.ceiling) {
+		s.limit = float64(s.ceiling)
+	}
+}
+
+func (s *host) sendPause(wait time.Duration) {
+	agent := s.limiter.getAgent()
+	if agent == nil {
+		return
+	}
+	msg := fmt.Sprintf("rate limited by %v, requests paused for %v", s.name, wait.Round(time.Second))
+	err := agent.SendPauseEvent(msg, time.Now().Add(wait))
+	if err != nil {
+		s.logger.Error("could not send pause event", "err", err)
+	}
+}
+
+func (s *host) sendResume(paused time.Time) {
+	agent := s.limiter.getAgent()
+	if agent == nil {
+		return
+	}
+	msg := fmt.Sprintf("resumed requests to %v after %v", s.name, time.Since(paused).Round(time.Second))
+	err := agent.SendResumeEvent(msg)
+	if err != nil {
+		s.logger.Error("could not send resume event", "err", err)
+	}
+}
+
+// waitFromHeaders returns time to wait based on Retry-After header or rate limit headers used by GitHub, GitLab, Jira and Azure DevOps. Returns 0 if no wait is needed.
+func waitFromHeaders(h http.Header, now time.Time) time.Duration {
+	if v := h.Get("Retry-After"); v != "" {
+		if sec, err := strconv.Atoi(v); err == nil {
+			return time.Duration(sec) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return t.Sub(now)
+		}
+	}
+	for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} {
+		if h.Get(prefix+"Remaining") != "0" {
+			continue
+		}
+		if t, ok := parseReset(h.Get(prefix+"Reset"), now); ok {
+			return t.Sub(now)
+		}
+	}
+	return 0
+}
+
+// parseReset parses reset time as unix timestamp, seconds until reset or ISO 8601 date used by Jira
+func parseReset(v string, now time.Time) (time.Time, bool) {
+	if v == "" {
+		return time.Time{}, false
+	}
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if n > 1000000000 {
+			return time.Unix(n, 0), true
+		}
+		return now.Add(time.Duration(n) * time.Second), true
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,194 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitFromHeaders(t *testing.T) {
+	now := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		Label   string
+		Headers map[string]string
+		Want    time.Duration
+	}{
+		{"none", nil, 0},
+		{"retry after seconds", map[string]string{"Retry-After": "30"}, 30 * time.Second},
+		{"retry after date", map[string]string{"Retry-After": "Wed, 01 Jan 2020 10:01:00 GMT"}, time.Minute},
+		{"github", map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Add(10*time.Minute).Unix(), 10)}, 10 * time.Minute},
+		{"github remaining", map[string]string{"X-RateLimit-Remaining": "10", "X-RateLimit-Reset": strconv.FormatInt(now.Add(10*time.Minute).Unix(), 10)}, 0},
+		{"gitlab", map[string]string{"RateLimit-Remaining": "0", "RateLimit-Reset": strconv.FormatInt(now.Add(time.Minute).Unix(), 10)}, time.Minute},
+		{"jira", map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "2020-01-01T10:05Z"}, 5 * time.Minute},
+	}
+	for _, c := range cases {
+		h := http.Header{}
+		for k, v := range c.Headers {
+			h.Set(k, v)
+		}
+		assert.Equal(t, c.Want, waitFromHeaders(h, now), c.Label)
+	}
+}
+
+func TestAIMD(t *testing.T) {
+	s := New(Opts{Logger: hclog.NewNullLogger(), Config: Config{Hosts: map[string]HostConfig{"Example.com": {MaxConcurrency: 8}}}})
+	assert.Equal(t, 8, s.Limit("example.com"))
+	assert.Equal(t, 0, s.Limit("other.com"))
+
+	h := s.host("example.com")
+	throttled := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	ok := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+
+	h.inflight = 1
+	h.release(throttled, nil)
+	assert.Equal(t, 4, s.Limit("example.com"))
+	assert.True(t, h.waitUntil.After(time.Now()), "waits using backoff when no headers")
+
+	// concurrent throttled responses only decrease once
+	h.inflight = 1
+	h.release(throttled, nil)
+	assert.Equal(t, 4, s.Limit("example.com"))
+
+	// limit is increased by 1 after limit successful responses
+	for i := 0; i < 5; i++ {
+		h.inflight = 1
+		h.release(ok, nil)
+	}
+	assert.Equal(t, 5, s.Limit("example.com"))
+	assert.Equal(t, time.Duration(0), h.backoff)
+
+	for i := 0; i < 100; i++ {
+		h.inflight = 1
+		h.release(ok, nil)
+	}
+	assert.Equal(t, 8, s.Limit("example.com"), "limited by ceiling")
+
+	// without ceiling limit starts from current concurrency
+	h2 := s.host("other.com")
+	h2.inflight = 6
+	h2.release(throttled, nil)
+	assert.Equal(t, 3, s.Limit("other.com"))
+}
+
+type testAgent struct {
+	mu      sync.Mutex
+	paused  int
+	resumed int
+}
+
+func (s *testAgent) SendPauseEvent(msg string, resumeDate time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused++
+	return nil
+}
+
+func (s *testAgent) SendResumeEvent(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resumed++
+	return nil
+}
+
+func TestConcurrencyAndRetryAfter(t *testing.T) {
+	var inflight, maxInflight, requests int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&inflight, 1)
+		defer atomic.AddInt64(&inflight, -1)
+		for {
+			m := atomic.LoadInt64(&maxInflight)
+			if n <= m || atomic.CompareAndSwapInt64(&maxInflight, m, n) {
+				break
+			}
+		}
+		if atomic.AddInt64(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	agent := &testAgent{}
+	s := New(Opts{Logger: hclog.NewNullLogger(), Config: Config{Hosts: map[string]HostConfig{"127.0.0.1": {MaxConcurrency: 2}}}, Agent: agent})
+	client := &http.Client{Transport: s.Wrap(http.DefaultTransport)}
+
+	res, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, 1, s.Limit("127.0.0.1"), "limit halved from ceiling")
+
+	started := time.Now()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := client.Get(ts.URL)
+			if assert.NoError(t, err) {
+				res.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.True(t, time.Since(started) > 900*time.Millisecond, "waits for Retry-After")
+	assert.True(t, atomic.LoadInt64(&maxInflight) <= 2, "limited by ceiling")
+	// wait was shorter than PauseEventAfter
+	assert.Equal(t, 0, agent.paused)
+}
+
+func TestSetAgent(t *testing.T) {
+	s := New(Opts{Logger: hclog.NewNullLogger()})
+	// host created before agent is set uses the new agent
+	h := s.host("example.com")
+	agent := &testAgent{}
+	s.SetAgent(agent)
+
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set("Retry-After", "120")
+	h.inflight = 1
+	h.release(resp, nil)
+	assert.Equal(t, 1, agent.paused)
+
+	h.waitUntil = time.Time{}
+	err := h.acquire(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, agent.resumed)
+}
+
+func TestPause(t *testing.T) {
+	agent := &testAgent{}
+	s := New(Opts{Logger: hclog.NewNullLogger(), Agent: agent})
+	s.Pause("example.com", 2*time.Minute)
+	h := s.host("example.com")
+	assert.True(t, h.waitUntil.After(time.Now().Add(time.Minute)))
+	assert.Equal(t, 1, agent.paused)
+
+	// already paused, by integration or by rate limit headers
+	s.Pause("example.com", 3*time.Minute)
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Remaining", "0")
+	resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(5*time.Minute).Unix(), 10))
+	h.inflight = 1
+	h.release(resp, nil)
+	assert.Equal(t, 1, agent.paused, "pause event is sent once")
+
+	// short pauses do not send events
+	s.Pause("other.com", time.Second)
+	assert.Equal(t, 1, agent.paused)
+
+	h.waitUntil = time.Time{}
+	err := h.acquire(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, agent.resumed)
+}
@@ -0,0 +1,175 @@
+// Package replay collects objects exported by integrations when replaying recorded http fixtures and compares them with golden files.
+package replay
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pinpt/agent/pkg/expin"
+	"github.com/pinpt/agent/pkg/expsessions"
+	"github.com/pinpt/agent/rpcdef"
+)
+
+type gitFetches struct {
+	mu   sync.Mutex
+	data []rpcdef.GitRepoFetch
+}
+
+func (s *gitFetches) Add(fetch rpcdef.GitRepoFetch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = append(s.data, fetch)
+}
+
+func (s *gitFetches) Data() (res []map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sort.Slice(s.data, func(i, j int) bool {
+		return s.data[i].RepoID < s.data[j].RepoID
+	})
+	for _, fetch := range s.data {
+		b, err := json.Marshal(fetch)
+		if err != nil {
+			panic(err)
+		}
+		var obj map[string]interface{}
+		err = json.Unmarshal(b, &obj)
+		if err != nil {
+			panic(err)
+		}
+		res = append(res, obj)
+	}
+	return
+}
+
+// gitRepoFetchModel is used in golden files for repos integration asked to process with ripsrc. Git is not processed when replaying.
+const gitRepoFetchModel = "agent.GitRepoFetch"
+
+// Output collects objects exported by integrations using in memory sessions.
+type Output struct {
+	logger   hclog.Logger
+	sessions *expsessions.Manager
+	writers  *expsessions.MockWriters
+	fetches  *gitFetches
+}
+
+// NewOutput creates output.
+func NewOutput(logger hclog.Logger) *Output {
+	s := &Output{}
+	s.logger = logger
+	s.writers = expsessions.NewMockWriters()
+	s.sessions = expsessions.New(expsessions.Opts{
+		Logger:    logger,
+		NewWriter: s.writers.NewWriter,
+	})
+	s.fetches = &gitFetches{}
+	return s
+}
+
+// Agent returns agent to pass to integration. It writes exported objects to output and skips git processing.
+func (s *Output) Agent(exp expin.Export) rpcdef.Agent {
+	return agentDelegate{logger: s.logger, sessions: s.sessions, fetches: s.fetches, exp: exp}
+}
+
+// Data returns exported objects by model. Fields in ignoreFields are removed from all objects, use for fields based on current time.
+func (s *Output) Data(ignoreFields []string) map[string][]map[string]interface{} {
+	res := s.writers.Data()
+	if len(s.fetches.data) != 0 {
+		res[gitRepoFetchModel] = s.fetches.Data()
+	}
+	for _, objs := range res {
+		for _, obj := range objs {
+			for _, f := range ignoreFields {
+				delete(obj, f)
+			}
+		}
+	}
+	return res
+}
+
+// agentDelegate writes exported objects to mock writers and skips git processing
+type agentDelegate struct {
+	logger   hclog.Logger
+	sessions *expsessions.Manager
+	fetches  *gitFetches
+	exp      expin.Export
+}
+
+func (s agentDelegate) ExportStarted(modelType string) (sessionID string, lastProcessed interface{}) {
+	id, lastProcessed, err := s.sessions.SessionRoot(s.exp, modelType)
+	if err != nil {
+		panic(err)
+	}
+	return strconv.Itoa(int(id)), lastProcessed
+}
+
+func (s agentDelegate) ExportDone(sessionID string, lastProcessed interface{}) {
+	err := s.sessions.Done(sessionIDFromString(sessionID), lastProcessed)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s agentDelegate) SendExported(sessionID string, objs []rpcdef.ExportObj) {
+	var data []map[string]interface{}
+	for _, obj := range objs {
+		data = append(data, obj.Data.(map[string]interface{}))
+	}
+	err := s.sessions.Write(sessionIDFromString(sessionID), data)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s agentDelegate) ExportGitRepo(fetch rpcdef.GitRepoFetch) error {
+	s.fetches.Add(fetch)
+	return nil
+}
+
+func (s agentDelegate) SessionStart(isTracking bool, name string, parentSessionID int, parentObjectID, parentObjectName string) (sessionID int, lastProcessed interface{}, _ error) {
+	id, lastProcessed, err := s.sessions.SessionFlex(s.exp, isTracking, name, expsessions.ID(parentSessionID), parentObjectID, parentObjectName)
+	if err != nil {
+		return 0, nil, err
+	}
+	return int(id), lastProcessed, nil
+}
+
+func (s agentDelegate) SessionProgress(id int, current, total int) error {
+	s.sessions.Progress(expsessions.ID(id), current, total)
+	return nil
+}
+
+func (s agentDelegate) SessionRollback(id int) error {
+	return s.sessions.Rollback(expsessions.ID(id))
+}
+
+func (s agentDelegate) OAuthNewAccessToken() (token string, _ error) {
+	// requests are not authenticated on replay
+	return "replay", nil
+}
+
+func (s agentDelegate) SendPauseEvent(msg string, resumeDate time.Time) error {
+	s.logger.Info("integration paused", "msg", msg, "integration", s.exp.String())
+	return nil
+}
+
+func (s agentDelegate) SendResumeEvent(msg string) error {
+	s.logger.Info("integration resumed", "msg", msg, "integration", s.exp.String())
+	return nil
+}
+
+func (s agentDelegate) Heartbeat() error {
+	return nil
+}
+
+func sessionIDFromString(str string) expsessions.ID {
+	id, err := strconv.Atoi(str)
+	if err != nil {
+		panic(err)
+	}
+	return expsessions.ID(id)
+}
@@ -0,0 +1,155 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+func goldenFile(dir string, model string) string {
+	return filepath.Join(dir, model+".json")
+}
+
+func marshalObjs(objs []map[string]interface{}) ([]byte, error) {
+	b, err := json.MarshalIndent(objs, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// WriteGolden replaces golden files in dir with got objects, one file per model.
+func WriteGolden(logger hclog.Logger, dir string, got map[string][]map[string]interface{}) error {
+	err := os.RemoveAll(dir)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(dir, 0777)
+	if err != nil {
+		return err
+	}
+	for model, objs := range got {
+		b, err := marshalObjs(objs)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(goldenFile(dir, model), b, 0644)
+		if err != nil {
+			return err
+		}
+		logger.Info("updated golden file", "model", model, "objects", len(objs))
+	}
+	return nil
+}
+
+func readGolden(dir string) (map[string][]map[string]interface{}, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	res := map[string][]map[string]interface{}{}
+	for _, loc := range files {
+		b, err := ioutil.ReadFile(loc)
+		if err != nil {
+			return nil, err
+		}
+		var objs []map[string]interface{}
+		err = json.Unmarshal(b, &objs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid golden file %v: %v", loc, err)
+		}
+		res[strings.TrimSuffix(filepath.Base(loc), ".json")] = objs
+	}
+	return res, nil
+}
+
+// CompareGolden compares got objects with golden files in dir. Differences are logged and returned as error.
+func CompareGolden(logger hclog.Logger, dir string, got map[string][]map[string]interface{}) error {
+	want, err := readGolden(dir)
+	if err != nil {
+		return err
+	}
+	models := map[string]bool{}
+	for k := range want {
+		models[k] = true
+	}
+	for k := range got {
+		models[k] = true
+	}
+	var modelsSorted []string
+	for k := range models {
+		modelsSorted = append(modelsSorted, k)
+	}
+	sort.Strings(modelsSorted)
+
+	var failed []string
+	for _, model := range modelsSorted {
+		diff, err := diffObjs(want[model], got[model])
+		if err != nil {
+			return err
+		}
+		if len(diff) == 0 {
+			logger.Info("golden file matches", "model", model, "objects", len(got[model]))
+			continue
+		}
+		failed = append(failed, model)
+		logger.Error("golden file does not match", "model", model, "want", len(want[model]), "got", len(got[model]))
+		for _, line := range diff {
+			logger.Error(line, "model", model)
+		}
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("output does not match golden files for models: %v", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// diffObjs returns human readable differences between objects matched by id
+func diffObjs(want, got []map[string]interface{}) (res []string, _ error) {
+	byID := func(objs []map[string]interface{}) map[string]string {
+		m := map[string]string{}
+		for i, obj := range objs {
+			id, _ := obj["id"].(string)
+			if id == "" {
+				id = fmt.Sprintf("index:%v", i)
+			}
+			b, err := json.Marshal(obj)
+			if err != nil {
+				panic(err)
+			}
+			m[id] = string(b)
+		}
+		return m
+	}
+	w := byID(want)
+	g := byID(got)
+	var ids []string
+	for id := range w {
+		ids = append(ids, id)
+	}
+	for id := range g {
+		if _, ok := w[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		wv, wok := w[id]
+		gv, gok := g[id]
+		switch {
+		case !gok:
+			res = append(res, "missing object "+id)
+		case !wok:
+			res = append(res, "unexpected object "+id+" "+gv)
+		case wv != gv:
+			res = append(res, "changed object "+id+"\nwant "+wv+"\ngot  "+gv)
+		}
+	}
+	return
+}
@@ -0,0 +1,261 @@
+package reqstats
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EnvRecordDir is the env variable to enable recording of all http requests and responses into fixture dir. Passed to integrations automatically, since they inherit agent env.
+const EnvRecordDir = "PP_AGENT_HTTP_RECORD_DIR"
+
+// EnvReplayDir is the env variable to replay responses from fixture dir instead of making real requests.
+const EnvReplayDir = "PP_AGENT_HTTP_REPLAY_DIR"
+
+// scrubbedHeaders are removed from recorded responses
+var scrubbedHeaders = []string{"Set-Cookie", "Authorization", "Www-Authenticate", "Proxy-Authorization"}
+
+// scrubbedParams are replaced in recorded urls, since some apis accept tokens in query params
+var scrubbedParams = []string{"access_token", "private_token", "token", "api_key", "apikey", "key", "password", "client_secret", "refresh_token"}
+
+const scrubbedValue = "REDACTED"
+
+// Interaction is a recorded request and response.
+type Interaction struct {
+	// Key identifies the request. It is created from method, scrubbed url and hash of the body.
+	Key        string      `json:"key"`
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+func scrubURL(u *url.URL) string {
+	u2 := *u
+	u2.User = nil
+	q := u2.Query()
+	for k := range q {
+		for _, p := range scrubbedParams {
+			if strings.EqualFold(k, p) {
+				q.Set(k, scrubbedValue)
+			}
+		}
+	}
+	// Encode sorts params by key, so the same request always produces the same url
+	u2.RawQuery = q.Encode()
+	return u2.String()
+}
+
+func scrubHeader(h http.Header) http.Header {
+	res := http.Header{}
+	for k, v := range h {
+		res[k] = v
+	}
+	for _, k := range scrubbedHeaders {
+		res.Del(k)
+	}
+	return res
+}
+
+// requestKey returns the key used to match recorded interaction. Authentication headers are not part of key, so fixtures can be replayed with dummy credentials.
+func requestKey(req *http.Request) (string, error) {
+	key := req.Method + " " + scrubURL(req.URL)
+	if req.Body == nil || req.GetBody == nil {
+		return key, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	if len(b) == 0 {
+		return key, nil
+	}
+	h := sha256.Sum256(b)
+	return key + " " + hex.EncodeToString(h[:8]), nil
+}
+
+// fixtureFileName returns the file to record to. Uses the binary name, which is the integration name for plugins.
+func fixtureFileName(dir string) string {
+	name := filepath.Base(os.Args[0])
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return filepath.Join(dir, name+".jsonl")
+}
+
+// fixtureFile is shared by recorders of all clients in the same process
+type fixtureFile struct {
+	loc string
+	mu  sync.Mutex
+}
+
+func newFixtureFile(dir string) (*fixtureFile, error) {
+	err := os.MkdirAll(dir, 0777)
+	if err != nil {
+		return nil, err
+	}
+	return &fixtureFile{loc: fixtureFileName(dir)}, nil
+}
+
+type recorder struct {
+	rt   http.RoundTripper
+	file *fixtureFile
+}
+
+func (s *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, err := requestKey(req)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.rt.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+	b, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	obj := Interaction{}
+	obj.Key = key
+	obj.Method = req.Method
+	obj.URL = scrubURL(req.URL)
+	obj.StatusCode = res.StatusCode
+	obj.Header = scrubHeader(res.Header)
+	obj.Body = string(b)
+
+	err = s.file.write(obj)
+	if err != nil {
+		return nil, fmt.Errorf("could not record http response: %v", err)
+	}
+	return res, nil
+}
+
+func (s *fixtureFile) write(obj Interaction) error {
+	line, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.loc, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type replayer struct {
+	mu sync.Mutex
+	// responses is map[key][]responses in recorded order
+	responses map[string][]Interaction
+	// served is map[key]count of responses returned
+	served map[string]int
+}
+
+// LoadFixtures reads all recorded interactions in dir.
+func LoadFixtures(dir string) (res []Interaction, _ error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	for _, loc := range files {
+		f, err := os.Open(loc)
+		if err != nil {
+			return nil, err
+		}
+		sc := bufio.NewScanner(f)
+		sc.Buffer(nil, 100*1024*1024)
+		for sc.Scan() {
+			if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+				continue
+			}
+			var obj Interaction
+			err := json.Unmarshal(sc.Bytes(), &obj)
+			if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("invalid fixture in %v: %v", loc, err)
+			}
+			res = append(res, obj)
+		}
+		err = sc.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+func newReplayer(dir string) (*replayer, error) {
+	all, err := LoadFixtures(dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &replayer{}
+	s.responses = map[string][]Interaction{}
+	s.served = map[string]int{}
+	for _, obj := range all {
+		s.responses[obj.Key] = append(s.responses[obj.Key], obj)
+	}
+	return s, nil
+}
+
+// RoundTrip returns recorded responses in order for the same request. The last response is repeated if the request was made more times than recorded.
+func (s *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, err := requestKey(req)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	all := s.responses[key]
+	if len(all) == 0 {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("no recorded http response for request: %v", key)
+	}
+	i := s.served[key]
+	if i >= len(all) {
+		i = len(all) - 1
+	}
+	s.served[key]++
+	obj := all[i]
+	s.mu.Unlock()
+
+	res := &http.Response{}
+	res.StatusCode = obj.StatusCode
+	res.Status = fmt.Sprintf("%d %s", obj.StatusCode, http.StatusText(obj.StatusCode))
+	res.Proto = "HTTP/1.1"
+	res.ProtoMajor = 1
+	res.ProtoMinor = 1
+	res.Header = obj.Header
+	if res.Header == nil {
+		res.Header = http.Header{}
+	}
+	res.Body = ioutil.NopCloser(strings.NewReader(obj.Body))
+	res.ContentLength = int64(len(obj.Body))
+	res.Request = req
+	return res, nil
+}
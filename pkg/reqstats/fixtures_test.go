@@ -0,0 +1,83 @@
+package reqstats
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reqstats-fixtures-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Page", r.URL.Query().Get("page"))
+		fmt.Fprintf(w, `{"page":%q,"call":%v}`, r.URL.Query().Get("page"), calls)
+	}))
+	defer server.Close()
+
+	get := func(c *http.Client, u string) (string, http.Header) {
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "token secret")
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b), res.Header
+	}
+
+	logger := hclog.NewNullLogger()
+	rec := New(Opts{Logger: logger, RecordDir: dir})
+	get(rec.Clients.Default, server.URL+"/a?page=1&access_token=secret")
+	get(rec.Clients.Default, server.URL+"/a?page=1&access_token=secret")
+	get(rec.Clients.Default, server.URL+"/a?page=2&access_token=secret")
+
+	fixtures, err := LoadFixtures(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert := assert.New(t)
+	assert.Len(fixtures, 3)
+	for _, f := range fixtures {
+		assert.False(strings.Contains(f.URL, "secret"), "token not scrubbed from url")
+		assert.Empty(f.Header.Get("Set-Cookie"))
+	}
+
+	rep := New(Opts{Logger: logger, ReplayDir: dir})
+	// token value does not matter for replay
+	body, header := get(rep.Clients.Default, server.URL+"/a?access_token=other&page=1")
+	assert.Equal(`{"page":"1","call":1}`, body)
+	assert.Equal("1", header.Get("X-Page"))
+	body, _ = get(rep.Clients.Default, server.URL+"/a?page=1&access_token=other")
+	assert.Equal(`{"page":"1","call":2}`, body)
+	// last response is repeated
+	body, _ = get(rep.Clients.Default, server.URL+"/a?page=1&access_token=other")
+	assert.Equal(`{"page":"1","call":2}`, body)
+	body, _ = get(rep.Clients.TLSInsecure, server.URL+"/a?page=2&access_token=other")
+	assert.Equal(`{"page":"2","call":3}`, body)
+	assert.Equal(3, calls)
+
+	_, err = rep.Clients.Default.Get(server.URL + "/not-recorded")
+	assert.Error(err)
+}
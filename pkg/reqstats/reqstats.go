@@ -2,15 +2,17 @@
 package reqstats
 
 import (
-	"crypto/tls"
 	"fmt"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
-	"github.com/pinpt/go-common/httpdefaults"
+	"github.com/pinpt/agent/pkg/netconf"
+	"github.com/pinpt/agent/pkg/ratelimit"
 )
 
 type Clients struct {
@@ -22,6 +24,14 @@ type Opts struct {
 	Logger hclog.Logger
 	// TLSInsecureSkipVerify to disable tls cert checks when integration uses TLSInsecure() client
 	TLSInsecureSkipVerify bool
+
+	// Agent is used to send pause and resume events when requests are paused because of rate limits. Optional. Rate limiter is shared by all client managers in the process, the last set agent is used.
+	Agent ratelimit.Agent
+
+	// RecordDir is the dir to record all requests and responses for later replay in tests. Defaults to PP_AGENT_HTTP_RECORD_DIR env variable.
+	RecordDir string
+	// ReplayDir is the dir with recorded responses. When set no real requests are made. Defaults to PP_AGENT_HTTP_REPLAY_DIR env variable.
+	ReplayDir string
 }
 
 type ClientManager struct {
@@ -31,6 +41,31 @@ type ClientManager struct {
 	Clients Clients
 
 	totalRequests *int64
+
+	replayer    *replayer
+	fixtureFile *fixtureFile
+	limiter     *ratelimit.Limiter
+}
+
+var limiter struct {
+	once sync.Once
+	v    *ratelimit.Limiter
+}
+
+// sharedLimiter returns rate limiter shared by all client managers in the process, so that limits apply to all requests to the same host and pause events are not sent once per client manager.
+func sharedLimiter(logger hclog.Logger) *ratelimit.Limiter {
+	limiter.once.Do(func() {
+		// per host limits are passed from agent config
+		rateLimits, err := ratelimit.FromEnv()
+		if err != nil {
+			panic(err)
+		}
+		limiter.v = ratelimit.New(ratelimit.Opts{
+			Logger: logger,
+			Config: rateLimits,
+		})
+	})
+	return limiter.v
 }
 
 func int64p() *int64 {
@@ -48,10 +83,40 @@ func New(opts Opts) *ClientManager {
 	s.logger = opts.Logger.Named("reqstats")
 	s.totalRequests = int64p()
 
+	if s.opts.RecordDir == "" {
+		s.opts.RecordDir = os.Getenv(EnvRecordDir)
+	}
+	if s.opts.ReplayDir == "" {
+		s.opts.ReplayDir = os.Getenv(EnvReplayDir)
+	}
+	if s.opts.ReplayDir != "" {
+		var err error
+		s.replayer, err = newReplayer(s.opts.ReplayDir)
+		if err != nil {
+			panic(fmt.Errorf("could not load http fixtures: %v", err))
+		}
+		s.logger.Warn("replaying recorded http responses, no real requests will be made", "dir", s.opts.ReplayDir)
+	} else if s.opts.RecordDir != "" {
+		s.logger.Warn("recording http responses", "dir", s.opts.RecordDir)
+	}
+
+	s.limiter = sharedLimiter(s.logger)
+	if opts.Agent != nil {
+		s.limiter.SetAgent(opts.Agent)
+	}
+
+	// proxy, ca and client certificates are passed from agent network config
+	newTransport := func(insecureSkipVerify bool) http.RoundTripper {
+		res, err := netconf.NewTransport(insecureSkipVerify)
+		if err != nil {
+			panic(fmt.Errorf("could not create http transport: %v", err))
+		}
+		return res
+	}
+
 	{
 		c := &http.Client{}
-		transport := httpdefaults.DefaultTransport()
-		c.Transport = s.wrapRoundTripper(transport)
+		c.Transport = s.wrapRoundTripper(newTransport(false))
 		s.Clients.Default = c
 	}
 
@@ -59,15 +124,18 @@ func New(opts Opts) *ClientManager {
 		s.Clients.TLSInsecure = s.Clients.Default
 	} else {
 		c := &http.Client{}
-		transport := httpdefaults.DefaultTransport()
-		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-		c.Transport = s.wrapRoundTripper(transport)
+		c.Transport = s.wrapRoundTripper(newTransport(true))
 		s.Clients.TLSInsecure = c
 	}
 
 	return s
 }
 
+// Pause makes all requests to host wait for the given time. Pause and resume events are sent by rate limiter, so integrations do not need to send them.
+func (s *ClientManager) Pause(host string, wait time.Duration) {
+	s.limiter.Pause(host, wait)
+}
+
 func (s ClientManager) PrintStats() string {
 	var res []string
 	l := func(args ...interface{}) {
@@ -79,6 +147,21 @@ func (s ClientManager) PrintStats() string {
 }
 
 func (s *ClientManager) wrapRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	if s.replayer != nil {
+		rt = s.replayer
+	} else {
+		rt = s.limiter.Wrap(rt)
+	}
+	if s.replayer == nil && s.opts.RecordDir != "" {
+		if s.fixtureFile == nil {
+			var err error
+			s.fixtureFile, err = newFixtureFile(s.opts.RecordDir)
+			if err != nil {
+				panic(fmt.Errorf("could not create http fixtures dir: %v", err))
+			}
+		}
+		rt = &recorder{rt: rt, file: s.fixtureFile}
+	}
 	fn := func(req *http.Request) (*http.Response, error) {
 		start := time.Now()
 		l := s.logger.With("url", req.URL.String())
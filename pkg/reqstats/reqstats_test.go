@@ -0,0 +1,14 @@
+package reqstats
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedLimiter(t *testing.T) {
+	c1 := New(Opts{Logger: hclog.NewNullLogger()})
+	c2 := New(Opts{Logger: hclog.NewNullLogger()})
+	assert.True(t, c1.limiter == c2.limiter, "limiter is shared by client managers")
+}
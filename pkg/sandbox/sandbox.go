@@ -0,0 +1,170 @@
+// Package sandbox applies resource limits and restrictions to integration processes on Linux.
+//
+// Limits are applied using a cgroup v2 per integration process. The integration binary is started via the hidden sandbox-exec agent command, which joins the cgroup, sets nice and ionice levels and optionally restricts the filesystem view before executing the integration binary. This works with go-plugin, which only accepts exec.Cmd.
+//
+// On other platforms sandbox is not supported and the integration is started without limits.
+package sandbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// Config is the user configuration for sandbox, passed from agent config. All limits are optional, zero values mean no limit.
+type Config struct {
+	// MemoryMaxMB is the memory limit for integration process in megabytes. Integration is killed when exceeding it.
+	MemoryMaxMB int `json:"memory_max_mb"`
+	// CPUPercent is the cpu limit in percent of one cpu, 200 allows using 2 cpus.
+	CPUPercent int `json:"cpu_percent"`
+	// PidsMax is the max number of processes and threads.
+	PidsMax int `json:"pids_max"`
+	// CgroupParent is the cgroup v2 directory under which cgroups for integrations are created. Defaults to the agent cgroup. Needs to be delegated to the agent user and must not contain processes, for example when using systemd Delegate=yes.
+	CgroupParent string `json:"cgroup_parent"`
+
+	// Nice is the nice level for integration process, 0 to 19.
+	Nice int `json:"nice"`
+	// IONiceClass is the io scheduling class, best-effort or idle.
+	IONiceClass string `json:"ionice_class"`
+	// IONiceLevel is the io priority in best-effort class, 0 (highest) to 7 (lowest).
+	IONiceLevel int `json:"ionice_level"`
+
+	// RestrictFS mounts the root filesystem read-only for integration process, except pinpoint root, temp dir and WritablePaths. Agent config is hidden. Requires running agent as root.
+	RestrictFS bool `json:"restrict_fs"`
+	// WritablePaths are additional paths kept writable when using RestrictFS.
+	WritablePaths []string `json:"writable_paths"`
+	// HiddenPaths are additional files or dirs hidden from integration when using RestrictFS.
+	HiddenPaths []string `json:"hidden_paths"`
+}
+
+// cgroupEnabled returns true if any cgroup limits are configured
+func (s Config) cgroupEnabled() bool {
+	return s.MemoryMaxMB != 0 || s.CPUPercent != 0 || s.PidsMax != 0
+}
+
+// Enabled returns true if any sandbox options are configured.
+func (s Config) Enabled() bool {
+	return s.cgroupEnabled() || s.Nice != 0 || s.IONiceClass != "" || s.RestrictFS
+}
+
+func (s Config) validate() error {
+	if s.MemoryMaxMB < 0 || s.CPUPercent < 0 || s.PidsMax < 0 {
+		return errors.New("limits can't be negative")
+	}
+	if s.Nice < 0 || s.Nice > 19 {
+		return fmt.Errorf("nice must be between 0 and 19, got %v", s.Nice)
+	}
+	switch s.IONiceClass {
+	case "", "best-effort", "idle":
+	default:
+		return fmt.Errorf("ionice_class must be best-effort or idle, got %q", s.IONiceClass)
+	}
+	if s.IONiceLevel < 0 || s.IONiceLevel > 7 {
+		return fmt.Errorf("ionice_level must be between 0 and 7, got %v", s.IONiceLevel)
+	}
+	return nil
+}
+
+// Opts are options for New.
+type Opts struct {
+	Logger hclog.Logger
+	Config Config
+	// Name is used in cgroup name, use integration name
+	Name string
+	// WritablePaths are always writable when using RestrictFS, pass pinpoint root.
+	WritablePaths []string
+	// HiddenPaths are always hidden when using RestrictFS, pass agent config.
+	HiddenPaths []string
+}
+
+// ShimOpts are passed to sandbox-exec command.
+type ShimOpts struct {
+	// Cgroup is the cgroup dir to join, empty if cgroup limits are not used
+	Cgroup        string   `json:"cgroup"`
+	Nice          int      `json:"nice"`
+	IONiceClass   string   `json:"ionice_class"`
+	IONiceLevel   int      `json:"ionice_level"`
+	RestrictFS    bool     `json:"restrict_fs"`
+	WritablePaths []string `json:"writable_paths"`
+	HiddenPaths   []string `json:"hidden_paths"`
+}
+
+// ShimCommandName is the name of hidden agent command used to start integrations in sandbox.
+const ShimCommandName = "sandbox-exec"
+
+// shimArgs returns the arguments for sandbox-exec: sandbox-exec <opts json> <binary> [args...]
+func shimArgs(opts ShimOpts, cmd *exec.Cmd) ([]string, error) {
+	b, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	res := []string{ShimCommandName, string(b), cmd.Path}
+	if len(cmd.Args) > 1 {
+		res = append(res, cmd.Args[1:]...)
+	}
+	return res, nil
+}
+
+// ParseShimArgs parses arguments passed to sandbox-exec command.
+func ParseShimArgs(args []string) (opts ShimOpts, bin string, binArgs []string, _ error) {
+	if len(args) < 2 {
+		return opts, "", nil, errors.New("usage: sandbox-exec <opts json> <binary> [args...]")
+	}
+	err := json.Unmarshal([]byte(args[0]), &opts)
+	if err != nil {
+		return opts, "", nil, fmt.Errorf("invalid sandbox opts: %v", err)
+	}
+	return opts, args[1], args[2:], nil
+}
+
+// parseProcCgroup returns cgroup v2 path from /proc/self/cgroup contents
+func parseProcCgroup(data string) (string, error) {
+	sc := bufio.NewScanner(strings.NewReader(data))
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "0::") {
+			return strings.TrimPrefix(line, "0::"), nil
+		}
+	}
+	return "", errors.New("cgroup v2 entry not found in /proc/self/cgroup, cgroup v2 is required for limits")
+}
+
+// parseEvents parses cgroup flat keyed files such as memory.events
+func parseEvents(data string) map[string]int64 {
+	res := map[string]int64{}
+	sc := bufio.NewScanner(strings.NewReader(data))
+	for sc.Scan() {
+		parts := strings.Fields(sc.Text())
+		if len(parts) != 2 {
+			continue
+		}
+		v, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		res[parts[0]] = v
+	}
+	return res
+}
+
+const cpuPeriod = 100000
+
+// cpuMax returns value for cpu.max file
+func cpuMax(percent int) string {
+	return strconv.Itoa(percent*cpuPeriod/100) + " " + strconv.Itoa(cpuPeriod)
+}
+
+// ioprio returns value for ioprio_set syscall, class is stored in upper bits
+func ioprio(class string, level int) int {
+	classes := map[string]int{
+		"best-effort": 2,
+		"idle":        3,
+	}
+	return classes[class]<<13 | level
+}
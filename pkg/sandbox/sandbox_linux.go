@@ -0,0 +1,397 @@
+// +build linux
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// cgroupMounts are checked for cgroup v2 filesystem, unified is used in hybrid setups
+var cgroupMounts = []string{"/sys/fs/cgroup", "/sys/fs/cgroup/unified"}
+
+const cgroup2SuperMagic = 0x63677270
+
+func isCgroup2(dir string) bool {
+	var st syscall.Statfs_t
+	err := syscall.Statfs(dir, &st)
+	if err != nil {
+		return false
+	}
+	return st.Type == cgroup2SuperMagic
+}
+
+// writeCgroupFile writes to existing cgroup interface file, does not create files when dir is not a cgroup
+func writeCgroupFile(loc string, value string) error {
+	f, err := os.OpenFile(loc, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write([]byte(value))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Sandbox contains the cgroup and options for one integration process.
+type Sandbox struct {
+	opts   Opts
+	logger hclog.Logger
+	conf   Config
+
+	cgroup string
+}
+
+var cgroupCounter int64
+
+// New creates sandbox. Creates cgroup with configured limits. Returns an error if limits are configured, but could not be applied.
+func New(opts Opts) (*Sandbox, error) {
+	s := &Sandbox{}
+	s.opts = opts
+	s.logger = opts.Logger.Named("sandbox")
+	s.conf = opts.Config
+	err := s.conf.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid sandbox config: %v", err)
+	}
+	if s.conf.RestrictFS && os.Geteuid() != 0 {
+		return nil, fmt.Errorf("sandbox restrict_fs requires running agent as root")
+	}
+	if s.conf.cgroupEnabled() {
+		err := s.createCgroup()
+		if err != nil {
+			return nil, fmt.Errorf("could not create cgroup for integration: %v", err)
+		}
+	}
+	return s, nil
+}
+
+func (s *Sandbox) cgroupParent() (string, error) {
+	if s.conf.CgroupParent != "" {
+		if !isCgroup2(s.conf.CgroupParent) {
+			return "", fmt.Errorf("cgroup_parent %v is not a cgroup v2 directory", s.conf.CgroupParent)
+		}
+		return s.conf.CgroupParent, nil
+	}
+	b, err := ioutil.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	p, err := parseProcCgroup(string(b))
+	if err != nil {
+		return "", err
+	}
+	for _, root := range cgroupMounts {
+		if isCgroup2(root) {
+			return filepath.Join(root, p), nil
+		}
+	}
+	return "", errors.New("cgroup v2 filesystem is not mounted, cgroup v2 is required for limits")
+}
+
+func (s *Sandbox) createCgroup() error {
+	parent, err := s.cgroupParent()
+	if err != nil {
+		return err
+	}
+	var controllers []string
+	if s.conf.MemoryMaxMB != 0 {
+		controllers = append(controllers, "+memory")
+	}
+	if s.conf.CPUPercent != 0 {
+		controllers = append(controllers, "+cpu")
+	}
+	if s.conf.PidsMax != 0 {
+		controllers = append(controllers, "+pids")
+	}
+	err = writeCgroupFile(filepath.Join(parent, "cgroup.subtree_control"), strings.Join(controllers, " "))
+	if err != nil {
+		return fmt.Errorf("could not enable controllers %v in %v, set sandbox.cgroup_parent to a cgroup delegated to the agent without processes: %v", controllers, parent, err)
+	}
+
+	n := atomic.AddInt64(&cgroupCounter, 1)
+	s.cgroup = filepath.Join(parent, "pinpoint-"+s.opts.Name+"-"+strconv.Itoa(os.Getpid())+"-"+strconv.FormatInt(n, 10))
+	err = os.Mkdir(s.cgroup, 0755)
+	if err != nil {
+		return err
+	}
+	write := func(file string, value string) error {
+		err := writeCgroupFile(filepath.Join(s.cgroup, file), value)
+		if err != nil {
+			return fmt.Errorf("could not set %v: %v", file, err)
+		}
+		return nil
+	}
+	if s.conf.MemoryMaxMB != 0 {
+		err := write("memory.max", strconv.FormatInt(int64(s.conf.MemoryMaxMB)*1024*1024, 10))
+		if err != nil {
+			return err
+		}
+		// do not allow swapping instead of hitting the limit, file does not exist if swap accounting is disabled
+		if _, err := os.Stat(filepath.Join(s.cgroup, "memory.swap.max")); err == nil {
+			err := write("memory.swap.max", "0")
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if s.conf.CPUPercent != 0 {
+		err := write("cpu.max", cpuMax(s.conf.CPUPercent))
+		if err != nil {
+			return err
+		}
+	}
+	if s.conf.PidsMax != 0 {
+		err := write("pids.max", strconv.Itoa(s.conf.PidsMax))
+		if err != nil {
+			return err
+		}
+	}
+	s.logger.Debug("created cgroup", "dir", s.cgroup)
+	return nil
+}
+
+// Wrap returns the command that starts cmd via sandbox-exec. Returns cmd as is when sandbox is not configured.
+func (s *Sandbox) Wrap(cmd *exec.Cmd) (*exec.Cmd, error) {
+	if !s.conf.Enabled() {
+		return cmd, nil
+	}
+	agentBin, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	shim := ShimOpts{}
+	shim.Cgroup = s.cgroup
+	shim.Nice = s.conf.Nice
+	shim.IONiceClass = s.conf.IONiceClass
+	shim.IONiceLevel = s.conf.IONiceLevel
+	if s.conf.RestrictFS {
+		shim.RestrictFS = true
+		shim.WritablePaths = append(append([]string{os.TempDir()}, s.opts.WritablePaths...), s.conf.WritablePaths...)
+		shim.HiddenPaths = append(append([]string{}, s.opts.HiddenPaths...), s.conf.HiddenPaths...)
+	}
+	args, err := shimArgs(shim, cmd)
+	if err != nil {
+		return nil, err
+	}
+	res := exec.Command(agentBin, args...)
+	res.Env = cmd.Env
+	res.Dir = cmd.Dir
+	if s.conf.RestrictFS {
+		res.SysProcAttr = &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWNS}
+	}
+	return res, nil
+}
+
+// Violation returns an error describing exceeded limits. Call after integration failed to replace generic rpc errors.
+func (s *Sandbox) Violation() error {
+	if s.cgroup == "" {
+		return nil
+	}
+	read := func(file string) map[string]int64 {
+		b, err := ioutil.ReadFile(filepath.Join(s.cgroup, file))
+		if err != nil {
+			return nil
+		}
+		return parseEvents(string(b))
+	}
+	if s.conf.MemoryMaxMB != 0 && read("memory.events")["oom_kill"] > 0 {
+		return fmt.Errorf("integration was killed after exceeding memory limit of %v MB (sandbox.memory_max_mb)", s.conf.MemoryMaxMB)
+	}
+	if s.conf.PidsMax != 0 && read("pids.events")["max"] > 0 {
+		return fmt.Errorf("integration reached the limit of %v processes and threads (sandbox.pids_max)", s.conf.PidsMax)
+	}
+	return nil
+}
+
+// Close removes the cgroup. Call after integration process exited.
+func (s *Sandbox) Close() error {
+	if s.cgroup == "" {
+		return nil
+	}
+	var err error
+	// process may still be exiting after kill
+	for i := 0; i < 20; i++ {
+		err = os.Remove(s.cgroup)
+		if err == nil || os.IsNotExist(err) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("could not remove cgroup %v: %v", s.cgroup, err)
+}
+
+// RunShim applies sandbox options to the current process and executes the integration binary. Only returns on error.
+func RunShim(opts ShimOpts, bin string, args []string) error {
+	// nice and ionice apply to the current thread, make sure exec happens on the same thread
+	runtime.LockOSThread()
+
+	if opts.Cgroup != "" {
+		err := writeCgroupFile(filepath.Join(opts.Cgroup, "cgroup.procs"), strconv.Itoa(os.Getpid()))
+		if err != nil {
+			return fmt.Errorf("could not join cgroup: %v", err)
+		}
+	}
+	if opts.Nice != 0 {
+		err := syscall.Setpriority(syscall.PRIO_PROCESS, 0, opts.Nice)
+		if err != nil {
+			return fmt.Errorf("could not set nice: %v", err)
+		}
+	}
+	if opts.IONiceClass != "" {
+		// IOPRIO_WHO_PROCESS = 1
+		_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, 1, 0, uintptr(ioprio(opts.IONiceClass, opts.IONiceLevel)))
+		if errno != 0 {
+			return fmt.Errorf("could not set ionice: %v", errno)
+		}
+	}
+	if opts.RestrictFS {
+		err := restrictFS(opts.WritablePaths, opts.HiddenPaths)
+		if err != nil {
+			return fmt.Errorf("could not restrict filesystem: %v", err)
+		}
+	}
+	bin, err := exec.LookPath(bin)
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(bin, append([]string{bin}, args...), os.Environ())
+}
+
+// restrictFS makes root filesystem read-only. Expects to run in a new mount namespace.
+func restrictFS(writable []string, hidden []string) error {
+	// do not propagate any changes to host
+	err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, "")
+	if err != nil {
+		return fmt.Errorf("make mounts private: %v", err)
+	}
+	// writable paths are separate mounts, so that they are not affected by read-only remount of root
+	for _, p := range writable {
+		if _, err := os.Stat(p); err != nil {
+			continue
+		}
+		err := syscall.Mount(p, p, "", syscall.MS_BIND|syscall.MS_REC, "")
+		if err != nil {
+			return fmt.Errorf("bind writable path %v: %v", p, err)
+		}
+	}
+	for _, p := range hidden {
+		fi, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		if fi.IsDir() {
+			err = syscall.Mount("tmpfs", p, "tmpfs", syscall.MS_RDONLY, "size=4k")
+		} else {
+			err = syscall.Mount("/dev/null", p, "", syscall.MS_BIND, "")
+		}
+		if err != nil {
+			return fmt.Errorf("hide path %v: %v", p, err)
+		}
+	}
+	// remount without MS_REC only applies to one mount, so remount root and all submounts, such as separate /home or /var filesystems
+	b, err := ioutil.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return fmt.Errorf("read mounts: %v", err)
+	}
+	for _, m := range readOnlyMounts(parseMountInfo(string(b)), writable) {
+		err := syscall.Mount(m.Path, m.Path, "", syscall.MS_BIND|syscall.MS_REMOUNT|syscall.MS_RDONLY|m.Flags, "")
+		if err != nil {
+			return fmt.Errorf("remount %v read-only: %v", m.Path, err)
+		}
+	}
+	return nil
+}
+
+type mount struct {
+	Path string
+	// Flags are the current mount flags that have to be kept on remount. Locked flags can not be cleared in user namespace.
+	Flags uintptr
+}
+
+var mountOptionFlags = map[string]uintptr{
+	"nosuid":     syscall.MS_NOSUID,
+	"nodev":      syscall.MS_NODEV,
+	"noexec":     syscall.MS_NOEXEC,
+	"noatime":    syscall.MS_NOATIME,
+	"nodiratime": syscall.MS_NODIRATIME,
+	"relatime":   syscall.MS_RELATIME,
+}
+
+// parseMountInfo returns mounts from /proc/self/mountinfo in the same order.
+func parseMountInfo(data string) (res []mount) {
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+		m := mount{}
+		m.Path = unescapeMountPath(fields[4])
+		for _, o := range strings.Split(fields[5], ",") {
+			m.Flags |= mountOptionFlags[o]
+		}
+		res = append(res, m)
+	}
+	return
+}
+
+// unescapeMountPath decodes octal escapes used for spaces and other special chars in mountinfo
+func unescapeMountPath(p string) string {
+	if !strings.Contains(p, `\`) {
+		return p
+	}
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		if p[i] == '\\' && i+3 < len(p) {
+			if v, err := strconv.ParseUint(p[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(p[i])
+	}
+	return b.String()
+}
+
+// readOnlyMounts returns mounts to remount read-only. Writable paths and mounts below them are kept writable. /proc and /dev are kept as is, since devpts and shared memory need to be writable and proc is not a real filesystem.
+func readOnlyMounts(mounts []mount, writable []string) (res []mount) {
+	under := func(p string, dir string) bool {
+		dir = strings.TrimSuffix(filepath.Clean(dir), "/")
+		return p == dir || strings.HasPrefix(p, dir+"/")
+	}
+	seen := map[string]bool{}
+	// remount applies to the top mount when there are several on the same path, these are listed last
+	for i := len(mounts) - 1; i >= 0; i-- {
+		m := mounts[i]
+		if seen[m.Path] {
+			continue
+		}
+		keep := under(m.Path, "/proc") || under(m.Path, "/dev")
+		for _, w := range writable {
+			if under(m.Path, w) {
+				keep = true
+			}
+		}
+		if keep {
+			continue
+		}
+		seen[m.Path] = true
+		res = append(res, m)
+	}
+	return
+}
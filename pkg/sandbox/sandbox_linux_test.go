@@ -0,0 +1,122 @@
+// +build linux
+
+package sandbox
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMountInfo(t *testing.T) {
+	data := `23 28 0:22 / /proc rw,nosuid,nodev,noexec,relatime - proc proc rw
+28 1 8:1 / / rw,relatime - ext4 /dev/sda1 rw
+29 28 8:2 / /home rw,nosuid,nodev - ext4 /dev/sda2 rw
+30 28 0:30 / /mnt/with\040space ro - tmpfs tmpfs rw
+`
+	got := parseMountInfo(data)
+	assert.Len(t, got, 4)
+	assert.Equal(t, mount{Path: "/proc", Flags: syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC | syscall.MS_RELATIME}, got[0])
+	assert.Equal(t, mount{Path: "/home", Flags: syscall.MS_NOSUID | syscall.MS_NODEV}, got[2])
+	assert.Equal(t, "/mnt/with space", got[3].Path)
+}
+
+func TestReadOnlyMounts(t *testing.T) {
+	mounts := []mount{
+		{Path: "/"},
+		{Path: "/proc"},
+		{Path: "/dev/shm"},
+		{Path: "/home"},
+		{Path: "/var/lib/agent"},
+		{Path: "/var/lib/agent/cache"},
+		{Path: "/var/lib/agent2"},
+		{Path: "/home", Flags: syscall.MS_NOSUID},
+	}
+	got := readOnlyMounts(mounts, []string{"/var/lib/agent/"})
+	var paths []string
+	for _, m := range got {
+		paths = append(paths, m.Path)
+	}
+	assert.ElementsMatch(t, []string{"/", "/home", "/var/lib/agent2"}, paths)
+	for _, m := range got {
+		if m.Path == "/home" {
+			assert.Equal(t, uintptr(syscall.MS_NOSUID), m.Flags, "flags of the top mount are used")
+		}
+	}
+}
+
+const envRestrictFSHelper = "PP_TEST_RESTRICT_FS_HELPER"
+
+// TestRestrictFS runs restrictFS in a child process in new mount namespace and checks that tmpfs submount is read-only. Requires root.
+func TestRestrictFS(t *testing.T) {
+	if os.Getenv(envRestrictFSHelper) != "" {
+		restrictFSHelper(t)
+		return
+	}
+	if os.Geteuid() != 0 {
+		t.Skip("requires root to create mount namespace")
+	}
+	dir, err := ioutil.TempDir("", "test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, d := range []string{"submount", "writable"} {
+		err := os.Mkdir(filepath.Join(dir, d), 0777)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRestrictFS$", "-test.v")
+	cmd.Env = append(os.Environ(), envRestrictFSHelper+"="+dir)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWNS}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("restrict fs helper failed: %v\n%s", err, out)
+	}
+	// changes in child namespace must not affect host
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "submount", "f"), nil, 0644))
+}
+
+func restrictFSHelper(t *testing.T) {
+	dir := os.Getenv(envRestrictFSHelper)
+	submount := filepath.Join(dir, "submount")
+	writable := filepath.Join(dir, "writable")
+	// make mounts private before creating submount, so it is not visible on host
+	err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = syscall.Mount("tmpfs", submount, "tmpfs", 0, "size=1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = restrictFS([]string{writable}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(submount, "f"), nil, 0644)
+	if !os.IsPermission(err) && !isReadOnly(err) {
+		t.Fatalf("expected tmpfs submount to be read-only, got err: %v", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "f"), nil, 0644)
+	if !isReadOnly(err) {
+		t.Fatalf("expected root to be read-only, got err: %v", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(writable, "f"), nil, 0644)
+	if err != nil {
+		t.Fatalf("expected writable path to be writable, got err: %v", err)
+	}
+}
+
+func isReadOnly(err error) bool {
+	if pe, ok := err.(*os.PathError); ok {
+		return pe.Err == syscall.EROFS
+	}
+	return false
+}
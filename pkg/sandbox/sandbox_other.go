@@ -0,0 +1,44 @@
+// +build !linux
+
+package sandbox
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+)
+
+// Sandbox is a no-op on platforms other than Linux.
+type Sandbox struct{}
+
+// New returns a sandbox that starts integrations without limits. Logs a warning if sandbox is configured.
+func New(opts Opts) (*Sandbox, error) {
+	err := opts.Config.validate()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Config.Enabled() {
+		opts.Logger.Warn("sandbox config is only supported on linux, starting integration without limits", "os", runtime.GOOS)
+	}
+	return &Sandbox{}, nil
+}
+
+// Wrap returns cmd as is.
+func (s *Sandbox) Wrap(cmd *exec.Cmd) (*exec.Cmd, error) {
+	return cmd, nil
+}
+
+// Violation always returns nil.
+func (s *Sandbox) Violation() error {
+	return nil
+}
+
+// Close does nothing.
+func (s *Sandbox) Close() error {
+	return nil
+}
+
+// RunShim is not supported on this platform.
+func RunShim(opts ShimOpts, bin string, args []string) error {
+	return errors.New("sandbox-exec is only supported on linux")
+}
@@ -0,0 +1,64 @@
+package sandbox
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, Config{}.validate())
+	assert.NoError(t, Config{MemoryMaxMB: 512, CPUPercent: 50, Nice: 10, IONiceClass: "idle"}.validate())
+	assert.Error(t, Config{MemoryMaxMB: -1}.validate())
+	assert.Error(t, Config{Nice: 20}.validate())
+	assert.Error(t, Config{IONiceClass: "realtime"}.validate())
+	assert.Error(t, Config{IONiceClass: "best-effort", IONiceLevel: 8}.validate())
+}
+
+func TestConfigEnabled(t *testing.T) {
+	assert.False(t, Config{CgroupParent: "/sys/fs/cgroup/x"}.Enabled())
+	assert.True(t, Config{PidsMax: 100}.Enabled())
+	assert.True(t, Config{Nice: 5}.Enabled())
+	assert.True(t, Config{RestrictFS: true}.Enabled())
+}
+
+func TestShimArgs(t *testing.T) {
+	opts := ShimOpts{Cgroup: "/sys/fs/cgroup/a", Nice: 5, RestrictFS: true, WritablePaths: []string{"/tmp"}}
+	args, err := shimArgs(opts, exec.Command("/bin/integration", "--flag", "v"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ShimCommandName, args[0])
+
+	opts2, bin, binArgs, err := ParseShimArgs(args[1:])
+	assert.NoError(t, err)
+	assert.Equal(t, opts, opts2)
+	assert.Equal(t, "/bin/integration", bin)
+	assert.Equal(t, []string{"--flag", "v"}, binArgs)
+
+	_, _, _, err = ParseShimArgs([]string{"{}"})
+	assert.Error(t, err)
+}
+
+func TestParseProcCgroup(t *testing.T) {
+	v, err := parseProcCgroup("0::/system.slice/pinpoint-agent.service\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "/system.slice/pinpoint-agent.service", v)
+
+	_, err = parseProcCgroup("12:memory:/user.slice\n11:pids:/user.slice\n")
+	assert.Error(t, err)
+}
+
+func TestParseEvents(t *testing.T) {
+	v := parseEvents("low 0\nhigh 0\nmax 12\noom 1\noom_kill 1\n")
+	assert.Equal(t, int64(1), v["oom_kill"])
+	assert.Equal(t, int64(12), v["max"])
+}
+
+func TestCPUMaxAndIOPrio(t *testing.T) {
+	assert.Equal(t, "50000 100000", cpuMax(50))
+	assert.Equal(t, "200000 100000", cpuMax(200))
+	assert.Equal(t, 2<<13|4, ioprio("best-effort", 4))
+	assert.Equal(t, 3<<13, ioprio("idle", 0))
+}
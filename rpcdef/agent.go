@@ -50,6 +50,9 @@ type Agent interface {
 	SendPauseEvent(msg string, resumeDate time.Time) error
 
 	SendResumeEvent(msg string) error
+
+	// Heartbeat is sent automatically every HeartbeatInterval while export is making progress, that is when integration called other agent methods since the last heartbeat or is paused waiting for rate limits. Integrations do not need to call it.
+	Heartbeat() error
 }
 
 type ExportObj struct {
@@ -263,13 +266,21 @@ func (s *AgentServer) SendResumeEvent(ctx context.Context, req *proto.SendResume
 	return
 }
 
+func (s *AgentServer) Heartbeat(ctx context.Context, req *proto.Empty) (resp *proto.Empty, err error) {
+	resp = &proto.Empty{}
+	err = s.Impl.Heartbeat()
+	return
+}
+
 type AgentClient struct {
-	client proto.AgentClient
+	client   proto.AgentClient
+	progress exportProgress
 }
 
 var _ Agent = (*AgentClient)(nil)
 
 func (s *AgentClient) ExportStarted(modelType string) (sessionID string, lastProcessed interface{}) {
+	s.progress.made()
 	args := &proto.ExportStartedReq{}
 	args.ModelType = modelType
 	resp, err := s.client.ExportStarted(context.Background(), args)
@@ -280,6 +291,7 @@ func (s *AgentClient) ExportStarted(modelType string) (sessionID string, lastPro
 }
 
 func (s *AgentClient) ExportDone(sessionID string, lastProcessed interface{}) {
+	s.progress.made()
 	args := &proto.ExportDoneReq{}
 	args.SessionId = sessionID
 	args.LastProcessed = lastProcessedMarshal(lastProcessed)
@@ -290,6 +302,7 @@ func (s *AgentClient) ExportDone(sessionID string, lastProcessed interface{}) {
 }
 
 func (s *AgentClient) SendExported(sessionID string, objs []ExportObj) {
+	s.progress.made()
 	args := &proto.SendExportedReq{}
 	args.SessionId = sessionID
 	for _, obj := range objs {
@@ -309,6 +322,7 @@ func (s *AgentClient) SendExported(sessionID string, objs []ExportObj) {
 }
 
 func (s *AgentClient) ExportGitRepo(fetch GitRepoFetch) error {
+	s.progress.made()
 	err := fetch.Validate()
 	if err != nil {
 		return err
@@ -337,6 +351,7 @@ func (s *AgentClient) ExportGitRepo(fetch GitRepoFetch) error {
 }
 
 func (s *AgentClient) SessionStart(isTracking bool, name string, parentSessionID int, parentObjectID, parentObjectName string) (sessionID int, lastProcessed interface{}, _ error) {
+	s.progress.made()
 	args := &proto.SessionStartReq{}
 	args.IsTracking = isTracking
 	args.Name = name
@@ -351,6 +366,7 @@ func (s *AgentClient) SessionStart(isTracking bool, name string, parentSessionID
 }
 
 func (s *AgentClient) SessionProgress(id int, current, total int) error {
+	s.progress.made()
 	args := &proto.SessionProgressReq{}
 	args.Id = int64(id)
 	args.Current = int64(current)
@@ -363,6 +379,7 @@ func (s *AgentClient) SessionProgress(id int, current, total int) error {
 }
 
 func (s *AgentClient) SessionRollback(id int) error {
+	s.progress.made()
 	args := &proto.SessionRollbackReq{}
 	args.Id = int64(id)
 	_, err := s.client.SessionRollback(context.Background(), args)
@@ -373,6 +390,7 @@ func (s *AgentClient) SessionRollback(id int) error {
 }
 
 func (s *AgentClient) OAuthNewAccessToken() (token string, _ error) {
+	s.progress.made()
 	args := &proto.Empty{}
 	resp, err := s.client.OAuthNewAccessToken(context.Background(), args)
 	if err != nil {
@@ -382,6 +400,7 @@ func (s *AgentClient) OAuthNewAccessToken() (token string, _ error) {
 }
 
 func (s *AgentClient) SendPauseEvent(msg string, resumeDate time.Time) error {
+	s.progress.setPaused(true)
 	args := &proto.SendPauseEventReq{
 		Message: msg,
 		Rfc3339: resumeDate.Format(time.RFC3339),
@@ -394,6 +413,7 @@ func (s *AgentClient) SendPauseEvent(msg string, resumeDate time.Time) error {
 }
 
 func (s *AgentClient) SendResumeEvent(msg string) error {
+	s.progress.setPaused(false)
 	args := &proto.SendResumeEventReq{
 		Message: msg,
 	}
@@ -403,3 +423,13 @@ func (s *AgentClient) SendResumeEvent(msg string) error {
 	}
 	return nil
 }
+
+func (s *AgentClient) Heartbeat() error {
+	ctx, cancel := context.WithTimeout(context.Background(), HeartbeatInterval)
+	defer cancel()
+	_, err := s.client.Heartbeat(ctx, &proto.Empty{})
+	if err != nil {
+		return err
+	}
+	return nil
+}
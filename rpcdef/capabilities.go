@@ -0,0 +1,108 @@
+package rpcdef
+
+import (
+	"encoding/json"
+
+	"github.com/hashicorp/go-plugin"
+)
+
+// Protocol versions supported by agent and integrations. Both sides advertise all supported versions and go-plugin picks the highest common one, so old and new integration binaries can be used with the same agent during updates.
+const (
+	// ProtocolVersionInitial is the original protocol. Init does not return capabilities.
+	ProtocolVersionInitial = 1
+	// ProtocolVersionCapabilities adds capabilities manifest returned from Init.
+	ProtocolVersionCapabilities = 2
+	// ProtocolVersionHeartbeat adds heartbeats sent by integration during export and goroutine dump used to debug hanging integrations.
+	ProtocolVersionHeartbeat = 3
+	// ProtocolVersionLatest is the latest protocol version supported
+	ProtocolVersionLatest = ProtocolVersionHeartbeat
+)
+
+// VersionedPlugins returns plugin sets for all supported protocol versions. The grpc interface is backwards compatible, so the same plugins are used for all versions.
+func VersionedPlugins(plugins plugin.PluginSet) map[int]plugin.PluginSet {
+	return map[int]plugin.PluginSet{
+		ProtocolVersionInitial:      plugins,
+		ProtocolVersionCapabilities: plugins,
+		ProtocolVersionHeartbeat:    plugins,
+	}
+}
+
+// Capabilities is the manifest returned by integration from Init.
+type Capabilities struct {
+	// ProtocolVersion is the version negotiated with the integration. Set by agent.
+	ProtocolVersion int `json:"protocol_version"`
+	// Legacy is true when integration uses protocol version without capabilities. All operations are assumed to be supported in this case.
+	Legacy bool `json:"legacy"`
+
+	// IntegrationTypes are types supported by integration binary, WORK, SOURCECODE or CODEQUALITY.
+	IntegrationTypes []string `json:"integration_types"`
+	// OnboardKinds are object types supported in OnboardExport.
+	OnboardKinds []OnboardExportType `json:"onboard_kinds"`
+	// MutationActions are the names of supported mutation functions passed to Mutate.
+	MutationActions []string `json:"mutation_actions"`
+	// Streaming is true if integration sends objects using sessions during export instead of returning them at the end.
+	Streaming bool `json:"streaming"`
+	// ConfigSchema is the JSON schema of integration config. Optional.
+	ConfigSchema map[string]interface{} `json:"config_schema,omitempty"`
+}
+
+// LegacyCapabilities returns capabilities used for integrations that do not support ProtocolVersionCapabilities.
+func LegacyCapabilities() Capabilities {
+	return Capabilities{
+		ProtocolVersion: ProtocolVersionInitial,
+		Legacy:          true,
+	}
+}
+
+// NegotiatedCapabilities returns capabilities of integration based on protocol version negotiated by go-plugin and capabilities returned from Init. Integrations using protocol version before ProtocolVersionCapabilities do not return capabilities, all operations are assumed to be supported for them.
+func NegotiatedCapabilities(protocolVersion int, fromInit Capabilities) Capabilities {
+	if protocolVersion < ProtocolVersionCapabilities {
+		res := LegacyCapabilities()
+		res.ProtocolVersion = protocolVersion
+		return res
+	}
+	res := fromInit
+	res.ProtocolVersion = protocolVersion
+	res.Legacy = false
+	return res
+}
+
+// SupportsHeartbeat returns true if integration sends heartbeats during export and supports goroutine dump.
+func (s Capabilities) SupportsHeartbeat() bool {
+	return s.ProtocolVersion >= ProtocolVersionHeartbeat
+}
+
+// SupportsOnboard returns true if integration supports onboard for the kind.
+func (s Capabilities) SupportsOnboard(kind OnboardExportType) bool {
+	if s.Legacy {
+		return true
+	}
+	for _, k := range s.OnboardKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsMutation returns true if integration supports mutation action.
+func (s Capabilities) SupportsMutation(action string) bool {
+	if s.Legacy {
+		return true
+	}
+	for _, a := range s.MutationActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// capabilitiesFromJSON parses capabilities returned from Init. Integrations using ProtocolVersionInitial return empty response, use NegotiatedCapabilities to handle these.
+func capabilitiesFromJSON(b []byte) (res Capabilities, _ error) {
+	if len(b) == 0 {
+		return res, nil
+	}
+	err := json.Unmarshal(b, &res)
+	return res, err
+}
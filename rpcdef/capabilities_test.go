@@ -0,0 +1,80 @@
+package rpcdef
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiatedCapabilities(t *testing.T) {
+	fromInit := Capabilities{
+		IntegrationTypes: []string{"WORK"},
+		OnboardKinds:     []OnboardExportType{OnboardExportTypeUsers},
+		MutationActions:  []string{"issue_set_title"},
+	}
+	cases := []struct {
+		Label           string
+		ProtocolVersion int
+		FromInit        Capabilities
+		Legacy          bool
+		Heartbeat       bool
+	}{
+		{"legacy plugin", ProtocolVersionInitial, Capabilities{}, true, false},
+		{"legacy plugin ignores init response", ProtocolVersionInitial, fromInit, true, false},
+		{"capabilities", ProtocolVersionCapabilities, fromInit, false, false},
+		{"heartbeat", ProtocolVersionHeartbeat, fromInit, false, true},
+		{"no capabilities declared", ProtocolVersionHeartbeat, Capabilities{}, false, true},
+		{"legacy flag from plugin is ignored", ProtocolVersionHeartbeat, Capabilities{Legacy: true}, false, true},
+	}
+	for _, c := range cases {
+		got := NegotiatedCapabilities(c.ProtocolVersion, c.FromInit)
+		assert.Equal(t, c.ProtocolVersion, got.ProtocolVersion, c.Label)
+		assert.Equal(t, c.Legacy, got.Legacy, c.Label)
+		assert.Equal(t, c.Heartbeat, got.SupportsHeartbeat(), c.Label)
+	}
+}
+
+func TestCapabilitiesSupports(t *testing.T) {
+	caps := Capabilities{
+		ProtocolVersion: ProtocolVersionCapabilities,
+		OnboardKinds:    []OnboardExportType{OnboardExportTypeUsers},
+		MutationActions: []string{"issue_set_title"},
+	}
+	legacy := LegacyCapabilities()
+	empty := Capabilities{ProtocolVersion: ProtocolVersionCapabilities}
+
+	cases := []struct {
+		Label string
+		Got   bool
+		Want  bool
+	}{
+		{"onboard declared", caps.SupportsOnboard(OnboardExportTypeUsers), true},
+		{"onboard not declared", caps.SupportsOnboard(OnboardExportTypeProjects), false},
+		{"onboard legacy", legacy.SupportsOnboard(OnboardExportTypeProjects), true},
+		{"onboard none declared", empty.SupportsOnboard(OnboardExportTypeUsers), false},
+		{"mutation declared", caps.SupportsMutation("issue_set_title"), true},
+		{"mutation not declared", caps.SupportsMutation("issue_add_comment"), false},
+		{"mutation legacy", legacy.SupportsMutation("issue_add_comment"), true},
+		{"mutation none declared", empty.SupportsMutation("issue_set_title"), false},
+		{"heartbeat capabilities version", caps.SupportsHeartbeat(), false},
+		{"heartbeat legacy", legacy.SupportsHeartbeat(), false},
+		{"heartbeat version", Capabilities{ProtocolVersion: ProtocolVersionHeartbeat}.SupportsHeartbeat(), true},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.Want, c.Got, c.Label)
+	}
+}
+
+func TestCapabilitiesFromJSON(t *testing.T) {
+	got, err := capabilitiesFromJSON(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Capabilities{}, got, "legacy is decided by negotiated version, not by empty response")
+
+	got, err = capabilitiesFromJSON([]byte(`{"integration_types":["WORK"],"mutation_actions":["a"]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"WORK"}, got.IntegrationTypes)
+	assert.True(t, got.SupportsMutation("a"))
+
+	_, err = capabilitiesFromJSON([]byte(`{`))
+	assert.Error(t, err)
+}
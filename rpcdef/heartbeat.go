@@ -0,0 +1,102 @@
+package rpcdef
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/pinpt/agent/rpcdef/proto"
+)
+
+// HeartbeatInterval is how often integration sends heartbeats to agent during export.
+const HeartbeatInterval = 10 * time.Second
+
+// exportProgress records calls from integration to agent. Heartbeats are only sent when export made progress, so that agent detects integrations stuck in export, even if the process still responds.
+type exportProgress struct {
+	mu     sync.Mutex
+	calls  int
+	paused bool
+}
+
+// made records that integration called agent.
+func (s *exportProgress) made() {
+	s.mu.Lock()
+	s.calls++
+	s.mu.Unlock()
+}
+
+// setPaused is called when integration is waiting for rate limits, heartbeats are sent while paused.
+func (s *exportProgress) setPaused(paused bool) {
+	s.mu.Lock()
+	s.calls++
+	s.paused = paused
+	s.mu.Unlock()
+}
+
+func (s *exportProgress) get() (calls int, paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls, s.paused
+}
+
+// startHeartbeat sends heartbeats to agent until returned func is called. Heartbeats are sent from a separate goroutine, but only when export made progress since the last heartbeat. Agent uses them to detect integration processes that stopped responding or are stuck.
+func (s *IntegrationServer) startHeartbeat() (stop func()) {
+	if s.agent == nil {
+		return func() {}
+	}
+	done := make(chan bool)
+	go func() {
+		ticker := time.NewTicker(HeartbeatInterval)
+		defer ticker.Stop()
+		last, _ := s.agent.progress.get()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				calls, paused := s.agent.progress.get()
+				if calls == last && !paused {
+					continue
+				}
+				last = calls
+				// agent does not support heartbeats when using older version, ignore errors
+				_ = s.agent.Heartbeat()
+			}
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
+
+// GoroutineDump returns stack traces of all goroutines in integration process.
+func (s *IntegrationServer) GoroutineDump(ctx context.Context, req *proto.Empty) (*proto.GoroutineDumpResp, error) {
+	res := &proto.GoroutineDumpResp{}
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			res.Data = buf[:n]
+			return res, nil
+		}
+		if len(buf) >= 64<<20 {
+			res.Data = buf
+			return res, nil
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// GoroutineDump returns stack traces of all goroutines in integration process. Only supported by integrations using ProtocolVersionHeartbeat.
+func (s *IntegrationClient) GoroutineDump(ctx context.Context) ([]byte, error) {
+	resp, err := s.client.GoroutineDump(ctx, &proto.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, errors.New("integration returned empty goroutine dump")
+	}
+	return resp.Data, nil
+}
@@ -16,7 +16,8 @@ import (
 
 type Integration interface {
 	// Init provides the connection details for connecting back to agent.
-	Init(agent Agent) error
+	// Returns capabilities supported by integration. Agent uses them to skip unsupported onboard and mutate calls and reports them to backend.
+	Init(agent Agent) (Capabilities, error)
 	// Export starts export of all data types for this integration.
 	// Config contains typed config common for all integrations and map[string]interface{} for custom fields.
 	Export(context.Context, ExportConfig) (ExportResult, error)
@@ -155,7 +156,7 @@ func NewIntegrationClient(protoClient proto.IntegrationClient, broker *plugin.GR
 	return s
 }
 
-func (s *IntegrationClient) Init(agent Agent) error {
+func (s *IntegrationClient) Init(agent Agent) (res Capabilities, _ error) {
 	server := &AgentServer{Impl: agent}
 	serverFunc := func(opts []grpc.ServerOption) *grpc.Server {
 		gs := grpc.NewServer(opts...)
@@ -167,8 +168,12 @@ func (s *IntegrationClient) Init(agent Agent) error {
 	go s.broker.AcceptAndServe(brokerID, serverFunc)
 
 	args := &proto.IntegrationInitReq{ServerId: brokerID}
-	_, err := s.client.Init(context.Background(), args)
-	return err
+	resp, err := s.client.Init(context.Background(), args)
+	if err != nil {
+		return res, err
+	}
+	// integrations using ProtocolVersionInitial return empty response, legacy capabilities are set by caller based on negotiated version
+	return capabilitiesFromJSON(resp.CapabilitiesJson)
 }
 
 func (s *IntegrationClient) Destroy() {
@@ -262,7 +267,8 @@ type IntegrationServer struct {
 	Impl   Integration
 	broker *plugin.GRPCBroker
 
-	conn *grpc.ClientConn
+	conn  *grpc.ClientConn
+	agent *AgentClient
 }
 
 func NewIntegrationServer(impl Integration, broker *plugin.GRPCBroker) *IntegrationServer {
@@ -276,14 +282,24 @@ func (s *IntegrationServer) Destroy() error {
 	return s.conn.Close()
 }
 
-func (s *IntegrationServer) Init(ctx context.Context, req *proto.IntegrationInitReq) (*proto.Empty, error) {
+func (s *IntegrationServer) Init(ctx context.Context, req *proto.IntegrationInitReq) (*proto.IntegrationInitResp, error) {
+	res := &proto.IntegrationInitResp{}
 	conn, err := s.broker.Dial(req.ServerId)
 	if err != nil {
-		return nil, err
+		return res, err
 	}
-	as := &AgentClient{proto.NewAgentClient(conn)}
-	err = s.Impl.Init(as)
-	return &proto.Empty{}, err
+	as := &AgentClient{client: proto.NewAgentClient(conn)}
+	s.agent = as
+	caps, err := s.Impl.Init(as)
+	if err != nil {
+		return res, err
+	}
+	caps.ProtocolVersion = ProtocolVersionLatest
+	res.CapabilitiesJson, err = json.Marshal(caps)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
 }
 
 func (s *IntegrationServer) Export(ctx context.Context, req *proto.IntegrationExportReq) (res *proto.IntegrationExportResp, _ error) {
@@ -293,6 +309,8 @@ func (s *IntegrationServer) Export(ctx context.Context, req *proto.IntegrationEx
 	if err != nil {
 		return res, err
 	}
+	stopHeartbeat := s.startHeartbeat()
+	defer stopHeartbeat()
 	res0, err := s.Impl.Export(ctx, config)
 	if err != nil {
 		return res, err
@@ -6,6 +6,7 @@ import "github.com/hashicorp/go-plugin"
 // a plugin and host. If the handshake fails, a user friendly error is shown.
 // This prevents users from executing bad plugins or executing a plugin
 // directory. It is a UX feature, not a security feature.
+// ProtocolVersion is only used as a fallback, supported versions are passed using VersionedPlugins.
 var Handshake = plugin.HandshakeConfig{
 	ProtocolVersion:  1,
 	MagicCookieKey:   "PLUGIN",
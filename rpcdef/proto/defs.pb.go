@@ -52,7 +52,7 @@ func (x IntegrationOnboardExportReq_Kind) String() string {
 }
 
 func (IntegrationOnboardExportReq_Kind) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{9, 0}
+	return fileDescriptor_bf10f51bd2cb5547, []int{10, 0}
 }
 
 type IntegrationOnboardExportResp_Error int32
@@ -77,7 +77,7 @@ func (x IntegrationOnboardExportResp_Error) String() string {
 }
 
 func (IntegrationOnboardExportResp_Error) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{10, 0}
+	return fileDescriptor_bf10f51bd2cb5547, []int{11, 0}
 }
 
 type ExportObj_DataType int32
@@ -99,7 +99,7 @@ func (x ExportObj_DataType) String() string {
 }
 
 func (ExportObj_DataType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{18, 0}
+	return fileDescriptor_bf10f51bd2cb5547, []int{20, 0}
 }
 
 type Empty struct {
@@ -172,6 +172,46 @@ func (m *IntegrationInitReq) GetServerId() uint32 {
 	return 0
 }
 
+type IntegrationInitResp struct {
+	// capabilities_json is empty for integrations using protocol version 1
+	CapabilitiesJson     []byte   `protobuf:"bytes,1,opt,name=capabilities_json,json=capabilitiesJson,proto3" json:"capabilities_json,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *IntegrationInitResp) Reset()         { *m = IntegrationInitResp{} }
+func (m *IntegrationInitResp) String() string { return proto.CompactTextString(m) }
+func (*IntegrationInitResp) ProtoMessage()    {}
+func (*IntegrationInitResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf10f51bd2cb5547, []int{2}
+}
+
+func (m *IntegrationInitResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_IntegrationInitResp.Unmarshal(m, b)
+}
+func (m *IntegrationInitResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_IntegrationInitResp.Marshal(b, m, deterministic)
+}
+func (m *IntegrationInitResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IntegrationInitResp.Merge(m, src)
+}
+func (m *IntegrationInitResp) XXX_Size() int {
+	return xxx_messageInfo_IntegrationInitResp.Size(m)
+}
+func (m *IntegrationInitResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_IntegrationInitResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IntegrationInitResp proto.InternalMessageInfo
+
+func (m *IntegrationInitResp) GetCapabilitiesJson() []byte {
+	if m != nil {
+		return m.CapabilitiesJson
+	}
+	return nil
+}
+
 type IntegrationExportReq struct {
 	Config               *IntegrationExportConfig `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
@@ -183,7 +223,7 @@ func (m *IntegrationExportReq) Reset()         { *m = IntegrationExportReq{} }
 func (m *IntegrationExportReq) String() string { return proto.CompactTextString(m) }
 func (*IntegrationExportReq) ProtoMessage()    {}
 func (*IntegrationExportReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{2}
+	return fileDescriptor_bf10f51bd2cb5547, []int{3}
 }
 
 func (m *IntegrationExportReq) XXX_Unmarshal(b []byte) error {
@@ -224,7 +264,7 @@ func (m *IntegrationExportConfig) Reset()         { *m = IntegrationExportConfig
 func (m *IntegrationExportConfig) String() string { return proto.CompactTextString(m) }
 func (*IntegrationExportConfig) ProtoMessage()    {}
 func (*IntegrationExportConfig) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{3}
+	return fileDescriptor_bf10f51bd2cb5547, []int{4}
 }
 
 func (m *IntegrationExportConfig) XXX_Unmarshal(b []byte) error {
@@ -277,7 +317,7 @@ func (m *IntegrationAgentConfig) Reset()         { *m = IntegrationAgentConfig{}
 func (m *IntegrationAgentConfig) String() string { return proto.CompactTextString(m) }
 func (*IntegrationAgentConfig) ProtoMessage()    {}
 func (*IntegrationAgentConfig) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{4}
+	return fileDescriptor_bf10f51bd2cb5547, []int{5}
 }
 
 func (m *IntegrationAgentConfig) XXX_Unmarshal(b []byte) error {
@@ -316,7 +356,7 @@ func (m *IntegrationExportResp) Reset()         { *m = IntegrationExportResp{} }
 func (m *IntegrationExportResp) String() string { return proto.CompactTextString(m) }
 func (*IntegrationExportResp) ProtoMessage()    {}
 func (*IntegrationExportResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{5}
+	return fileDescriptor_bf10f51bd2cb5547, []int{6}
 }
 
 func (m *IntegrationExportResp) XXX_Unmarshal(b []byte) error {
@@ -358,7 +398,7 @@ func (m *IntegrationExportRespProject) Reset()         { *m = IntegrationExportR
 func (m *IntegrationExportRespProject) String() string { return proto.CompactTextString(m) }
 func (*IntegrationExportRespProject) ProtoMessage()    {}
 func (*IntegrationExportRespProject) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{6}
+	return fileDescriptor_bf10f51bd2cb5547, []int{7}
 }
 
 func (m *IntegrationExportRespProject) XXX_Unmarshal(b []byte) error {
@@ -418,7 +458,7 @@ func (m *IntegrationValidateConfigReq) Reset()         { *m = IntegrationValidat
 func (m *IntegrationValidateConfigReq) String() string { return proto.CompactTextString(m) }
 func (*IntegrationValidateConfigReq) ProtoMessage()    {}
 func (*IntegrationValidateConfigReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{7}
+	return fileDescriptor_bf10f51bd2cb5547, []int{8}
 }
 
 func (m *IntegrationValidateConfigReq) XXX_Unmarshal(b []byte) error {
@@ -459,7 +499,7 @@ func (m *IntegrationValidateConfigResp) Reset()         { *m = IntegrationValida
 func (m *IntegrationValidateConfigResp) String() string { return proto.CompactTextString(m) }
 func (*IntegrationValidateConfigResp) ProtoMessage()    {}
 func (*IntegrationValidateConfigResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{8}
+	return fileDescriptor_bf10f51bd2cb5547, []int{9}
 }
 
 func (m *IntegrationValidateConfigResp) XXX_Unmarshal(b []byte) error {
@@ -513,7 +553,7 @@ func (m *IntegrationOnboardExportReq) Reset()         { *m = IntegrationOnboardE
 func (m *IntegrationOnboardExportReq) String() string { return proto.CompactTextString(m) }
 func (*IntegrationOnboardExportReq) ProtoMessage()    {}
 func (*IntegrationOnboardExportReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{9}
+	return fileDescriptor_bf10f51bd2cb5547, []int{10}
 }
 
 func (m *IntegrationOnboardExportReq) XXX_Unmarshal(b []byte) error {
@@ -560,7 +600,7 @@ func (m *IntegrationOnboardExportResp) Reset()         { *m = IntegrationOnboard
 func (m *IntegrationOnboardExportResp) String() string { return proto.CompactTextString(m) }
 func (*IntegrationOnboardExportResp) ProtoMessage()    {}
 func (*IntegrationOnboardExportResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{10}
+	return fileDescriptor_bf10f51bd2cb5547, []int{11}
 }
 
 func (m *IntegrationOnboardExportResp) XXX_Unmarshal(b []byte) error {
@@ -608,7 +648,7 @@ func (m *IntegrationMutateReq) Reset()         { *m = IntegrationMutateReq{} }
 func (m *IntegrationMutateReq) String() string { return proto.CompactTextString(m) }
 func (*IntegrationMutateReq) ProtoMessage()    {}
 func (*IntegrationMutateReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{11}
+	return fileDescriptor_bf10f51bd2cb5547, []int{12}
 }
 
 func (m *IntegrationMutateReq) XXX_Unmarshal(b []byte) error {
@@ -661,7 +701,7 @@ func (m *IntegrationMutateResp) Reset()         { *m = IntegrationMutateResp{} }
 func (m *IntegrationMutateResp) String() string { return proto.CompactTextString(m) }
 func (*IntegrationMutateResp) ProtoMessage()    {}
 func (*IntegrationMutateResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{12}
+	return fileDescriptor_bf10f51bd2cb5547, []int{13}
 }
 
 func (m *IntegrationMutateResp) XXX_Unmarshal(b []byte) error {
@@ -689,6 +729,45 @@ func (m *IntegrationMutateResp) GetJson() string {
 	return ""
 }
 
+type GoroutineDumpResp struct {
+	Data                 []byte   `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GoroutineDumpResp) Reset()         { *m = GoroutineDumpResp{} }
+func (m *GoroutineDumpResp) String() string { return proto.CompactTextString(m) }
+func (*GoroutineDumpResp) ProtoMessage()    {}
+func (*GoroutineDumpResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf10f51bd2cb5547, []int{14}
+}
+
+func (m *GoroutineDumpResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GoroutineDumpResp.Unmarshal(m, b)
+}
+func (m *GoroutineDumpResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GoroutineDumpResp.Marshal(b, m, deterministic)
+}
+func (m *GoroutineDumpResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GoroutineDumpResp.Merge(m, src)
+}
+func (m *GoroutineDumpResp) XXX_Size() int {
+	return xxx_messageInfo_GoroutineDumpResp.Size(m)
+}
+func (m *GoroutineDumpResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_GoroutineDumpResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GoroutineDumpResp proto.InternalMessageInfo
+
+func (m *GoroutineDumpResp) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
 type LastProcessed struct {
 	DataStr              string   `protobuf:"bytes,1,opt,name=data_str,json=dataStr,proto3" json:"data_str,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -700,7 +779,7 @@ func (m *LastProcessed) Reset()         { *m = LastProcessed{} }
 func (m *LastProcessed) String() string { return proto.CompactTextString(m) }
 func (*LastProcessed) ProtoMessage()    {}
 func (*LastProcessed) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{13}
+	return fileDescriptor_bf10f51bd2cb5547, []int{15}
 }
 
 func (m *LastProcessed) XXX_Unmarshal(b []byte) error {
@@ -739,7 +818,7 @@ func (m *ExportStartedReq) Reset()         { *m = ExportStartedReq{} }
 func (m *ExportStartedReq) String() string { return proto.CompactTextString(m) }
 func (*ExportStartedReq) ProtoMessage()    {}
 func (*ExportStartedReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{14}
+	return fileDescriptor_bf10f51bd2cb5547, []int{16}
 }
 
 func (m *ExportStartedReq) XXX_Unmarshal(b []byte) error {
@@ -779,7 +858,7 @@ func (m *ExportStartedResp) Reset()         { *m = ExportStartedResp{} }
 func (m *ExportStartedResp) String() string { return proto.CompactTextString(m) }
 func (*ExportStartedResp) ProtoMessage()    {}
 func (*ExportStartedResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{15}
+	return fileDescriptor_bf10f51bd2cb5547, []int{17}
 }
 
 func (m *ExportStartedResp) XXX_Unmarshal(b []byte) error {
@@ -826,7 +905,7 @@ func (m *ExportDoneReq) Reset()         { *m = ExportDoneReq{} }
 func (m *ExportDoneReq) String() string { return proto.CompactTextString(m) }
 func (*ExportDoneReq) ProtoMessage()    {}
 func (*ExportDoneReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{16}
+	return fileDescriptor_bf10f51bd2cb5547, []int{18}
 }
 
 func (m *ExportDoneReq) XXX_Unmarshal(b []byte) error {
@@ -873,7 +952,7 @@ func (m *SendExportedReq) Reset()         { *m = SendExportedReq{} }
 func (m *SendExportedReq) String() string { return proto.CompactTextString(m) }
 func (*SendExportedReq) ProtoMessage()    {}
 func (*SendExportedReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{17}
+	return fileDescriptor_bf10f51bd2cb5547, []int{19}
 }
 
 func (m *SendExportedReq) XXX_Unmarshal(b []byte) error {
@@ -920,7 +999,7 @@ func (m *ExportObj) Reset()         { *m = ExportObj{} }
 func (m *ExportObj) String() string { return proto.CompactTextString(m) }
 func (*ExportObj) ProtoMessage()    {}
 func (*ExportObj) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{18}
+	return fileDescriptor_bf10f51bd2cb5547, []int{20}
 }
 
 func (m *ExportObj) XXX_Unmarshal(b []byte) error {
@@ -972,7 +1051,7 @@ func (m *ExportGitRepoReq) Reset()         { *m = ExportGitRepoReq{} }
 func (m *ExportGitRepoReq) String() string { return proto.CompactTextString(m) }
 func (*ExportGitRepoReq) ProtoMessage()    {}
 func (*ExportGitRepoReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{19}
+	return fileDescriptor_bf10f51bd2cb5547, []int{21}
 }
 
 func (m *ExportGitRepoReq) XXX_Unmarshal(b []byte) error {
@@ -1057,7 +1136,7 @@ func (m *ExportGitRepoPR) Reset()         { *m = ExportGitRepoPR{} }
 func (m *ExportGitRepoPR) String() string { return proto.CompactTextString(m) }
 func (*ExportGitRepoPR) ProtoMessage()    {}
 func (*ExportGitRepoPR) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{20}
+	return fileDescriptor_bf10f51bd2cb5547, []int{22}
 }
 
 func (m *ExportGitRepoPR) XXX_Unmarshal(b []byte) error {
@@ -1128,7 +1207,7 @@ func (m *SessionStartReq) Reset()         { *m = SessionStartReq{} }
 func (m *SessionStartReq) String() string { return proto.CompactTextString(m) }
 func (*SessionStartReq) ProtoMessage()    {}
 func (*SessionStartReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{21}
+	return fileDescriptor_bf10f51bd2cb5547, []int{23}
 }
 
 func (m *SessionStartReq) XXX_Unmarshal(b []byte) error {
@@ -1196,7 +1275,7 @@ func (m *SessionStartResp) Reset()         { *m = SessionStartResp{} }
 func (m *SessionStartResp) String() string { return proto.CompactTextString(m) }
 func (*SessionStartResp) ProtoMessage()    {}
 func (*SessionStartResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{22}
+	return fileDescriptor_bf10f51bd2cb5547, []int{24}
 }
 
 func (m *SessionStartResp) XXX_Unmarshal(b []byte) error {
@@ -1244,7 +1323,7 @@ func (m *SessionProgressReq) Reset()         { *m = SessionProgressReq{} }
 func (m *SessionProgressReq) String() string { return proto.CompactTextString(m) }
 func (*SessionProgressReq) ProtoMessage()    {}
 func (*SessionProgressReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{23}
+	return fileDescriptor_bf10f51bd2cb5547, []int{25}
 }
 
 func (m *SessionProgressReq) XXX_Unmarshal(b []byte) error {
@@ -1297,7 +1376,7 @@ func (m *SessionRollbackReq) Reset()         { *m = SessionRollbackReq{} }
 func (m *SessionRollbackReq) String() string { return proto.CompactTextString(m) }
 func (*SessionRollbackReq) ProtoMessage()    {}
 func (*SessionRollbackReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{24}
+	return fileDescriptor_bf10f51bd2cb5547, []int{26}
 }
 
 func (m *SessionRollbackReq) XXX_Unmarshal(b []byte) error {
@@ -1336,7 +1415,7 @@ func (m *OAuthNewAccessTokenResp) Reset()         { *m = OAuthNewAccessTokenResp
 func (m *OAuthNewAccessTokenResp) String() string { return proto.CompactTextString(m) }
 func (*OAuthNewAccessTokenResp) ProtoMessage()    {}
 func (*OAuthNewAccessTokenResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{25}
+	return fileDescriptor_bf10f51bd2cb5547, []int{27}
 }
 
 func (m *OAuthNewAccessTokenResp) XXX_Unmarshal(b []byte) error {
@@ -1376,7 +1455,7 @@ func (m *SendPauseEventReq) Reset()         { *m = SendPauseEventReq{} }
 func (m *SendPauseEventReq) String() string { return proto.CompactTextString(m) }
 func (*SendPauseEventReq) ProtoMessage()    {}
 func (*SendPauseEventReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{26}
+	return fileDescriptor_bf10f51bd2cb5547, []int{28}
 }
 
 func (m *SendPauseEventReq) XXX_Unmarshal(b []byte) error {
@@ -1422,7 +1501,7 @@ func (m *SendResumeEventReq) Reset()         { *m = SendResumeEventReq{} }
 func (m *SendResumeEventReq) String() string { return proto.CompactTextString(m) }
 func (*SendResumeEventReq) ProtoMessage()    {}
 func (*SendResumeEventReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bf10f51bd2cb5547, []int{27}
+	return fileDescriptor_bf10f51bd2cb5547, []int{29}
 }
 
 func (m *SendResumeEventReq) XXX_Unmarshal(b []byte) error {
@@ -1456,6 +1535,7 @@ func init() {
 	proto.RegisterEnum("proto.ExportObj_DataType", ExportObj_DataType_name, ExportObj_DataType_value)
 	proto.RegisterType((*Empty)(nil), "proto.Empty")
 	proto.RegisterType((*IntegrationInitReq)(nil), "proto.IntegrationInitReq")
+	proto.RegisterType((*IntegrationInitResp)(nil), "proto.IntegrationInitResp")
 	proto.RegisterType((*IntegrationExportReq)(nil), "proto.IntegrationExportReq")
 	proto.RegisterType((*IntegrationExportConfig)(nil), "proto.IntegrationExportConfig")
 	proto.RegisterType((*IntegrationAgentConfig)(nil), "proto.IntegrationAgentConfig")
@@ -1467,6 +1547,7 @@ func init() {
 	proto.RegisterType((*IntegrationOnboardExportResp)(nil), "proto.IntegrationOnboardExportResp")
 	proto.RegisterType((*IntegrationMutateReq)(nil), "proto.IntegrationMutateReq")
 	proto.RegisterType((*IntegrationMutateResp)(nil), "proto.IntegrationMutateResp")
+	proto.RegisterType((*GoroutineDumpResp)(nil), "proto.GoroutineDumpResp")
 	proto.RegisterType((*LastProcessed)(nil), "proto.LastProcessed")
 	proto.RegisterType((*ExportStartedReq)(nil), "proto.ExportStartedReq")
 	proto.RegisterType((*ExportStartedResp)(nil), "proto.ExportStartedResp")
@@ -1487,98 +1568,102 @@ func init() {
 func init() { proto.RegisterFile("defs.proto", fileDescriptor_bf10f51bd2cb5547) }
 
 var fileDescriptor_bf10f51bd2cb5547 = []byte{
-	// 1445 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x56, 0xcd, 0x72, 0x1a, 0xc7,
-	0x16, 0x36, 0x0c, 0x20, 0x38, 0x08, 0x84, 0xda, 0xb2, 0x85, 0x91, 0x7d, 0xad, 0x6a, 0xeb, 0xde,
-	0xab, 0xeb, 0x9b, 0xc2, 0xb1, 0x94, 0x52, 0xc5, 0xf6, 0xc2, 0x71, 0x64, 0xec, 0xc2, 0x4e, 0x80,
-	0x1a, 0x90, 0xe2, 0xaa, 0x2c, 0xa8, 0x86, 0x69, 0xa4, 0x91, 0x60, 0x66, 0xdc, 0xdd, 0x38, 0x51,
-	0x55, 0x76, 0xd9, 0x66, 0x97, 0x37, 0xc8, 0x13, 0xe4, 0x39, 0xb2, 0xc8, 0x2b, 0xe4, 0x51, 0x92,
-	0xea, 0x9f, 0x81, 0x99, 0x01, 0xc9, 0xae, 0x72, 0xb2, 0x9a, 0x39, 0xa7, 0xcf, 0xe9, 0xf3, 0xd3,
-	0xdf, 0xf9, 0x01, 0x70, 0xe8, 0x88, 0xd7, 0x03, 0xe6, 0x0b, 0x1f, 0x65, 0xd5, 0x07, 0xaf, 0x40,
-	0xb6, 0x31, 0x09, 0xc4, 0x05, 0x7e, 0x08, 0xa8, 0xe9, 0x09, 0x7a, 0xc2, 0x88, 0x70, 0x7d, 0xaf,
-	0xe9, 0xb9, 0xc2, 0xa6, 0x6f, 0xd1, 0x16, 0x14, 0x38, 0x65, 0xef, 0x28, 0xeb, 0xbb, 0x4e, 0x35,
-	0xb5, 0x9d, 0xda, 0x2d, 0xd9, 0x79, 0xcd, 0x68, 0x3a, 0xb8, 0x05, 0x1b, 0x11, 0x95, 0xc6, 0xf7,
-	0x81, 0xcf, 0x94, 0xd2, 0x01, 0xe4, 0x86, 0xbe, 0x37, 0x72, 0x4f, 0x94, 0x46, 0x71, 0xef, 0x5f,
-	0xda, 0x64, 0x7d, 0x41, 0xf8, 0x50, 0x49, 0xd9, 0x46, 0x1a, 0xff, 0x9a, 0x82, 0xcd, 0x4b, 0x64,
-	0xd0, 0x01, 0x6c, 0xba, 0xf3, 0xa3, 0xbe, 0xd6, 0xe8, 0x9f, 0x71, 0xdf, 0x53, 0x46, 0x56, 0xed,
-	0x1b, 0x91, 0x63, 0xad, 0xf3, 0x8a, 0xfb, 0x1e, 0xfa, 0x02, 0x56, 0xc9, 0x09, 0xf5, 0x84, 0xd1,
-	0xa8, 0xa6, 0x95, 0x47, 0x77, 0x16, 0x3d, 0x7a, 0x26, 0xa5, 0x8c, 0x43, 0x45, 0x32, 0x27, 0x64,
-	0x0a, 0xa6, 0x9c, 0xf6, 0x7d, 0x32, 0x15, 0xa7, 0x55, 0x6b, 0x3b, 0xb5, 0x9b, 0xb7, 0xf3, 0x53,
-	0x4e, 0xdb, 0x92, 0xc6, 0x8f, 0xe0, 0xe6, 0xf2, 0x3b, 0xd0, 0x5d, 0x28, 0x0e, 0xa7, 0x5c, 0xf8,
-	0x93, 0x79, 0xee, 0x0a, 0x36, 0x84, 0xac, 0xa6, 0x83, 0xdf, 0xc0, 0x8d, 0x25, 0xd9, 0xe3, 0x01,
-	0x7a, 0x0a, 0xf9, 0x80, 0xf9, 0x67, 0x74, 0x28, 0x78, 0xd5, 0xda, 0xb6, 0x76, 0x8b, 0x7b, 0xf7,
-	0x2e, 0x4b, 0xa0, 0x94, 0xef, 0x68, 0x59, 0x7b, 0xa6, 0x84, 0x7f, 0x80, 0xdb, 0x57, 0x49, 0xa2,
-	0x32, 0xa4, 0x67, 0x1e, 0xa5, 0x5d, 0x07, 0xdd, 0x80, 0x1c, 0xa3, 0x23, 0xe9, 0x65, 0x5a, 0xf1,
-	0xb2, 0x8c, 0x8e, 0x9a, 0x8e, 0x8c, 0x80, 0x51, 0xe2, 0x90, 0xc1, 0x98, 0xca, 0x33, 0x4b, 0x47,
-	0x10, 0xb2, 0x9a, 0x0e, 0xda, 0x80, 0x2c, 0x65, 0xcc, 0x67, 0xd5, 0x8c, 0x56, 0x53, 0x04, 0x3e,
-	0x8e, 0x59, 0x3f, 0x26, 0x63, 0xd7, 0x21, 0x82, 0x9a, 0xcc, 0x7e, 0x04, 0x3a, 0x2e, 0xe0, 0xce,
-	0x15, 0xf7, 0xf2, 0x00, 0xdd, 0x84, 0x9c, 0xf2, 0x80, 0x57, 0x53, 0xdb, 0xd6, 0x6e, 0xc1, 0x36,
-	0x14, 0xba, 0x05, 0x79, 0x46, 0x03, 0xbf, 0x3f, 0x65, 0x63, 0x13, 0xe0, 0x8a, 0xa4, 0x8f, 0xd8,
-	0x18, 0xfd, 0x1b, 0xca, 0x06, 0xde, 0xef, 0x28, 0xe3, 0xae, 0xef, 0x99, 0x28, 0x4b, 0x9a, 0x7b,
-	0xac, 0x99, 0xf8, 0xf7, 0x14, 0x6c, 0x45, 0x6c, 0xb7, 0xbd, 0x81, 0x4f, 0x98, 0xf3, 0xd1, 0x80,
-	0x47, 0x4f, 0x20, 0x73, 0xee, 0x7a, 0x3a, 0xed, 0xe5, 0xbd, 0xff, 0x2e, 0x6a, 0x25, 0x2d, 0xd5,
-	0x5f, 0xbb, 0x9e, 0x63, 0x2b, 0x25, 0xfc, 0x18, 0x32, 0x92, 0x42, 0x05, 0xc8, 0x1e, 0x75, 0x1b,
-	0x76, 0xb7, 0x72, 0x4d, 0xfe, 0xda, 0x8d, 0x4e, 0xbb, 0x5b, 0x49, 0xa1, 0x55, 0xc8, 0x77, 0xec,
-	0xf6, 0xab, 0xc6, 0x61, 0xaf, 0x5b, 0x49, 0xa3, 0x32, 0xc0, 0x37, 0x6d, 0xfb, 0xf5, 0x61, 0xbb,
-	0xf5, 0xa2, 0xf9, 0xb2, 0x62, 0xe1, 0x5f, 0x52, 0xb1, 0x47, 0x4a, 0x98, 0x51, 0x18, 0x34, 0x4f,
-	0x9b, 0x52, 0xae, 0xfd, 0xef, 0xbd, 0xae, 0xf1, 0xa0, 0xde, 0x90, 0x0a, 0x06, 0x05, 0xb2, 0x6a,
-	0x1c, 0x22, 0x88, 0xae, 0xd0, 0xb4, 0xaa, 0xd0, 0xbc, 0x64, 0xc8, 0xa2, 0xc4, 0x3b, 0x90, 0x55,
-	0xc2, 0x28, 0x0f, 0x99, 0x56, 0xbb, 0xd5, 0xa8, 0x5c, 0x43, 0xeb, 0x50, 0x6a, 0xb5, 0x7b, 0xfd,
-	0xee, 0x51, 0xa7, 0xd3, 0xb6, 0x7b, 0x8d, 0xe7, 0x95, 0x14, 0xfe, 0x29, 0x15, 0xeb, 0x2f, 0x5f,
-	0x4f, 0x05, 0x11, 0xf4, 0x63, 0xd2, 0xbd, 0x05, 0x85, 0x89, 0xba, 0xa4, 0x3f, 0xf2, 0x0c, 0x12,
-	0xf2, 0x9a, 0xf1, 0xc2, 0x93, 0x68, 0x37, 0x87, 0xd2, 0xcd, 0x10, 0xed, 0x9a, 0xf5, 0x9c, 0x08,
-	0x82, 0xff, 0x1f, 0xab, 0xd7, 0xd0, 0x1b, 0x1e, 0x20, 0x04, 0x99, 0x59, 0x1f, 0x2a, 0xd8, 0xea,
-	0x1f, 0xdf, 0x87, 0xd2, 0x57, 0x84, 0x8b, 0x0e, 0xf3, 0x87, 0x94, 0x73, 0xea, 0x48, 0x10, 0xaa,
-	0x7c, 0x70, 0xc1, 0x8c, 0xe0, 0x8a, 0xa4, 0xbb, 0x82, 0xe1, 0x87, 0x50, 0xd1, 0xee, 0x76, 0x05,
-	0x61, 0x82, 0x3a, 0x32, 0xc4, 0x3b, 0x00, 0x13, 0xdf, 0xa1, 0xe3, 0xbe, 0xb8, 0x08, 0xa8, 0x51,
-	0x28, 0x28, 0x4e, 0xef, 0x22, 0xa0, 0xd8, 0x87, 0xf5, 0x84, 0x0a, 0x0f, 0xa4, 0x0e, 0xa7, 0x5c,
-	0x02, 0x76, 0xde, 0x70, 0x0a, 0x86, 0xd3, 0x74, 0xd0, 0x13, 0x28, 0x8f, 0x09, 0x17, 0xfd, 0x20,
-	0xf4, 0xc9, 0xf4, 0xc2, 0x0d, 0x93, 0xbd, 0x98, 0xbf, 0x76, 0x69, 0x1c, 0x25, 0xf1, 0x39, 0x94,
-	0xb4, 0xc1, 0xe7, 0xbe, 0x47, 0x8d, 0x83, 0xff, 0x98, 0xb1, 0x63, 0x58, 0xeb, 0x52, 0xcf, 0x40,
-	0x6b, 0x96, 0x8f, 0xab, 0xcc, 0xed, 0x40, 0xc6, 0x1f, 0x9c, 0x85, 0xed, 0xb2, 0x62, 0x8c, 0xe8,
-	0x0b, 0xda, 0x83, 0x33, 0x5b, 0x9d, 0xe2, 0x09, 0x14, 0x66, 0x2c, 0x74, 0x60, 0x00, 0x3a, 0x4b,
-	0x70, 0x79, 0xef, 0x56, 0x52, 0xaf, 0x2e, 0x1f, 0x5e, 0x26, 0x5c, 0x63, 0x57, 0xfe, 0xc9, 0xd7,
-	0x56, 0x00, 0xd1, 0x98, 0x56, 0xff, 0x78, 0x03, 0xf2, 0xa1, 0xa4, 0x84, 0xf4, 0xab, 0x6e, 0xbb,
-	0x55, 0xb9, 0x86, 0x7f, 0x4c, 0x87, 0x0f, 0xfb, 0x52, 0x0e, 0xd3, 0xc0, 0x97, 0x81, 0x6c, 0x82,
-	0x6a, 0x3e, 0xf3, 0x28, 0x72, 0x92, 0xd4, 0xdd, 0x76, 0xea, 0xb9, 0x6f, 0xa7, 0xb4, 0xef, 0x91,
-	0x09, 0x35, 0xf0, 0x04, 0xcd, 0x6a, 0x91, 0x09, 0xd5, 0x6d, 0x6c, 0xa4, 0xfd, 0xb5, 0xc2, 0x36,
-	0x36, 0x52, 0x36, 0x2b, 0x60, 0xc9, 0xe6, 0xa6, 0xdb, 0xb0, 0xfc, 0x45, 0x75, 0xb8, 0x3e, 0xf4,
-	0x27, 0x13, 0x57, 0xc8, 0xae, 0xd7, 0x17, 0x74, 0x12, 0x8c, 0x89, 0xa0, 0xd5, 0xac, 0x92, 0x58,
-	0xd7, 0x47, 0x47, 0x6c, 0xdc, 0x33, 0x07, 0x52, 0x7e, 0xc0, 0x88, 0x37, 0x3c, 0x8d, 0xcb, 0xe7,
-	0xb4, 0xbc, 0x3e, 0x8a, 0xca, 0xef, 0x82, 0x15, 0x30, 0x5e, 0x5d, 0x51, 0xf9, 0xbe, 0x19, 0xcb,
-	0x9b, 0x09, 0xb6, 0x63, 0xdb, 0x52, 0x04, 0xff, 0x9c, 0x82, 0xb5, 0xc4, 0xc1, 0x87, 0x0e, 0x20,
-	0x13, 0x96, 0x35, 0x0f, 0xeb, 0x2e, 0x14, 0x8d, 0x9b, 0x2a, 0x49, 0x3a, 0x60, 0xd0, 0x2c, 0x95,
-	0xa4, 0xff, 0xc0, 0x9a, 0xc2, 0x9d, 0x09, 0x9e, 0x9f, 0x12, 0x13, 0xb3, 0x82, 0xd8, 0xa1, 0xe2,
-	0x76, 0x4f, 0x09, 0xfe, 0x2d, 0x25, 0x31, 0xa6, 0xe0, 0xa3, 0x4a, 0x48, 0x3e, 0xcd, 0x5d, 0x28,
-	0xba, 0xbc, 0x2f, 0x18, 0x19, 0x9e, 0xbb, 0x9e, 0xee, 0x2d, 0x79, 0x1b, 0x5c, 0xde, 0x33, 0x1c,
-	0xf9, 0xf4, 0x91, 0xb7, 0x51, 0xff, 0xe8, 0x3e, 0xac, 0x07, 0x84, 0xc9, 0x05, 0x23, 0x82, 0x4f,
-	0xe9, 0xb1, 0x65, 0xaf, 0xe9, 0x83, 0xee, 0x0c, 0xa5, 0xbb, 0x50, 0x31, 0xb2, 0xfe, 0x40, 0x0e,
-	0x62, 0x29, 0xaa, 0x43, 0x28, 0x6b, 0x7e, 0x5b, 0xb1, 0x9b, 0x0e, 0xfa, 0x04, 0x50, 0x5c, 0x52,
-	0xd9, 0xd5, 0x91, 0x54, 0xa2, 0xb2, 0x32, 0x68, 0xec, 0x41, 0x25, 0x1e, 0xcb, 0xd2, 0x66, 0x60,
-	0xfd, 0x6d, 0xf5, 0xd9, 0x03, 0x64, 0xec, 0x75, 0x98, 0x7f, 0xc2, 0x28, 0xe7, 0x32, 0x7d, 0xf3,
-	0x47, 0xb5, 0xd4, 0xa3, 0x56, 0x61, 0x65, 0x38, 0x65, 0xd2, 0x55, 0x75, 0xb7, 0x65, 0x87, 0xa4,
-	0xdc, 0x1b, 0x84, 0x2f, 0xc8, 0xd8, 0xe4, 0x49, 0x13, 0x78, 0x67, 0x76, 0xab, 0xed, 0x8f, 0xc7,
-	0x03, 0x32, 0x3c, 0x5f, 0x72, 0x2b, 0x7e, 0x00, 0x9b, 0xed, 0x67, 0x53, 0x71, 0xda, 0xa2, 0xdf,
-	0x3d, 0x1b, 0x4a, 0x7f, 0x7a, 0xfe, 0x39, 0xf5, 0x54, 0xc8, 0xea, 0xda, 0x73, 0x1a, 0x36, 0x62,
-	0x4d, 0xe0, 0x97, 0xb0, 0x2e, 0x9b, 0x49, 0x87, 0x4c, 0x39, 0x6d, 0xbc, 0xa3, 0x9e, 0x7a, 0xea,
-	0x2a, 0xac, 0x4c, 0x28, 0xe7, 0xe4, 0x24, 0xec, 0xad, 0x21, 0x29, 0x4f, 0xd8, 0x68, 0xb8, 0xbf,
-	0xbf, 0xff, 0x68, 0xb6, 0x2b, 0x68, 0x12, 0xd7, 0xa5, 0x7f, 0x9e, 0x6c, 0xb5, 0xd3, 0xc9, 0x07,
-	0xdc, 0xb4, 0xf7, 0x67, 0x1a, 0x8a, 0x91, 0x81, 0x81, 0x1e, 0x40, 0x46, 0x6e, 0xd5, 0xe8, 0xd6,
-	0xe2, 0xb4, 0x32, 0xdb, 0x76, 0x6d, 0x35, 0x2c, 0x24, 0xb9, 0x91, 0xa3, 0x43, 0xc8, 0xe9, 0xc2,
-	0x41, 0x5b, 0x97, 0xef, 0x7f, 0x6f, 0x6b, 0xb7, 0xaf, 0x5a, 0x0e, 0xd1, 0xb7, 0x50, 0x8e, 0xaf,
-	0x4a, 0x68, 0xc9, 0x32, 0xb9, 0xb0, 0xa4, 0xd5, 0x76, 0xde, 0x2f, 0xc4, 0x03, 0xf4, 0x06, 0x4a,
-	0xb1, 0x35, 0x00, 0xe1, 0xf7, 0xaf, 0x30, 0xb5, 0x7b, 0x1f, 0xb0, 0x4b, 0xc8, 0xd8, 0xf5, 0x84,
-	0x5d, 0x16, 0xfb, 0x6c, 0x13, 0x58, 0x16, 0xfb, 0x7c, 0x30, 0xef, 0xfd, 0x91, 0x81, 0xac, 0x5a,
-	0xc9, 0xd1, 0x97, 0xe1, 0xf8, 0x32, 0xf3, 0x12, 0x6d, 0xc6, 0x5a, 0xd6, 0x7c, 0xf0, 0xd6, 0xaa,
-	0xcb, 0x0f, 0x78, 0x80, 0x3e, 0x05, 0x98, 0x8f, 0x40, 0xb4, 0x11, 0x93, 0x33, 0x53, 0x31, 0xf1,
-	0x80, 0x9f, 0xc1, 0x6a, 0x74, 0x8e, 0xa1, 0xb0, 0x4f, 0x26, 0x86, 0x5b, 0x42, 0xeb, 0x20, 0xf4,
-	0xd5, 0xf4, 0xcb, 0x84, 0xaf, 0xf3, 0x59, 0x92, 0xd0, 0x7b, 0x2a, 0xad, 0xcd, 0xbb, 0x40, 0xc4,
-	0x5a, 0xac, 0xcd, 0xd5, 0x36, 0x97, 0xf2, 0x79, 0x80, 0x1e, 0xcf, 0x5a, 0x62, 0x58, 0xd6, 0x33,
-	0xac, 0x2e, 0x96, 0x7b, 0xc2, 0xf8, 0x5c, 0x37, 0x2c, 0xde, 0xa4, 0x6e, 0xa4, 0xa8, 0x17, 0x70,
-	0x7e, 0x7d, 0x49, 0x49, 0xa3, 0x98, 0x50, 0x2d, 0xdc, 0xf1, 0x2e, 0x2b, 0xfe, 0xcf, 0xa1, 0x1c,
-	0x2f, 0x73, 0x54, 0x8d, 0x64, 0x3b, 0x56, 0xfd, 0xcb, 0x5c, 0x8f, 0xd5, 0x75, 0xc4, 0xf5, 0x64,
-	0xbd, 0xc7, 0x75, 0x07, 0x39, 0x45, 0xec, 0xff, 0x15, 0x00, 0x00, 0xff, 0xff, 0x61, 0x59, 0xb1,
-	0xc3, 0x59, 0x0f, 0x00, 0x00,
+	// 1515 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x57, 0x4f, 0x6f, 0x1b, 0x45,
+	0x14, 0xaf, 0xbd, 0x71, 0x62, 0xbf, 0xc4, 0x8e, 0x33, 0x4d, 0x1a, 0xd7, 0x49, 0x69, 0x34, 0x0d,
+	0x34, 0xb4, 0x28, 0xd0, 0x04, 0x05, 0xda, 0x0a, 0x95, 0x36, 0x71, 0x83, 0x5b, 0xb0, 0xad, 0xb5,
+	0x13, 0x2a, 0x71, 0xb0, 0xc6, 0xde, 0x71, 0xb2, 0x89, 0xbd, 0xbb, 0x9d, 0x19, 0x17, 0x22, 0x71,
+	0xe3, 0xc2, 0x81, 0x1b, 0xdf, 0x80, 0x4f, 0xc0, 0xa7, 0xe0, 0xc0, 0x81, 0xcf, 0x84, 0xe6, 0xcf,
+	0xda, 0xbb, 0x6b, 0x27, 0xad, 0x54, 0x38, 0x79, 0xdf, 0xbf, 0x79, 0x7f, 0xe6, 0xcd, 0xef, 0x3d,
+	0x03, 0x38, 0xb4, 0xc7, 0xb7, 0x03, 0xe6, 0x0b, 0x1f, 0x65, 0xd4, 0x0f, 0x9e, 0x83, 0x4c, 0x65,
+	0x10, 0x88, 0x0b, 0xfc, 0x00, 0x50, 0xd5, 0x13, 0xf4, 0x84, 0x11, 0xe1, 0xfa, 0x5e, 0xd5, 0x73,
+	0x85, 0x4d, 0x5f, 0xa3, 0x35, 0xc8, 0x71, 0xca, 0xde, 0x50, 0xd6, 0x76, 0x9d, 0x52, 0x6a, 0x23,
+	0xb5, 0x95, 0xb7, 0xb3, 0x9a, 0x51, 0x75, 0xf0, 0x33, 0xb8, 0x3e, 0x61, 0xc2, 0x03, 0x74, 0x1f,
+	0x96, 0xba, 0x24, 0x20, 0x1d, 0xb7, 0xef, 0x0a, 0x97, 0xf2, 0xf6, 0x19, 0xf7, 0x3d, 0x65, 0xbb,
+	0x60, 0x17, 0xa3, 0x82, 0x17, 0xdc, 0xf7, 0x70, 0x0d, 0x96, 0x23, 0x67, 0x54, 0x7e, 0x0a, 0x7c,
+	0xa6, 0x1c, 0xef, 0xc1, 0x6c, 0xd7, 0xf7, 0x7a, 0xee, 0x89, 0xb2, 0x9c, 0xdf, 0xf9, 0x40, 0x87,
+	0xbd, 0x3d, 0xa1, 0xbc, 0xaf, 0xb4, 0x6c, 0xa3, 0x8d, 0xff, 0x4c, 0xc1, 0xea, 0x25, 0x3a, 0x68,
+	0x0f, 0x56, 0xdd, 0xb1, 0xa8, 0xad, 0x2d, 0xa2, 0xe1, 0xad, 0x44, 0xc4, 0xda, 0x46, 0xc6, 0x88,
+	0xbe, 0x86, 0x05, 0x72, 0x42, 0x3d, 0x61, 0x2c, 0x4a, 0x69, 0x15, 0xd1, 0xad, 0xc9, 0x88, 0x9e,
+	0x4a, 0x2d, 0x13, 0xd0, 0x3c, 0x19, 0x13, 0xb2, 0x8c, 0x43, 0x4e, 0xdb, 0x3e, 0x19, 0x8a, 0xd3,
+	0x92, 0xb5, 0x91, 0xda, 0xca, 0xda, 0xd9, 0x21, 0xa7, 0x75, 0x49, 0xe3, 0x87, 0x70, 0x63, 0xfa,
+	0x19, 0xe8, 0x36, 0xcc, 0x77, 0x87, 0x5c, 0xf8, 0x83, 0x71, 0xfd, 0x73, 0x36, 0x84, 0xac, 0xaa,
+	0x83, 0x5f, 0xc1, 0xca, 0x94, 0xea, 0xf1, 0x00, 0x3d, 0x81, 0x6c, 0xc0, 0xfc, 0x33, 0xda, 0x15,
+	0xbc, 0x64, 0x6d, 0x58, 0x5b, 0xf3, 0x3b, 0x77, 0x2e, 0x2b, 0xa0, 0xd4, 0x6f, 0x68, 0x5d, 0x7b,
+	0x64, 0x84, 0x7f, 0x86, 0xf5, 0xab, 0x34, 0x51, 0x01, 0xd2, 0xa3, 0x88, 0xd2, 0xae, 0x83, 0x56,
+	0x60, 0x96, 0xd1, 0x9e, 0x8c, 0x32, 0xad, 0x78, 0x19, 0x46, 0x7b, 0x55, 0x47, 0x66, 0xc0, 0x28,
+	0x71, 0x48, 0xa7, 0x4f, 0xa5, 0xcc, 0xd2, 0x19, 0x84, 0xac, 0xaa, 0x83, 0x96, 0x21, 0x43, 0x19,
+	0xf3, 0x59, 0x69, 0x46, 0x9b, 0x29, 0x02, 0x1f, 0xc7, 0xbc, 0x1f, 0x93, 0xbe, 0xeb, 0x10, 0x41,
+	0x4d, 0x65, 0xdf, 0xa3, 0x3b, 0x2e, 0xe0, 0xd6, 0x15, 0xe7, 0xf2, 0x00, 0xdd, 0x80, 0x59, 0x15,
+	0x01, 0x2f, 0xa5, 0x36, 0xac, 0xad, 0x9c, 0x6d, 0x28, 0x74, 0x13, 0xb2, 0x8c, 0x06, 0x7e, 0x7b,
+	0xc8, 0xfa, 0x26, 0xc1, 0x39, 0x49, 0x1f, 0xb1, 0x3e, 0xfa, 0x10, 0x0a, 0xe6, 0x89, 0xbc, 0xa1,
+	0x8c, 0xbb, 0xbe, 0x67, 0xb2, 0xcc, 0x6b, 0xee, 0xb1, 0x66, 0xe2, 0x7f, 0x52, 0xb0, 0x16, 0xf1,
+	0x5d, 0xf7, 0x3a, 0x3e, 0x61, 0xce, 0x7b, 0x37, 0x3c, 0x7a, 0x0c, 0x33, 0xe7, 0xae, 0xa7, 0xcb,
+	0x5e, 0xd8, 0xb9, 0x3b, 0x69, 0x95, 0xf4, 0xb4, 0xfd, 0xd2, 0xf5, 0x1c, 0x5b, 0x19, 0xe1, 0x47,
+	0x30, 0x23, 0x29, 0x94, 0x83, 0xcc, 0x51, 0xb3, 0x62, 0x37, 0x8b, 0xd7, 0xe4, 0xa7, 0x5d, 0x69,
+	0xd4, 0x9b, 0xc5, 0x14, 0x5a, 0x80, 0x6c, 0xc3, 0xae, 0xbf, 0xa8, 0xec, 0xb7, 0x9a, 0xc5, 0x34,
+	0x2a, 0x00, 0x7c, 0x5f, 0xb7, 0x5f, 0xee, 0xd7, 0x6b, 0xcf, 0xab, 0x87, 0x45, 0x0b, 0xff, 0x91,
+	0x82, 0xf5, 0xcb, 0xdd, 0xa8, 0x1e, 0x34, 0x57, 0x9b, 0x52, 0xa1, 0x7d, 0xfc, 0xd6, 0xd0, 0x78,
+	0xb0, 0x5d, 0x91, 0x06, 0xa6, 0x0b, 0xe4, 0xab, 0x71, 0x88, 0x20, 0xfa, 0x85, 0xa6, 0xd5, 0x0b,
+	0xcd, 0x4a, 0x86, 0x02, 0x8e, 0x4d, 0xc8, 0x28, 0x65, 0x94, 0x85, 0x99, 0x5a, 0xbd, 0x56, 0x29,
+	0x5e, 0x43, 0x4b, 0x90, 0xaf, 0xd5, 0x5b, 0xed, 0xe6, 0x51, 0xa3, 0x51, 0xb7, 0x5b, 0x95, 0x83,
+	0x62, 0x0a, 0xff, 0x96, 0x8a, 0xe1, 0xcb, 0x77, 0x43, 0x41, 0x04, 0x7d, 0x9f, 0x72, 0xaf, 0x41,
+	0x6e, 0xa0, 0x0e, 0x69, 0xf7, 0x3c, 0xd3, 0x09, 0x59, 0xcd, 0x78, 0xee, 0xc9, 0x6e, 0x37, 0x42,
+	0x19, 0x66, 0xd8, 0xed, 0x9a, 0x75, 0x40, 0x04, 0xc1, 0xf7, 0x61, 0x65, 0x4a, 0x34, 0x3c, 0x40,
+	0x08, 0x66, 0x46, 0x38, 0x94, 0xb3, 0xd5, 0x37, 0xbe, 0x0b, 0x4b, 0x87, 0x3e, 0xf3, 0x87, 0xc2,
+	0xf5, 0xe8, 0xc1, 0x70, 0x10, 0x84, 0x8a, 0xea, 0x6c, 0x0d, 0x58, 0xea, 0x1b, 0xdf, 0x83, 0xfc,
+	0xb7, 0x84, 0x8b, 0x06, 0xf3, 0xbb, 0x94, 0x73, 0xea, 0xc8, 0x6e, 0x55, 0x85, 0xe3, 0x82, 0x99,
+	0x13, 0xe7, 0x24, 0xdd, 0x14, 0x0c, 0x3f, 0x80, 0xa2, 0xce, 0xab, 0x29, 0x08, 0x13, 0xd4, 0x91,
+	0xb5, 0xb8, 0x05, 0x30, 0xf0, 0x1d, 0xda, 0x6f, 0x8b, 0x8b, 0x80, 0x1a, 0x83, 0x9c, 0xe2, 0xb4,
+	0x2e, 0x02, 0x8a, 0x7d, 0x58, 0x4a, 0x98, 0xf0, 0x40, 0xda, 0x70, 0xca, 0x65, 0x67, 0x8f, 0x91,
+	0x29, 0x67, 0x38, 0x55, 0x07, 0x3d, 0x86, 0x42, 0x9f, 0x70, 0xd1, 0x0e, 0xc2, 0x98, 0x0c, 0x68,
+	0x2e, 0x9b, 0x32, 0xc7, 0xe2, 0xb5, 0xf3, 0xfd, 0x28, 0x89, 0xcf, 0x21, 0xaf, 0x1d, 0x1e, 0xf8,
+	0x1e, 0x35, 0x01, 0xfe, 0x6f, 0xce, 0x8e, 0x61, 0xb1, 0x49, 0x3d, 0xd3, 0x83, 0xa3, 0x7a, 0x5c,
+	0xe5, 0x6e, 0x13, 0x66, 0xfc, 0xce, 0x59, 0x88, 0xab, 0x45, 0xe3, 0x44, 0x1f, 0x50, 0xef, 0x9c,
+	0xd9, 0x4a, 0x8a, 0x07, 0x90, 0x1b, 0xb1, 0xd0, 0x9e, 0xe9, 0xe4, 0x51, 0x81, 0x0b, 0x3b, 0x37,
+	0x93, 0x76, 0xdb, 0xb2, 0x43, 0x64, 0xc1, 0x75, 0x93, 0xcb, 0xaf, 0xd1, 0x6d, 0xa7, 0x23, 0xb7,
+	0xbd, 0x0c, 0xd9, 0x50, 0x53, 0xf6, 0xfe, 0x8b, 0x66, 0xbd, 0x56, 0xbc, 0x86, 0x7f, 0x49, 0x87,
+	0x17, 0x7b, 0x28, 0xc7, 0x70, 0xe0, 0xcb, 0x44, 0x56, 0x41, 0xa1, 0xd4, 0x38, 0x8b, 0x59, 0x49,
+	0x6a, 0x58, 0x1e, 0x7a, 0xee, 0xeb, 0x21, 0x6d, 0x7b, 0x64, 0x40, 0x4d, 0x1f, 0x83, 0x66, 0xd5,
+	0xc8, 0x80, 0x6a, 0xbc, 0xeb, 0xe9, 0x78, 0xad, 0x10, 0xef, 0x7a, 0xca, 0x67, 0x11, 0x2c, 0x89,
+	0x82, 0x1a, 0xaf, 0xe5, 0x27, 0xda, 0x86, 0xeb, 0x5d, 0x7f, 0x30, 0x70, 0x85, 0x84, 0xc7, 0xb6,
+	0xa0, 0x83, 0xa0, 0x4f, 0x04, 0x2d, 0x65, 0x94, 0xc6, 0x92, 0x16, 0x1d, 0xb1, 0x7e, 0xcb, 0x08,
+	0xa4, 0x7e, 0x87, 0x11, 0xaf, 0x7b, 0x1a, 0xd7, 0x9f, 0xd5, 0xfa, 0x5a, 0x14, 0xd5, 0xdf, 0x02,
+	0x2b, 0x60, 0xbc, 0x34, 0xa7, 0xea, 0x7d, 0x23, 0x56, 0x37, 0x93, 0x6c, 0xc3, 0xb6, 0xa5, 0x0a,
+	0xfe, 0x3d, 0x05, 0x8b, 0x09, 0xc1, 0xbb, 0x4e, 0x2a, 0x93, 0x96, 0x35, 0x4e, 0xeb, 0x36, 0xcc,
+	0x9b, 0x30, 0x55, 0x91, 0x74, 0xc2, 0xa0, 0x59, 0xaa, 0x48, 0x1f, 0xc1, 0xa2, 0xea, 0x3b, 0x93,
+	0x3c, 0x3f, 0x25, 0x26, 0x67, 0xd5, 0x62, 0xfb, 0x8a, 0xdb, 0x3c, 0x25, 0xf8, 0xef, 0x94, 0xec,
+	0x31, 0xd5, 0x3e, 0xea, 0x09, 0xc9, 0xab, 0xb9, 0x0d, 0xf3, 0x2e, 0x6f, 0x0b, 0x46, 0xba, 0xe7,
+	0xae, 0xa7, 0x41, 0x28, 0x6b, 0x83, 0xcb, 0x5b, 0x86, 0x23, 0xaf, 0x3e, 0x72, 0x37, 0xea, 0x1b,
+	0xdd, 0x83, 0xa5, 0x80, 0x30, 0xb9, 0x89, 0x44, 0xfa, 0x53, 0x46, 0x6c, 0xd9, 0x8b, 0x5a, 0xd0,
+	0x1c, 0x75, 0xe9, 0x16, 0x14, 0x8d, 0xae, 0xdf, 0x91, 0x13, 0x5b, 0xaa, 0xea, 0x14, 0x0a, 0x9a,
+	0x5f, 0x57, 0xec, 0xaa, 0x83, 0x3e, 0x01, 0x14, 0xd7, 0x54, 0x7e, 0x75, 0x26, 0xc5, 0xa8, 0xae,
+	0x4c, 0x1a, 0x7b, 0x50, 0x8c, 0xe7, 0x32, 0x15, 0x0c, 0xac, 0xff, 0xec, 0x7d, 0xb6, 0x00, 0x19,
+	0x7f, 0x0d, 0xe6, 0x9f, 0x30, 0xca, 0xb9, 0x2c, 0xdf, 0xf8, 0x52, 0x2d, 0x75, 0xa9, 0x25, 0x98,
+	0xeb, 0x0e, 0x99, 0x0c, 0x55, 0x9d, 0x6d, 0xd9, 0x21, 0x29, 0x17, 0x0c, 0xe1, 0x0b, 0xd2, 0x37,
+	0x75, 0xd2, 0x04, 0xde, 0x1c, 0x9d, 0x6a, 0xfb, 0xfd, 0x7e, 0x87, 0x74, 0xcf, 0xa7, 0x9c, 0x8a,
+	0x3f, 0x85, 0xd5, 0xfa, 0xd3, 0xa1, 0x38, 0xad, 0xd1, 0x1f, 0x9f, 0x76, 0x65, 0x3c, 0x2d, 0xff,
+	0x9c, 0x7a, 0x2a, 0x65, 0x75, 0xec, 0x39, 0x0d, 0x11, 0x5b, 0x13, 0xf8, 0x10, 0x96, 0x24, 0x98,
+	0x34, 0xc8, 0x90, 0xd3, 0xca, 0x1b, 0xea, 0xa9, 0xab, 0x2e, 0xc1, 0xdc, 0x80, 0x72, 0x4e, 0x4e,
+	0x42, 0x6c, 0x0d, 0x49, 0x29, 0x61, 0xbd, 0xee, 0xee, 0xee, 0xee, 0xc3, 0xd1, 0x52, 0xa1, 0x49,
+	0xbc, 0x2d, 0xe3, 0xf3, 0x24, 0xd4, 0x0e, 0x07, 0xef, 0x70, 0xd2, 0xce, 0x5f, 0x16, 0xcc, 0x47,
+	0x26, 0x0b, 0xfa, 0x0a, 0x66, 0xe4, 0x3e, 0x8e, 0x6e, 0x4e, 0x8e, 0x35, 0xb3, 0xda, 0x97, 0xcb,
+	0x97, 0x89, 0x78, 0x80, 0xf6, 0x61, 0x56, 0x3f, 0x23, 0xb4, 0x76, 0xf9, 0xda, 0xf8, 0xba, 0xbc,
+	0x7e, 0xd5, 0x4e, 0x89, 0x7e, 0x80, 0x42, 0x7c, 0xc3, 0x42, 0x53, 0x76, 0xd0, 0x89, 0xdd, 0xae,
+	0xbc, 0xf9, 0x76, 0x25, 0x1e, 0xa0, 0x57, 0x90, 0x8f, 0x6d, 0x0f, 0x08, 0xbf, 0x7d, 0xf3, 0x29,
+	0xdf, 0x79, 0x87, 0x15, 0x44, 0xe6, 0xae, 0x07, 0xf3, 0xb4, 0xdc, 0x47, 0x0b, 0x44, 0x79, 0xfd,
+	0x72, 0x21, 0x0f, 0xd0, 0x17, 0x90, 0x8f, 0xcd, 0x6e, 0xb4, 0x10, 0xc2, 0x96, 0xfc, 0xb3, 0x55,
+	0x2e, 0x19, 0x6a, 0x62, 0xbe, 0xef, 0xfc, 0x9a, 0x81, 0x8c, 0xfa, 0x0b, 0x80, 0x9e, 0x85, 0x53,
+	0xd0, 0x8c, 0x5d, 0xb4, 0x1a, 0x43, 0xbe, 0xf1, 0xfc, 0x2e, 0x97, 0xa6, 0x0b, 0x78, 0x80, 0x3e,
+	0x03, 0x18, 0x4f, 0x52, 0xb4, 0x1c, 0xd3, 0x33, 0xc3, 0xb5, 0x1c, 0x8b, 0x0c, 0x7d, 0x0e, 0x0b,
+	0xd1, 0x71, 0x88, 0x42, 0xb8, 0x4d, 0xcc, 0xc8, 0x84, 0xd5, 0x1e, 0xe4, 0x63, 0xb0, 0x9b, 0x88,
+	0x75, 0x3c, 0x92, 0x12, 0x76, 0x4f, 0x60, 0xc1, 0x3c, 0x43, 0x15, 0x75, 0xc4, 0x5b, 0x0c, 0x2d,
+	0xcb, 0xab, 0x53, 0xf9, 0x3c, 0x40, 0x8f, 0x60, 0x31, 0x81, 0x0e, 0xa3, 0x96, 0x9f, 0x44, 0x8d,
+	0x84, 0xf3, 0xb1, 0x6d, 0x88, 0x01, 0x49, 0xdb, 0x08, 0x36, 0x24, 0x6c, 0xf7, 0xe1, 0xfa, 0x14,
+	0x64, 0x48, 0xdc, 0x72, 0xb8, 0x53, 0x5e, 0x86, 0x21, 0x5f, 0x42, 0x21, 0x8e, 0x16, 0xa8, 0x14,
+	0xa9, 0x76, 0x0c, 0x44, 0xa6, 0x85, 0x1e, 0x83, 0x87, 0x48, 0xe8, 0x49, 0xd8, 0x48, 0xd8, 0xde,
+	0x85, 0xdc, 0x37, 0x94, 0x30, 0xd1, 0xa1, 0x44, 0x24, 0x02, 0x8e, 0x51, 0x9d, 0x59, 0x45, 0xec,
+	0xfe, 0x3b, 0x00, 0x8a, 0x93, 0x15, 0x6d, 0x36, 0x10, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1593,11 +1678,13 @@ const _ = grpc.SupportPackageIsVersion4
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type IntegrationClient interface {
-	Init(ctx context.Context, in *IntegrationInitReq, opts ...grpc.CallOption) (*Empty, error)
+	Init(ctx context.Context, in *IntegrationInitReq, opts ...grpc.CallOption) (*IntegrationInitResp, error)
 	Export(ctx context.Context, in *IntegrationExportReq, opts ...grpc.CallOption) (*IntegrationExportResp, error)
 	ValidateConfig(ctx context.Context, in *IntegrationValidateConfigReq, opts ...grpc.CallOption) (*IntegrationValidateConfigResp, error)
 	OnboardExport(ctx context.Context, in *IntegrationOnboardExportReq, opts ...grpc.CallOption) (*IntegrationOnboardExportResp, error)
 	Mutate(ctx context.Context, in *IntegrationMutateReq, opts ...grpc.CallOption) (*IntegrationMutateResp, error)
+	// GoroutineDump is used by agent to debug hanging integrations, only supported in protocol version 3
+	GoroutineDump(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GoroutineDumpResp, error)
 }
 
 type integrationClient struct {
@@ -1608,8 +1695,8 @@ func NewIntegrationClient(cc *grpc.ClientConn) IntegrationClient {
 	return &integrationClient{cc}
 }
 
-func (c *integrationClient) Init(ctx context.Context, in *IntegrationInitReq, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
+func (c *integrationClient) Init(ctx context.Context, in *IntegrationInitReq, opts ...grpc.CallOption) (*IntegrationInitResp, error) {
+	out := new(IntegrationInitResp)
 	err := c.cc.Invoke(ctx, "/proto.Integration/Init", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -1653,20 +1740,31 @@ func (c *integrationClient) Mutate(ctx context.Context, in *IntegrationMutateReq
 	return out, nil
 }
 
+func (c *integrationClient) GoroutineDump(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GoroutineDumpResp, error) {
+	out := new(GoroutineDumpResp)
+	err := c.cc.Invoke(ctx, "/proto.Integration/GoroutineDump", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // IntegrationServer is the server API for Integration service.
 type IntegrationServer interface {
-	Init(context.Context, *IntegrationInitReq) (*Empty, error)
+	Init(context.Context, *IntegrationInitReq) (*IntegrationInitResp, error)
 	Export(context.Context, *IntegrationExportReq) (*IntegrationExportResp, error)
 	ValidateConfig(context.Context, *IntegrationValidateConfigReq) (*IntegrationValidateConfigResp, error)
 	OnboardExport(context.Context, *IntegrationOnboardExportReq) (*IntegrationOnboardExportResp, error)
 	Mutate(context.Context, *IntegrationMutateReq) (*IntegrationMutateResp, error)
+	// GoroutineDump is used by agent to debug hanging integrations, only supported in protocol version 3
+	GoroutineDump(context.Context, *Empty) (*GoroutineDumpResp, error)
 }
 
 // UnimplementedIntegrationServer can be embedded to have forward compatible implementations.
 type UnimplementedIntegrationServer struct {
 }
 
-func (*UnimplementedIntegrationServer) Init(ctx context.Context, req *IntegrationInitReq) (*Empty, error) {
+func (*UnimplementedIntegrationServer) Init(ctx context.Context, req *IntegrationInitReq) (*IntegrationInitResp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Init not implemented")
 }
 func (*UnimplementedIntegrationServer) Export(ctx context.Context, req *IntegrationExportReq) (*IntegrationExportResp, error) {
@@ -1681,6 +1779,9 @@ func (*UnimplementedIntegrationServer) OnboardExport(ctx context.Context, req *I
 func (*UnimplementedIntegrationServer) Mutate(ctx context.Context, req *IntegrationMutateReq) (*IntegrationMutateResp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Mutate not implemented")
 }
+func (*UnimplementedIntegrationServer) GoroutineDump(ctx context.Context, req *Empty) (*GoroutineDumpResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GoroutineDump not implemented")
+}
 
 func RegisterIntegrationServer(s *grpc.Server, srv IntegrationServer) {
 	s.RegisterService(&_Integration_serviceDesc, srv)
@@ -1776,6 +1877,24 @@ func _Integration_Mutate_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Integration_GoroutineDump_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntegrationServer).GoroutineDump(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Integration/GoroutineDump",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntegrationServer).GoroutineDump(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Integration_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "proto.Integration",
 	HandlerType: (*IntegrationServer)(nil),
@@ -1800,6 +1919,10 @@ var _Integration_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Mutate",
 			Handler:    _Integration_Mutate_Handler,
 		},
+		{
+			MethodName: "GoroutineDump",
+			Handler:    _Integration_GoroutineDump_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "defs.proto",
@@ -1820,6 +1943,8 @@ type AgentClient interface {
 	OAuthNewAccessToken(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*OAuthNewAccessTokenResp, error)
 	SendPauseEvent(ctx context.Context, in *SendPauseEventReq, opts ...grpc.CallOption) (*Empty, error)
 	SendResumeEvent(ctx context.Context, in *SendResumeEventReq, opts ...grpc.CallOption) (*Empty, error)
+	// Heartbeat is sent by integration periodically while export is running, only supported in protocol version 3
+	Heartbeat(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
 }
 
 type agentClient struct {
@@ -1920,6 +2045,15 @@ func (c *agentClient) SendResumeEvent(ctx context.Context, in *SendResumeEventRe
 	return out, nil
 }
 
+func (c *agentClient) Heartbeat(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/proto.Agent/Heartbeat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AgentServer is the server API for Agent service.
 type AgentServer interface {
 	ExportStarted(context.Context, *ExportStartedReq) (*ExportStartedResp, error)
@@ -1933,6 +2067,8 @@ type AgentServer interface {
 	OAuthNewAccessToken(context.Context, *Empty) (*OAuthNewAccessTokenResp, error)
 	SendPauseEvent(context.Context, *SendPauseEventReq) (*Empty, error)
 	SendResumeEvent(context.Context, *SendResumeEventReq) (*Empty, error)
+	// Heartbeat is sent by integration periodically while export is running, only supported in protocol version 3
+	Heartbeat(context.Context, *Empty) (*Empty, error)
 }
 
 // UnimplementedAgentServer can be embedded to have forward compatible implementations.
@@ -1969,6 +2105,9 @@ func (*UnimplementedAgentServer) SendPauseEvent(ctx context.Context, req *SendPa
 func (*UnimplementedAgentServer) SendResumeEvent(ctx context.Context, req *SendResumeEventReq) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SendResumeEvent not implemented")
 }
+func (*UnimplementedAgentServer) Heartbeat(ctx context.Context, req *Empty) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
+}
 
 func RegisterAgentServer(s *grpc.Server, srv AgentServer) {
 	s.RegisterService(&_Agent_serviceDesc, srv)
@@ -2154,6 +2293,24 @@ func _Agent_SendResumeEvent_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Agent_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Agent/Heartbeat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).Heartbeat(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Agent_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "proto.Agent",
 	HandlerType: (*AgentServer)(nil),
@@ -2198,6 +2355,10 @@ var _Agent_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SendResumeEvent",
 			Handler:    _Agent_SendResumeEvent_Handler,
 		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _Agent_Heartbeat_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "defs.proto",
@@ -88,6 +88,9 @@ type Export struct {
 	store filestore.Store
 
 	prs map[string]PR
+
+	// mailmap is loaded from .mailmap in repo root
+	mailmap *commitusers.Mailmap
 }
 
 func New(opts Opts, locs fsconf.Locs) *Export {
@@ -192,6 +195,15 @@ func (s *Export) run(ctx context.Context) (duration ExportDuration, rerr error)
 		return
 	}
 
+	s.mailmap, err = commitusers.LoadMailmap(filepath.Join(repoDir, ".mailmap"))
+	if err != nil {
+		rerr = fmt.Errorf("could not read .mailmap: %v", err)
+		return
+	}
+	if s.mailmap.Len() != 0 {
+		s.logger.Debug("using .mailmap for commit users", "entries", s.mailmap.Len())
+	}
+
 	skipsrc, remotebranches, err := s.skipRipsrc(ctx, repoDir)
 	if err != nil {
 		rerr = err
@@ -415,10 +427,19 @@ func (s *Export) commit(commit slimrippy.Commit) error {
 		})
 	}
 
+	writeCommitUserLink := func(obj commitusers.CommitUserLink) error {
+		return sessions.Write(s.sessions.CommitUserLink, []map[string]interface{}{
+			obj.ToMap(),
+		})
+	}
+
 	customerID := s.opts.CustomerID
 
 	repoID := s.opts.RepoID
 
+	commit.Authored.Name, commit.Authored.Email = s.canonicalUser(commit.Authored.Name, commit.Authored.Email)
+	commit.Committed.Name, commit.Committed.Email = s.canonicalUser(commit.Committed.Name, commit.Committed.Email)
+
 	c := sourcecode.Commit{
 		RefID:          commit.SHA,
 		RefType:        s.opts.RefType,
@@ -461,9 +482,48 @@ func (s *Export) commit(commit slimrippy.Commit) error {
 		}
 	}
 
+	for _, trailer := range commitusers.ParseTrailers(commit.Message) {
+		name, email := s.canonicalUser(trailer.Name, trailer.Email)
+		// name is required for commit users, trailers without a name only create links
+		if name != "" {
+			user := commitusers.CommitUser{}
+			user.CustomerID = customerID
+			user.Email = email
+			user.Name = name
+			err := writeCommitUser(user)
+			if err != nil {
+				return err
+			}
+		}
+		link := commitusers.CommitUserLink{
+			CustomerID: customerID,
+			RefType:    s.opts.RefType,
+			RepoID:     repoID,
+			CommitID:   s.commitID(commit.SHA),
+			UserRefID:  ids.CodeCommitEmail(customerID, email),
+			Email:      email,
+			Name:       name,
+			Role:       commitusers.TrailerLinkRole(trailer.Kind),
+		}
+		err := writeCommitUserLink(link)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// canonicalUser applies repo .mailmap and then agent level email aliases.
+func (s *Export) canonicalUser(name, email string) (string, string) {
+	if email == "" {
+		return name, email
+	}
+	name, email = s.mailmap.Resolve(name, email)
+	email = s.opts.CommitUsers.ResolveAlias(email)
+	return name, email
+}
+
 func commitURL(commitURLTemplate, sha string) string {
 	return strings.ReplaceAll(commitURLTemplate, "@@@sha@@@", sha)
 }
@@ -12,6 +12,8 @@ type sessions struct {
 	PRBranch   expsessions.ID
 	Commit     expsessions.ID
 	CommitUser expsessions.ID
+	// CommitUserLink contains co-authors and sign-offs from commit message trailers
+	CommitUserLink expsessions.ID
 
 	sessionManager         *expsessions.Manager
 	sessionRootID          expsessions.ID
@@ -44,6 +46,10 @@ func (s *sessions) Open() error {
 	if err != nil {
 		return err
 	}
+	s.CommitUserLink, err = s.session(commitusers.LinkTableName)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -64,6 +70,10 @@ func (s *sessions) Close() error {
 	if err != nil {
 		return err
 	}
+	err = s.sessionManager.Done(s.CommitUserLink, nil)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -23,9 +23,13 @@ func NewIntegration(logger hclog.Logger) *Integration {
 	return s
 }
 
-func (s *Integration) Init(agent rpcdef.Agent) error {
+func (s *Integration) Init(agent rpcdef.Agent) (rpcdef.Capabilities, error) {
 	s.agent = agent
-	return nil
+	// export only, used to test hang detection
+	return rpcdef.Capabilities{
+		IntegrationTypes: []string{"SOURCECODE"},
+		Streaming:        true,
+	}, nil
 }
 
 func (s *Integration) Export(ctx context.Context, config rpcdef.ExportConfig) (res rpcdef.ExportResult, _ error) {